@@ -0,0 +1,120 @@
+// mood/cmd/web/activation.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// resendActivationFlash is shown after every valid resend request, whether or not the
+// email belongs to an inactive account, so the form can't be used to discover accounts.
+const resendActivationFlash = "If that email belongs to an account that still needs activating, we've sent a new activation link."
+
+// sendActivation creates an activation token for user and emails them the link in the
+// background. Failures are logged rather than returned: the account already exists and
+// the user can ask for a new link from the resend page.
+func (app *application) sendActivation(ctx context.Context, user *data.User) {
+	token, err := app.activations.New(ctx, user.ID, data.ActivationTTL)
+	if err != nil {
+		app.logger.Error("Failed to create activation token", "error", err, "userID", user.ID)
+		return
+	}
+	app.sendNotification(user.Email, "user_activation", map[string]any{
+		"Name":          user.Name,
+		"ActivationURL": app.absoluteURL("/user/activate?token=" + url.QueryEscape(token)),
+		"ExpiresIn":     "3 days",
+	})
+}
+
+// activateUser handles GET /user/activate?token=. A valid token activates its account and
+// is then deleted, along with any other activation links sent to the same user.
+func (app *application) activateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.activations.GetUserID(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverError(w, r, err)
+			return
+		}
+		app.session.Put(r, "flash", "This activation link is invalid or has expired. Enter your email to get a new one.")
+		http.Redirect(w, r, "/user/resend-activation", http.StatusSeeOther)
+		return
+	}
+
+	err = app.users.Activate(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if err := app.activations.DeleteAllForUser(r.Context(), userID); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.session.Put(r, "flash", "Your account is activated! Please log in.")
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+// resendActivationForm displays the form that requests a new activation email.
+func (app *application) resendActivationForm(w http.ResponseWriter, r *http.Request) {
+	templateData := app.newTemplateData(r)
+	templateData.Title = "Resend Activation - Feel Flow"
+	templateData.FormData = map[string]string{"email": r.URL.Query().Get("email")}
+	err := app.render(w, http.StatusOK, "resend_activation.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// resendActivation handles POST /user/resend-activation. Like forgotPassword, the lookup
+// and email happen in the background and the response never depends on the address.
+func (app *application) resendActivation(w http.ResponseWriter, r *http.Request) {
+	// 1. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	email := r.PostForm.Get("email")
+
+	// 2. Validate the Address's Shape.
+	v := validator.NewValidator()
+	v.Check(validator.NotBlank(email), "email", "Email must be provided")
+	v.Check(validator.MaxLength(email, 254), "email", "Must not be more than 254 characters")
+	v.Check(validator.Matches(email, validator.EmailRX), "email", "Must be a valid email address")
+	if !v.ValidData() {
+		templateData := app.newTemplateData(r)
+		templateData.Title = "Resend Activation (Error) - Feel Flow"
+		templateData.FormData = map[string]string{"email": email}
+		templateData.FormErrors = v.Errors
+		app.renderFormBlock(w, r, "resend_activation.tmpl", "resend-activation-form-block", templateData)
+		return
+	}
+
+	// 3. Send a New Link to Inactive Accounts Only.
+	app.background(func() {
+		ctx := context.Background()
+		user, err := app.users.GetByEmail(ctx, email)
+		if err != nil {
+			if !errors.Is(err, data.ErrRecordNotFound) {
+				app.logger.Error("Failed to look up user for activation resend", "error", err)
+			}
+			return
+		}
+		if user.Activated {
+			return
+		}
+		app.sendActivation(ctx, user)
+	})
+
+	// 4. Respond Identically Either Way.
+	app.session.Put(r, "flash", resendActivationFlash)
+	app.redirectAfterForm(w, r, "/user/login")
+}
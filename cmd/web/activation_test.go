@@ -0,0 +1,105 @@
+// mood/cmd/web/activation_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestResendActivation_InvalidEmail(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+
+	rr := postForm(t, app, app.resendActivation, "/user/resend-activation", url.Values{"email": {""}})
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Email must be provided") {
+		t.Error("Expected the email validation error in the response")
+	}
+}
+
+func TestSignupActivateLogin(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	email := "new.user@example.com"
+	credentials := url.Values{"email": {email}, "password": {"n3wPa55word!"}}
+
+	// 1. Signing up creates an inactive account and emails an activation link.
+	signup := url.Values{"name": {"New User"}, "email": {email}, "password": {"n3wPa55word!"}}
+	if rr := postForm(t, app, app.signupUser, "/user/signup", signup); rr.Code != http.StatusSeeOther {
+		t.Fatalf("Signup: expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+	app.wg.Wait()
+	sent := stub.Sent()
+	if len(sent) != 1 || sent[0].Recipient != email || sent[0].TemplateName != "user_activation" {
+		t.Fatalf("Expected one activation email to %s, got %+v", email, sent)
+	}
+	activationURL, err := url.Parse(sent[0].Data.(map[string]any)["ActivationURL"].(string))
+	if err != nil {
+		t.Fatalf("Bad activation URL: %v", err)
+	}
+
+	// 2. Logging in before activating explains why it failed.
+	rr := postForm(t, app, app.loginUser, "/user/login", credentials)
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "check your email") {
+		t.Fatalf("Login before activation: expected the activation message, got %d", rr.Code)
+	}
+
+	// 3. The emailed link activates the account, and only works once.
+	for i, wantLocation := range []string{"/user/login", "/user/resend-activation"} {
+		rr = httptest.NewRecorder()
+		app.activateUser(rr, newSessionRequest(t, http.MethodGet, activationURL.RequestURI(), nil))
+		if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != wantLocation {
+			t.Fatalf("Activation attempt %d: expected a redirect to %s, got %d %q", i+1, wantLocation, rr.Code, rr.Header().Get("Location"))
+		}
+	}
+
+	// 4. Now the login succeeds.
+	if rr := postForm(t, app, app.loginUser, "/user/login", credentials); rr.Code != http.StatusSeeOther {
+		t.Fatalf("Login after activation: expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+}
+
+func TestResendActivation_OnlyInactiveAccounts(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	activeID := insertTestUser(t, app)
+	active, err := app.users.Get(context.Background(), activeID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+	inactive := &data.User{Name: "Inactive", Email: "inactive@example.com"}
+	if err := inactive.Password.Set("pa55word123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.users.Insert(context.Background(), inactive); err != nil {
+		t.Fatalf("Failed to insert inactive user: %v", err)
+	}
+
+	var responses []*httptest.ResponseRecorder
+	for _, email := range []string{active.Email, inactive.Email, "nobody@example.com"} {
+		responses = append(responses, postForm(t, app, app.resendActivation, "/user/resend-activation", url.Values{"email": {email}}))
+	}
+	app.wg.Wait()
+
+	for _, rr := range responses[1:] {
+		if rr.Code != responses[0].Code || rr.Header().Get("Location") != responses[0].Header().Get("Location") {
+			t.Errorf("Responses differ: %d %q vs %d %q", rr.Code, rr.Header().Get("Location"), responses[0].Code, responses[0].Header().Get("Location"))
+		}
+	}
+	sent := stub.Sent()
+	if len(sent) != 1 || sent[0].Recipient != inactive.Email {
+		t.Fatalf("Expected one activation email to %s, got %+v", inactive.Email, sent)
+	}
+}
@@ -0,0 +1,465 @@
+// mood/cmd/web/api.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// Page size bounds for list endpoints. The default matches a comfortable API page,
+// the maximum keeps a single response (and its LIMIT) reasonably small.
+const (
+	apiDefaultPageSize = 20
+	apiMaxPageSize     = 100
+)
+
+/*
+==========================================================================
+
+	JSON API Helpers
+==========================================================================
+*/
+
+// requireAPIAuthentication is the API counterpart of requireAuthentication.
+// Instead of redirecting to the login page it answers with a 401 JSON body.
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+			return
+		}
+		w.Header().Add("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+/*
+==========================================================================
+
+	Mood API Handlers
+==========================================================================
+*/
+
+// apiCreateMood handles POST /api/v1/moods.
+// The mood is always owned by the authenticated user: any client-supplied
+// id, user_id or timestamps are ignored and replaced by server-assigned values.
+func (app *application) apiCreateMood(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	// 2. Decode JSON Body. Intensity is optional, so it starts at the default.
+	mood := data.Mood{Intensity: data.DefaultMoodIntensity}
+	err := app.readJSON(w, r, &mood)
+	if err != nil {
+		app.errorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 3. Server-Assigned Fields: never trust the client for ownership or identity.
+	mood.ID = 0
+	mood.UserID = userID
+
+	// 4. Validation.
+	v := validator.NewValidator()
+	data.ValidateMood(v, &mood)
+	if !v.ValidData() {
+		app.errorJSON(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	// 5. Insert, keeping only the allowed formatting.
+	mood.Content = data.SanitizeHTML(mood.Content)
+	err = app.moods.Insert(r.Context(), &mood)
+	if err != nil {
+		app.logger.Error("API mood insert failed", "userID", userID, "error", err)
+		app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		return
+	}
+	app.invalidateEmotions(userID)
+
+	// 6. Respond with the created resource (including DB-assigned id/timestamps).
+	headers := http.Header{"Location": {fmt.Sprintf("/api/v1/moods/%d", mood.ID)}}
+	app.writeJSON(w, http.StatusCreated, map[string]any{"mood": mood}, headers)
+}
+
+// apiListMoods handles GET /api/v1/moods.
+// It accepts the same filters and sort orders as the dashboard (query, emotion, start_date,
+// end_date, weekday, min_intensity, max_intensity, and sort, one of data.SortOrders:
+// created_at_asc, created_at_desc, emotion_asc or title_asc) plus page and page_size, and
+// returns {"metadata": {...}, "moods": [...]}, with each mood's created_at and updated_at
+// in RFC 3339.
+func (app *application) apiListMoods(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	// 2. Parse & Validate Query Parameters.
+	//    Unlike the dashboard, which silently falls back to defaults, API clients get a 400
+	//    naming each bad parameter, so they can spot bugs in their own requests.
+	qs := r.URL.Query()
+	v := validator.NewValidator()
+
+	page := apiReadInt(qs.Get("page"), 1, v, "page")
+	pageSize := apiReadInt(qs.Get("page_size"), apiDefaultPageSize, v, "page_size")
+	v.Check(page > 0, "page", "must be greater than zero")
+	v.Check(page <= 10_000_000, "page", "must be less than 10 million")
+	v.Check(pageSize > 0, "page_size", "must be greater than zero")
+	v.Check(pageSize <= apiMaxPageSize, "page_size", fmt.Sprintf("must be a maximum of %d", apiMaxPageSize))
+
+	startDate := apiReadDate(qs.Get("start_date"), v, "start_date")
+	endDate := apiReadDate(qs.Get("end_date"), v, "end_date")
+	if !endDate.IsZero() {
+		endDate = endDate.Add(24*time.Hour - time.Nanosecond) // Inclusive of the whole end day.
+		v.Check(startDate.IsZero() || !endDate.Before(startDate), "end_date", "must not be before start_date")
+	}
+
+	weekday, weekdayErr := parseWeekday(qs.Get("weekday"))
+	v.Check(weekdayErr == nil, "weekday", "must be a day name (e.g. mon) or a number from 0 (Sunday) to 6")
+
+	minIntensity := apiReadInt(qs.Get("min_intensity"), data.MoodIntensityMin, v, "min_intensity")
+	maxIntensity := apiReadInt(qs.Get("max_intensity"), data.MoodIntensityMax, v, "max_intensity")
+	data.ValidateIntensityRange(v, minIntensity, maxIntensity)
+
+	sort := qs.Get("sort")
+	if sort == "" {
+		sort = data.DefaultSort
+	}
+	v.Check(data.ValidSort(sort), "sort", "must be one of "+strings.Join(data.SortOrders(), ", "))
+
+	if !v.ValidData() {
+		app.errorJSON(w, http.StatusBadRequest, v.Errors)
+		return
+	}
+
+	// 3. Fetch the Page.
+	criteria := data.FilterCriteria{
+		TextQuery: qs.Get("query"),
+		Emotion:   qs.Get("emotion"),
+		StartDate: startDate,
+		EndDate:   endDate,
+		Weekday:   weekday,
+		Sort:      sort,
+		Page:      page,
+		PageSize:  pageSize,
+		UserID:    userID,
+
+		MinIntensity: minIntensity,
+		MaxIntensity: maxIntensity,
+	}
+	moods, metadata, err := app.moods.GetFiltered(r.Context(), criteria)
+	if err != nil {
+		app.logger.Error("API mood list failed", "userID", userID, "error", err)
+		app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		return
+	}
+
+	// 4. Respond with the list envelope.
+	app.writeJSON(w, http.StatusOK, map[string]any{"metadata": metadata, "moods": moods}, nil)
+}
+
+// apiShowMood handles GET /api/v1/moods/{id}, returning {"mood": {...}}.
+// Moods belonging to other users get the same JSON 404 as ones that don't exist.
+func (app *application) apiShowMood(w http.ResponseWriter, r *http.Request) {
+	mood, ok := app.apiOwnedMood(w, r)
+	if !ok {
+		return
+	}
+	app.writeJSON(w, http.StatusOK, map[string]any{"mood": mood}, nil)
+}
+
+// apiReadInt parses an integer query value, returning def when it is empty
+// and recording a validation error when it is not a number.
+func apiReadInt(s string, def int, v *validator.Validator, key string) int {
+	if s == "" {
+		return def
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return def
+	}
+	return i
+}
+
+// apiReadDate parses a YYYY-MM-DD query value, returning the zero time when it
+// is empty and recording a validation error when it is malformed.
+func apiReadDate(s string, v *validator.Validator, key string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		v.AddError(key, "must be a date in YYYY-MM-DD format")
+		return time.Time{}
+	}
+	return t
+}
+
+// apiDeleteMood handles DELETE /api/v1/moods/{id}.
+// It shares the delete path with the HTML handler but never sets a flash message,
+// answering 204 No Content on success and a JSON 404 if the mood isn't the user's.
+func (app *application) apiDeleteMood(w http.ResponseWriter, r *http.Request) {
+	// 1. Get Mood ID.
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+
+	// 2. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	// 3. Delete.
+	err = app.deleteMoodEntry(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.logger.Error("API mood delete failed", "id", id, "userID", userID, "error", err)
+			app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		}
+		return
+	}
+
+	// 4. Success: no body.
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiReplaceMood handles PUT /api/v1/moods/{id}.
+// The body must describe the whole mood: every field is required and validated,
+// exactly as on create. The private note is not part of the API resource and is kept.
+// A body that includes "version" only applies if the mood is still at that version
+// (409 otherwise); without one, the replacement applies to whatever is stored.
+func (app *application) apiReplaceMood(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.apiOwnedMood(w, r)
+	if !ok {
+		return
+	}
+
+	// 1. Decode the full replacement. Intensity is optional and defaults as on create.
+	mood := data.Mood{Intensity: data.DefaultMoodIntensity}
+	err := app.readJSON(w, r, &mood)
+	if err != nil {
+		app.errorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Server-Assigned Fields.
+	mood.ID = existing.ID
+	mood.UserID = existing.UserID
+	mood.CreatedAt = existing.CreatedAt
+	mood.PrivateNote = existing.PrivateNote
+	if mood.Version == 0 {
+		mood.Version = existing.Version
+	}
+
+	// 3. Validation.
+	v := validator.NewValidator()
+	data.ValidateMood(v, &mood)
+	if !v.ValidData() {
+		app.errorJSON(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	// 4. Update, keeping only the allowed formatting.
+	mood.Content = data.SanitizeHTML(mood.Content)
+	err = app.moods.Update(r.Context(), &mood)
+	if err != nil {
+		app.apiUpdateFailed(w, err, mood.ID, mood.UserID)
+		return
+	}
+	app.invalidateEmotions(mood.UserID)
+	app.writeJSON(w, http.StatusOK, map[string]any{"mood": mood}, nil)
+}
+
+// moodPatch lists the fields a PATCH may change. Pointers distinguish "absent" from
+// "set to empty", and DisallowUnknownFields rejects anything not listed (user_id, id...).
+type moodPatch struct {
+	Title     *string `json:"title"`
+	Content   *string `json:"content"`
+	Emotion   *string `json:"emotion"`
+	Emoji     *string `json:"emoji"`
+	Color     *string `json:"color"`
+	Intensity *int    `json:"intensity"`
+}
+
+// apiPatchMood handles PATCH /api/v1/moods/{id}.
+// Only the supplied fields change; the merged mood is validated as a whole and
+// written with UpdatePartial so untouched columns are never rewritten.
+func (app *application) apiPatchMood(w http.ResponseWriter, r *http.Request) {
+	mood, ok := app.apiOwnedMood(w, r)
+	if !ok {
+		return
+	}
+
+	// 1. Decode the partial body.
+	var patch moodPatch
+	err := app.readJSON(w, r, &patch)
+	if err != nil {
+		app.errorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Merge supplied fields into the stored mood.
+	var fields []string
+	apply := func(name string, src *string, dst *string) {
+		if src != nil {
+			*dst = *src
+			fields = append(fields, name)
+		}
+	}
+	apply("title", patch.Title, &mood.Title)
+	apply("content", patch.Content, &mood.Content)
+	apply("emotion", patch.Emotion, &mood.Emotion)
+	apply("emoji", patch.Emoji, &mood.Emoji)
+	apply("color", patch.Color, &mood.Color)
+	if patch.Intensity != nil {
+		mood.Intensity = *patch.Intensity
+		fields = append(fields, "intensity")
+	}
+
+	if len(fields) == 0 {
+		app.errorJSON(w, http.StatusBadRequest, "body must contain at least one field to update")
+		return
+	}
+
+	// 3. Validate the merged result.
+	v := validator.NewValidator()
+	data.ValidateMood(v, mood)
+	if !v.ValidData() {
+		app.errorJSON(w, http.StatusUnprocessableEntity, v.Errors)
+		return
+	}
+
+	// 4. Update only the changed columns, keeping only the allowed formatting.
+	if patch.Content != nil {
+		mood.Content = data.SanitizeHTML(mood.Content)
+	}
+	err = app.moods.UpdatePartial(r.Context(), mood, fields)
+	if err != nil {
+		app.apiUpdateFailed(w, err, mood.ID, mood.UserID)
+		return
+	}
+	app.invalidateEmotions(mood.UserID)
+	app.writeJSON(w, http.StatusOK, map[string]any{"mood": mood}, nil)
+}
+
+// apiOwnedMood resolves the {id} path value to a mood owned by the authenticated user.
+// On failure it writes the JSON error (401/404/500) and returns ok=false.
+func (app *application) apiOwnedMood(w http.ResponseWriter, r *http.Request) (*data.Mood, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+		return nil, false
+	}
+
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return nil, false
+	}
+
+	mood, err := app.moods.Get(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+		} else {
+			app.logger.Error("API mood lookup failed", "id", id, "userID", userID, "error", err)
+			app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		}
+		return nil, false
+	}
+	return mood, true
+}
+
+// apiUpdateFailed maps an Update/UpdatePartial error to a JSON response.
+func (app *application) apiUpdateFailed(w http.ResponseWriter, err error, id, userID int64) {
+	if errors.Is(err, data.ErrRecordNotFound) {
+		app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+		return
+	}
+	if errors.Is(err, data.ErrEditConflict) {
+		app.errorJSON(w, http.StatusConflict, "the mood was changed since you read it; fetch it again and retry")
+		return
+	}
+	app.logger.Error("API mood update failed", "id", id, "userID", userID, "error", err)
+	app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+// schemaField describes one property of an API resource for GET .../schema.
+type schemaField struct {
+	Type      string `json:"type"`
+	Required  bool   `json:"required"`
+	ReadOnly  bool   `json:"read_only,omitempty"`
+	Format    string `json:"format,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+	Minimum   int    `json:"minimum,omitempty"`
+	Maximum   int    `json:"maximum,omitempty"`
+	Default   any    `json:"default,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// apiShowMe handles GET /api/v1/me.
+// It returns the authenticated user's profile and preferences (including the
+// check-in reminder) so clients can configure themselves. The password is never serialized.
+func (app *application) apiShowMe(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.errorJSON(w, http.StatusNotFound, "the requested resource could not be found")
+			return
+		}
+		app.logger.Error("API get current user failed", "userID", userID, "error", err)
+		app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, map[string]any{"user": user}, nil)
+}
+
+// apiMoodSchema handles GET /api/v1/moods/schema.
+// It describes the mood resource's fields and limits. Limits come from the data
+// package constants used by ValidateMood, so the description can't drift from validation.
+func (app *application) apiMoodSchema(w http.ResponseWriter, r *http.Request) {
+	fields := map[string]schemaField{
+		"id":         {Type: "integer", ReadOnly: true},
+		"created_at": {Type: "string", Format: "date-time", ReadOnly: true},
+		"updated_at": {Type: "string", Format: "date-time", ReadOnly: true},
+		"user_id":    {Type: "integer", ReadOnly: true, Notes: "always the authenticated user"},
+		"version":    {Type: "integer", Notes: "incremented on every update; send it with PUT to get a 409 instead of overwriting newer changes"},
+		"pinned":     {Type: "boolean", ReadOnly: true, Notes: "pinned entries are listed first in every sort order"},
+		"title":      {Type: "string", Required: true, MaxLength: data.MoodTitleMaxLength},
+		"content":    {Type: "string", Format: "html", Required: true, MaxLength: data.MoodContentMaxLength, Notes: fmt.Sprintf("must contain text once HTML is stripped; max_length counts that text, and the HTML itself may be at most %d bytes", data.MoodContentMaxBytes)},
+		"emotion":    {Type: "string", Required: true, MaxLength: data.MoodEmotionMaxLength},
+		"emoji":      {Type: "string", Required: true, MaxLength: data.MoodEmojiMaxRunes, Notes: "length counted in Unicode code points; must include at least one emoji character"},
+		"color":      {Type: "string", Required: true, Pattern: validator.HexColorRX.String()},
+		"intensity":  {Type: "integer", Minimum: data.MoodIntensityMin, Maximum: data.MoodIntensityMax, Default: data.DefaultMoodIntensity},
+	}
+	app.writeJSON(w, http.StatusOK, map[string]any{"schema": map[string]any{"resource": "mood", "fields": fields}}, nil)
+}
@@ -0,0 +1,518 @@
+// mood/cmd/web/api_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+func TestAPICreateMood(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	t.Run("HappyPath", func(t *testing.T) {
+		body := `{"title":"API entry","content":"<p>Hello</p>","emotion":"Happy","emoji":"😊","color":"#FFCA28"}`
+		r := newSessionRequest(t, http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+
+		app.apiCreateMood(rr, r)
+
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Mood data.Mood `json:"mood"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Mood.ID == 0 || resp.Mood.CreatedAt.IsZero() {
+			t.Errorf("Expected DB-assigned id and created_at, got %+v", resp.Mood)
+		}
+		if resp.Mood.UserID != userID {
+			t.Errorf("Expected user_id %d, got %d", userID, resp.Mood.UserID)
+		}
+	})
+
+	t.Run("ValidationFailure", func(t *testing.T) {
+		body := `{"title":"","content":"<p></p>","emotion":"Happy","emoji":"😊","color":"not-a-color"}`
+		r := newSessionRequest(t, http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+
+		app.apiCreateMood(rr, r)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+		}
+		var resp struct {
+			Error map[string]string `json:"error"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, field := range []string{"title", "content", "color"} {
+			if _, ok := resp.Error[field]; !ok {
+				t.Errorf("Expected a validation error for %q, got %v", field, resp.Error)
+			}
+		}
+	})
+
+	t.Run("UserIDSpoofingIgnored", func(t *testing.T) {
+		body := `{"title":"Spoof","content":"<p>x</p>","emotion":"Sad","emoji":"😢","color":"#5C8DDE","user_id":` +
+			strconv.FormatInt(otherUserID, 10) + `}`
+		r := newSessionRequest(t, http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+
+		app.apiCreateMood(rr, r)
+
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Mood data.Mood `json:"mood"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if resp.Mood.UserID != userID {
+			t.Errorf("Expected mood owned by %d, got %d", userID, resp.Mood.UserID)
+		}
+		if _, err := app.moods.Get(context.Background(), resp.Mood.ID, otherUserID); err == nil {
+			t.Error("Spoofed user_id should not own the created mood")
+		}
+	})
+}
+
+func TestAPICreateMood_BadJSON(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Malformed", `{"title": "oops"`},
+		{"UnknownField", `{"title":"x","mood_score":5}`},
+		{"Empty", ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSessionRequest(t, http.MethodPost, "/api/v1/moods", strings.NewReader(tt.body))
+			app.session.Put(r, "authenticatedUserID", int64(1))
+			rr := httptest.NewRecorder()
+
+			app.apiCreateMood(rr, r)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", ct)
+			}
+		})
+	}
+}
+
+func TestAPIListMoods(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	for i := 0; i < 5; i++ {
+		mood := &data.Mood{Title: "Entry " + strconv.Itoa(i), Content: "<p>x</p>", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: userID}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Setup insert failed: %v", err)
+		}
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/api/v1/moods?page=1&page_size=2", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+
+	app.apiListMoods(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Metadata data.Metadata `json:"metadata"`
+		Moods    []data.Mood   `json:"moods"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Metadata.TotalRecords != 5 {
+		t.Errorf("Expected total_records 5, got %d", resp.Metadata.TotalRecords)
+	}
+	if resp.Metadata.LastPage != 3 {
+		t.Errorf("Expected last_page 3, got %d", resp.Metadata.LastPage)
+	}
+	if len(resp.Moods) != 2 {
+		t.Errorf("Expected 2 moods on the page, got %d", len(resp.Moods))
+	}
+
+	// Timestamps are RFC 3339 strings, which clients can parse without knowing Go's defaults.
+	var raw struct {
+		Metadata map[string]any `json:"metadata"`
+		Moods    []struct {
+			CreatedAt string `json:"created_at"`
+			UpdatedAt string `json:"updated_at"`
+		} `json:"moods"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if raw.Metadata == nil {
+		t.Error("Expected a metadata object in the response")
+	}
+	for i, mood := range raw.Moods {
+		for field, value := range map[string]string{"created_at": mood.CreatedAt, "updated_at": mood.UpdatedAt} {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				t.Errorf("Mood %d: %s %q is not RFC 3339: %v", i, field, value, err)
+			}
+		}
+	}
+}
+
+func TestAPIListMoods_InvalidParams(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{"NonNumericPage", "page=abc", "page"},
+		{"ZeroPage", "page=0", "page"},
+		{"NegativePage", "page=-1", "page"},
+		{"NonNumericPageSize", "page_size=ten", "page_size"},
+		{"FractionalPageSize", "page_size=2.5", "page_size"},
+		{"ZeroPageSize", "page_size=0", "page_size"},
+		{"PageSizeTooLarge", "page_size=1000", "page_size"},
+		{"BadDate", "start_date=05/01/2024", "start_date"},
+		{"UnknownSort", "sort=user_id", "sort"},
+		{"IntensityTooHigh", "max_intensity=6", "max_intensity"},
+		{"NonNumericIntensity", "min_intensity=low", "min_intensity"},
+		{"InvertedIntensity", "min_intensity=4&max_intensity=2", "max_intensity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSessionRequest(t, http.MethodGet, "/api/v1/moods?"+tt.query, nil)
+			app.session.Put(r, "authenticatedUserID", int64(1))
+			rr := httptest.NewRecorder()
+
+			app.apiListMoods(rr, r)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+			var resp struct {
+				Error map[string]string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if _, ok := resp.Error[tt.field]; !ok {
+				t.Errorf("Expected a validation error for %q, got %v", tt.field, resp.Error)
+			}
+		})
+	}
+}
+
+func TestAPIJSON_TimestampsAreRFC3339(t *testing.T) {
+	app := newTestApplication(t)
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	created := time.Date(2024, 5, 10, 9, 30, 15, 123456789, zone)
+	mood := data.Mood{ID: 1, CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	rr := httptest.NewRecorder()
+	app.writeJSON(rr, http.StatusOK, map[string]any{"moods": []data.Mood{mood}}, nil)
+
+	var resp struct {
+		Moods []map[string]any `json:"moods"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got := resp.Moods[0]["created_at"]; got != "2024-05-10T09:30:15.123456789+02:00" {
+		t.Errorf("created_at = %v, want RFC 3339 with the offset", got)
+	}
+	for _, field := range []string{"created_at", "updated_at"} {
+		value, _ := resp.Moods[0][field].(string)
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Errorf("%s %q is not RFC 3339: %v", field, value, err)
+		}
+	}
+}
+
+func TestAPIShowMood(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	mood := &data.Mood{Title: "Shown", Content: "<p>x</p>", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: userID, PrivateNote: "secret"}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+
+	newShowRequest := func(id string, asUser int64) *http.Request {
+		r := newSessionRequest(t, http.MethodGet, "/api/v1/moods/"+id, nil)
+		r.SetPathValue("id", id)
+		app.session.Put(r, "authenticatedUserID", asUser)
+		return r
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiShowMood(rr, newShowRequest(strconv.FormatInt(mood.ID, 10), userID))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Mood data.Mood `json:"mood"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Mood.ID != mood.ID || resp.Mood.Title != "Shown" {
+			t.Errorf("Unexpected mood: %+v", resp.Mood)
+		}
+		if strings.Contains(rr.Body.String(), "secret") {
+			t.Error("The private note must not be serialized")
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		id     string
+		asUser int64
+	}{
+		"NotOwned": {strconv.FormatInt(mood.ID, 10), otherUserID},
+		"Missing":  {"999999", userID},
+		"BadID":    {"abc", userID},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			app.apiShowMood(rr, newShowRequest(tc.id, tc.asUser))
+			if rr.Code != http.StatusNotFound {
+				t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Expected a JSON error body, got Content-Type %q", ct)
+			}
+		})
+	}
+}
+
+func TestAPIDeleteMood(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	mood := &data.Mood{Title: "To delete", Content: "<p>x</p>", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+
+	newDeleteRequest := func(id int64, asUser int64) *http.Request {
+		r := newSessionRequest(t, http.MethodDelete, "/api/v1/moods/"+strconv.FormatInt(id, 10), nil)
+		r.SetPathValue("id", strconv.FormatInt(id, 10))
+		app.session.Put(r, "authenticatedUserID", asUser)
+		return r
+	}
+
+	t.Run("NotOwned", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiDeleteMood(rr, newDeleteRequest(mood.ID, otherUserID))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		r := newDeleteRequest(mood.ID, userID)
+		rr := httptest.NewRecorder()
+		app.apiDeleteMood(rr, r)
+
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("Expected empty body, got %q", rr.Body.String())
+		}
+		if app.session.Exists(r, "flash") {
+			t.Error("API delete must not set a flash message")
+		}
+		if _, err := app.moods.Get(context.Background(), mood.ID, userID); err == nil {
+			t.Error("Expected mood to be deleted")
+		}
+	})
+}
+
+func TestAPIUpdateMood(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	original := &data.Mood{Title: "Original", Content: "<p>Keep me</p>", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: userID}
+	if err := app.moods.Insert(context.Background(), original); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	idStr := strconv.FormatInt(original.ID, 10)
+
+	newRequest := func(method, body string, asUser int64) *http.Request {
+		r := newSessionRequest(t, method, "/api/v1/moods/"+idStr, strings.NewReader(body))
+		r.SetPathValue("id", idStr)
+		app.session.Put(r, "authenticatedUserID", asUser)
+		return r
+	}
+
+	t.Run("PatchTitleOnly", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiPatchMood(rr, newRequest(http.MethodPatch, `{"title":"Renamed"}`, userID))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		stored, err := app.moods.Get(context.Background(), original.ID, userID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if stored.Title != "Renamed" {
+			t.Errorf("Expected title %q, got %q", "Renamed", stored.Title)
+		}
+		if stored.Content != original.Content || stored.Emotion != original.Emotion || stored.Color != original.Color {
+			t.Errorf("Expected untouched fields to be kept, got %+v", stored)
+		}
+	})
+
+	t.Run("PatchUnknownFieldRejected", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiPatchMood(rr, newRequest(http.MethodPatch, `{"user_id":`+strconv.FormatInt(otherUserID, 10)+`}`, userID))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("PutMissingRequiredField", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		body := `{"content":"<p>x</p>","emotion":"Sad","emoji":"😢","color":"#5C8DDE"}`
+		app.apiReplaceMood(rr, newRequest(http.MethodPut, body, userID))
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status %d, got %d (body: %s)", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Error map[string]string `json:"error"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if _, ok := resp.Error["title"]; !ok {
+			t.Errorf("Expected a validation error for title, got %v", resp.Error)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiPatchMood(rr, newRequest(http.MethodPatch, `{"title":"Hijack"}`, otherUserID))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestAPIMoodSchema(t *testing.T) {
+	app := newTestApplication(t)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/moods/schema", nil)
+	rr := httptest.NewRecorder()
+
+	app.apiMoodSchema(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp struct {
+		Schema struct {
+			Fields map[string]schemaField `json:"fields"`
+		} `json:"schema"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	fields := resp.Schema.Fields
+
+	limits := map[string]int{
+		"title":   data.MoodTitleMaxLength,
+		"emotion": data.MoodEmotionMaxLength,
+		"emoji":   data.MoodEmojiMaxRunes,
+		"content": data.MoodContentMaxLength,
+	}
+	for name, want := range limits {
+		if got := fields[name].MaxLength; got != want {
+			t.Errorf("%s: expected max_length %d, got %d", name, want, got)
+		}
+	}
+	if fields["color"].Pattern != validator.HexColorRX.String() {
+		t.Errorf("Expected color pattern %q, got %q", validator.HexColorRX.String(), fields["color"].Pattern)
+	}
+
+	// The advertised title limit must be the one ValidateMood actually enforces.
+	v := validator.NewValidator()
+	mood := &data.Mood{Title: strings.Repeat("a", fields["title"].MaxLength+1), Content: "x", Emotion: "Calm", Emoji: "😌", Color: "#69B36C"}
+	data.ValidateMood(v, mood)
+	if _, ok := v.Errors["title"]; !ok {
+		t.Error("Expected ValidateMood to reject a title one over the advertised max_length")
+	}
+}
+
+func TestAPIShowMe(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	if err := app.users.UpdateReminder(context.Background(), userID, true, "21:15", "daily"); err != nil {
+		t.Fatalf("Failed to set reminder: %v", err)
+	}
+
+	t.Run("Authenticated", func(t *testing.T) {
+		r := newSessionRequest(t, http.MethodGet, "/api/v1/me", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+
+		app.apiShowMe(rr, r)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			User map[string]any `json:"user"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.User["reminder_enabled"] != true || resp.User["reminder_time"] != "21:15" {
+			t.Errorf("Expected reminder fields in response, got %+v", resp.User)
+		}
+		if resp.User["id"] != float64(userID) || resp.User["time_format"] == nil {
+			t.Errorf("Expected id and preferences in response, got %+v", resp.User)
+		}
+		if strings.Contains(rr.Body.String(), "password") {
+			t.Errorf("Response must not include password data: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.apiShowMe(rr, newSessionRequest(t, http.MethodGet, "/api/v1/me", nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// mood/cmd/web/colors.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// intensityShadeStep is how much closer to white each intensity step below the
+// strongest moves a card's color, so intensity 1 is 60% of the way to white.
+const intensityShadeStep = 0.15
+
+// intensityShade returns the emotion color for a card at the given intensity: 5 keeps
+// the full color and each step down blends it further toward white, so milder entries
+// look lighter. color may be any hex code validator.HexColorRX accepts; the result is
+// "#RRGGBB", or "#RRGGBBAA" with the alpha kept. Anything else is returned unchanged,
+// and an intensity outside 1-5 is treated as the nearest end of the scale.
+func intensityShade(color string, intensity int) string {
+	if !validator.Matches(color, validator.HexColorRX) {
+		return color
+	}
+	digits := color[1:]
+	if len(digits) == 3 || len(digits) == 4 { // Shorthand: #abc is #aabbcc.
+		var expanded strings.Builder
+		for _, r := range digits {
+			expanded.WriteString(strings.Repeat(string(r), 2))
+		}
+		digits = expanded.String()
+	}
+
+	intensity = min(max(intensity, data.MoodIntensityMin), data.MoodIntensityMax)
+	white := intensityShadeStep * float64(data.MoodIntensityMax-intensity)
+
+	var shade strings.Builder
+	shade.WriteByte('#')
+	for i := 0; i < len(digits); i += 2 {
+		channel, _ := strconv.ParseUint(digits[i:i+2], 16, 8) // Valid hex, checked above.
+		// Blend red, green and blue; leave alpha alone.
+		if i < 6 {
+			channel = uint64(math.Round(float64(channel) + (255-float64(channel))*white))
+		}
+		fmt.Fprintf(&shade, "%02X", channel)
+	}
+	return shade.String()
+}
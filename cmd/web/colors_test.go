@@ -0,0 +1,48 @@
+// mood/cmd/web/colors_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestIntensityShade(t *testing.T) {
+	tests := []struct {
+		name      string
+		color     string
+		intensity int
+		want      string
+	}{
+		{"StrongestKeepsColor", "#FFCA28", 5, "#FFCA28"},
+		{"MildestIsLightest", "#FFCA28", 1, "#FFEAA9"},
+		{"Middle", "#FFCA28", 3, "#FFDA69"},
+		{"BlackAtMildest", "#000000", 1, "#999999"},
+		{"WhiteStaysWhite", "#ffffff", 1, "#FFFFFF"},
+		{"LowercaseUppercased", "#5c8dde", 5, "#5C8DDE"},
+		{"Shorthand", "#000", 1, "#999999"},
+		{"AlphaKept", "#00000080", 1, "#99999980"},
+		{"BelowScaleClamped", "#000000", 0, "#999999"},
+		{"AboveScaleClamped", "#000000", 9, "#000000"},
+		{"NotHexUnchanged", "emotion-unknown", 1, "emotion-unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intensityShade(tt.color, tt.intensity); got != tt.want {
+				t.Errorf("intensityShade(%q, %d) = %q, want %q", tt.color, tt.intensity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDisplayMoods_DisplayColor(t *testing.T) {
+	moods := []*data.Mood{{ID: 1, Title: "T", Content: "<p>c</p>", Color: "#FFCA28", Intensity: 1}}
+
+	got := newDisplayMoods(moods, data.DefaultPreviewLength)[0]
+	if got.DisplayColor != "#FFEAA9" {
+		t.Errorf("Expected the shaded color, got %q", got.DisplayColor)
+	}
+	if got.Color != "#FFCA28" {
+		t.Errorf("Expected the raw color to be kept for the filter and legend, got %q", got.Color)
+	}
+}
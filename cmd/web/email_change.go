@@ -0,0 +1,79 @@
+// mood/cmd/web/email_change.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// sendEmailChangeConfirmation emails a confirmation link for the user's pending email to
+// the new address. Links sent for an earlier pending address stop working, so only the
+// latest request can be confirmed.
+func (app *application) sendEmailChangeConfirmation(ctx context.Context, user *data.User, newEmail string) error {
+	if err := app.emailChanges.DeleteAllForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("email change: %w", err)
+	}
+	token, err := app.emailChanges.New(ctx, user.ID, data.EmailChangeTTL)
+	if err != nil {
+		return fmt.Errorf("email change: %w", err)
+	}
+	app.sendNotification(newEmail, "email_change_confirmation", map[string]any{
+		"Name":       user.Name,
+		"NewEmail":   newEmail,
+		"ConfirmURL": app.absoluteURL("/user/confirm-email?token=" + url.QueryEscape(token)),
+		"ExpiresIn":  "24 hours",
+	})
+	return nil
+}
+
+// confirmEmailChange handles GET /user/confirm-email?token=. A valid token makes the
+// user's pending email their login email, then the previous address is told about it.
+func (app *application) confirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.emailChanges.GetUserID(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverError(w, r, err)
+			return
+		}
+		app.session.Put(r, "flash", "This email confirmation link is invalid or has expired. Change your email again to get a new one.")
+		http.Redirect(w, r, "/user/profile", http.StatusSeeOther)
+		return
+	}
+
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	newEmail, err := app.users.ConfirmEmailChange(r.Context(), userID)
+	switch {
+	case errors.Is(err, data.ErrDuplicateEmail):
+		app.session.Put(r, "flash", "That email address is now used by another account, so your email wasn't changed.")
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.session.Put(r, "flash", "There's no email change waiting to be confirmed.")
+	case err != nil:
+		app.serverError(w, r, err)
+		return
+	default:
+		app.logger.Info("Email change confirmed", "userID", userID)
+		// Let the previous address know the login email changed, in case it wasn't the account owner.
+		app.notifyEmailChanged(user.Name, user.Email, newEmail)
+		app.session.Put(r, "flash", fmt.Sprintf("Your email address is now %s. Use it the next time you log in.", newEmail))
+	}
+	if err := app.emailChanges.DeleteAllForUser(r.Context(), userID); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/user/profile", http.StatusSeeOther)
+}
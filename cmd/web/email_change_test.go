@@ -0,0 +1,69 @@
+// mood/cmd/web/email_change_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEmailChangeConfirmation(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	userID := insertTestUser(t, app)
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+	newEmail := "moved@example.com"
+
+	// 1. Changing the email only makes it pending and emails the new address.
+	form := url.Values{"name": {"Renamed"}, "email": {newEmail}}
+	r := newSessionRequest(t, http.MethodPost, "/user/profile/update", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.updateUserProfile(rr, r)
+	app.wg.Wait()
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+	pending, _ := app.users.Get(context.Background(), userID)
+	if pending.Name != "Renamed" || pending.Email != user.Email || pending.PendingEmail != newEmail {
+		t.Fatalf("Expected renamed user with %s pending, got %+v", newEmail, pending)
+	}
+	sent := stub.Sent()
+	if len(sent) != 1 || sent[0].Recipient != newEmail || sent[0].TemplateName != "email_change_confirmation" {
+		t.Fatalf("Expected one confirmation email to %s, got %+v", newEmail, sent)
+	}
+	confirmURL, err := url.Parse(sent[0].Data.(map[string]any)["ConfirmURL"].(string))
+	if err != nil {
+		t.Fatalf("Bad confirmation URL: %v", err)
+	}
+
+	// 2. The link applies the change once and tells the old address.
+	rr = httptest.NewRecorder()
+	app.confirmEmailChange(rr, newSessionRequest(t, http.MethodGet, confirmURL.RequestURI(), nil))
+	app.wg.Wait()
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/user/profile" {
+		t.Fatalf("Expected a redirect to /user/profile, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	confirmed, _ := app.users.Get(context.Background(), userID)
+	if confirmed.Email != newEmail || confirmed.PendingEmail != "" {
+		t.Errorf("Expected email %s with nothing pending, got %q pending %q", newEmail, confirmed.Email, confirmed.PendingEmail)
+	}
+	sent = stub.Sent()
+	if len(sent) != 2 || sent[1].Recipient != user.Email || sent[1].TemplateName != "email_changed" {
+		t.Errorf("Expected an email_changed notice to %s, got %+v", user.Email, sent)
+	}
+
+	// 3. A used link no longer works.
+	if _, err := app.emailChanges.GetUserID(context.Background(), confirmURL.Query().Get("token")); err == nil {
+		t.Error("Expected the confirmation token to be deleted after use")
+	}
+}
@@ -0,0 +1,114 @@
+// mood/cmd/web/emotion_cache.go
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// emotionCache holds each user's distinct emotions for the dashboard's emotion
+// dropdown, so paging and filtering don't re-run the DISTINCT query on every request.
+// Handlers that change a user's entries call Invalidate, and the TTL bounds how stale
+// an entry can get if a write path is ever missed.
+type emotionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	fetch   func(ctx context.Context, userID int64) ([]data.EmotionDetail, error)
+	now     func() time.Time // Swappable clock for tests.
+	entries map[int64]emotionCacheEntry
+	gen     uint64 // Bumped by Invalidate, so a fetch that raced one isn't stored.
+}
+
+// emotionCacheEntry is one user's cached emotions.
+type emotionCacheEntry struct {
+	emotions  []data.EmotionDetail
+	fetchedAt time.Time
+}
+
+// newEmotionCache creates a cache that keeps each user's emotions for up to ttl.
+func newEmotionCache(ttl time.Duration, fetch func(ctx context.Context, userID int64) ([]data.EmotionDetail, error)) *emotionCache {
+	return &emotionCache{
+		ttl:     ttl,
+		fetch:   fetch,
+		now:     time.Now,
+		entries: make(map[int64]emotionCacheEntry),
+	}
+}
+
+// Get returns the user's cached emotions, fetching them if there are none or they are
+// older than the TTL. The lock isn't held while fetching, so one slow query doesn't
+// hold up other users. A failed fetch is not cached. The returned slice is shared
+// between requests and must not be modified.
+func (c *emotionCache) Get(ctx context.Context, userID int64) ([]data.EmotionDetail, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	gen := c.gen
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.emotions, nil
+	}
+
+	emotions, err := c.fetch(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen == gen {
+		c.entries[userID] = emotionCacheEntry{emotions: emotions, fetchedAt: c.now()}
+	}
+	return emotions, nil
+}
+
+// Invalidate drops the user's cached emotions, so the next Get reads them afresh.
+func (c *emotionCache) Invalidate(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+	c.gen++
+}
+
+// evictExpired forgets entries older than the TTL. Get would refetch them anyway,
+// so this only frees memory held for users who have logged out or deleted their account.
+func (c *emotionCache) evictExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for userID, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl {
+			delete(c.entries, userID)
+		}
+	}
+}
+
+// startEviction runs evictExpired every period for the life of the process.
+func (c *emotionCache) startEviction(period time.Duration) {
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			c.evictExpired()
+		}
+	}()
+}
+
+// distinctEmotions returns the user's emotion/emoji/color combinations for the
+// dashboard's emotion dropdown, through the cache when there is one.
+func (app *application) distinctEmotions(ctx context.Context, userID int64) ([]data.EmotionDetail, error) {
+	if app.emotionCache == nil {
+		return app.moods.GetDistinctEmotionDetails(ctx, userID)
+	}
+	return app.emotionCache.Get(ctx, userID)
+}
+
+// invalidateEmotions drops the user's cached dropdown emotions after their entries change.
+func (app *application) invalidateEmotions(userID int64) {
+	if app.emotionCache != nil {
+		app.emotionCache.Invalidate(userID)
+	}
+}
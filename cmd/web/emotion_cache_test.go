@@ -0,0 +1,141 @@
+// mood/cmd/web/emotion_cache_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestEmotionCache(t *testing.T) {
+	calls := map[int64]int{}
+	next := []data.EmotionDetail{{Name: "Happy", Emoji: "😊", Color: "#FFD700"}}
+	var fetchErr error
+	cache := newEmotionCache(time.Minute, func(ctx context.Context, userID int64) ([]data.EmotionDetail, error) {
+		calls[userID]++
+		return next, fetchErr
+	})
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// First call fetches; the second is served from the cache.
+	got, err := cache.Get(ctx, 1)
+	if err != nil || len(got) != 1 || calls[1] != 1 {
+		t.Fatalf("First Get: got %+v, err %v, calls %d", got, err, calls[1])
+	}
+	next = []data.EmotionDetail{{Name: "Sad", Emoji: "😢", Color: "#4682B4"}}
+	got, _ = cache.Get(ctx, 1)
+	if got[0].Name != "Happy" || calls[1] != 1 {
+		t.Errorf("Expected cached value, got %+v after %d fetches", got, calls[1])
+	}
+
+	// Users are cached separately.
+	got, _ = cache.Get(ctx, 2)
+	if got[0].Name != "Sad" || calls[2] != 1 {
+		t.Errorf("Expected user 2 to be fetched, got %+v after %d fetches", got, calls[2])
+	}
+
+	// Invalidate forces a refetch for that user only.
+	cache.Invalidate(1)
+	got, _ = cache.Get(ctx, 1)
+	if got[0].Name != "Sad" || calls[1] != 2 {
+		t.Errorf("Expected refetch after Invalidate, got %+v after %d fetches", got, calls[1])
+	}
+	cache.Get(ctx, 2)
+	if calls[2] != 1 {
+		t.Errorf("Invalidating user 1 refetched user 2 (%d fetches)", calls[2])
+	}
+
+	// Once the TTL has passed the value is refreshed.
+	now = now.Add(time.Minute)
+	cache.Get(ctx, 1)
+	if calls[1] != 3 {
+		t.Errorf("Expected refetch after TTL, got %d fetches", calls[1])
+	}
+
+	// A failed fetch is reported and not cached.
+	fetchErr = errors.New("db down")
+	cache.Invalidate(1)
+	if _, err := cache.Get(ctx, 1); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+	fetchErr = nil
+	if _, err := cache.Get(ctx, 1); err != nil || calls[1] != 5 {
+		t.Errorf("Expected a retry after a failed fetch, err %v, calls %d", err, calls[1])
+	}
+}
+
+func TestEmotionCache_InvalidateDuringFetch(t *testing.T) {
+	var cache *emotionCache
+	calls := 0
+	cache = newEmotionCache(time.Minute, func(ctx context.Context, userID int64) ([]data.EmotionDetail, error) {
+		calls++
+		if calls == 1 {
+			cache.Invalidate(userID) // An entry is saved while the first fetch is in flight.
+		}
+		return []data.EmotionDetail{{Name: "Happy"}}, nil
+	})
+
+	cache.Get(context.Background(), 1)
+	cache.Get(context.Background(), 1)
+	if calls != 2 {
+		t.Errorf("Expected a fetch that raced Invalidate not to be cached, got %d fetches", calls)
+	}
+}
+
+func TestEmotionCache_EvictExpired(t *testing.T) {
+	cache := newEmotionCache(time.Minute, func(ctx context.Context, userID int64) ([]data.EmotionDetail, error) {
+		return []data.EmotionDetail{}, nil
+	})
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.Get(context.Background(), 1)
+	now = now.Add(30 * time.Second)
+	cache.Get(context.Background(), 2)
+
+	now = now.Add(30 * time.Second)
+	cache.evictExpired()
+	if _, ok := cache.entries[1]; ok {
+		t.Error("Expected user 1's expired entry to be evicted")
+	}
+	if _, ok := cache.entries[2]; !ok {
+		t.Error("Expected user 2's fresh entry to be kept")
+	}
+}
+
+func TestDistinctEmotions_InvalidatedOnDelete(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	ctx := context.Background()
+
+	happy := &data.Mood{Title: "A", Content: "<p>x</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}
+	if err := app.moods.Insert(ctx, happy); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	if got, err := app.distinctEmotions(ctx, userID); err != nil || len(got) != 1 {
+		t.Fatalf("Expected 1 emotion, got %+v, err %v", got, err)
+	}
+
+	// Written straight through the model, so the cache doesn't know yet.
+	sad := &data.Mood{Title: "B", Content: "<p>x</p>", Emotion: "Sad", Emoji: "😢", Color: "#4682B4", UserID: userID}
+	if err := app.moods.Insert(ctx, sad); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	if got, _ := app.distinctEmotions(ctx, userID); len(got) != 1 {
+		t.Fatalf("Expected the cached single emotion, got %+v", got)
+	}
+
+	// Deleting through the handler helper invalidates the user's entry.
+	if err := app.deleteMoodEntry(ctx, happy.ID, userID); err != nil {
+		t.Fatalf("deleteMoodEntry: %v", err)
+	}
+	got, err := app.distinctEmotions(ctx, userID)
+	if err != nil || len(got) != 1 || got[0].Name != "Sad" {
+		t.Errorf("Expected only Sad after the delete, got %+v, err %v", got, err)
+	}
+}
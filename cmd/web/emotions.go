@@ -0,0 +1,132 @@
+// mood/cmd/web/emotions.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// customEmotions loads the user's saved custom emotions. A failure is logged and
+// shown as none, since the built-in emotions still make the picker usable.
+func (app *application) customEmotions(r *http.Request, userID int64) []data.EmotionDetail {
+	emotions, err := app.emotions.GetAllForUser(r.Context(), userID)
+	if err != nil {
+		app.logger.Error("Failed to fetch custom emotions", "error", err, "userID", userID)
+		return nil
+	}
+	return emotions
+}
+
+// addCustomEmotionsToPicker appends the user's saved custom emotions to the mood
+// form's picker, after the built-in ones.
+func (app *application) addCustomEmotionsToPicker(r *http.Request, td *TemplateData) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		return
+	}
+	td.DefaultEmotions = pickerEmotions(td.DefaultEmotions, app.customEmotions(r, userID))
+}
+
+// pickerEmotions merges saved custom emotions into the built-in picker choices. A
+// custom emotion named like one already in the picker is skipped, so the built-in
+// look wins and each radio value stays unique.
+func pickerEmotions(builtIn []EmotionDetails, custom []data.EmotionDetail) []EmotionDetails {
+	merged := make([]EmotionDetails, 0, len(builtIn)+len(custom))
+	merged = append(merged, builtIn...)
+	seen := make(map[string]bool, len(builtIn))
+	for _, emotion := range builtIn {
+		seen[emotion.Name] = true
+	}
+	for _, emotion := range custom {
+		if seen[emotion.Name] {
+			continue
+		}
+		seen[emotion.Name] = true
+		merged = append(merged, EmotionDetails{Name: emotion.Name, Emoji: emotion.Emoji, Color: emotion.Color, Custom: true})
+	}
+	return merged
+}
+
+// saveCustomEmotion handles POST /user/emotions. It adds a custom emotion to the
+// user's picker, or updates the emoji and color of one they already saved.
+func (app *application) saveCustomEmotion(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	emotion := data.EmotionDetail{
+		Name:  r.PostForm.Get("emotion"),
+		Emoji: r.PostForm.Get("emoji"),
+		Color: r.PostForm.Get("color"),
+	}
+
+	// 3. Validate.
+	v := validator.NewValidator()
+	if data.ValidateCustomEmotion(v, &emotion); !v.ValidData() {
+		first := v.OrderedErrors()[0]
+		app.session.Put(r, "flash", fmt.Sprintf("Couldn't save emotion: %s %s.", first.Field, first.Message))
+		app.redirectAfterForm(w, r, "/user/profile?page=2")
+		return
+	}
+
+	// 4. Save.
+	err := app.emotions.Save(r.Context(), userID, emotion)
+	switch {
+	case errors.Is(err, data.ErrTooManyEmotions):
+		app.session.Put(r, "flash", fmt.Sprintf("You can keep up to %d custom emotions. Remove one to add another.", data.MaxCustomEmotions))
+	case err != nil:
+		app.serverError(w, r, fmt.Errorf("save custom emotion: %w", err))
+		return
+	default:
+		app.logger.Info("Custom emotion saved", "userID", userID, "emotion", emotion.Name)
+		app.session.Put(r, "flash", fmt.Sprintf("Saved %s %s to your emotions.", emotion.Emoji, emotion.Name))
+	}
+
+	app.redirectAfterForm(w, r, "/user/profile?page=2")
+}
+
+// deleteCustomEmotion handles POST /user/emotions/delete. The name is posted rather
+// than put in the path, since custom emotion names can contain any character.
+// Entries already logged with the emotion keep it.
+func (app *application) deleteCustomEmotion(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	name := r.PostForm.Get("emotion")
+
+	// 3. Delete (scoped to the user, so another user's emotion is simply "not found").
+	err := app.emotions.Delete(r.Context(), userID, name)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, fmt.Errorf("delete custom emotion: %w", err))
+		}
+		return
+	}
+	app.logger.Info("Custom emotion deleted", "userID", userID, "emotion", name)
+	app.session.Put(r, "flash", fmt.Sprintf("Removed %s from your emotions.", name))
+
+	app.redirectAfterForm(w, r, "/user/profile?page=2")
+}
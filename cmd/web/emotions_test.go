@@ -0,0 +1,83 @@
+// mood/cmd/web/emotions_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestPickerEmotions(t *testing.T) {
+	builtIn := []EmotionDetails{{Name: "Happy", Emoji: "😊", Color: "#FFCA28"}}
+	custom := []data.EmotionDetail{
+		{Name: "Grateful", Emoji: "🙏", Color: "#8E7CC3"},
+		{Name: "Happy", Emoji: "😁", Color: "#000000"}, // Can't replace a built-in emotion.
+	}
+	got := pickerEmotions(builtIn, custom)
+	want := []EmotionDetails{
+		{Name: "Happy", Emoji: "😊", Color: "#FFCA28"},
+		{Name: "Grateful", Emoji: "🙏", Color: "#8E7CC3", Custom: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pickerEmotions = %+v, want %+v", got, want)
+	}
+	if len(builtIn) != 1 {
+		t.Errorf("pickerEmotions modified the built-in slice: %+v", builtIn)
+	}
+}
+
+func TestCustomEmotions(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+
+	// Save a custom emotion.
+	form := url.Values{"emotion": {"Grateful"}, "emoji": {"🙏"}, "color": {"#8E7CC3"}}
+	r := newSessionRequest(t, http.MethodPost, "/user/emotions", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.saveCustomEmotion(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+
+	// It appears in the new-mood picker without any entry using it.
+	r = newSessionRequest(t, http.MethodGet, "/mood/new", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr = httptest.NewRecorder()
+	app.showMoodForm(rr, r)
+	if body := rr.Body.String(); !strings.Contains(body, `value="Grateful"`) || !strings.Contains(body, "custom-emotion-option") {
+		t.Errorf("Expected the custom emotion in the picker, got:\n%s", body)
+	}
+
+	// A built-in name is rejected with a flash and nothing is stored.
+	form = url.Values{"emotion": {"Happy"}, "emoji": {"😁"}, "color": {"#000000"}}
+	r = newSessionRequest(t, http.MethodPost, "/user/emotions", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	app.saveCustomEmotion(httptest.NewRecorder(), r)
+	if flash := app.session.GetString(r, "flash"); !strings.Contains(flash, "Couldn't save emotion") {
+		t.Errorf("Expected a validation flash, got %q", flash)
+	}
+
+	// Remove it.
+	form = url.Values{"emotion": {"Grateful"}}
+	r = newSessionRequest(t, http.MethodPost, "/user/emotions/delete", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr = httptest.NewRecorder()
+	app.deleteCustomEmotion(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if emotions, _ := app.emotions.GetAllForUser(context.Background(), userID); len(emotions) != 0 {
+		t.Errorf("Expected no custom emotions after delete, got %+v", emotions)
+	}
+}
@@ -0,0 +1,63 @@
+// mood/cmd/web/etag.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// dashboardETag hashes everything that changes the rendered dashboard fragment:
+// the displayMoods slice, pagination metadata, the echoed filters, saved views and
+// the display preferences. Identical inputs always give the same ETag, so an HTMX request for
+// an unchanged dashboard can be answered with 304 Not Modified.
+//
+// The ETag is weak because the fragment also embeds a per-request CSRF token,
+// which is equivalent but not byte-identical between renders.
+func dashboardETag(td *TemplateData) (string, error) {
+	payload := struct {
+		Moods       []displayMood
+		Metadata    data.Metadata
+		Emotions    []data.EmotionDetail
+		Filters     []string
+		SavedViews  []savedViewLink
+		PrivacyMode bool
+		ViewMode    string
+		TimeFormat  string
+	}{
+		Moods:       td.DisplayMoods,
+		Metadata:    td.Metadata,
+		Emotions:    td.AvailableEmotions,
+		Filters:     []string{td.SearchQuery, td.FilterEmotion, td.FilterStartDate, td.FilterEndDate, td.FilterWeekday, td.FilterMinIntensity, td.FilterMaxIntensity, td.SortOrder},
+		SavedViews:  td.SavedViews,
+		PrivacyMode: td.PrivacyMode,
+		ViewMode:    td.ViewMode,
+		TimeFormat:  td.TimeFormat,
+	}
+	// json.Marshal encodes struct fields in declaration order, so the output is deterministic.
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches reports whether an If-None-Match header contains etag.
+// Weak comparison is used, so W/"x" and "x" match each other.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// mood/cmd/web/etag_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestDashboardETag(t *testing.T) {
+	newData := func() *TemplateData {
+		return &TemplateData{
+			DisplayMoods: []displayMood{
+				{ID: 2, Title: "Second", Emotion: "Calm", UpdatedAt: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)},
+				{ID: 1, Title: "First", Emotion: "Happy", UpdatedAt: time.Date(2024, 5, 9, 12, 0, 0, 0, time.UTC)},
+			},
+			Metadata:   data.Metadata{CurrentPage: 1, PageSize: 4, FirstPage: 1, LastPage: 1, TotalRecords: 2},
+			TimeFormat: "24h",
+		}
+	}
+
+	first, err := dashboardETag(newData())
+	if err != nil {
+		t.Fatalf("dashboardETag returned error: %v", err)
+	}
+	second, _ := dashboardETag(newData())
+	if first != second {
+		t.Errorf("Expected identical data to give the same ETag, got %s and %s", first, second)
+	}
+
+	// Anything that changes the fragment must change the ETag.
+	changes := map[string]func(td *TemplateData){
+		"EditedTitle": func(td *TemplateData) { td.DisplayMoods[0].Title = "Edited" },
+		"Updated":     func(td *TemplateData) { td.DisplayMoods[1].UpdatedAt = td.DisplayMoods[1].UpdatedAt.Add(time.Second) },
+		"Reordered": func(td *TemplateData) {
+			td.DisplayMoods[0], td.DisplayMoods[1] = td.DisplayMoods[1], td.DisplayMoods[0]
+		},
+		"Deleted":      func(td *TemplateData) { td.DisplayMoods = td.DisplayMoods[:1] },
+		"Page":         func(td *TemplateData) { td.Metadata.CurrentPage = 2 },
+		"Filter":       func(td *TemplateData) { td.FilterWeekday = "mon" },
+		"PrivacyMode":  func(td *TemplateData) { td.PrivacyMode = true },
+		"ClockFormat":  func(td *TemplateData) { td.TimeFormat = "12h" },
+		"NewEmotion":   func(td *TemplateData) { td.AvailableEmotions = []data.EmotionDetail{{Name: "Sad"}} },
+		"SearchChange": func(td *TemplateData) { td.SearchQuery = "work" },
+	}
+	for name, change := range changes {
+		td := newData()
+		change(td)
+		etag, _ := dashboardETag(td)
+		if etag == first {
+			t.Errorf("%s: expected the ETag to change", name)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `W/"abc"`, want: true},
+		{header: `"abc"`, want: true},
+		{header: `"x", W/"abc"`, want: true},
+		{header: "*", want: true},
+		{header: `W/"abd"`, want: false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `W/"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestShowDashboardPage_ETag(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	if err := app.moods.Insert(context.Background(), &data.Mood{Title: "T", Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := newSessionRequest(t, http.MethodGet, "/dashboard", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		r.Header.Set("HX-Request", "true")
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		app.showDashboardPage(rr, r)
+		return rr
+	}
+
+	first := get("")
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag on the HTMX fragment")
+	}
+	if second := get(""); second.Header().Get("ETag") != etag {
+		t.Errorf("Expected identical requests to share an ETag, got %s and %s", etag, second.Header().Get("ETag"))
+	}
+
+	notModified := get(etag)
+	if notModified.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching If-None-Match, got %d", notModified.Code)
+	}
+	if notModified.Body.Len() != 0 {
+		t.Error("Expected an empty body with 304")
+	}
+
+	if stale := get(`W/"stale"`); stale.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale ETag, got %d", stale.Code)
+	}
+}
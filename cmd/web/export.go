@@ -0,0 +1,417 @@
+// mood/cmd/web/export.go
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// journalRule and journalDivider separate entries, and an entry's header from its body,
+// in the plain-text journal export.
+var (
+	journalRule    = strings.Repeat("=", 60)
+	journalDivider = strings.Repeat("-", 60)
+)
+
+// blockBreakRX matches the closing tags and line breaks that should become newlines
+// when rich-text content is flattened to plain text.
+var blockBreakRX = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6]|blockquote)>`)
+
+// exportPageSize is how many entries each GetFiltered call returns while a filtered
+// export is collected.
+const exportPageSize = 500
+
+// exportCriteria reads the dashboard's filter parameters (query, emotion, start_date,
+// end_date, weekday, min_intensity, max_intensity) from an export URL, so the
+// dashboard's "Export these results" links download just what the user is viewing.
+// Invalid values are ignored, as they are on the dashboard. filtered reports whether
+// any of them narrows the export.
+func exportCriteria(query url.Values, userID int64, location *time.Location) (criteria data.FilterCriteria, filtered bool) {
+	criteria = data.FilterCriteria{
+		TextQuery: strings.TrimSpace(query.Get("query")),
+		Emotion:   query.Get("emotion"),
+		Location:  location,
+		Sort:      "created_at_asc",
+		PageSize:  exportPageSize,
+		UserID:    userID,
+	}
+	if name, emoji, ok := data.DecodeEmotionFilter(criteria.Emotion); ok {
+		criteria.Emotion = data.EncodeEmotionFilter(name, emoji)
+	}
+	if s := query.Get("start_date"); s != "" {
+		criteria.StartDate, _, _ = parseFilterDay(s, location) // Zero, so no bound, if invalid.
+	}
+	if s := query.Get("end_date"); s != "" {
+		_, criteria.EndDate, _ = parseFilterDay(s, location)
+		if !criteria.StartDate.IsZero() && criteria.EndDate.Before(criteria.StartDate) {
+			criteria.EndDate = time.Time{}
+		}
+	}
+	criteria.Weekday, _ = parseWeekday(query.Get("weekday"))
+	criteria.MinIntensity, criteria.MaxIntensity, _ = parseIntensityRange(query.Get("min_intensity"), query.Get("max_intensity"))
+
+	filtered = criteria.TextQuery != "" || criteria.Emotion != "" ||
+		!criteria.StartDate.IsZero() || !criteria.EndDate.IsZero() || criteria.Weekday != data.AnyWeekday ||
+		criteria.MinIntensity > data.MoodIntensityMin || criteria.MaxIntensity < data.MoodIntensityMax
+	return criteria, filtered
+}
+
+// exportEntries returns the entries an export should contain, oldest first: every one
+// of the user's entries, or, when filtered, those matching criteria, fetched a page at a time.
+func (app *application) exportEntries(ctx context.Context, criteria data.FilterCriteria, filtered bool) ([]*data.Mood, error) {
+	if !filtered {
+		return app.moods.GetAllForUser(ctx, criteria.UserID)
+	}
+
+	moods := []*data.Mood{}
+	for page := 1; ; page++ {
+		criteria.Page = page
+		batch, metadata, err := app.moods.GetFiltered(ctx, criteria)
+		if err != nil {
+			return nil, err
+		}
+		moods = append(moods, batch...)
+		if page >= metadata.LastPage {
+			break
+		}
+	}
+	// GetFiltered lists pinned entries first; an export is in plain date order.
+	slices.SortStableFunc(moods, func(a, b *data.Mood) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return moods, nil
+}
+
+// exportJournal handles GET /user/export/journal?year=&month=[&redact=1].
+// It downloads one month of the user's entries as a readable plain-text journal.
+// With redact=1 the titles and written content are left out, so the download can
+// be shared as a record of dates and emotions only.
+func (app *application) exportJournal(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse & Validate the Month.
+	query := r.URL.Query()
+	v := validator.NewValidator()
+	year, yearErr := strconv.Atoi(query.Get("year"))
+	month, monthErr := strconv.Atoi(query.Get("month"))
+	v.Check(yearErr == nil && year >= 2000 && year <= 9999, "year", "must be a year between 2000 and 9999")
+	v.Check(monthErr == nil && month >= 1 && month <= 12, "month", "must be a month number from 1 to 12")
+	if !v.ValidData() {
+		app.logger.Warn("Invalid journal export period", "userID", userID, "year", query.Get("year"), "month", query.Get("month"))
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	redact := query.Get("redact") == "1"
+
+	// 3. Fetch the User (for the heading, clock format and time zone) and the Month's Entries.
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get user for journal export: %w", err))
+		return
+	}
+	location := app.locationForUser(r, user)
+	moods, err := app.moods.GetByMonth(r.Context(), userID, location.String(), year, time.Month(month))
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get moods for journal export: %w", err))
+		return
+	}
+
+	// 4. Build the Journal in a Buffer, so an error can't leave a half-sent download.
+	period := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	writeJournal(buf, user.Name, period, moods, user.TimeFormat, redact)
+
+	// 5. Send as a Download.
+	filename := fmt.Sprintf("feelflow-journal-%04d-%02d.txt", year, month)
+	if redact {
+		filename = fmt.Sprintf("feelflow-journal-%04d-%02d-redacted.txt", year, month)
+	}
+	app.logger.Info("Export downloaded", "userID", userID, "file", filename, "rows", len(moods))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// exportMoods handles GET /mood/export[?redact=1]. It downloads all of the user's entries
+// as a CSV, one row per entry with the content flattened to plain text. The dashboard's
+// filter parameters (see exportCriteria) narrow it to the matching entries. With redact=1
+// the title and content columns are left out, as in the redacted journal, so the file
+// can be shared for its dates and emotions alone.
+func (app *application) exportMoods(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Fetch Every Entry, or Just the Filtered Ones.
+	criteria, filtered := exportCriteria(r.URL.Query(), userID, app.userLocation(r))
+	moods, err := app.exportEntries(r.Context(), criteria, filtered)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get moods for CSV export: %w", err))
+		return
+	}
+
+	// 3. Build Rows: encoding/csv quotes commas, quotes and newlines in the content.
+	redact := r.URL.Query().Get("redact") == "1"
+	rows := make([][]string, 0, len(moods))
+	for _, mood := range moods {
+		created := mood.CreatedAt.UTC().Format(time.RFC3339)
+		updated := mood.UpdatedAt.UTC().Format(time.RFC3339)
+		if redact {
+			rows = append(rows, []string{strconv.FormatInt(mood.ID, 10), created, updated, mood.Emotion, mood.Emoji, mood.Color})
+			continue
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(mood.ID, 10),
+			created,
+			updated,
+			mood.Title,
+			mood.Emotion,
+			mood.Emoji,
+			mood.Color,
+			journalPlainText(mood.Content),
+		})
+	}
+	header := []string{"id", "created_at", "updated_at", "title", "emotion", "emoji", "color", "content"}
+	if redact {
+		header = []string{"id", "created_at", "updated_at", "emotion", "emoji", "color"}
+	}
+	filename := "moods" + exportSuffix(filtered, redact) + ".csv"
+	app.writeCSV(w, r, filename, header, rows)
+}
+
+// exportMarkdown handles GET /mood/export.md. It downloads every entry as one
+// Markdown journal, oldest first: a section per entry headed by its date and title,
+// then its emotion and its content converted to Markdown. The dashboard's filter
+// parameters (see exportCriteria) narrow it to the matching entries.
+func (app *application) exportMarkdown(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Fetch the User (for the heading, clock format and time zone) and Every Entry,
+	//    or Just the Filtered Ones.
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get user for Markdown export: %w", err))
+		return
+	}
+	location := app.locationForUser(r, user)
+	criteria, filtered := exportCriteria(r.URL.Query(), userID, location)
+	moods, err := app.exportEntries(r.Context(), criteria, filtered)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get moods for Markdown export: %w", err))
+		return
+	}
+
+	// 3. Build the Journal in a Buffer, so an error can't leave a half-sent download.
+	for _, mood := range moods {
+		mood.CreatedAt = mood.CreatedAt.In(location)
+	}
+	buf := new(bytes.Buffer)
+	writeMarkdownJournal(buf, user.Name, moods, user.TimeFormat)
+
+	// 4. Send as a Download named for today's date.
+	filename := fmt.Sprintf("feelflow-journal-%s.md", time.Now().In(location).Format("2006-01-02"))
+	if filtered {
+		filename = fmt.Sprintf("feelflow-journal-%s-filtered.md", time.Now().In(location).Format("2006-01-02"))
+	}
+	app.logger.Info("Export downloaded", "userID", userID, "file", filename, "rows", len(moods))
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// dataExport is the document GET /user/export.json downloads. It relies on the
+// models' JSON tags: the password hash and private notes are tagged json:"-".
+// Moods holds []*data.Mood, or []redactedMood for a redacted export.
+type dataExport struct {
+	User  *data.User `json:"user"`
+	Moods any        `json:"moods"`
+}
+
+// redactedMood is an entry in a redacted JSON export: its dates and emotion, without
+// the title or content.
+type redactedMood struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Emotion   string    `json:"emotion"`
+	Emoji     string    `json:"emoji"`
+	Color     string    `json:"color"`
+	Intensity int       `json:"intensity"`
+}
+
+// exportSuffix is the filename suffix marking a filtered and/or redacted export.
+func exportSuffix(filtered, redact bool) string {
+	suffix := ""
+	if filtered {
+		suffix += "-filtered"
+	}
+	if redact {
+		suffix += "-redacted"
+	}
+	return suffix
+}
+
+// exportJSON handles GET /user/export.json[?redact=1]. It downloads the user's profile
+// and every entry as one JSON document, for moving the data to another tool. The
+// dashboard's filter parameters (see exportCriteria) narrow it to the matching entries.
+// With redact=1 each entry's title and content are left out (see redactedMood).
+func (app *application) exportJSON(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Fetch the Profile and Every Entry, or Just the Filtered Ones.
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get user for JSON export: %w", err))
+		return
+	}
+	criteria, filtered := exportCriteria(r.URL.Query(), userID, app.locationForUser(r, user))
+	moods, err := app.exportEntries(r.Context(), criteria, filtered)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get moods for JSON export: %w", err))
+		return
+	}
+
+	// 3. Marshal before sending, so an error can't leave a half-sent download.
+	export := dataExport{User: user, Moods: moods}
+	redact := r.URL.Query().Get("redact") == "1"
+	if redact {
+		redacted := make([]redactedMood, len(moods))
+		for i, mood := range moods {
+			redacted[i] = redactedMood{
+				ID: mood.ID, CreatedAt: mood.CreatedAt, UpdatedAt: mood.UpdatedAt,
+				Emotion: mood.Emotion, Emoji: mood.Emoji, Color: mood.Color, Intensity: mood.Intensity,
+			}
+		}
+		export.Moods = redacted
+	}
+	js, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("marshal JSON export: %w", err))
+		return
+	}
+
+	// 4. Send as a Download named for today's date.
+	filename := fmt.Sprintf("feelflow-export-%s%s.json", time.Now().UTC().Format("2006-01-02"), exportSuffix(filtered, redact))
+	app.logger.Info("Export downloaded", "userID", userID, "file", filename, "rows", len(moods))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(js, '\n'))
+}
+
+// writeJournal formats one month of entries as plain text: a heading, then each
+// entry's date, emotion and title above its content, with rules between entries.
+// When redact is true only each entry's date and emotion are written.
+func writeJournal(w io.Writer, name string, period time.Time, moods []*data.Mood, timeFormat string, redact bool) {
+	fmt.Fprintf(w, "Feel Flow Journal: %s\n", period.Format("January 2006"))
+	if name != "" {
+		fmt.Fprintf(w, "%s\n", name)
+	}
+	fmt.Fprintf(w, "%d %s\n", len(moods), pluralize(len(moods), "entry", "entries"))
+	if redact {
+		fmt.Fprintf(w, "Redacted: titles and entry text are not included.\n")
+	}
+
+	if len(moods) == 0 {
+		fmt.Fprintf(w, "\n%s\nNo entries were logged this month.\n", journalRule)
+		return
+	}
+
+	for _, mood := range moods {
+		fmt.Fprintf(w, "\n%s\n", journalRule)
+		fmt.Fprintf(w, "%s  |  %s %s\n", humanDate(mood.CreatedAt, timeFormat), mood.Emoji, mood.Emotion)
+		if redact {
+			continue
+		}
+		fmt.Fprintf(w, "%s\n", mood.Title)
+		fmt.Fprintf(w, "%s\n", journalDivider)
+		fmt.Fprintf(w, "%s\n", journalPlainText(mood.Content))
+	}
+	fmt.Fprintf(w, "%s\n", journalRule)
+}
+
+// writeMarkdownJournal formats entries as a Markdown document: a title, then an H2
+// per entry with its date and title, its emotion, and its content, with a rule
+// between entries. Entries are written in the order given.
+func writeMarkdownJournal(w io.Writer, name string, moods []*data.Mood, timeFormat string) {
+	fmt.Fprintf(w, "# Feel Flow Journal\n\n")
+	if name != "" {
+		fmt.Fprintf(w, "%s · ", markdownEscaper.Replace(name))
+	}
+	fmt.Fprintf(w, "%d %s\n", len(moods), pluralize(len(moods), "entry", "entries"))
+
+	if len(moods) == 0 {
+		fmt.Fprintf(w, "\nNo entries have been logged yet.\n")
+		return
+	}
+
+	for i, mood := range moods {
+		if i > 0 {
+			fmt.Fprintf(w, "\n---\n")
+		}
+		fmt.Fprintf(w, "\n## %s — %s\n\n", humanDate(mood.CreatedAt, timeFormat), markdownEscaper.Replace(mood.Title))
+		fmt.Fprintf(w, "%s **%s** · intensity %d/%d\n", mood.Emoji, markdownEscaper.Replace(mood.Emotion), mood.Intensity, data.MoodIntensityMax)
+		if content := htmlToMarkdown(mood.Content); content != "" {
+			fmt.Fprintf(w, "\n%s\n", content)
+		}
+	}
+}
+
+// journalPlainText flattens rich-text content for the journal: paragraph and line
+// breaks become newlines, all other markup is stripped and entities are decoded.
+func journalPlainText(content string) string {
+	withBreaks := blockBreakRX.ReplaceAllString(content, "\n")
+	plain := html.UnescapeString(data.SanitizePreview(withBreaks))
+
+	lines := strings.Split(plain, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// pluralize picks the singular or plural form of a word for n.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
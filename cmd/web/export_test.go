@@ -0,0 +1,425 @@
+// mood/cmd/web/export_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestWriteJournal(t *testing.T) {
+	period := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	moods := []*data.Mood{
+		{Title: "Morning walk", Content: "<p>Sunny &amp; calm.</p><p>Saw a <strong>heron</strong>.</p>", Emotion: "Calm", Emoji: "😌", CreatedAt: time.Date(2024, 5, 3, 8, 30, 0, 0, time.UTC)},
+		{Title: "Deadline", Content: "<p>Stressful day</p>", Emotion: "Anxious", Emoji: "😟", CreatedAt: time.Date(2024, 5, 20, 18, 5, 0, 0, time.UTC)},
+	}
+
+	buf := new(bytes.Buffer)
+	writeJournal(buf, "Test User", period, moods, "12h", false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Feel Flow Journal: May 2024",
+		"Test User",
+		"2 entries",
+		"May 03, 2024 at 8:30 AM  |  😌 Calm",
+		"Morning walk",
+		"Sunny & calm.\nSaw a heron.",
+		"Deadline",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected journal to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<p>") || strings.Contains(out, "<strong>") {
+		t.Errorf("Expected HTML to be stripped, got:\n%s", out)
+	}
+
+	buf.Reset()
+	writeJournal(buf, "Test User", period, nil, "24h", false)
+	if !strings.Contains(buf.String(), "No entries were logged this month.") {
+		t.Errorf("Expected an empty-month note, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteJournal_Redacted(t *testing.T) {
+	period := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	moods := []*data.Mood{
+		{Title: "Morning walk", Content: "<p>Saw a heron.</p>", Emotion: "Calm", Emoji: "😌", CreatedAt: time.Date(2024, 5, 3, 8, 30, 0, 0, time.UTC)},
+	}
+
+	buf := new(bytes.Buffer)
+	writeJournal(buf, "Test User", period, moods, "12h", false)
+	if out := buf.String(); !strings.Contains(out, "Morning walk") || !strings.Contains(out, "Saw a heron.") {
+		t.Fatalf("Expected the normal journal to include the entry's writing, got:\n%s", out)
+	}
+
+	buf.Reset()
+	writeJournal(buf, "Test User", period, moods, "12h", true)
+	out := buf.String()
+	for _, want := range []string{"1 entry", "May 03, 2024 at 8:30 AM  |  😌 Calm", "Redacted"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected redacted journal to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"Morning walk", "heron"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("Expected redacted journal to omit %q, got:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestExportJournal_InvalidPeriod(t *testing.T) {
+	app := newTestApplication(t)
+
+	for _, target := range []string{
+		"/user/export/journal",
+		"/user/export/journal?year=2024&month=13",
+		"/user/export/journal?year=2024&month=0",
+		"/user/export/journal?year=abc&month=5",
+		"/user/export/journal?year=1999&month=5",
+	} {
+		r := newSessionRequest(t, http.MethodGet, target, nil)
+		app.session.Put(r, "authenticatedUserID", int64(1))
+		rr := httptest.NewRecorder()
+		app.exportJournal(rr, r)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestExportJournal(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	entries := []struct {
+		title string
+		at    time.Time
+	}{
+		{"April fools", time.Date(2024, 4, 30, 23, 59, 0, 0, time.UTC)},
+		{"May first", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"May last", time.Date(2024, 5, 31, 23, 59, 0, 0, time.UTC)},
+		{"June start", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, e := range entries {
+		mood := &data.Mood{Title: e.title, Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+		if _, err := app.moods.DB.Exec(`UPDATE moods SET created_at = $1 WHERE id = $2`, e.at, mood.ID); err != nil {
+			t.Fatalf("Failed to backdate mood: %v", err)
+		}
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/user/export/journal?year=2024&month=5", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.exportJournal(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); cd != `attachment; filename="feelflow-journal-2024-05.txt"` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"May first", "May last"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected journal to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"April fools", "June start"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("Expected journal to exclude %q from another month", unwanted)
+		}
+	}
+}
+
+func TestExportMoods(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	export := func() *httptest.ResponseRecorder {
+		r := newSessionRequest(t, http.MethodGet, "/mood/export", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.exportMoods(rr, r)
+		return rr
+	}
+
+	// An empty account still gets a valid file with just the header row.
+	rr := export()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); cd != `attachment; filename="moods.csv"` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Unexpected Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil || len(records) != 1 || strings.Join(records[0], ",") != "id,created_at,updated_at,title,emotion,emoji,color,content" {
+		t.Fatalf("Expected only the header row, got %v (err %v)", records, err)
+	}
+
+	// Commas, quotes and line breaks in the content stay inside one column.
+	mood := &data.Mood{Title: "Busy, busy", Content: `<p>Said "no", finally.</p><p>Second line</p>`, Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+	records, err = csv.NewReader(export().Body).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected a header and one row, got %v (err %v)", records, err)
+	}
+	row := records[1]
+	if len(row) != 8 || row[3] != "Busy, busy" || row[7] != "Said \"no\", finally.\nSecond line" {
+		t.Errorf("Unexpected row %q", row)
+	}
+}
+
+func TestExportCriteria(t *testing.T) {
+	belize, err := time.LoadLocation("America/Belize")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	// No parameters, or only invalid ones, export everything.
+	for _, raw := range []string{"", "start_date=May+5&weekday=funday&min_intensity=9", "query=+++&sort=title_asc&page=3"} {
+		query, _ := url.ParseQuery(raw)
+		if _, filtered := exportCriteria(query, 7, belize); filtered {
+			t.Errorf("exportCriteria(%q): expected no filtering", raw)
+		}
+	}
+
+	query, _ := url.ParseQuery("query=+work+&emotion=Anxious::😟&start_date=2024-03-01&end_date=2024-03-31&weekday=mon&min_intensity=3")
+	criteria, filtered := exportCriteria(query, 7, belize)
+	if !filtered {
+		t.Fatal("Expected the filters to narrow the export")
+	}
+	if criteria.TextQuery != "work" || criteria.Emotion != data.EncodeEmotionFilter("Anxious", "😟") {
+		t.Errorf("Unexpected text/emotion filters: %q, %q", criteria.TextQuery, criteria.Emotion)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, belize); !criteria.StartDate.Equal(want) {
+		t.Errorf("Expected start %v in the user's zone, got %v", want, criteria.StartDate)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, belize).Add(-time.Nanosecond); !criteria.EndDate.Equal(want) {
+		t.Errorf("Expected inclusive end %v, got %v", want, criteria.EndDate)
+	}
+	if criteria.Weekday != 1 || criteria.MinIntensity != 3 || criteria.MaxIntensity != data.MoodIntensityMax {
+		t.Errorf("Unexpected weekday/intensity: %d, %d-%d", criteria.Weekday, criteria.MinIntensity, criteria.MaxIntensity)
+	}
+	if criteria.UserID != 7 || criteria.PageSize != exportPageSize {
+		t.Errorf("Unexpected user/page size: %d, %d", criteria.UserID, criteria.PageSize)
+	}
+
+	// An end date before the start date is dropped, as on the dashboard.
+	query, _ = url.ParseQuery("start_date=2024-03-10&end_date=2024-03-01")
+	if criteria, _ := exportCriteria(query, 7, belize); !criteria.EndDate.IsZero() {
+		t.Errorf("Expected the inverted end date to be ignored, got %v", criteria.EndDate)
+	}
+}
+
+func TestExportMoods_Filtered(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	for _, m := range []struct{ title, emotion, emoji string }{
+		{"Calm one", "Calm", "😌"},
+		{"Anxious one", "Anxious", "😟"},
+		{"Anxious two", "Anxious", "😟"},
+	} {
+		mood := &data.Mood{Title: m.title, Content: "<p>x</p>", Emotion: m.emotion, Emoji: m.emoji, Color: "#ADD8E6", UserID: userID}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+
+	target := "/mood/export?" + url.Values{"emotion": {data.EncodeEmotionFilter("Anxious", "😟")}}.Encode()
+	r := newSessionRequest(t, http.MethodGet, target, nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.exportMoods(rr, r)
+
+	if cd := rr.Header().Get("Content-Disposition"); cd != `attachment; filename="moods-filtered.csv"` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Expected a header and the two anxious rows, got %v (err %v)", records, err)
+	}
+	if records[1][3] != "Anxious one" || records[2][3] != "Anxious two" {
+		t.Errorf("Expected the matching entries oldest first, got %q and %q", records[1][3], records[2][3])
+	}
+}
+
+func TestWriteMarkdownJournal(t *testing.T) {
+	moods := []*data.Mood{
+		{Title: "Morning walk", Content: "<p>Saw a <strong>heron</strong>.</p>", Emotion: "Calm", Emoji: "😌", Intensity: 2, CreatedAt: time.Date(2024, 5, 3, 8, 30, 0, 0, time.UTC)},
+		{Title: "Deadline_day", Content: "<p>Stressful</p>", Emotion: "Anxious", Emoji: "😟", Intensity: 4, CreatedAt: time.Date(2024, 5, 20, 18, 5, 0, 0, time.UTC)},
+	}
+
+	buf := new(bytes.Buffer)
+	writeMarkdownJournal(buf, "Test User", moods, "24h")
+	want := "# Feel Flow Journal\n\n" +
+		"Test User · 2 entries\n" +
+		"\n## May 03, 2024 at 08:30 — Morning walk\n\n" +
+		"😌 **Calm** · intensity 2/5\n" +
+		"\nSaw a **heron**.\n" +
+		"\n---\n" +
+		"\n## May 20, 2024 at 18:05 — Deadline\\_day\n\n" +
+		"😟 **Anxious** · intensity 4/5\n" +
+		"\nStressful\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeMarkdownJournal =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	writeMarkdownJournal(buf, "", nil, "24h")
+	if got := buf.String(); !strings.Contains(got, "0 entries") || !strings.Contains(got, "No entries have been logged yet.") {
+		t.Errorf("Unexpected empty journal:\n%s", got)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	for _, title := range []string{"First", "Second"} {
+		mood := &data.Mood{Title: title, Content: "<p>Entry " + title + "</p>", Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: userID, Intensity: 3}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/mood/export.md", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.exportMarkdown(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/markdown") {
+		t.Errorf("Unexpected Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+	if !regexp.MustCompile(`^attachment; filename="feelflow-journal-\d{4}-\d{2}-\d{2}\.md"$`).MatchString(rr.Header().Get("Content-Disposition")) {
+		t.Errorf("Unexpected Content-Disposition %q", rr.Header().Get("Content-Disposition"))
+	}
+	body := rr.Body.String()
+	first, second := strings.Index(body, "— First"), strings.Index(body, "— Second")
+	if first < 0 || second < first {
+		t.Errorf("Expected both entries, oldest first, got:\n%s", body)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	mood := &data.Mood{Title: "Portable", Content: "<p>c</p>", Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: userID, PrivateNote: "just for me"}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+	var hash []byte
+	if err := app.users.DB.QueryRow(`SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&hash); err != nil {
+		t.Fatalf("Failed to read password hash: %v", err)
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/user/export.json", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.exportJSON(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); !regexp.MustCompile(`^attachment; filename="feelflow-export-\d{4}-\d{2}-\d{2}\.json"$`).MatchString(cd) {
+		t.Errorf("Expected a dated filename, got %q", cd)
+	}
+	body := rr.Body.String()
+	for _, secret := range []string{string(hash), base64.StdEncoding.EncodeToString(hash), "password", "just for me"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("Export leaked %q:\n%s", secret, body)
+		}
+	}
+
+	var got struct {
+		User  map[string]any   `json:"user"`
+		Moods []map[string]any `json:"moods"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if got.User["name"] != "Test User" || got.User["email"] == nil || got.User["created_at"] == nil {
+		t.Errorf("Expected the profile's name, email and created_at, got %v", got.User)
+	}
+	if len(got.Moods) != 1 || got.Moods[0]["title"] != "Portable" {
+		t.Errorf("Expected the one entry, got %v", got.Moods)
+	}
+}
+
+func TestExport_Redacted(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	mood := &data.Mood{Title: "Secret title", Content: "<p>My private writing</p>", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: userID, PrivateNote: "just for me"}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+	get := func(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		r := newSessionRequest(t, http.MethodGet, target, nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d", target, rr.Code)
+		}
+		return rr
+	}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"CSV", app.exportMoods, "/mood/export"},
+		{"JSON", app.exportJSON, "/user/export.json"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			normal := get(tt.handler, tt.target).Body.String()
+			if !strings.Contains(normal, "My private writing") || !strings.Contains(normal, "Secret title") {
+				t.Errorf("Expected the normal export to include the title and content, got:\n%s", normal)
+			}
+
+			rr := get(tt.handler, tt.target+"?redact=1")
+			redacted := rr.Body.String()
+			for _, unwanted := range []string{"My private writing", "Secret title", "just for me", "content"} {
+				if strings.Contains(redacted, unwanted) {
+					t.Errorf("Expected the redacted export to omit %q, got:\n%s", unwanted, redacted)
+				}
+			}
+			for _, want := range []string{"Calm", "😌", "#69B36C", mood.CreatedAt.UTC().Format("2006-01-02")} {
+				if !strings.Contains(redacted, want) {
+					t.Errorf("Expected the redacted export to keep %q, got:\n%s", want, redacted)
+				}
+			}
+			if cd := rr.Header().Get("Content-Disposition"); !strings.Contains(cd, "-redacted.") {
+				t.Errorf("Expected a redacted filename, got %q", cd)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// mood/cmd/web/global_totals.go
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// globalTotals are the app-wide, non-identifying numbers shown on the About page.
+type globalTotals struct {
+	Moods int
+	Users int
+}
+
+// globalTotalsCache holds the last globalTotals for a short TTL, so a busy
+// public page doesn't run two COUNT(*) queries on every hit.
+type globalTotalsCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	fetch     func() (globalTotals, error)
+	now       func() time.Time // Swappable clock for tests.
+	value     globalTotals
+	fetchedAt time.Time
+	loaded    bool
+}
+
+// newGlobalTotalsCache creates a cache that calls fetch at most once per ttl.
+func newGlobalTotalsCache(ttl time.Duration, fetch func() (globalTotals, error)) *globalTotalsCache {
+	return &globalTotalsCache{ttl: ttl, fetch: fetch, now: time.Now}
+}
+
+// Get returns the cached totals, refreshing them first if they are older than the TTL.
+// A failed refresh is not cached, so the next call tries again.
+func (c *globalTotalsCache) Get() (globalTotals, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded && c.now().Sub(c.fetchedAt) < c.ttl {
+		return c.value, nil
+	}
+
+	totals, err := c.fetch()
+	if err != nil {
+		return globalTotals{}, err
+	}
+	c.value = totals
+	c.fetchedAt = c.now()
+	c.loaded = true
+	return totals, nil
+}
+
+// fetchGlobalTotals reads the About page aggregates straight from the database.
+// It uses its own context rather than a request's: the result is cached for every
+// visitor, so one client disconnecting shouldn't abort the shared refresh.
+func (app *application) fetchGlobalTotals() (globalTotals, error) {
+	moods, err := app.moods.GetGlobalTotals(context.Background())
+	if err != nil {
+		return globalTotals{}, err
+	}
+	users, err := app.users.Count(context.Background())
+	if err != nil {
+		return globalTotals{}, err
+	}
+	return globalTotals{Moods: moods, Users: users}, nil
+}
@@ -0,0 +1,78 @@
+// mood/cmd/web/global_totals_test.go
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGlobalTotalsCache(t *testing.T) {
+	calls := 0
+	next := globalTotals{Moods: 10, Users: 2}
+	var fetchErr error
+	cache := newGlobalTotalsCache(time.Minute, func() (globalTotals, error) {
+		calls++
+		return next, fetchErr
+	})
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	// First call fetches.
+	got, err := cache.Get()
+	if err != nil || got != (globalTotals{Moods: 10, Users: 2}) || calls != 1 {
+		t.Fatalf("First Get: got %+v, err %v, calls %d", got, err, calls)
+	}
+
+	// Within the TTL the cached value is returned, even if the source changed.
+	next = globalTotals{Moods: 11, Users: 3}
+	now = now.Add(59 * time.Second)
+	got, _ = cache.Get()
+	if got.Moods != 10 || calls != 1 {
+		t.Errorf("Expected cached value within TTL, got %+v after %d fetches", got, calls)
+	}
+
+	// Once the TTL has passed the value is refreshed.
+	now = now.Add(2 * time.Second)
+	got, _ = cache.Get()
+	if got.Moods != 11 || calls != 2 {
+		t.Errorf("Expected refreshed value after TTL, got %+v after %d fetches", got, calls)
+	}
+
+	// A failed refresh is reported and not cached.
+	fetchErr = errors.New("db down")
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.Get(); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+	fetchErr = nil
+	next = globalTotals{Moods: 12, Users: 3}
+	got, err = cache.Get()
+	if err != nil || got.Moods != 12 || calls != 4 {
+		t.Errorf("Expected a retry after a failed fetch, got %+v, err %v, calls %d", got, err, calls)
+	}
+}
+
+func TestShowAboutPage_GlobalTotals(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+	app.globalTotals = newGlobalTotalsCache(time.Minute, func() (globalTotals, error) {
+		return globalTotals{Moods: 4321, Users: 87}, nil
+	})
+
+	rr := httptest.NewRecorder()
+	app.showAboutPage(rr, newSessionRequest(t, http.MethodGet, "/about", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"4321", "87"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected about page to contain %q", want)
+		}
+	}
+}
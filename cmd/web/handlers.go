@@ -2,20 +2,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"html/template"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/justinas/nosurf"
 	"github.com/mickali02/mood/internal/data"
 	"github.com/mickali02/mood/internal/validator"
-	"github.com/microcosm-cc/bluemonday"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // getUserIDFromSession checks if a user is logged in by looking for their ID in the session
@@ -34,11 +37,12 @@ func (app *application) getUserIDFromSession(r *http.Request) int64 {
 	return userID
 }
 
-// Helper function to strip HTML and truncate text
+// Helper function to strip HTML and truncate text. The result is plain text (entities
+// decoded, so "&amp;" is never cut in half), which must be escaped before it's rendered.
 func truncateTextWithEllipsis(htmlContent string, limit int) string {
-	// 1. Sanitize HTML: Use bluemonday's strict policy to remove all HTML tags.
-	p := bluemonday.StrictPolicy()
-	plainText := p.Sanitize(htmlContent)
+	// 1. Sanitize HTML: Use the strict preview policy to remove all HTML tags, then
+	//    decode the entities it escaped so the limit counts characters the user sees.
+	plainText := html.UnescapeString(data.SanitizePreview(htmlContent))
 
 	// 2. Check Length: Count runes (Unicode characters) for accurate length.
 	//    Using utf8.RuneCountInString handles multi-byte characters correctly
@@ -51,6 +55,319 @@ func truncateTextWithEllipsis(htmlContent string, limit int) string {
 	return string(runes[:limit]) + "..."
 }
 
+// filterChip describes one active dashboard filter, rendered as a removable chip.
+// ClearURL is the current dashboard URL with only that filter's parameter removed.
+type filterChip struct {
+	Label    string
+	ClearURL string
+}
+
+// dashboardFilterParams lists the query parameters that act as dashboard filters,
+// in the order their chips are displayed, along with the label prefix for each.
+var dashboardFilterParams = []struct {
+	Param string
+	Label string
+}{
+	{Param: "query", Label: "Search"},
+	{Param: "emotion", Label: "Emotion"},
+	{Param: "start_date", Label: "From"},
+	{Param: "end_date", Label: "To"},
+	{Param: "weekday", Label: "Day"},
+	{Param: "min_intensity", Label: "Min intensity"},
+	{Param: "max_intensity", Label: "Max intensity"},
+}
+
+// dashboardSort returns sort if it is a safelisted sort order, or data.DefaultSort.
+func dashboardSort(sort string) string {
+	if data.ValidSort(sort) {
+		return sort
+	}
+	return data.DefaultSort
+}
+
+// weekdayParams maps each accepted weekday value to its canonical short name,
+// indexed by time.Weekday (0 = Sunday). Numbers 0–6 are accepted as well.
+var weekdayParams = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseWeekday reads a dashboard "weekday" parameter such as "mon", "Monday" or "1".
+// An empty value means any day and returns data.AnyWeekday.
+func parseWeekday(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return data.AnyWeekday, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 6 {
+			return data.AnyWeekday, fmt.Errorf("weekday %d out of range 0-6", n)
+		}
+		return n, nil
+	}
+	for i, name := range weekdayParams {
+		if s == name || s == strings.ToLower(time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+	return data.AnyWeekday, fmt.Errorf("unknown weekday %q", s)
+}
+
+// weekdayParam returns the canonical short name for a weekday, or "" for data.AnyWeekday.
+func weekdayParam(day int) string {
+	if day < 0 || day >= len(weekdayParams) {
+		return ""
+	}
+	return weekdayParams[day]
+}
+
+// parseIntensityRange reads the dashboard's "min_intensity" and "max_intensity" parameters.
+// A blank bound defaults to the end of the 1–5 scale. On error the full range is returned.
+func parseIntensityRange(minStr, maxStr string) (int, int, error) {
+	minIntensity, maxIntensity := data.MoodIntensityMin, data.MoodIntensityMax
+	var err error
+	if minStr = strings.TrimSpace(minStr); minStr != "" {
+		if minIntensity, err = strconv.Atoi(minStr); err != nil {
+			return data.MoodIntensityMin, data.MoodIntensityMax, fmt.Errorf("min_intensity %q is not a whole number", minStr)
+		}
+	}
+	if maxStr = strings.TrimSpace(maxStr); maxStr != "" {
+		if maxIntensity, err = strconv.Atoi(maxStr); err != nil {
+			return data.MoodIntensityMin, data.MoodIntensityMax, fmt.Errorf("max_intensity %q is not a whole number", maxStr)
+		}
+	}
+	v := validator.NewValidator()
+	data.ValidateIntensityRange(v, minIntensity, maxIntensity)
+	if !v.ValidData() {
+		first := v.OrderedErrors()[0]
+		return data.MoodIntensityMin, data.MoodIntensityMax, fmt.Errorf("%s %s", first.Field, first.Message)
+	}
+	return minIntensity, maxIntensity, nil
+}
+
+// intensityParams returns the query values for an intensity range, leaving a bound
+// blank when it's already the end of the scale.
+func intensityParams(minIntensity, maxIntensity int) (string, string) {
+	var minStr, maxStr string
+	if minIntensity > data.MoodIntensityMin {
+		minStr = strconv.Itoa(minIntensity)
+	}
+	if maxIntensity < data.MoodIntensityMax {
+		maxStr = strconv.Itoa(maxIntensity)
+	}
+	return minStr, maxStr
+}
+
+// timeZoneCookie names the cookie dashboard.js sets to the browser's IANA time zone
+// (e.g. "Europe/London"), so date filters can follow the user's calendar.
+const timeZoneCookie = "tz"
+
+// requestLocation returns the location named by the time zone cookie, or UTC when the
+// cookie is missing or names an unknown zone.
+func (app *application) requestLocation(r *http.Request) *time.Location {
+	cookie, err := r.Cookie(timeZoneCookie)
+	if err != nil || cookie.Value == "" {
+		return time.UTC
+	}
+	name, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		app.logger.Warn("Unknown time zone cookie, using UTC", "tz", name, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// currentUser loads the logged-in user for reading their display preferences. It returns
+// nil if nobody is logged in or the user can't be loaded (the error is logged), and
+// callers then fall back to the defaults rather than failing the page.
+func (app *application) currentUser(r *http.Request) *data.User {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		return nil
+	}
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		app.logger.Error("Failed to fetch user for preferences", "error", err, "userID", userID)
+		return nil
+	}
+	return user
+}
+
+// previewLength returns how many characters of an entry the user wants previewed on
+// dashboard cards, or data.DefaultPreviewLength for a nil user.
+func previewLength(user *data.User) int {
+	if user == nil || user.PreviewLength < data.MinPreviewLength || user.PreviewLength > data.MaxPreviewLength {
+		return data.DefaultPreviewLength
+	}
+	return user.PreviewLength
+}
+
+// userLocation returns the time zone the logged-in user chose on their profile. Users
+// still on the default (UTC, which every existing account starts with) fall back to the
+// browser's zone from requestLocation, so picking a zone is never required for dates to
+// line up, but an explicit choice always wins.
+func (app *application) userLocation(r *http.Request) *time.Location {
+	return app.locationForUser(r, app.currentUser(r))
+}
+
+// locationForUser is userLocation for a handler that has already loaded the user.
+// A nil user (logged out, or not loadable) gets the browser's zone.
+func (app *application) locationForUser(r *http.Request, user *data.User) *time.Location {
+	if user != nil && user.TimeZone != "" && user.TimeZone != data.DefaultTimeZone {
+		loc, err := time.LoadLocation(user.TimeZone)
+		if err == nil {
+			return loc
+		}
+		app.logger.Warn("Unknown stored time zone, using the browser's", "tz", user.TimeZone, "error", err)
+	}
+	return app.requestLocation(r)
+}
+
+// parseFilterDay parses a YYYY-MM-DD filter value as local midnight in loc and returns
+// the first and last instants of that day. Using AddDate rather than adding 24 hours
+// keeps the end boundary correct on days with a daylight-saving change.
+func parseFilterDay(s string, loc *time.Location) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// buildFilterChips turns the active filters in a dashboard query string into chips.
+// Each ClearURL keeps the other filters and the sort order but drops page, since
+// removing a filter changes the results and the old page number may not exist.
+func buildFilterChips(query url.Values) []filterChip {
+	chips := make([]filterChip, 0, len(dashboardFilterParams))
+	// An invalid intensity range is ignored by the dashboard, so it gets no chips either.
+	minIntensity, maxIntensity, intensityErr := parseIntensityRange(query.Get("min_intensity"), query.Get("max_intensity"))
+	minIntensityStr, maxIntensityStr := intensityParams(minIntensity, maxIntensity)
+	for _, f := range dashboardFilterParams {
+		value := strings.TrimSpace(query.Get(f.Param))
+		if value == "" {
+			continue
+		}
+
+		// Emotion values are sent as "Name::Emoji"; show them as "Emoji Name".
+		display := value
+		if f.Param == "emotion" {
+			if name, emoji, ok := data.DecodeEmotionFilter(value); ok {
+				display = emoji + " " + name
+			}
+		}
+		// Weekdays may be sent as "mon" or "1"; show the full day name.
+		if f.Param == "weekday" {
+			day, err := parseWeekday(value)
+			if err != nil || day == data.AnyWeekday {
+				continue
+			}
+			display = time.Weekday(day).String()
+		}
+		// Intensity bounds at the end of the scale don't narrow anything, so they get no chip.
+		if f.Param == "min_intensity" || f.Param == "max_intensity" {
+			display = minIntensityStr
+			if f.Param == "max_intensity" {
+				display = maxIntensityStr
+			}
+			if intensityErr != nil || display == "" {
+				continue
+			}
+		}
+
+		// Copy the query and drop just this one filter.
+		remaining := url.Values{}
+		for key, vals := range query {
+			if key != f.Param && key != "page" {
+				remaining[key] = vals
+			}
+		}
+		clearURL := "/dashboard"
+		if encoded := remaining.Encode(); encoded != "" {
+			clearURL += "?" + encoded
+		}
+
+		chips = append(chips, filterChip{Label: f.Label + ": " + display, ClearURL: clearURL})
+	}
+	return chips
+}
+
+// pendingSubmission is a mood form POST that arrived after the session expired.
+// It is kept in the session (keyed by the form's path) so the form can be
+// repopulated once the user logs back in.
+type pendingSubmission struct {
+	Path   string            `json:"path"`
+	Fields map[string]string `json:"fields"`
+}
+
+// pendingSubmissionFields are the mood form fields worth preserving across a re-login.
+var pendingSubmissionFields = []string{"title", "content", "emotion", "emoji", "color", "emotion_choice", "private_note", "intensity"}
+
+// maxPendingSubmissionBytes keeps the stash well inside the 4KB session cookie limit
+// (the cookie is encrypted and base64-encoded, which inflates it by roughly a third).
+const maxPendingSubmissionBytes = 2048
+
+// stashPendingSubmission saves the submitted mood form fields in the session.
+// If the entry is too large for the cookie, the content is dropped but the smaller
+// fields are still kept. It reports whether the content survived.
+func (app *application) stashPendingSubmission(r *http.Request, form url.Values) bool {
+	pending := pendingSubmission{Path: r.URL.Path, Fields: make(map[string]string)}
+	for _, key := range pendingSubmissionFields {
+		if value := form.Get(key); value != "" {
+			pending.Fields[key] = value
+		}
+	}
+
+	js, err := json.Marshal(pending)
+	if err != nil {
+		app.logger.Error("failed to encode pending submission", "error", err)
+		return false
+	}
+	contentKept := true
+	if len(js) > maxPendingSubmissionBytes {
+		delete(pending.Fields, "content")
+		delete(pending.Fields, "private_note")
+		contentKept = false
+		js, _ = json.Marshal(pending)
+		if len(js) > maxPendingSubmissionBytes {
+			app.logger.Warn("pending submission too large to stash", "path", pending.Path, "bytes", len(js))
+			return false
+		}
+	}
+
+	app.session.Put(r, "pendingSubmission", string(js))
+	return contentKept
+}
+
+// popPendingSubmission returns (and removes) the stashed fields for path, or nil
+// if there is no stash or it belongs to a different form.
+func (app *application) popPendingSubmission(r *http.Request, path string) map[string]string {
+	raw := app.session.GetString(r, "pendingSubmission")
+	if raw == "" {
+		return nil
+	}
+	var pending pendingSubmission
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil || pending.Path != path {
+		return nil
+	}
+	app.session.Remove(r, "pendingSubmission")
+	return pending.Fields
+}
+
+// pendingSubmissionPath returns the form path of the stashed submission, if any.
+// loginUser uses it to send the user straight back to the form they were filling in.
+func (app *application) pendingSubmissionPath(r *http.Request) string {
+	raw := app.session.GetString(r, "pendingSubmission")
+	if raw == "" {
+		return ""
+	}
+	var pending pendingSubmission
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil {
+		return ""
+	}
+	return pending.Path
+}
+
 /*
 ==========================================================================
 
@@ -74,7 +391,7 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	// --- 2. FETCHING USER DETAILS (for personalization) ---
 	// Once authenticated, we fetch the user's details (like their name) from the database.
 	// This is used to personalize the dashboard (e.g., "Hi, [UserName]!").
-	user, err := app.users.Get(userID)
+	user, err := app.users.Get(r.Context(), userID)
 	if err != nil {
 		// If fetching fails (e.g., database error), log it.
 		// We still proceed but with an empty user struct, so the page doesn't crash.
@@ -98,8 +415,21 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	filterCombinedEmotion := query.Get("emotion") // For filtering by a specific emotion (e.g., "Happy::😊")
 	filterStartDateStr := query.Get("start_date") // Start of date range filter
 	filterEndDateStr := query.Get("end_date")     // End of date range filter
+	filterWeekdayStr := query.Get("weekday")      // Day of the week filter (e.g., "mon" or 0-6)
+	minIntensityStr := query.Get("min_intensity") // Lowest intensity to show (1-5)
+	maxIntensityStr := query.Get("max_intensity") // Highest intensity to show (1-5)
+	sortOrder := query.Get("sort")                // Sort order, one of the data.ValidSort values
 	pageStr := query.Get("page")                  // Requested page number for pagination
 
+	// A whitespace-only search is no search, so it doesn't count as an active filter.
+	searchQuery = strings.TrimSpace(searchQuery)
+
+	// Re-encode the emotion filter so older unescaped links (e.g. "Happy::😊") still
+	// match the dropdown option values built by EmotionFilterValue.
+	if name, emoji, ok := data.DecodeEmotionFilter(filterCombinedEmotion); ok {
+		filterCombinedEmotion = data.EncodeEmotionFilter(name, emoji)
+	}
+
 	// --- 3a. PAGE NUMBER PARSING & VALIDATION ---
 	// Convert the page string to an integer.
 	page, err := strconv.Atoi(pageStr)
@@ -114,11 +444,14 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	// --- 3b. DATE FILTER PARSING & VALIDATION ---
 	var filterStartDate, filterEndDate time.Time // Initialize as zero-value time.Time
 
+	// Dates are calendar days in the user's own time zone, so "From: 10 May" starts at
+	// their local midnight rather than UTC midnight.
+	location := app.locationForUser(r, user)
+
 	// Parse the start date string if provided.
 	if filterStartDateStr != "" {
 		var parseErr error
-		// time.Parse expects a layout string ("2006-01-02") and the date string to parse.
-		filterStartDate, parseErr = time.Parse("2006-01-02", filterStartDateStr)
+		filterStartDate, _, parseErr = parseFilterDay(filterStartDateStr, location)
 		if parseErr != nil {
 			// If parsing fails, log a warning and keep filterStartDate as zero (effectively no start date filter).
 			app.logger.Warn("Invalid start date format", "date", filterStartDateStr, "error", parseErr)
@@ -129,13 +462,11 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	// Parse the end date string if provided.
 	if filterEndDateStr != "" {
 		var parseErr error
-		parsedEndDate, parseErr := time.Parse("2006-01-02", filterEndDateStr)
+		// The end date is inclusive, so the filter runs to the last instant of that day.
+		_, filterEndDate, parseErr = parseFilterDay(filterEndDateStr, location)
 		if parseErr != nil {
 			app.logger.Warn("Invalid end date format", "date", filterEndDateStr, "error", parseErr)
 			filterEndDate = time.Time{} // Reset to zero value
-		} else {
-			// To make the end date inclusive for the entire day, set it to the end of that day.
-			filterEndDate = parsedEndDate.Add(24*time.Hour - 1*time.Nanosecond)
 		}
 		// Basic validation: if both dates are set, end date should not be before start date.
 		if !filterStartDate.IsZero() && !filterEndDate.IsZero() && filterEndDate.Before(filterStartDate) {
@@ -144,7 +475,28 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	// --- 3c. APPLYING VALIDATION RESULTS ---
+	// --- 3c. WEEKDAY FILTER PARSING & VALIDATION ---
+	// An unrecognised weekday is ignored, like an unparseable date.
+	filterWeekday, weekdayErr := parseWeekday(filterWeekdayStr)
+	if weekdayErr != nil {
+		app.logger.Warn("Invalid weekday filter", "weekday", filterWeekdayStr, "error", weekdayErr)
+	}
+
+	// --- 3c-1. INTENSITY RANGE ---
+	// An out-of-range or inverted range is ignored too, falling back to the full 1-5 scale.
+	minIntensity, maxIntensity, intensityErr := parseIntensityRange(minIntensityStr, maxIntensityStr)
+	if intensityErr != nil {
+		app.logger.Warn("Invalid intensity filter", "min", minIntensityStr, "max", maxIntensityStr, "error", intensityErr)
+	}
+
+	// --- 3c-2. SORT ORDER ---
+	// Only safelisted sort keys reach the query; anything else falls back to newest first.
+	if sortOrder != "" && !data.ValidSort(sortOrder) {
+		app.logger.Warn("Invalid sort order", "sort", sortOrder)
+	}
+	sortOrder = dashboardSort(sortOrder)
+
+	// --- 3d. APPLYING VALIDATION RESULTS ---
 	// If any validation checks (e.g., for the page number) failed:
 	if !v.ValidData() {
 		app.logger.Warn("Invalid page parameter", "page", pageStr, "errors", v.Errors)
@@ -160,8 +512,13 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 		Emotion:   filterCombinedEmotion,
 		StartDate: filterStartDate,
 		EndDate:   filterEndDate,
+		Weekday:   filterWeekday,
+		Location:  location,
+		Sort:      sortOrder,
 		Page:      page, PageSize: 4, // Defines how many mood entries to show per page
-		UserID: userID, // Crucial: ensures we only fetch moods for the logged-in user
+		UserID:       userID, // Crucial: ensures we only fetch moods for the logged-in user
+		MinIntensity: minIntensity,
+		MaxIntensity: maxIntensity,
 	}
 
 	// --- 5. FETCHING MOOD ENTRIES & METADATA FROM DATABASE ---
@@ -170,7 +527,7 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	//   - `moods`: A slice of *data.Mood pointers matching the filters.
 	//   - `metadata`: Pagination information (total records, current page, last page, etc.).
 	//   - `err`: Any error encountered during the database query.
-	moods, metadata, err := app.moods.GetFiltered(criteria)
+	moods, metadata, err := app.moods.GetFiltered(r.Context(), criteria)
 	if err != nil {
 		// Specific error handling for a case where an invalid UserID might be passed.
 		// This is more of a consistency check; userID should be valid from the session.
@@ -186,34 +543,22 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 		metadata = data.Metadata{}
 	}
 
-	// Define the character limit for the short content on dashboard cards
-	const shortContentCharacterLimit = 35 // Adjust as needed
-
 	// --- 6. TRANSFORMING MOOD DATA FOR DISPLAY ---
 	// The `data.Mood` struct might contain raw data (e.g., HTML content as a string).
 	// We transform it into a `displayMood` struct, which is tailored for the template.
 	// For example, `Content` is converted to `template.HTML` to prevent XSS vulnerabilities
 	// when rendering user-generated HTML content.
-	displayMoods := make([]displayMood, len(moods))
-	for i, moodEntry := range moods {
-		displayMoods[i] = displayMood{
-			ID:           moodEntry.ID,
-			CreatedAt:    moodEntry.CreatedAt,
-			UpdatedAt:    moodEntry.UpdatedAt,
-			Title:        moodEntry.Title,
-			Content:      template.HTML(moodEntry.Content),                                                       // Mark content as safe HTML for template
-			ShortContent: template.HTML(truncateTextWithEllipsis(moodEntry.Content, shortContentCharacterLimit)), // Truncated plain text
-			RawContent:   moodEntry.Content,                                                                      // Keep raw content for "View More" modal
-			Emotion:      moodEntry.Emotion,
-			Emoji:        moodEntry.Emoji,
-			Color:        moodEntry.Color,
-		}
-	}
+	displayMoods := newDisplayMoods(moods, previewLength(user))
+
+	// 6a. Intensity Trends: each card shows whether the entry felt stronger or milder
+	//     than the one logged before it.
+	app.setIntensityTrends(r.Context(), userID, moods, displayMoods)
 
 	// --- 7. FETCHING DISTINCT EMOTIONS (for filter dropdown) ---
 	// To populate the "Filter by Emotion" dropdown, we fetch all unique emotion/emoji/color
-	// combinations that the current user has logged.
-	availableEmotions, err := app.moods.GetDistinctEmotionDetails(userID)
+	// combinations that the current user has logged. They are cached per user until
+	// the user's entries change (see emotion_cache.go).
+	availableEmotions, err := app.distinctEmotions(r.Context(), userID)
 	if err != nil {
 		app.logger.Error("Failed to fetch distinct emotions", "error", err, "userID", userID)
 		availableEmotions = []data.EmotionDetail{} // Default to empty slice
@@ -229,6 +574,11 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	templateData.FilterEmotion = filterCombinedEmotion
 	templateData.FilterStartDate = filterStartDateStr
 	templateData.FilterEndDate = filterEndDateStr
+	templateData.FilterWeekday = weekdayParam(filterWeekday) // Canonical form, e.g. "1" is echoed back as "mon"
+	templateData.FilterMinIntensity, templateData.FilterMaxIntensity = intensityParams(minIntensity, maxIntensity)
+	templateData.SortOrder = sortOrder
+	templateData.FilterChips = buildFilterChips(query) // Removable chips for each active filter
+	templateData.SavedViews = app.savedViewLinks(r.Context(), userID)
 	// Data to display.
 	templateData.DisplayMoods = displayMoods
 	templateData.HasMoodEntries = len(displayMoods) > 0 // For conditional rendering in template
@@ -239,29 +589,45 @@ func (app *application) showDashboardPage(w http.ResponseWriter, r *http.Request
 	// --- 9. RENDERING THE PAGE (Full Page Load vs. HTMX Partial Update) ---
 	// Check if the request is an HTMX request by looking for the "HX-Request" header.
 	// HTMX uses this header to indicate that it's an AJAX request expecting a partial HTML response.
+	// The same URL serves both the full page and the fragment, so caches must key on it.
+	w.Header().Set("Vary", "HX-Request")
 	if r.Header.Get("HX-Request") == "true" {
 		// --- 9a. HTMX PARTIAL UPDATE ---
 		// If it's an HTMX request (e.g., user changed a filter, clicked pagination).
 		app.logger.Info("Handling HTMX request for dashboard content area")
-		// Retrieve the "dashboard.tmpl" template from our pre-compiled cache.
-		ts, ok := app.templateCache["dashboard.tmpl"]
-		if !ok {
-			err := fmt.Errorf("template %q does not exist", "dashboard.tmpl")
-			app.logger.Error("Template lookup failed", "template", "dashboard.tmpl", "error", err)
-			// Send an HTMX error response if possible, or fallback
-			http.Error(w, "Error loading dashboard content.", http.StatusInternalServerError)
-			return
+
+		// Skip re-rendering if the browser already has this exact fragment. A pending
+		// flash message always forces a render, since it has just been popped from the session.
+		if templateData.Flash == "" {
+			etag, etagErr := dashboardETag(templateData)
+			if etagErr != nil {
+				app.logger.Warn("Failed to compute dashboard ETag", "error", etagErr)
+			} else {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", "private, no-cache") // Always revalidate, never share.
+				if etagMatches(r.Header.Get("If-None-Match"), etag) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
 		}
+
 		// Execute only the relevant block for HTMX swap
-		err = ts.ExecuteTemplate(w, "dashboard-content", templateData)
+		err = app.renderNamed(w, http.StatusOK, "dashboard.tmpl", "dashboard-content", templateData)
 		if err != nil {
-			app.logger.Error("Failed to execute template block", "block", "dashboard-content", "error", err)
-			// Don't write further if header already sent
+			app.serverError(w, r, err)
 		}
 	} else {
 		// --- 9b. FULL PAGE LOAD ---
 		// If not an HTMX request, it's a standard browser request for the full page.
 		app.logger.Info("Handling full page request for dashboard")
+		// The Memories panel sits outside the swapped area, so it's only needed here.
+		// It's a nice-to-have; the dashboard still renders if it fails.
+		memories, memErr := app.moods.GetOnThisDay(r.Context(), userID, location.String(), time.Now())
+		if memErr != nil {
+			app.logger.Error("Failed to fetch on-this-day memories", "error", memErr, "userID", userID)
+		}
+		templateData.Memories = newDisplayMoods(memories, previewLength(user))
 		// Render the entire "dashboard.tmpl" page with all its layout.
 		// `app.render` is a helper function that handles template execution and writing to the response.
 		err = app.render(w, http.StatusOK, "dashboard.tmpl", templateData)
@@ -286,17 +652,10 @@ func (app *application) showLandingPage(w http.ResponseWriter, r *http.Request)
 
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Rendering landing page content fragment")
-		ts, ok := app.templateCache["landing.tmpl"]
-		if !ok {
-			err := fmt.Errorf("template %q does not exist", "landing.tmpl")
-			app.logger.Error("Template lookup failed for landing", "template", "landing.tmpl", "error", err)
-			http.Error(w, "Error loading landing page content.", http.StatusInternalServerError)
-			return
-		}
 		// Execute only the "page-content" block for HTMX swap
-		err := ts.ExecuteTemplate(w, "page-content", templateData)
+		err := app.renderNamed(w, http.StatusOK, "landing.tmpl", "page-content", templateData)
 		if err != nil {
-			app.logger.Error("Failed to execute landing template block", "block", "page-content", "error", err)
+			app.serverError(w, r, err)
 		}
 	} else {
 		app.logger.Info("Full page request for landing page")
@@ -313,19 +672,22 @@ func (app *application) showAboutPage(w http.ResponseWriter, r *http.Request) {
 	templateData := app.newTemplateData(r)
 	templateData.Title = "About Feel Flow" // Title for full page
 
+	// App-wide totals are optional; the page still renders if they can't be loaded.
+	if app.globalTotals != nil {
+		totals, err := app.globalTotals.Get()
+		if err != nil {
+			app.logger.Error("Failed to load global totals for about page", "error", err)
+		} else {
+			templateData.GlobalTotals = &totals
+		}
+	}
+
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Rendering about page content fragment")
-		ts, ok := app.templateCache["about.tmpl"]
-		if !ok {
-			err := fmt.Errorf("template %q does not exist", "about.tmpl")
-			app.logger.Error("Template lookup failed for about", "template", "about.tmpl", "error", err)
-			http.Error(w, "Error loading about page content.", http.StatusInternalServerError)
-			return
-		}
 		// Execute only the "page-content" block for HTMX swap
-		err := ts.ExecuteTemplate(w, "page-content", templateData)
+		err := app.renderNamed(w, http.StatusOK, "about.tmpl", "page-content", templateData)
 		if err != nil {
-			app.logger.Error("Failed to execute about template block", "block", "page-content", "error", err)
+			app.serverError(w, r, err)
 		}
 	} else {
 		app.logger.Info("Full page request for about page")
@@ -346,11 +708,21 @@ func (app *application) showAboutPage(w http.ResponseWriter, r *http.Request) {
 // showMoodForm displays the HTML form for creating a new mood entry.
 // This is the 'C' in CRUD - Create. It serves the page where users input new mood data.
 func (app *application) showMoodForm(w http.ResponseWriter, r *http.Request) {
+	// 0. One Entry Per Day: send the user to today's entry if they already have one.
+	if app.redirectToTodaysEntry(w, r, app.getUserIDFromSession(r)) {
+		return
+	}
+
 	// 1. Prepare Base Template Data: Initializes common data like CSRF token, auth status.
 	templateData := app.newTemplateData(r)
 	// 2. Set Page-Specific Data: Title for the HTML head, HeaderText for the main heading on the form.
 	templateData.Title = "New Mood Entry"
 	templateData.HeaderText = "Log Your Mood"
+	// Restore a submission that was interrupted by an expired session, if any.
+	if fields := app.popPendingSubmission(r, r.URL.Path); fields != nil {
+		templateData.FormData = fields
+	}
+	app.addCustomEmotionsToPicker(r, templateData)
 	// 3. Render Form: Uses the "mood_form.tmpl" template.
 	//    `app.render` is a helper to execute the template with data and send to the browser.
 	err := app.render(w, http.StatusOK, "mood_form.tmpl", templateData)
@@ -360,6 +732,50 @@ func (app *application) showMoodForm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// redirectToTodaysEntry enforces the one-entry-per-day journaling mode. If the user has
+// it turned on and already logged an entry today (in their own time zone), it redirects
+// to that entry's edit form and reports true; otherwise it writes nothing. Errors are
+// logged and treated as "no entry", so a failed check never blocks logging a mood.
+func (app *application) redirectToTodaysEntry(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	if userID == 0 {
+		return false
+	}
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		app.logger.Error("Failed to fetch user for one-entry-per-day check", "error", err, "userID", userID)
+		return false
+	}
+	if !user.OneEntryPerDay {
+		return false
+	}
+	id, exists, err := app.moods.ExistsOnDate(r.Context(), userID, time.Now().In(app.locationForUser(r, user)))
+	if err != nil {
+		app.logger.Error("Failed to check for today's entry", "error", err, "userID", userID)
+		return false
+	}
+	if !exists {
+		return false
+	}
+	app.session.Put(r, "flash", "You've already logged a mood today. You can add to it here.")
+	app.redirectAfterForm(w, r, fmt.Sprintf("/mood/edit/%d", id))
+	return true
+}
+
+// parseIntensity reads the mood form's "intensity" field. A blank value means
+// data.DefaultMoodIntensity; anything that isn't a number becomes 0, which
+// ValidateMood then rejects.
+func parseIntensity(s string) int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return data.DefaultMoodIntensity
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 // createMood handles the submission (POST request) of the new mood form.
 // After submitting the form, this handler processes the data, validates it, and saves it to the database.
 func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
@@ -384,6 +800,11 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 3b. One Entry Per Day: never create a second entry for today; go to the first.
+	if app.redirectToTodaysEntry(w, r, userID) {
+		return
+	}
+
 	// 4. Extract Data: Get individual field values from the parsed form.
 	title := r.PostForm.Get("title")
 	content := r.PostForm.Get("content")              // Content from Quill editor (HTML).
@@ -391,15 +812,20 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 	emoji := r.PostForm.Get("emoji")                  // Final selected/custom emoji.
 	color := r.PostForm.Get("color")                  // Final selected/custom color.
 	emotionChoice := r.PostForm.Get("emotion_choice") // Keep track of radio button selection
+	privateNote := r.PostForm.Get("private_note")     // Optional owner-only note.
+	intensityStr := r.PostForm.Get("intensity")       // 1-5; blank means the default.
 
 	// 5. Populate Mood Struct: Create a `data.Mood` struct with the extracted data.
 	mood := &data.Mood{
 		Title:   title,
-		Content: content, // Storing raw HTML from Quill.
+		Content: content, // Quill HTML; sanitized before it's stored.
 		Emotion: emotionName,
 		Emoji:   emoji,
 		Color:   color,
 		UserID:  userID, // Associate mood with the logged-in user.
+
+		PrivateNote: privateNote,
+		Intensity:   parseIntensity(intensityStr),
 	}
 
 	// 6. Validation: Validate the mood data using our custom validator.
@@ -413,6 +839,7 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 		templateData.Title = "New Mood Entry (Error)"
 		templateData.HeaderText = "Log Your Mood"
 		templateData.FormErrors = v.Errors // Pass validation errors to the template.
+		templateData.OrderedFormErrors = v.OrderedErrors()
 		// Repopulate form data for user convenience
 		templateData.FormData = map[string]string{
 			"title":          title,
@@ -421,7 +848,10 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 			"emoji":          emoji,
 			"color":          color,
 			"emotion_choice": emotionChoice, // Repopulate selected radio
+			"private_note":   privateNote,
+			"intensity":      intensityStr,
 		}
+		app.addCustomEmotionsToPicker(r, templateData)
 		// Re-render the form with a 422 Unprocessable Entity status.
 		errRender := app.render(w, http.StatusUnprocessableEntity, "mood_form.tmpl", templateData)
 		if errRender != nil {
@@ -430,13 +860,15 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 8. Database Insert: If data is valid, insert the new mood into the database.
-	//    `app.moods.Insert` is a method on our MoodModel.
-	err = app.moods.Insert(mood)
+	// 8. Database Insert: If data is valid, store only the allowed formatting (a
+	//    <script> or onerror="..." posted directly, bypassing the editor, is dropped).
+	mood.Content = data.SanitizeHTML(mood.Content)
+	err = app.moods.Insert(r.Context(), mood)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
+	app.invalidateEmotions(userID)
 
 	// 9. Success & Redirect: On successful creation...
 	//    Set a flash message to inform the user.
@@ -446,6 +878,55 @@ func (app *application) createMood(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
 }
 
+// showMoodDetail displays a single mood entry on its own page (GET /mood/{id}).
+// This is the permalink for an entry: the full content is shown outside the dashboard modal,
+// sanitized with Mood.SanitizeContent, along with the owner-only private note.
+func (app *application) showMoodDetail(w http.ResponseWriter, r *http.Request) {
+	// 1. Get Mood ID from the URL path.
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	// 2. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 3. Fetch the Mood: Get enforces ownership, so other users' entries are a 404.
+	mood, err := app.moods.Get(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 4. Prepare Template Data.
+	templateData := app.newTemplateData(r)
+	templateData.Title = mood.Title
+	templateData.Mood = mood
+	templateData.MoodHTML = template.HTML(mood.SanitizeContent())
+
+	// 4a. Edit History: nice to have, so the entry still shows if it can't be loaded.
+	revisions, err := app.moods.GetRevisions(r.Context(), id, userID)
+	if err != nil {
+		app.logger.Error("Failed to fetch mood revisions", "error", err, "moodID", id, "userID", userID)
+	}
+	templateData.Revisions = newDisplayRevisions(revisions)
+
+	// 5. Render the detail page.
+	err = app.render(w, http.StatusOK, "mood_detail.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
 // showEditMoodForm displays the form for editing an existing mood entry.
 // This is part of 'U' in CRUD - Update. It first reads existing data to pre-fill the form.
 func (app *application) showEditMoodForm(w http.ResponseWriter, r *http.Request) {
@@ -466,7 +947,7 @@ func (app *application) showEditMoodForm(w http.ResponseWriter, r *http.Request)
 
 	// 3. Fetch Existing Mood: Get the mood entry from the database using its ID and the UserID.
 	//    This also acts as an ownership check: user can only edit their own moods.
-	mood, err := app.moods.Get(id, userID)
+	mood, err := app.moods.Get(r.Context(), id, userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) { // Mood not found or not owned by user.
 			app.notFound(w)
@@ -490,8 +971,18 @@ func (app *application) showEditMoodForm(w http.ResponseWriter, r *http.Request)
 		"emoji":          mood.Emoji,
 		"color":          mood.Color,
 		"emotion_choice": mood.Emotion, // Pre-select the correct radio button
+		"private_note":   mood.PrivateNote,
+		"intensity":      strconv.Itoa(mood.Intensity),
+		"version":        strconv.Itoa(mood.Version), // Sent back on save to detect edits made elsewhere
+	}
+	// Restore a submission that was interrupted by an expired session, if any.
+	if fields := app.popPendingSubmission(r, r.URL.Path); fields != nil {
+		for key, value := range fields {
+			templateData.FormData[key] = value
+		}
 	}
 
+	app.addCustomEmotionsToPicker(r, templateData)
 	// 5. Render Form: Use the "mood_edit_form.tmpl" template.
 	err = app.render(w, http.StatusOK, "mood_edit_form.tmpl", templateData)
 	if err != nil {
@@ -517,7 +1008,7 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Fetch Original Mood (for ownership check & context on error):
 	//    It's good practice to re-fetch or verify ownership before an update.
-	originalMoodForCheck, err := app.moods.Get(id, userID)
+	originalMoodForCheck, err := app.moods.Get(r.Context(), id, userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFound(w)
@@ -547,6 +1038,10 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 	emoji := r.PostForm.Get("emoji")
 	color := r.PostForm.Get("color")
 	emotionChoice := r.PostForm.Get("emotion_choice")
+	privateNote := r.PostForm.Get("private_note")
+	intensityStr := r.PostForm.Get("intensity")
+	versionStr := r.PostForm.Get("version")
+	version, _ := strconv.Atoi(versionStr) // A missing or garbled version can't match, so it's reported as a conflict.
 
 	// 7. Populate Mood Struct with Updated Values:
 	//    Crucially, include the ID for the `UPDATE` SQL query and UserID for the `WHERE` clause.
@@ -558,6 +1053,10 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 		Emoji:   emoji,
 		Color:   color,
 		UserID:  userID, // Include UserID for ownership check in model
+
+		PrivateNote: privateNote,
+		Intensity:   parseIntensity(intensityStr),
+		Version:     version,
 	}
 
 	// 8. Validation: Validate the *updated* mood data.
@@ -571,6 +1070,7 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 		templateData.HeaderText = "Update Your Mood Entry"
 		templateData.Mood = originalMoodForCheck // Pass original mood for context
 		templateData.FormErrors = v.Errors
+		templateData.OrderedFormErrors = v.OrderedErrors()
 		// Repopulate form with submitted (invalid) data
 		templateData.FormData = map[string]string{
 			"title":          title,
@@ -579,7 +1079,11 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 			"emoji":          emoji,
 			"color":          color,
 			"emotion_choice": emotionChoice,
+			"private_note":   privateNote,
+			"intensity":      intensityStr,
+			"version":        versionStr, // Keep the version the user started from, not the latest
 		}
+		app.addCustomEmotionsToPicker(r, templateData)
 		errRender := app.render(w, http.StatusUnprocessableEntity, "mood_edit_form.tmpl", templateData)
 		if errRender != nil {
 			app.serverError(w, r, errRender)
@@ -587,17 +1091,22 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 10. Database Update: If valid, perform the update in the database.
+	// 10. Database Update: If valid, store only the allowed formatting, as on create.
 	//     `app.moods.Update` will internally ensure `id` and `UserID` match.
-	err = app.moods.Update(mood)
+	mood.Content = data.SanitizeHTML(mood.Content)
+	err = app.moods.Update(r.Context(), mood)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) { // Handle case where mood was deleted between GET and POST
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound): // Handle case where mood was deleted between GET and POST
 			app.notFound(w)
-		} else {
+		case errors.Is(err, data.ErrEditConflict): // Saved elsewhere (another tab or device) since the form was opened
+			app.renderEditConflict(w, r, id, userID, r.PostForm)
+		default:
 			app.serverError(w, r, err)
 		}
 		return
 	}
+	app.invalidateEmotions(userID)
 
 	// 11. Success & Redirect:
 	app.session.Put(r, "flash", "Mood entry successfully updated!")
@@ -612,6 +1121,51 @@ func (app *application) updateMood(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// editConflictMessage explains why an edit wasn't saved when MoodModel.Update reports
+// ErrEditConflict.
+const editConflictMessage = "This entry was changed elsewhere (in another tab or on another device) since you opened it. Your changes are below; save again to replace the other version, or reload to see it."
+
+// renderEditConflict re-renders the edit form after a conflicting save. The user's
+// submitted values are kept so nothing they typed is lost, and the form now carries the
+// latest version, so saving again deliberately overwrites the other change.
+func (app *application) renderEditConflict(w http.ResponseWriter, r *http.Request, id, userID int64, form url.Values) {
+	latest, err := app.moods.Get(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	templateData := app.newTemplateData(r)
+	templateData.Title = fmt.Sprintf("Edit Mood Entry #%d (Conflict)", id)
+	templateData.HeaderText = "Update Your Mood Entry"
+	templateData.Mood = latest
+	templateData.FormErrors = map[string]string{"entry": editConflictMessage}
+	templateData.OrderedFormErrors = []validator.FieldError{{Field: "entry", Message: editConflictMessage}}
+	templateData.FormData = map[string]string{"version": strconv.Itoa(latest.Version)}
+	for _, key := range []string{"title", "content", "emotion", "emoji", "color", "emotion_choice", "private_note", "intensity"} {
+		templateData.FormData[key] = form.Get(key)
+	}
+	app.addCustomEmotionsToPicker(r, templateData)
+	app.renderFormBlock(w, r, "mood_edit_form.tmpl", "mood-form-content", templateData)
+}
+
+// deleteMoodEntry deletes a mood owned by userID and logs the outcome.
+// It never touches the session, so HTML, API and bulk callers can all reuse it
+// and decide for themselves how (or whether) to tell the user.
+func (app *application) deleteMoodEntry(ctx context.Context, id, userID int64) error {
+	err := app.moods.Delete(ctx, id, userID) // Model handles ownership check
+	if err != nil {
+		return err
+	}
+	app.invalidateEmotions(userID)
+	app.logger.Info("Mood entry deleted successfully", "id", id, "userID", userID)
+	return nil
+}
+
 // deleteMood handles the deletion of a mood entry.
 // This is the 'D' in CRUD - Delete. It removes a mood entry based on its ID and user ownership.
 func (app *application) deleteMood(w http.ResponseWriter, r *http.Request) {
@@ -636,9 +1190,9 @@ func (app *application) deleteMood(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 4. Database Delete: Call the model's Delete method.
-	//    The model's `Delete` method should handle the ownership check
-	err = app.moods.Delete(id, userID) // Model handles ownership check
+	// 4. Database Delete: The shared helper deletes (with ownership check) and logs.
+	//    Flash messages are this handler's responsibility, not the shared path's.
+	err = app.deleteMoodEntry(r.Context(), id, userID)
 
 	// 5. Handle Deletion Result:
 	deleteErrOccurred := false
@@ -658,8 +1212,7 @@ func (app *application) deleteMood(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// Successful delete
-		app.logger.Info("Mood entry deleted successfully", "id", id, "userID", userID)
-		flashMessage = "Mood entry successfully deleted."
+		flashMessage = "Mood entry moved to the trash."
 	}
 
 	// 6. Set Flash Message (only on actual success):
@@ -673,135 +1226,197 @@ func (app *application) deleteMood(w http.ResponseWriter, r *http.Request) {
 		currentFlash := app.session.PopString(r, "flash") // Get the flash message for HTMX response
 		app.logger.Info("Popped flash message for HTMX delete response", "message", currentFlash)
 
-		// --- Logic to re-render dashboard content after delete ---
-		// Determine the correct page to show after deletion (handle deleting last item on a page)
-		currentPage := 1 // Default
-		searchQuery := ""
-		filterCombinedEmotion := ""
-		filterStartDateStr := ""
-		filterEndDateStr := ""
-
-		// Parse Referer URL to maintain filters/page
-		refererURL, parseErr := url.Parse(r.Header.Get("Referer"))
-		if parseErr == nil {
-			refQuery := refererURL.Query()
-			searchQuery = refQuery.Get("query")
-			filterCombinedEmotion = refQuery.Get("emotion")
-			filterStartDateStr = refQuery.Get("start_date")
-			filterEndDateStr = refQuery.Get("end_date")
-			pageStr := refQuery.Get("page")
-			parsedPage, convErr := strconv.Atoi(pageStr)
-			if convErr == nil && parsedPage > 0 {
-				currentPage = parsedPage
-			}
-		} else {
-			app.logger.Warn("Could not parse Referer URL for delete refresh", "referer", r.Header.Get("Referer"), "error", parseErr)
-		}
+		app.renderDashboardAfterChange(w, r, userID, currentFlash)
+		return // Stop execution after HTMX response
+	}
+
+	// Standard redirect for non-HTMX requests if no error occurred
+	if !deleteErrOccurred {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	}
+	// If deleteErrOccurred, the serverError handler already wrote the response.
+}
 
-		// Parse dates from referer strings
-		var filterStartDate, filterEndDate time.Time
-		if filterStartDateStr != "" { /* ... date parsing logic ... */
-			var parseErrStart error
-			filterStartDate, parseErrStart = time.Parse("2006-01-02", filterStartDateStr)
-			if parseErrStart != nil {
-				filterStartDate = time.Time{}
+// maxBulkDeleteIDs caps how many entries one bulk delete may name.
+const maxBulkDeleteIDs = 100
+
+// parseMoodIDs reads mood IDs from form values that may each hold one ID or a
+// comma-separated list, so both repeated checkboxes and "1,2,3" work. Blank items are
+// ignored, duplicates are dropped, and anything that isn't a positive integer is an error.
+func parseMoodIDs(values []string) ([]int64, error) {
+	ids := []int64{}
+	seen := make(map[int64]bool)
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
 			}
-		}
-		if filterEndDateStr != "" { /* ... date parsing logic ... */
-			var parseErrEnd error
-			parsedEndDate, parseErrEnd := time.Parse("2006-01-02", filterEndDateStr)
-			if parseErrEnd == nil {
-				filterEndDate = parsedEndDate.Add(24*time.Hour - 1*time.Nanosecond)
-			} else {
-				filterEndDate = time.Time{}
+			id, err := strconv.ParseInt(item, 10, 64)
+			if err != nil || id < 1 {
+				return nil, fmt.Errorf("invalid mood ID %q", item)
 			}
-			if !filterStartDate.IsZero() && !filterEndDate.IsZero() && filterEndDate.Before(filterStartDate) {
-				filterEndDate = time.Time{}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
 			}
 		}
+	}
+	return ids, nil
+}
 
-		// Check current total count with same filters to adjust page number if needed
-		countCriteria := data.FilterCriteria{
-			TextQuery: searchQuery, Emotion: filterCombinedEmotion,
-			StartDate: filterStartDate, EndDate: filterEndDate,
-			PageSize: 4, Page: 1, UserID: userID, // PageSize matters, Page 1 to get total
-		}
-		_, tempMetadata, countErr := app.moods.GetFiltered(countCriteria)
-		if countErr != nil {
-			app.logger.Error("Failed to get count for page adjustment after delete", "error", countErr)
-		} else {
-			lastPage := tempMetadata.LastPage
-			if lastPage == 0 {
-				lastPage = 1
-			} // Ensure lastPage is at least 1
-			if currentPage > lastPage {
-				app.logger.Info("Adjusting page after delete", "old_page", currentPage, "new_page", lastPage)
-				currentPage = lastPage // Go to the new last page
-			}
-		}
+// bulkDeleteMoods handles POST /mood/bulk-delete, moving every selected entry to the
+// trash at once. IDs the user doesn't own are skipped silently, so the flash counts
+// only the entries that were actually moved.
+func (app *application) bulkDeleteMoods(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
 
-		// Fetch moods for the potentially adjusted current page
-		criteria := data.FilterCriteria{
-			TextQuery: searchQuery, Emotion: filterCombinedEmotion,
-			StartDate: filterStartDate, EndDate: filterEndDate,
-			Page: currentPage, PageSize: 4, UserID: userID,
-		}
-		moods, metadata, fetchErr := app.moods.GetFiltered(criteria)
-		if fetchErr != nil {
-			app.logger.Error("Failed to fetch filtered moods after delete", "error", fetchErr)
-			// Send HTMX error response or fallback
-			http.Error(w, "Error reloading dashboard content.", http.StatusInternalServerError)
+	// 2. Parse and Validate the IDs.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	ids, err := parseMoodIDs(r.PostForm["ids"])
+	if err != nil || len(ids) > maxBulkDeleteIDs {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Delete (Owned Entries Only) and Report the Count.
+	flashMessage := "No entries were selected."
+	if len(ids) > 0 {
+		deleted, err := app.moods.DeleteMany(r.Context(), ids, userID)
+		if err != nil {
+			app.serverError(w, r, err)
 			return
 		}
+		app.invalidateEmotions(userID)
+		app.logger.Info("Mood entries bulk deleted", "requested", len(ids), "deleted", deleted, "userID", userID)
+		flashMessage = fmt.Sprintf("Moved %d %s to the trash.", deleted, pluralize(int(deleted), "entry", "entries"))
+	}
 
-		// Prepare data for re-rendering the dashboard fragment
-		displayMoods := make([]displayMood, len(moods))
-		for i, moodEntry := range moods {
-			displayMoods[i] = displayMood{ /* ... populate displayMood ... */
-				ID: moodEntry.ID, CreatedAt: moodEntry.CreatedAt, UpdatedAt: moodEntry.UpdatedAt,
-				Title: moodEntry.Title, Content: template.HTML(moodEntry.Content), RawContent: moodEntry.Content,
-				Emotion: moodEntry.Emotion, Emoji: moodEntry.Emoji, Color: moodEntry.Color,
-			}
+	// 4. Respond: HTMX gets the refreshed dashboard, like a single delete.
+	if r.Header.Get("HX-Request") == "true" {
+		app.renderDashboardAfterChange(w, r, userID, flashMessage)
+		return
+	}
+	app.session.Put(r, "flash", flashMessage)
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// renderDashboardAfterChange re-renders the dashboard content block after an HTMX delete
+// or pin, keeping the filters and page from the Referer and stepping back a page if a
+// deletion emptied the one the user was on. flash is shown above the entries.
+func (app *application) renderDashboardAfterChange(w http.ResponseWriter, r *http.Request, userID int64, flash string) {
+	// Determine the correct page to show after deletion (handle deleting last item on a page)
+	currentPage := 1 // Default
+	searchQuery := ""
+	filterCombinedEmotion := ""
+	filterStartDateStr := ""
+	filterEndDateStr := ""
+	filterWeekday := data.AnyWeekday
+	minIntensity, maxIntensity := data.MoodIntensityMin, data.MoodIntensityMax
+	sortOrder := data.DefaultSort
+
+	// Parse Referer URL to maintain filters/page
+	refererURL, parseErr := url.Parse(r.Header.Get("Referer"))
+	if parseErr == nil {
+		refQuery := refererURL.Query()
+		searchQuery = refQuery.Get("query")
+		filterCombinedEmotion = refQuery.Get("emotion")
+		filterStartDateStr = refQuery.Get("start_date")
+		filterEndDateStr = refQuery.Get("end_date")
+		if day, weekdayErr := parseWeekday(refQuery.Get("weekday")); weekdayErr == nil {
+			filterWeekday = day
 		}
-		availableEmotions, emotionErr := app.moods.GetDistinctEmotionDetails(userID)
-		if emotionErr != nil {
-			availableEmotions = []data.EmotionDetail{}
+		if lo, hi, intensityErr := parseIntensityRange(refQuery.Get("min_intensity"), refQuery.Get("max_intensity")); intensityErr == nil {
+			minIntensity, maxIntensity = lo, hi
 		}
-
-		templateData := app.newTemplateData(r)
-		templateData.Flash = currentFlash // Pass the popped flash message
-		templateData.SearchQuery = searchQuery
-		templateData.FilterEmotion = filterCombinedEmotion
-		templateData.FilterStartDate = filterStartDateStr
-		templateData.FilterEndDate = filterEndDateStr
-		templateData.DisplayMoods = displayMoods
-		templateData.HasMoodEntries = len(displayMoods) > 0
-		templateData.AvailableEmotions = availableEmotions
-		templateData.Metadata = metadata
-		// Don't need to fetch User again here, newTemplateData handles it if authenticated
-
-		// Render just the dashboard content block for HTMX swap
-		ts, ok := app.templateCache["dashboard.tmpl"]
-		if !ok {
-			err := fmt.Errorf("template %q does not exist", "dashboard.tmpl")
-			app.logger.Error("Template lookup failed", "template", "dashboard.tmpl", "error", err)
-			http.Error(w, "Error loading dashboard content.", http.StatusInternalServerError)
-			return
+		sortOrder = dashboardSort(refQuery.Get("sort"))
+		pageStr := refQuery.Get("page")
+		parsedPage, convErr := strconv.Atoi(pageStr)
+		if convErr == nil && parsedPage > 0 {
+			currentPage = parsedPage
 		}
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusOK) // Important: OK status for successful HTMX swap
-		execErr := ts.ExecuteTemplate(w, "dashboard-content", templateData)
-		if execErr != nil {
-			app.logger.Error("Failed to execute template block for delete refresh", "block", "dashboard-content", "error", execErr)
+	} else {
+		app.logger.Warn("Could not parse Referer URL for delete refresh", "referer", r.Header.Get("Referer"), "error", parseErr)
+	}
+
+	// Parse dates from referer strings, in the user's time zone like the dashboard does
+	var filterStartDate, filterEndDate time.Time
+	user := app.currentUser(r)
+	location := app.locationForUser(r, user)
+	if filterStartDateStr != "" { /* ... date parsing logic ... */
+		var parseErrStart error
+		filterStartDate, _, parseErrStart = parseFilterDay(filterStartDateStr, location)
+		if parseErrStart != nil {
+			filterStartDate = time.Time{}
+		}
+	}
+	if filterEndDateStr != "" { /* ... date parsing logic ... */
+		var parseErrEnd error
+		_, filterEndDate, parseErrEnd = parseFilterDay(filterEndDateStr, location)
+		if parseErrEnd != nil {
+			filterEndDate = time.Time{}
+		}
+		if !filterStartDate.IsZero() && !filterEndDate.IsZero() && filterEndDate.Before(filterStartDate) {
+			filterEndDate = time.Time{}
 		}
-		return // Stop execution after HTMX response
 	}
 
-	// Standard redirect for non-HTMX requests if no error occurred
-	if !deleteErrOccurred {
-		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	// Fetch moods for the current page; GetFiltered clamps it to the new last page if a
+	// deletion emptied it, and the returned metadata carries the page actually shown.
+	criteria := data.FilterCriteria{
+		TextQuery: searchQuery, Emotion: filterCombinedEmotion,
+		StartDate: filterStartDate, EndDate: filterEndDate, Weekday: filterWeekday, Location: location,
+		MinIntensity: minIntensity, MaxIntensity: maxIntensity,
+		Sort: sortOrder, Page: currentPage, PageSize: 4, UserID: userID,
+	}
+	moods, metadata, fetchErr := app.moods.GetFiltered(r.Context(), criteria)
+	if fetchErr != nil {
+		app.logger.Error("Failed to fetch filtered moods after delete", "error", fetchErr)
+		// Send HTMX error response or fallback
+		http.Error(w, "Error reloading dashboard content.", http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare data for re-rendering the dashboard fragment
+	displayMoods := newDisplayMoods(moods, previewLength(user))
+	app.setIntensityTrends(r.Context(), userID, moods, displayMoods)
+	availableEmotions, emotionErr := app.distinctEmotions(r.Context(), userID)
+	if emotionErr != nil {
+		availableEmotions = []data.EmotionDetail{}
+	}
+
+	templateData := app.newTemplateData(r)
+	templateData.Flash = flash // Pass the popped flash message
+	templateData.SearchQuery = searchQuery
+	templateData.FilterEmotion = filterCombinedEmotion
+	templateData.FilterStartDate = filterStartDateStr
+	templateData.FilterEndDate = filterEndDateStr
+	templateData.FilterWeekday = weekdayParam(filterWeekday)
+	templateData.FilterMinIntensity, templateData.FilterMaxIntensity = intensityParams(minIntensity, maxIntensity)
+	templateData.SortOrder = sortOrder
+	if parseErr == nil {
+		templateData.FilterChips = buildFilterChips(refererURL.Query())
+	}
+	templateData.SavedViews = app.savedViewLinks(r.Context(), userID)
+	templateData.DisplayMoods = displayMoods
+	templateData.HasMoodEntries = len(displayMoods) > 0
+	templateData.AvailableEmotions = availableEmotions
+	templateData.Metadata = metadata
+	// Don't need to fetch User again here, newTemplateData handles it if authenticated
+
+	// Render just the dashboard content block for HTMX swap (200 OK so HTMX swaps it in)
+	execErr := app.renderNamed(w, http.StatusOK, "dashboard.tmpl", "dashboard-content", templateData)
+	if execErr != nil {
+		app.serverError(w, r, execErr)
 	}
-	// If deleteErrOccurred, the serverError handler already wrote the response.
 }
 
 /*
@@ -850,6 +1465,7 @@ func (app *application) signupUser(w http.ResponseWriter, r *http.Request) {
 	v.Check(validator.NotBlank(passwordInput), "password", "Password must be provided")
 	v.Check(validator.MinLength(passwordInput, 8), "password", "Must be at least 8 characters long")
 	v.Check(validator.MaxLength(passwordInput, 72), "password", "Must not be more than 72 characters")
+	data.ValidateNewPassword(v, "password", passwordInput)
 
 	// Helper function to render signup form with errors
 	renderSignupError := func(formErrors map[string]string) {
@@ -860,18 +1476,10 @@ func (app *application) signupUser(w http.ResponseWriter, r *http.Request) {
 
 		if r.Header.Get("HX-Request") == "true" {
 			app.logger.Info("HTMX: Re-rendering signup form fragment due to validation errors")
-			ts, ok := app.templateCache["signup.tmpl"]
-			if !ok {
-				errMsg := fmt.Sprintf("template %q does not exist", "signup.tmpl")
-				app.logger.Error("Template lookup failed for signup fragment", "template", "signup.tmpl", "error", errMsg)
-				http.Error(w, "Error processing signup.", http.StatusInternalServerError)
-				return
-			}
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.WriteHeader(http.StatusOK) // Return 200 OK for HTMX fragment with errors
-			errRender := ts.ExecuteTemplate(w, "signup-form-block", templateData)
+			// Return 200 OK for HTMX fragment with errors
+			errRender := app.renderNamed(w, http.StatusOK, "signup.tmpl", "signup-form-block", templateData)
 			if errRender != nil {
-				app.logger.Error("Failed to execute signup template block for HTMX", "block", "signup-form-block", "error", errRender)
+				app.serverError(w, r, errRender)
 			}
 		} else {
 			errRender := app.render(w, http.StatusUnprocessableEntity, "signup.tmpl", templateData)
@@ -886,14 +1494,14 @@ func (app *application) signupUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := &data.User{Name: name, Email: email, Activated: true}
+	user := &data.User{Name: name, Email: email, Activated: false} // Activated by the emailed link.
 	err = user.Password.Set(passwordInput)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	err = app.users.Insert(user)
+	err = app.users.Insert(r.Context(), user)
 	if err != nil {
 		if errors.Is(err, data.ErrDuplicateEmail) {
 			// Add the duplicate email error to the validator's map
@@ -906,7 +1514,9 @@ func (app *application) signupUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	app.session.Put(r, "flash", "Your signup was successful! Please log in.")
+	app.sendActivation(r.Context(), user)
+
+	app.session.Put(r, "flash", "Your signup was successful! Check your email for a link to activate your account, then log in.")
 	// For successful signup, always redirect fully.
 	// If HTMX was used, it will follow this redirect.
 	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
@@ -943,6 +1553,7 @@ func (app *application) loginUser(w http.ResponseWriter, r *http.Request) {
 	// 3. Extract Credentials.
 	email := r.PostForm.Get("email")
 	passwordInput := r.PostForm.Get("password")
+	rememberMe := r.PostForm.Get("remember_me") != ""
 
 	// 4. Basic Validation (Presence): Check if email/password were provided.
 	//    A generic error message is used for login failures to avoid revealing which field was incorrect.
@@ -952,59 +1563,84 @@ func (app *application) loginUser(w http.ResponseWriter, r *http.Request) {
 
 	isHTMXRequest := r.Header.Get("HX-Request") == "true"
 
-	genericError := func() {
+	loginErrorWithStatus := func(status int, key, message string) {
 		templateData := app.newTemplateData(r)
 		templateData.Title = "Login (Error) - Feel Flow"
 		templateData.FormData = map[string]string{"email": email}
-		templateData.FormErrors = map[string]string{"generic": "Invalid email or password."}
+		if rememberMe {
+			templateData.FormData["remember_me"] = "on"
+		}
+		templateData.FormErrors = map[string]string{key: message}
 
 		if isHTMXRequest {
 			app.logger.Info("HTMX: Re-rendering login form fragment due to validation errors")
-			ts, ok := app.templateCache["login.tmpl"]
-			if !ok {
-				errMsg := fmt.Sprintf("template %q does not exist", "login.tmpl")
-				app.logger.Error("Template lookup failed for login fragment", "template", "login.tmpl", "error", errMsg)
-				http.Error(w, "Error processing login.", http.StatusInternalServerError)
-				return
-			}
-			// *** CRITICAL: Set Content-Type for HTMX fragment response ***
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.WriteHeader(http.StatusOK)
-			errRender := ts.ExecuteTemplate(w, "login-form-block", templateData)
+			errRender := app.renderNamed(w, http.StatusOK, "login.tmpl", "login-form-block", templateData)
 			if errRender != nil {
-				app.logger.Error("Failed to execute login template block for HTMX", "block", "login-form-block", "error", errRender)
+				app.serverError(w, r, errRender)
 			}
 		} else {
-			errRender := app.render(w, http.StatusUnprocessableEntity, "login.tmpl", templateData)
+			errRender := app.render(w, status, "login.tmpl", templateData)
 			if errRender != nil {
 				app.serverError(w, r, errRender)
 			}
 		}
 	}
 
+	loginError := func(key, message string) { loginErrorWithStatus(http.StatusUnprocessableEntity, key, message) }
+	genericError := func() { loginError("generic", "Invalid email or password.") }
+
 	if !v.ValidData() {
 		genericError()
 		return
 	}
 
-	id, err := app.users.Authenticate(email, passwordInput)
+	// 5. Per-Email Lockout: only failed attempts use up the allowance, so the rightful
+	//    owner can still log in right up until someone has guessed wrong too often.
+	failureKey := loginFailureKey(email)
+	if app.loginFailures != nil {
+		if ok, wait := app.loginFailures.Peek(failureKey); !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			app.logger.Warn("Login locked out after repeated failures", "remote_ip", clientIP(r))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			loginErrorWithStatus(http.StatusTooManyRequests, "generic",
+				fmt.Sprintf("Too many failed login attempts. Please try again in %d %s.", seconds, pluralize(seconds, "second", "seconds")))
+			return
+		}
+	}
+
+	user, err := app.users.AuthenticateUser(r.Context(), email, passwordInput)
 	if err != nil {
-		if errors.Is(err, data.ErrInvalidCredentials) {
+		switch {
+		case errors.Is(err, data.ErrInvalidCredentials):
+			if app.loginFailures != nil {
+				app.loginFailures.Allow(failureKey)
+			}
 			genericError()
-		} else {
+		case errors.Is(err, data.ErrNotActivated):
+			loginError("activation", "Your account isn't activated yet. Please check your email for the activation link.")
+		default:
 			app.serverError(w, r, err)
 		}
 		return
 	}
 
-	app.session.Put(r, "authenticatedUserID", id)
+	// 6. Log In: "Remember me" keeps the login for 30 days instead of 12 hours; see
+	//    logIn for what that costs in security.
+	app.logIn(r, user.ID, rememberMe)
 	app.session.Put(r, "flash", "You have been logged in successfully!")
 
+	// If a mood form was interrupted by an expired session, go back to it.
+	redirectTo := "/dashboard"
+	if path := app.pendingSubmissionPath(r); path != "" {
+		redirectTo = path
+		app.session.Put(r, "flash", "Welcome back! Your unsaved entry has been restored.")
+	}
+
 	if isHTMXRequest {
-		w.Header().Set("HX-Redirect", "/dashboard")
+		w.Header().Set("HX-Redirect", redirectTo)
 		w.WriteHeader(http.StatusOK)
 	} else {
-		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		http.Redirect(w, r, redirectTo, http.StatusSeeOther)
 	}
 }
 
@@ -1018,8 +1654,9 @@ func (app *application) logoutUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 2. Clear Session: Remove the "authenticatedUserID" from the session.
-	app.session.Remove(r, "authenticatedUserID")
+	// 2. Clear Session: Remove the login and everything stored alongside it, so a
+	//    remembered login can't outlive an explicit logout.
+	app.logOut(r)
 	// 3. Notify User & Redirect: Set flash message and redirect to the landing page.
 	app.session.Put(r, "flash", "You have been logged out successfully.")
 	http.Redirect(w, r, "/landing", http.StatusSeeOther) // Redirect to landing page
@@ -1031,6 +1668,9 @@ func (app *application) logoutUser(w http.ResponseWriter, r *http.Request) {
 	Stats Page Handler
 ==========================================================================
 */
+// statsMissingDaysWindow is how many recent days the stats page checks for gaps.
+const statsMissingDaysWindow = 14
+
 // showStatsPage displays various mood statistics for the logged-in user.
 // Fetches and aggregates user's mood data to display charts and summaries.
 func (app *application) showStatsPage(w http.ResponseWriter, r *http.Request) {
@@ -1042,7 +1682,8 @@ func (app *application) showStatsPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Fetch Stats Data: Call MoodModel's GetAllStats method for the current user.
-	stats, err := app.moods.GetAllStats(userID)
+	location := app.userLocation(r)
+	stats, err := app.moods.GetAllStats(r.Context(), userID, location.String())
 	if err != nil {
 		app.logger.Error("Failed to fetch mood stats", "error", err, "userID", userID)
 		app.serverError(w, r, err)
@@ -1067,19 +1708,49 @@ func (app *application) showStatsPage(w http.ResponseWriter, r *http.Request) {
 	)
 	// *** END ADDED LOGGING ***
 
-	// 5. Marshal Emotion Counts to JSON: For use by JavaScript charting libraries.
-	emotionCountsJSON, err := json.Marshal(stats.EmotionCounts)
+	// 5. Marshal Chart Datasets to JSON: For use by JavaScript charting libraries.
+	//    Empty datasets become "[]", so the charts never have to parse "null".
+	datasets := map[string]any{
+		"emotion counts": stats.EmotionCounts,
+		"weekly counts":  stats.WeeklyCounts,
+		"emotion trend":  stats.EmotionTrend,
+		"monthly counts": stats.MonthlyCounts,
+		"weekday counts": stats.WeekdayCounts,
+		"hourly counts":  stats.HourlyCounts,
+	}
+	datasetJSON := make(map[string]string, len(datasets))
+	for name, dataset := range datasets {
+		js, err := chartDatasetJSON(dataset)
+		if err != nil {
+			app.serverError(w, r, fmt.Errorf("marshal %s: %w", name, err))
+			return
+		}
+		datasetJSON[name] = js
+	}
+
+	// 5b. Find Recent Days Without Entries, so the user can see what to backfill.
+	//     This is a nice-to-have; the page still renders if it fails.
+	missingDays, err := app.moods.GetMissingDays(r.Context(), userID, location.String(), statsMissingDaysWindow)
 	if err != nil {
-		app.serverError(w, r, fmt.Errorf("marshal emotion counts: %w", err))
-		return
+		app.logger.Error("Failed to fetch missing days", "error", err, "userID", userID)
+		missingDays = nil
 	}
 
 	// 6. Prepare Template Data:
 	templateData := app.newTemplateData(r)
 	templateData.Title = "Mood Statistics"
-	templateData.Stats = stats                                          // Pass the aggregated stats.
-	templateData.EmotionCountsJSON = string(emotionCountsJSON)          // Pass JSON string for charts.
-	templateData.Quote = "Every mood matters. Thanks for checking in 💖" // Inspirational quote.
+	templateData.MissingDays = missingDays
+	templateData.MissingDaysWindow = statsMissingDaysWindow
+	templateData.Stats = stats                     // Pass the aggregated stats.
+	templateData.HasStats = stats.TotalEntries > 0 // Charts only render once there's something to chart.
+	templateData.EmotionCountsJSON = datasetJSON["emotion counts"]
+	templateData.WeeklyCountsJSON = datasetJSON["weekly counts"]
+	templateData.EmotionTrendJSON = datasetJSON["emotion trend"]
+	templateData.MonthlyCountsJSON = datasetJSON["monthly counts"]
+	templateData.WeekdayCountsJSON = datasetJSON["weekday counts"]
+	templateData.HourlyCountsJSON = datasetJSON["hourly counts"]
+	templateData.Insight = data.GenerateInsight(stats) // One-sentence summary of the stats.
+	templateData.Quote = app.statsQuote(location)      // Rotates daily in the user's time zone.
 
 	// 7. Render Stats Page: Use "stats.tmpl".
 	renderErr := app.render(w, http.StatusOK, "stats.tmpl", templateData)
@@ -1088,6 +1759,103 @@ func (app *application) showStatsPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// setIntensityTrends fills in TrendVsPrevious for a page of dashboard cards, where
+// displayMoods was made from moods. The entries logged just before them are fetched in
+// one query, since they can fall off the page or outside the filters. A failure only
+// costs the arrows, so it is logged rather than returned.
+func (app *application) setIntensityTrends(ctx context.Context, userID int64, moods []*data.Mood, displayMoods []displayMood) {
+	ids := make([]int64, len(moods))
+	for i, moodEntry := range moods {
+		ids[i] = moodEntry.ID
+	}
+	predecessors, err := app.moods.GetPredecessors(ctx, userID, ids)
+	if err != nil {
+		app.logger.Error("Failed to fetch previous entries for trends", "error", err, "userID", userID)
+		return
+	}
+	trends := intensityTrends(append(predecessors, moods...))
+	for i := range displayMoods {
+		displayMoods[i].TrendVsPrevious = trends[displayMoods[i].ID]
+	}
+}
+
+// chartDatasetJSON marshals one chart dataset for a data attribute. A nil or
+// empty slice gives "[]", never "null".
+func chartDatasetJSON(dataset any) (string, error) {
+	js, err := json.Marshal(dataset)
+	if err != nil {
+		return "", err
+	}
+	if string(js) == "null" {
+		return "[]", nil
+	}
+	return string(js), nil
+}
+
+// showStatsData returns every stats chart dataset in one JSON payload (GET /stats/data.json),
+// so the front-end can fetch them all with a single request.
+func (app *application) showStatsData(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	// 2. Fetch Stats: Same aggregation as the HTML stats page.
+	stats, err := app.moods.GetAllStats(r.Context(), userID, app.userLocation(r).String())
+	if err != nil {
+		app.logger.Error("Failed to fetch mood stats for JSON", "error", err, "userID", userID)
+		app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		return
+	}
+
+	// 3. Respond with one key per chart dataset.
+	app.writeJSON(w, http.StatusOK, map[string]any{
+		"totalEntries":  stats.TotalEntries,
+		"emotionCounts": stats.EmotionCounts,
+		"weeklyCounts":  stats.WeeklyCounts,
+		"monthlyCounts": stats.MonthlyCounts,
+		"weekdayCounts": stats.WeekdayCounts,
+		"hourlyCounts":  stats.HourlyCounts,
+		"sameDayPairs":  stats.SameDayPairs,
+	}, nil)
+}
+
+// statsHeatmapDays is how many days, including today, GET /stats/heatmap.json covers.
+const statsHeatmapDays = 365
+
+// showStatsHeatmap returns per-day entry counts for a calendar heatmap (GET /stats/heatmap.json).
+// Only days with entries are listed; "from" and "to" (inclusive, in the user's time zone) let the front-end
+// lay out the full grid and treat every missing date as zero.
+func (app *application) showStatsHeatmap(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		return
+	}
+
+	// 2. Fetch the Last Year of Days, in the user's time zone.
+	location := app.userLocation(r)
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	from := today.AddDate(0, 0, -(statsHeatmapDays - 1))
+	days, err := app.moods.GetDailyCounts(r.Context(), userID, location.String(), from, today.AddDate(0, 0, 1))
+	if err != nil {
+		app.logger.Error("Failed to fetch daily counts for heatmap", "error", err, "userID", userID)
+		app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		return
+	}
+
+	// 3. Respond with the range and the non-empty days.
+	app.writeJSON(w, http.StatusOK, map[string]any{
+		"from": from.Format("2006-01-02"),
+		"to":   today.Format("2006-01-02"),
+		"days": days,
+	}, nil)
+}
+
 /*
 ==========================================================================
 	User Profile Handlers
@@ -1105,7 +1873,7 @@ func (app *application) showUserProfilePage(w http.ResponseWriter, r *http.Reque
 	}
 
 	// 2. Fetch User Data: Get current user details to display and pre-fill forms.
-	user, err := app.users.Get(userID)
+	user, err := app.users.Get(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFound(w)
@@ -1122,7 +1890,7 @@ func (app *application) showUserProfilePage(w http.ResponseWriter, r *http.Reque
 		currentPage = 1 // Default to first page/section of profile.
 	}
 	// Define total pages for profile.
-	profileTotalPages := 2 // Page 1: Info/Password, Page 2: Reset/Delete
+	profileTotalPages := 2 // Page 1: Info/Password, Page 2: Emotions/Journal/Reset/Delete
 	if currentPage > profileTotalPages {
 		currentPage = profileTotalPages // Cap at max pages
 	}
@@ -1134,6 +1902,9 @@ func (app *application) showUserProfilePage(w http.ResponseWriter, r *http.Reque
 	templateData.User = user                      // Pass user object for display.
 	templateData.ProfileCurrentPage = currentPage // Use the processed currentPage
 	templateData.ProfileTotalPages = profileTotalPages
+	if currentPage == 2 {
+		templateData.CustomEmotions = app.customEmotions(r, userID)
+	}
 
 	// Pre-fill form data for name/email fields if not already set by a previous error.
 	if templateData.FormData == nil {
@@ -1150,17 +1921,10 @@ func (app *application) showUserProfilePage(w http.ResponseWriter, r *http.Reque
 	// --- MODIFIED: Check for HTMX request ---
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Rendering profile content fragment", "page", currentPage)
-		ts, ok := app.templateCache["profile.tmpl"]
-		if !ok {
-			err := fmt.Errorf("template %q does not exist", "profile.tmpl")
-			app.logger.Error("Template lookup failed for profile", "template", "profile.tmpl", "error", err)
-			http.Error(w, "Error loading profile content.", http.StatusInternalServerError)
-			return
-		}
 		// Execute only the "profile-content" block for HTMX swap
-		err = ts.ExecuteTemplate(w, "profile-content", templateData)
+		err = app.renderNamed(w, http.StatusOK, "profile.tmpl", "profile-content", templateData)
 		if err != nil {
-			app.logger.Error("Failed to execute profile template block", "block", "profile-content", "error", err)
+			app.serverError(w, r, err)
 		}
 	} else {
 		app.logger.Info("Full page request for profile", "page", currentPage)
@@ -1182,7 +1946,7 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 	}
 
 	// 2. Fetch Current User Data (needed if validation fails, to show original state or for context).
-	user, err := app.users.Get(userID)
+	user, err := app.users.Get(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFound(w) // User doesn't exist
@@ -1234,7 +1998,7 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		templateData := app.newTemplateData(r)
 		templateData.Title = "User Profile (Error)"
 		// Pass the original user for display context, but use submitted data in FormData
-		templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt}
+		templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt, PendingEmail: user.PendingEmail}
 		templateData.FormErrors = v.Errors
 		templateData.FormData = map[string]string{
 			"name":  updatedUser.Name,  // Show the invalid submitted name
@@ -1245,21 +2009,10 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		// --- UPDATED: Render fragment for HTMX on validation error ---
 		if r.Header.Get("HX-Request") == "true" {
 			app.logger.Info("HTMX: Re-rendering profile content due to name/email update validation errors")
-			ts, ok := app.templateCache["profile.tmpl"]
-			if !ok {
-				errMsg := fmt.Errorf("template profile.tmpl not found")
-				app.logger.Error(errMsg.Error())
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-
-			// *** FIX: Set Content-Type and send 200 OK for HTMX fragment swap with errors ***
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.WriteHeader(http.StatusOK) // <--- CHANGE IS HERE
-
-			errRender := ts.ExecuteTemplate(w, "profile-content", templateData)
+			// Send 200 OK for HTMX fragment swap with errors
+			errRender := app.renderNamed(w, http.StatusOK, "profile.tmpl", "profile-content", templateData)
 			if errRender != nil {
-				app.logger.Error("Failed to execute profile template block for name/email validation error", "error", errRender)
+				app.serverError(w, r, errRender)
 			}
 		} else {
 			// Keep 422 for non-HTMX full page reload
@@ -1272,41 +2025,40 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 	}
 
 	// 8. Database Update (User Profile).
-	// Now that validation passed, call the Update method with the validated data.
-	// Note: The Update method in UserModel updates only name and email based on ID.
-	err = app.users.Update(updatedUser) // Pass the struct with validated data
+	// A new address only becomes pending: the login email stays the same until the
+	// confirmation link sent to the new address is clicked. Emails are case-insensitive,
+	// so a change of case alone is applied straight away.
+	newEmail := updatedUser.Email
+	emailChanged := !strings.EqualFold(originalEmail, newEmail)
+	if emailChanged {
+		updatedUser.Email = originalEmail
+		err = app.users.SetPendingEmail(r.Context(), userID, newEmail)
+	}
+	if err == nil {
+		// Note: The Update method in UserModel updates only name and email based on ID.
+		err = app.users.Update(r.Context(), updatedUser) // Pass the struct with validated data
+	}
 	if err != nil {
 		if errors.Is(err, data.ErrDuplicateEmail) {
 			v.AddError("email", "Email address is already in use") // Add specific error
 			templateData := app.newTemplateData(r)
 			templateData.Title = "User Profile (Error)"
 			// Pass original user data for display context
-			templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: originalEmail, CreatedAt: user.CreatedAt}
+			templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: originalEmail, CreatedAt: user.CreatedAt, PendingEmail: user.PendingEmail}
 			templateData.FormErrors = v.Errors
 			templateData.FormData = map[string]string{
-				"name":  updatedUser.Name,  // Show submitted name
-				"email": updatedUser.Email, // Show submitted (duplicate) email
+				"name":  updatedUser.Name, // Show submitted name
+				"email": newEmail,         // Show submitted (duplicate) email
 			}
 			templateData.ProfileCurrentPage = 1
 
 			// --- UPDATED: Render fragment for HTMX on duplicate email error ---
 			if r.Header.Get("HX-Request") == "true" {
 				app.logger.Info("HTMX: Re-rendering profile content due to duplicate email on update")
-				ts, ok := app.templateCache["profile.tmpl"]
-				if !ok {
-					errMsg := fmt.Errorf("template profile.tmpl not found")
-					app.logger.Error(errMsg.Error())
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-					return
-				}
-
-				// *** FIX: Set Content-Type and send 200 OK for HTMX fragment swap with errors ***
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				w.WriteHeader(http.StatusOK) // <--- CHANGE IS HERE
-
-				errRender := ts.ExecuteTemplate(w, "profile-content", templateData)
+				// Send 200 OK for HTMX fragment swap with errors
+				errRender := app.renderNamed(w, http.StatusOK, "profile.tmpl", "profile-content", templateData)
 				if errRender != nil {
-					app.logger.Error("Failed to execute profile template block for duplicate email error", "error", errRender)
+					app.serverError(w, r, errRender)
 				}
 			} else {
 				// Keep 422 for non-HTMX full page reload
@@ -1326,7 +2078,15 @@ func (app *application) updateUserProfile(w http.ResponseWriter, r *http.Request
 	}
 
 	// 9. Success.
-	app.session.Put(r, "flash", "Profile updated successfully.")
+	if emailChanged {
+		if err := app.sendEmailChangeConfirmation(r.Context(), updatedUser, newEmail); err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		app.session.Put(r, "flash", fmt.Sprintf("Profile updated. We've sent a confirmation link to %s; you'll keep logging in with %s until you click it.", newEmail, originalEmail))
+	} else {
+		app.session.Put(r, "flash", "Profile updated successfully.")
+	}
 	// --- MODIFIED: Send HX-Redirect for HTMX success ---
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Sending HX-Redirect to /user/profile after profile update")
@@ -1352,7 +2112,7 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 	}
 
 	// 2. Fetch User (needed for current password check and context).
-	user, err := app.users.Get(userID)
+	user, err := app.users.Get(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.logger.Warn("Attempt to change password for non-existent user", "userID", userID)
@@ -1389,7 +2149,7 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 	renderPasswordError := func(formErrors map[string]string) {
 		templateData := app.newTemplateData(r)
 		templateData.Title = "User Profile (Password Error)"
-		templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt}
+		templateData.User = &data.User{ID: user.ID, Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt, PendingEmail: user.PendingEmail}
 		templateData.FormErrors = formErrors
 		if templateData.FormData == nil {
 			templateData.FormData = make(map[string]string)
@@ -1404,18 +2164,10 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 
 		if r.Header.Get("HX-Request") == "true" {
 			app.logger.Info("HTMX: Re-rendering profile content due to password change validation errors")
-			ts, ok := app.templateCache["profile.tmpl"]
-			if !ok {
-				errMsg := fmt.Errorf("template profile.tmpl not found")
-				app.logger.Error(errMsg.Error())
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.WriteHeader(http.StatusOK) // Send 200 OK for HTMX swap
-			errRender := ts.ExecuteTemplate(w, "profile-content", templateData)
+			// Send 200 OK for HTMX swap
+			errRender := app.renderNamed(w, http.StatusOK, "profile.tmpl", "profile-content", templateData)
 			if errRender != nil {
-				app.logger.Error("Failed to execute profile template block for password error", "error", errRender)
+				app.serverError(w, r, errRender)
 			}
 		} else {
 			errRender := app.render(w, http.StatusUnprocessableEntity, "profile.tmpl", templateData)
@@ -1446,7 +2198,7 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 
 	// 8. Hash the *new* password directly using bcrypt
 	// Cost factor 12 is a good default
-	hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	hashedNewPassword, err := data.HashPassword(newPassword)
 	if err != nil {
 		app.serverError(w, r, fmt.Errorf("error hashing new password: %w", err))
 		return
@@ -1454,7 +2206,7 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 
 	// 9. Update Password in Database using the generated hash
 	// Pass the userID and the []byte hash directly
-	err = app.users.UpdatePassword(user.ID, hashedNewPassword)
+	err = app.users.UpdatePassword(r.Context(), user.ID, hashedNewPassword)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFound(w) // Should not happen if user was fetched, but defensive
@@ -1465,6 +2217,7 @@ func (app *application) changeUserPassword(w http.ResponseWriter, r *http.Reques
 	}
 
 	// 10. Success.
+	app.notifyPasswordChanged(user.Name, user.Email)
 	app.session.Put(r, "flash", "Password updated successfully.")
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Sending HX-Redirect to /user/profile after password update")
@@ -1493,11 +2246,12 @@ func (app *application) resetUserEntries(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 3. Delete All Moods for UserID: Call MoodModel method.
-	err := app.moods.DeleteAllByUserID(userID)
+	err := app.moods.DeleteAllByUserID(r.Context(), userID)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
+	app.invalidateEmotions(userID)
 
 	// 4. Success.
 	app.session.Put(r, "flash", "All your mood entries have been reset.")
@@ -1514,6 +2268,7 @@ func (app *application) resetUserEntries(w http.ResponseWriter, r *http.Request)
 
 // deleteUserAccount handles the permanent deletion of a user's account and all their data.
 // Critical data deletion feature. Removes user and associated mood entries.
+// The user must re-enter their password; otherwise profile page 2 is shown again with an error.
 func (app *application) deleteUserAccount(w http.ResponseWriter, r *http.Request) {
 	// 1. Authentication.
 	userID := app.getUserIDFromSession(r)
@@ -1529,9 +2284,57 @@ func (app *application) deleteUserAccount(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// 3. Delete User from Database: UserModel's Delete method.
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Confirm with the Current Password: deletion is irreversible, so a misclick
+	//    (or a forged request riding an open session) must not be enough.
+	user, err := app.users.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.logger.Warn("Attempt to delete non-existent user account", "userID", userID)
+			app.clientError(w, http.StatusUnauthorized)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	confirmPassword := r.PostForm.Get("confirm_delete_password")
+	v := validator.NewValidator()
+	v.Check(validator.NotBlank(confirmPassword), "confirm_delete_password", "Enter your password to confirm")
+	if v.ValidData() {
+		match, err := user.Password.Matches(confirmPassword)
+		if err != nil {
+			app.serverError(w, r, fmt.Errorf("error matching password: %w", err))
+			return
+		}
+		v.Check(match, "confirm_delete_password", "Password incorrect; your account was not deleted")
+	}
+	if !v.ValidData() {
+		app.logger.Warn("Account deletion not confirmed", "userID", userID)
+		templateData := app.newTemplateData(r)
+		templateData.Title = "User Profile (Delete Account)"
+		templateData.User = user
+		templateData.FormErrors = v.Errors
+		templateData.ProfileCurrentPage = 2
+		templateData.CustomEmotions = app.customEmotions(r, userID)
+		if r.Header.Get("HX-Request") == "true" {
+			err = app.renderNamed(w, http.StatusOK, "profile.tmpl", "profile-content", templateData)
+		} else {
+			err = app.render(w, http.StatusUnprocessableEntity, "profile.tmpl", templateData)
+		}
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 4. Delete User from Database: UserModel's Delete method.
 	//    (Database constraints like ON DELETE CASCADE should handle deleting associated moods).
-	err := app.users.Delete(userID)
+	err = app.users.Delete(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			// User might have already been deleted. Log, but proceed with logout.
@@ -1541,10 +2344,11 @@ func (app *application) deleteUserAccount(w http.ResponseWriter, r *http.Request
 			return
 		}
 	}
+	app.invalidateEmotions(userID) // Nothing left to show; free the entry now rather than at eviction.
 
-	// 4. Log User Out: Clear their session.
-	app.session.Remove(r, "authenticatedUserID")
-	// 5. Notify and Redirect to Public Page.
+	// 5. Log User Out: Clear their session.
+	app.logOut(r)
+	// 6. Notify and Redirect to Public Page.
 	app.session.Put(r, "flash", "Your account has been successfully deleted.")
 	if r.Header.Get("HX-Request") == "true" {
 		app.logger.Info("HTMX: Sending HX-Redirect to /landing after account deletion")
@@ -1555,6 +2359,396 @@ func (app *application) deleteUserAccount(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// updateUserTheme handles POST /user/theme, persisting the user's light/dark/system preference.
+// The theme is stored server-side so it follows the user across devices and is applied on first paint.
+func (app *application) updateUserTheme(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Method Check & Parse Form.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		app.clientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Validate the submitted theme against the allowed values.
+	theme := r.PostForm.Get("theme")
+	v := validator.NewValidator()
+	data.ValidateTheme(v, theme)
+	if !v.ValidData() {
+		app.logger.Warn("Invalid theme submitted", "userID", userID, "theme", theme)
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// 4. Persist the preference.
+	err = app.users.UpdateTheme(r.Context(), userID, theme)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 5. Success.
+	app.session.Put(r, "flash", "Theme preference saved.")
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/user/profile")
+		w.WriteHeader(http.StatusOK)
+	} else {
+		http.Redirect(w, r, "/user/profile", http.StatusSeeOther)
+	}
+}
+
+// updateUserTimeFormat handles POST /user/time-format, persisting the 12h/24h clock preference
+// used by FormatDate when rendering entry timestamps.
+func (app *application) updateUserTimeFormat(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Method Check & Parse Form.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		app.clientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Validate the submitted format against the allowed values.
+	timeFormat := r.PostForm.Get("time_format")
+	v := validator.NewValidator()
+	data.ValidateTimeFormat(v, timeFormat)
+	if !v.ValidData() {
+		app.logger.Warn("Invalid time format submitted", "userID", userID, "time_format", timeFormat)
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// 4. Persist the preference.
+	err = app.users.UpdateTimeFormat(r.Context(), userID, timeFormat)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 5. Success.
+	app.session.Put(r, "flash", "Time format preference saved.")
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/user/profile")
+		w.WriteHeader(http.StatusOK)
+	} else {
+		http.Redirect(w, r, "/user/profile", http.StatusSeeOther)
+	}
+}
+
+// updateUserReminder saves the user's check-in reminder preference (POST /user/reminder).
+// Clients read it from GET /api/v1/me to schedule their own local notifications; the
+// hourly job in reminders.go also emails it to users who turned on email reminders.
+func (app *application) updateUserReminder(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Form.
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Validate. The checkbox is only submitted when ticked.
+	reminderEnabled := r.PostForm.Get("reminder_enabled") != ""
+	reminderTime := strings.TrimSpace(r.PostForm.Get("reminder_time"))
+	reminderFrequency := r.PostForm.Get("reminder_frequency")
+	if reminderFrequency == "" {
+		reminderFrequency = "daily" // Older forms don't send one.
+	}
+	v := validator.NewValidator()
+	data.ValidateReminder(v, reminderEnabled, reminderTime)
+	data.ValidateReminderFrequency(v, reminderFrequency)
+
+	// 4. Persist the preference, or report why it couldn't be saved.
+	if !v.ValidData() {
+		app.logger.Warn("Invalid reminder submitted", "userID", userID, "reminder_time", reminderTime, "errors", v.Errors)
+		app.session.Put(r, "flash", v.OrderedErrors()[0].Message)
+	} else {
+		err = app.users.UpdateReminder(r.Context(), userID, reminderEnabled, reminderTime, reminderFrequency)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				app.notFound(w)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+		app.session.Put(r, "flash", "Reminder preference saved.")
+	}
+
+	// 5. Back to the profile page either way.
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/user/profile")
+		w.WriteHeader(http.StatusOK)
+	} else {
+		http.Redirect(w, r, "/user/profile", http.StatusSeeOther)
+	}
+}
+
+// updateEmailReminders turns check-in reminder emails on or off (POST /user/email-reminders).
+// The checkbox is only submitted when ticked, so its absence means "off". The emails use
+// the time and frequency saved by updateUserReminder.
+func (app *application) updateEmailReminders(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	enabled := r.PostForm.Get("email_reminders") != ""
+
+	err := app.users.UpdateEmailReminders(r.Context(), userID, enabled)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	switch user := app.currentUser(r); {
+	case !enabled:
+		app.session.Put(r, "flash", "Reminder emails are off.")
+	case user != nil && user.ReminderTime == "":
+		app.session.Put(r, "flash", "Reminder emails are on. Save a reminder time to start receiving them.")
+	default:
+		app.session.Put(r, "flash", "Reminder emails are on.")
+	}
+	app.redirectAfterForm(w, r, "/user/profile")
+}
+
+// updateOneEntryPerDay turns the one-entry-per-day journaling mode on or off.
+// The checkbox is only submitted when ticked, so its absence means "off".
+func (app *application) updateOneEntryPerDay(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	enabled := r.PostForm.Get("one_entry_per_day") != ""
+
+	err := app.users.UpdateOneEntryPerDay(r.Context(), userID, enabled)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if enabled {
+		app.session.Put(r, "flash", "One entry per day is on. Logging again today will open today's entry.")
+	} else {
+		app.session.Put(r, "flash", "One entry per day is off.")
+	}
+	app.redirectAfterForm(w, r, "/user/profile")
+}
+
+// updateUserTimeZone saves the user's time zone (POST /user/timezone). Stats and the
+// dashboard's date filters use it to decide which day an entry falls on.
+func (app *application) updateUserTimeZone(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	timeZone := r.PostForm.Get("timezone")
+
+	v := validator.NewValidator()
+	data.ValidateTimeZone(v, timeZone)
+	if !v.ValidData() {
+		app.logger.Warn("Invalid time zone submitted", "userID", userID, "timezone", timeZone)
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err := app.users.UpdateTimeZone(r.Context(), userID, timeZone)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	app.session.Put(r, "flash", "Time zone saved.")
+	app.redirectAfterForm(w, r, "/user/profile")
+}
+
+// updatePreviewLength saves how much of each entry dashboard cards preview
+// (POST /user/preview-length). Anything that isn't a whole number in range is a 422.
+func (app *application) updatePreviewLength(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	lengthStr := r.PostForm.Get("preview_length")
+
+	v := validator.NewValidator()
+	length, err := strconv.Atoi(lengthStr)
+	v.Check(err == nil, "preview_length", "Preview length must be a whole number")
+	if v.ValidData() {
+		data.ValidatePreviewLength(v, length)
+	}
+	if !v.ValidData() {
+		app.logger.Warn("Invalid preview length submitted", "userID", userID, "preview_length", lengthStr)
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = app.users.UpdatePreviewLength(r.Context(), userID, length)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	app.session.Put(r, "flash", "Preview length saved.")
+	app.redirectAfterForm(w, r, "/user/profile")
+}
+
+/*
+==========================================================================
+
+	Session Preference Handlers
+==========================================================================
+*/
+
+// togglePrivacyMode flips the session-scoped privacy mode on or off.
+// When enabled, the dashboard masks entry content previews (useful on shared screens)
+// while titles and emotions stay visible. This is not persisted to the database.
+func (app *application) togglePrivacyMode(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Method Check.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		app.clientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 3. Flip the flag stored in the session.
+	enabled := !app.session.GetBool(r, "privacyMode")
+	app.session.Put(r, "privacyMode", enabled)
+	app.logger.Info("Privacy mode toggled", "userID", userID, "enabled", enabled)
+
+	// 4. Send the user back to the dashboard so the previews re-render.
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/dashboard")
+		w.WriteHeader(http.StatusOK)
+	} else {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	}
+}
+
+// Dashboard layouts selectable with toggleViewMode.
+const (
+	viewModeCards = "cards" // Default: one card per entry with a content preview.
+	viewModeList  = "list"  // Compact: one row per entry, for scanning many at once.
+)
+
+// toggleViewMode switches the session-scoped dashboard layout between cards and list.
+// Handles POST /user/view-mode.
+func (app *application) toggleViewMode(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Method Check.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		app.clientError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 3. Flip the mode stored in the session.
+	mode := viewModeList
+	if app.session.GetString(r, "viewMode") == viewModeList {
+		mode = viewModeCards
+	}
+	app.session.Put(r, "viewMode", mode)
+	app.logger.Info("Dashboard view mode toggled", "userID", userID, "mode", mode)
+
+	// 4. Send the user back to the dashboard so the entries re-render.
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/dashboard")
+		w.WriteHeader(http.StatusOK)
+	} else {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	}
+}
+
+// showCSRFToken returns the current CSRF token as JSON, so long-lived HTMX pages can
+// pick up a fresh token for their X-CSRF-Token header instead of failing with 403.
+// Handles GET /csrf-token; it is a safe request that changes no state.
+func (app *application) showCSRFToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	app.writeJSON(w, http.StatusOK, map[string]string{"csrf_token": nosurf.Token(r)}, nil)
+}
+
 /*
 ==========================================================================
 
@@ -1570,7 +2764,7 @@ func (app *application) serverError(w http.ResponseWriter, r *http.Request, err
 		method = r.Method
 		uri    = r.URL.RequestURI()
 	)
-	app.logger.Error("server error encountered", "error", err.Error(), "method", method, "uri", uri)
+	app.requestLogger(r).Error("server error encountered", "error", err.Error(), "method", method, "uri", uri)
 	// 2. Check if Headers Already Sent: If response headers have been written, we can't send a new error page.
 	//    This prevents "http: superfluous response.WriteHeader call" errors.
 	if headersSent := w.Header().Get("Content-Type"); headersSent != "" {
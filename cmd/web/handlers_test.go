@@ -0,0 +1,1322 @@
+// mood/cmd/web/handlers_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestTogglePrivacyMode(t *testing.T) {
+	app := newTestApplication(t)
+	r := newSessionRequest(t, http.MethodPost, "/user/privacy-mode", nil)
+	app.session.Put(r, "authenticatedUserID", int64(1))
+
+	// First toggle turns privacy mode on.
+	rr := httptest.NewRecorder()
+	app.togglePrivacyMode(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if !app.session.GetBool(r, "privacyMode") {
+		t.Fatal("Expected privacyMode to be true after first toggle")
+	}
+
+	// newTemplateData looks the user up in the database for authenticated
+	// requests, so drop the auth key before checking the template data.
+	app.session.Remove(r, "authenticatedUserID")
+	if td := app.newTemplateData(r); !td.PrivacyMode {
+		t.Error("Expected PrivacyMode to be true in template data")
+	}
+
+	// Second toggle turns it back off.
+	app.session.Put(r, "authenticatedUserID", int64(1))
+	rr = httptest.NewRecorder()
+	app.togglePrivacyMode(rr, r)
+	app.session.Remove(r, "authenticatedUserID")
+	if td := app.newTemplateData(r); td.PrivacyMode {
+		t.Error("Expected PrivacyMode to be false after second toggle")
+	}
+}
+
+func TestToggleViewMode(t *testing.T) {
+	app := newTestApplication(t)
+	r := newSessionRequest(t, http.MethodPost, "/user/view-mode", nil)
+
+	// Cards is the default layout.
+	if td := app.newTemplateData(r); td.ViewMode != viewModeCards {
+		t.Fatalf("Expected default ViewMode %q, got %q", viewModeCards, td.ViewMode)
+	}
+
+	// First toggle switches to the compact list.
+	app.session.Put(r, "authenticatedUserID", int64(1))
+	rr := httptest.NewRecorder()
+	app.toggleViewMode(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	app.session.Remove(r, "authenticatedUserID")
+	if td := app.newTemplateData(r); td.ViewMode != viewModeList {
+		t.Errorf("Expected ViewMode %q after first toggle, got %q", viewModeList, td.ViewMode)
+	}
+
+	// An HTMX toggle redirects with HX-Redirect and switches back to cards.
+	app.session.Put(r, "authenticatedUserID", int64(1))
+	r.Header.Set("HX-Request", "true")
+	rr = httptest.NewRecorder()
+	app.toggleViewMode(rr, r)
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Redirect") != "/dashboard" {
+		t.Errorf("Expected 200 with HX-Redirect to /dashboard, got %d %q", rr.Code, rr.Header().Get("HX-Redirect"))
+	}
+	app.session.Remove(r, "authenticatedUserID")
+	if td := app.newTemplateData(r); td.ViewMode != viewModeCards {
+		t.Errorf("Expected ViewMode %q after second toggle, got %q", viewModeCards, td.ViewMode)
+	}
+}
+
+func TestShowDashboardPage_ViewModeSurvivesHTMX(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	if err := app.moods.Insert(context.Background(), &data.Mood{Title: "T", Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/dashboard", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	get := func() *httptest.ResponseRecorder {
+		r.Header.Set("HX-Request", "true")
+		rr := httptest.NewRecorder()
+		app.showDashboardPage(rr, r)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rr.Code)
+		}
+		return rr
+	}
+
+	cards := get()
+	if strings.Contains(cards.Body.String(), "mood-list-compact") {
+		t.Fatal("Expected the card layout before toggling")
+	}
+
+	app.toggleViewMode(httptest.NewRecorder(), r)
+
+	list := get()
+	if !strings.Contains(list.Body.String(), "mood-list-compact") {
+		t.Error("Expected the HTMX fragment to use the compact list after toggling")
+	}
+	if list.Header().Get("ETag") == cards.Header().Get("ETag") {
+		t.Error("Expected the ETag to change with the view mode")
+	}
+}
+
+func TestDashboardSort(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                "created_at_desc",
+		"created_at_asc":  "created_at_asc",
+		"emotion_asc":     "emotion_asc",
+		"id; DROP TABLE":  "created_at_desc",
+		"created_at_desc": "created_at_desc",
+	} {
+		if got := dashboardSort(in); got != want {
+			t.Errorf("dashboardSort(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestShowDashboardPage_Sort(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	for _, title := range []string{"Older entry", "Newer entry"} {
+		mood := &data.Mood{Title: title, Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+
+	// A pagination request carries the sort along with the filters.
+	r := newSessionRequest(t, http.MethodGet, "/dashboard?sort=created_at_asc&page=1", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	r.Header.Set("HX-Request", "true")
+	rr := httptest.NewRecorder()
+	app.showDashboardPage(rr, r)
+
+	body := rr.Body.String()
+	if !strings.Contains(body, `<option value="created_at_asc" selected>`) {
+		t.Error("Expected the chosen sort to stay selected in the fragment")
+	}
+	if older, newer := strings.Index(body, "Older entry"), strings.Index(body, "Newer entry"); older < 0 || newer < older {
+		t.Errorf("Expected oldest-first order, got Older at %d and Newer at %d", older, newer)
+	}
+}
+
+func TestShowDashboardPage_ClearFilters(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	mood := &data.Mood{Title: "Only entry", Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+	get := func(target string) string {
+		r := newSessionRequest(t, http.MethodGet, target, nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		r.Header.Set("HX-Request", "true")
+		rr := httptest.NewRecorder()
+		app.showDashboardPage(rr, r)
+		return rr.Body.String()
+	}
+
+	// Stacked filters with a page left over from before they changed: GetFiltered clamps
+	// it to the last page, which here is the only one.
+	emotion := data.EncodeEmotionFilter("Happy", "😊")
+	body := get("/dashboard?" + url.Values{"emotion": {emotion}, "start_date": {"2000-01-01"}, "page": {"5"}}.Encode())
+	if !strings.Contains(body, "Only entry") {
+		t.Errorf("Expected a stale page to show the last page, got:\n%s", body)
+	}
+	if !strings.Contains(body, `class="btn cancel-btn clear-filters-btn"`) {
+		t.Error("Expected a clear-filters control while filters are active")
+	}
+	selectedEmotion := `value="` + emotion + `" selected`
+	if !strings.Contains(body, selectedEmotion) {
+		t.Errorf("Expected %q in the filtered fragment", selectedEmotion)
+	}
+
+	// Clearing re-fetches /dashboard with no params: nothing stays selected or filled in.
+	body = get("/dashboard?query=+&emotion=&start_date=&end_date=")
+	if strings.Contains(body, "clear-filters-btn") {
+		t.Error("Expected no clear-filters control once every filter is empty")
+	}
+	if strings.Contains(body, selectedEmotion) || strings.Contains(body, `value="2000-01-01"`) || !strings.Contains(body, `name="query" placeholder="Search entries..." value=""`) {
+		t.Errorf("Expected the emotion dropdown, dates and search to be cleared, got:\n%s", body)
+	}
+}
+
+func TestShowCSRFToken(t *testing.T) {
+	app := newTestApplication(t)
+	// nosurf only issues a token to requests that pass through its handler.
+	handler := app.noSurf(http.HandlerFunc(app.showCSRFToken))
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/csrf-token", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Expected Cache-Control no-store, got %q", cc)
+	}
+	var resp struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.CSRFToken == "" {
+		t.Error("Expected a non-empty csrf_token")
+	}
+}
+
+func TestBuildFilterChips(t *testing.T) {
+	query := url.Values{
+		"query":      {"work"},
+		"emotion":    {"Happy::😊"},
+		"start_date": {"2024-05-01"},
+		"end_date":   {"2024-05-31"},
+		"sort":       {"title_asc"},
+		"page":       {"2"},
+	}
+
+	chips := buildFilterChips(query)
+
+	expectedLabels := []string{"Search: work", "Emotion: 😊 Happy", "From: 2024-05-01", "To: 2024-05-31"}
+	if len(chips) != len(expectedLabels) {
+		t.Fatalf("Expected %d chips, got %d: %+v", len(expectedLabels), len(chips), chips)
+	}
+	dropped := []string{"query", "emotion", "start_date", "end_date"}
+	for i, chip := range chips {
+		if chip.Label != expectedLabels[i] {
+			t.Errorf("Chip %d: expected label %q, got %q", i, expectedLabels[i], chip.Label)
+		}
+		u, err := url.Parse(chip.ClearURL)
+		if err != nil {
+			t.Fatalf("Chip %d: invalid ClearURL %q: %v", i, chip.ClearURL, err)
+		}
+		if u.Path != "/dashboard" {
+			t.Errorf("Chip %d: expected path /dashboard, got %q", i, u.Path)
+		}
+		got := u.Query()
+		for key := range query {
+			_, present := got[key]
+			if key == "page" {
+				if present {
+					t.Errorf("Chip %d: expected page to reset, got %q", i, chip.ClearURL)
+				}
+				continue
+			}
+			if key == dropped[i] && present {
+				t.Errorf("Chip %d: expected %q to be dropped from %q", i, key, chip.ClearURL)
+			}
+			if key != dropped[i] && got.Get(key) != query.Get(key) {
+				t.Errorf("Chip %d: expected %q=%q to be kept in %q", i, key, query.Get(key), chip.ClearURL)
+			}
+		}
+	}
+
+	if chips := buildFilterChips(url.Values{"page": {"3"}, "query": {"  "}}); len(chips) != 0 {
+		t.Errorf("Expected no chips without active filters, got %+v", chips)
+	}
+
+	chips = buildFilterChips(url.Values{"weekday": {"1"}})
+	if len(chips) != 1 || chips[0].Label != "Day: Monday" {
+		t.Errorf("Expected a single \"Day: Monday\" chip, got %+v", chips)
+	}
+
+	// Bounds at the end of the scale narrow nothing; an inverted range is ignored entirely.
+	chips = buildFilterChips(url.Values{"min_intensity": {"4"}, "max_intensity": {"5"}})
+	if len(chips) != 1 || chips[0].Label != "Min intensity: 4" || chips[0].ClearURL != "/dashboard?max_intensity=5" {
+		t.Errorf("Expected a single \"Min intensity: 4\" chip, got %+v", chips)
+	}
+	if chips := buildFilterChips(url.Values{"min_intensity": {"4"}, "max_intensity": {"2"}}); len(chips) != 0 {
+		t.Errorf("Expected no chips for an inverted intensity range, got %+v", chips)
+	}
+}
+
+func TestParseIntensityRange(t *testing.T) {
+	tests := []struct {
+		min, max         string
+		wantMin, wantMax int
+		wantErr          bool
+	}{
+		{min: "", max: "", wantMin: 1, wantMax: 5},
+		{min: "3", max: "", wantMin: 3, wantMax: 5},
+		{min: "", max: " 2 ", wantMin: 1, wantMax: 2},
+		{min: "4", max: "4", wantMin: 4, wantMax: 4},
+		{min: "0", max: "", wantMin: 1, wantMax: 5, wantErr: true},
+		{min: "", max: "6", wantMin: 1, wantMax: 5, wantErr: true},
+		{min: "4", max: "2", wantMin: 1, wantMax: 5, wantErr: true},
+		{min: "high", max: "", wantMin: 1, wantMax: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		gotMin, gotMax, err := parseIntensityRange(tt.min, tt.max)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIntensityRange(%q, %q): unexpected error state: %v", tt.min, tt.max, err)
+		}
+		if gotMin != tt.wantMin || gotMax != tt.wantMax {
+			t.Errorf("parseIntensityRange(%q, %q) = %d, %d, want %d, %d", tt.min, tt.max, gotMin, gotMax, tt.wantMin, tt.wantMax)
+		}
+	}
+	if minStr, maxStr := intensityParams(1, 3); minStr != "" || maxStr != "3" {
+		t.Errorf("intensityParams(1, 3) = %q, %q, want \"\", \"3\"", minStr, maxStr)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "", want: data.AnyWeekday},
+		{input: "mon", want: 1},
+		{input: "Monday", want: 1},
+		{input: " SUN ", want: 0},
+		{input: "0", want: 0},
+		{input: "6", want: 6},
+		{input: "7", want: data.AnyWeekday, wantErr: true},
+		{input: "-1", want: data.AnyWeekday, wantErr: true},
+		{input: "someday", want: data.AnyWeekday, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWeekday(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWeekday(%q): unexpected error state: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseWeekday(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+	if got := weekdayParam(1); got != "mon" {
+		t.Errorf("weekdayParam(1) = %q, want \"mon\"", got)
+	}
+}
+
+func TestParseFilterDay(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	start, end, err := parseFilterDay("2024-05-10", newYork)
+	if err != nil {
+		t.Fatalf("parseFilterDay failed: %v", err)
+	}
+	if want := time.Date(2024, 5, 10, 4, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("start = %v, want %v", start.UTC(), want)
+	}
+	if want := time.Date(2024, 5, 11, 4, 0, 0, 0, time.UTC).Add(-time.Nanosecond); !end.Equal(want) {
+		t.Errorf("end = %v, want %v", end.UTC(), want)
+	}
+
+	// An entry at local 00:30 falls inside that local day.
+	entry := time.Date(2024, 5, 10, 0, 30, 0, 0, newYork)
+	if entry.Before(start) || entry.After(end) {
+		t.Errorf("Expected %v to fall within [%v, %v]", entry, start, end)
+	}
+
+	// The spring-forward day is only 23 hours long.
+	start, end, _ = parseFilterDay("2024-03-10", newYork)
+	if got := end.Sub(start) + time.Nanosecond; got != 23*time.Hour {
+		t.Errorf("Expected a 23h day across the DST change, got %v", got)
+	}
+
+	if _, _, err := parseFilterDay("10/05/2024", newYork); err == nil {
+		t.Error("Expected an error for a malformed date")
+	}
+}
+
+func TestRequestLocation(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name   string
+		cookie string
+		want   string
+	}{
+		{"NoCookie", "", "UTC"},
+		{"Escaped", "America%2FNew_York", "America/New_York"},
+		{"Plain", "Europe/London", "Europe/London"},
+		{"Unknown", "Mars/Olympus_Mons", "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: timeZoneCookie, Value: tt.cookie})
+			}
+			if got := app.requestLocation(r).String(); got != tt.want {
+				if _, err := time.LoadLocation(tt.want); err != nil {
+					t.Skipf("time zone data unavailable: %v", err)
+				}
+				t.Errorf("requestLocation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUserTheme(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	tests := []struct {
+		name       string
+		theme      string
+		wantStatus int
+		wantTheme  string
+	}{
+		{"Dark", "dark", http.StatusSeeOther, "dark"},
+		{"Light", "light", http.StatusSeeOther, "light"},
+		{"Invalid", "neon", http.StatusUnprocessableEntity, "light"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"theme": {tt.theme}}
+			r := newSessionRequest(t, http.MethodPost, "/user/theme", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			app.session.Put(r, "authenticatedUserID", userID)
+
+			rr := httptest.NewRecorder()
+			app.updateUserTheme(rr, r)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			user, err := app.users.Get(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("Failed to fetch user: %v", err)
+			}
+			if user.Theme != tt.wantTheme {
+				t.Errorf("Expected stored theme %q, got %q", tt.wantTheme, user.Theme)
+			}
+			if td := app.newTemplateData(r); td.Theme != tt.wantTheme {
+				t.Errorf("Expected template theme %q, got %q", tt.wantTheme, td.Theme)
+			}
+		})
+	}
+}
+
+func TestProfileThemeToggle(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+
+	rr := httptest.NewRecorder()
+	templateData := &TemplateData{Title: "User Profile", Theme: "dark", TimeFormat: "24h", ProfileCurrentPage: 1}
+	if err := app.render(rr, http.StatusOK, "profile.tmpl", templateData); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, `<html lang="en" data-theme="dark">`) {
+		t.Error("Expected the saved theme on <html> for first paint")
+	}
+	if !strings.Contains(body, `action="/user/theme"`) {
+		t.Error("Expected a theme form posting to /user/theme")
+	}
+	if !strings.Contains(body, `<option value="dark" selected>`) {
+		t.Error("Expected the saved theme to be selected")
+	}
+}
+
+func TestNotifyPasswordChanged(t *testing.T) {
+	t.Run("Sent", func(t *testing.T) {
+		app := newTestApplication(t)
+		stub := &stubMailer{}
+		app.mailer = stub
+
+		app.notifyPasswordChanged("Alice", "alice@example.com")
+		app.wg.Wait()
+
+		sent := stub.Sent()
+		if len(sent) != 1 {
+			t.Fatalf("Expected 1 email, got %d", len(sent))
+		}
+		if sent[0].Recipient != "alice@example.com" || sent[0].TemplateName != "password_changed" {
+			t.Errorf("Unexpected email: %+v", sent[0])
+		}
+	})
+
+	t.Run("FailureIsBestEffort", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.mailer = &stubMailer{err: errors.New("smtp down")}
+
+		// Must not panic or block; the error is only logged.
+		app.notifyPasswordChanged("Alice", "alice@example.com")
+		app.wg.Wait()
+	})
+}
+
+func TestChangeUserPassword_SendsNotification(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	userID := insertTestUser(t, app)
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+
+	form := url.Values{
+		"current_password": {"pa55word123"},
+		"new_password":     {"n3wPa55word!"},
+		"confirm_password": {"n3wPa55word!"},
+	}
+	r := newSessionRequest(t, http.MethodPost, "/user/profile/password", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+
+	rr := httptest.NewRecorder()
+	app.changeUserPassword(rr, r)
+	app.wg.Wait()
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+	sent := stub.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 notification email, got %d", len(sent))
+	}
+	if sent[0].Recipient != user.Email || sent[0].TemplateName != "password_changed" {
+		t.Errorf("Unexpected email: %+v", sent[0])
+	}
+}
+
+func TestPreserveFormOnExpiredSession(t *testing.T) {
+	app := newTestApplication(t)
+	handlerCalled := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handlerCalled = true })
+
+	form := url.Values{
+		"title":   {"Half-written entry"},
+		"content": {"<p>I was in the middle of writing this</p>"},
+		"emotion": {"Calm"},
+		"emoji":   {"😌"},
+		"color":   {"#90EE90"},
+	}
+	// No authenticatedUserID in the session: it has expired.
+	r := newSessionRequest(t, http.MethodPost, "/mood/new", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	app.preserveFormOnExpiredSession(next).ServeHTTP(rr, r)
+
+	if handlerCalled {
+		t.Fatal("Expected the handler not to run for an expired session")
+	}
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/user/login" {
+		t.Errorf("Expected redirect to /user/login, got %q", loc)
+	}
+	if app.session.GetString(r, "flash") == "" {
+		t.Error("Expected a flash message explaining the expired session")
+	}
+	if got := app.pendingSubmissionPath(r); got != "/mood/new" {
+		t.Errorf("Expected stash for /mood/new, got %q", got)
+	}
+
+	// A different form must not receive the stash.
+	if fields := app.popPendingSubmission(r, "/mood/edit/1"); fields != nil {
+		t.Errorf("Expected no stash for another path, got %v", fields)
+	}
+
+	fields := app.popPendingSubmission(r, "/mood/new")
+	if fields["title"] != "Half-written entry" || fields["content"] != form.Get("content") {
+		t.Errorf("Unexpected stashed fields: %v", fields)
+	}
+	if app.popPendingSubmission(r, "/mood/new") != nil {
+		t.Error("Expected the stash to be removed after it is popped")
+	}
+}
+
+func TestStashPendingSubmission_TooLarge(t *testing.T) {
+	app := newTestApplication(t)
+	r := newSessionRequest(t, http.MethodPost, "/mood/new", nil)
+	form := url.Values{
+		"title":   {"Long entry"},
+		"content": {strings.Repeat("x", 5000)},
+	}
+
+	if app.stashPendingSubmission(r, form) {
+		t.Error("Expected oversized content not to be kept")
+	}
+	fields := app.popPendingSubmission(r, "/mood/new")
+	if fields["title"] != "Long entry" {
+		t.Errorf("Expected the title to survive, got %v", fields)
+	}
+	if _, ok := fields["content"]; ok {
+		t.Error("Expected oversized content to be dropped from the stash")
+	}
+}
+
+func TestShowMoodDetail(t *testing.T) {
+	t.Run("Unauthenticated", func(t *testing.T) {
+		app := newTestApplication(t)
+		r := newSessionRequest(t, http.MethodGet, "/mood/1", nil)
+		r.SetPathValue("id", "1")
+		rr := httptest.NewRecorder()
+
+		app.requireAuthentication(http.HandlerFunc(app.showMoodDetail)).ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusFound, rr.Code)
+		}
+		if loc := rr.Header().Get("Location"); loc != "/user/login" {
+			t.Errorf("Expected redirect to /user/login, got %q", loc)
+		}
+	})
+
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	mood := &data.Mood{
+		Title: "Detail entry", Content: `<p>Full <strong>content</strong><script>alert(1)</script></p>`,
+		Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: userID, PrivateNote: "just for me",
+	}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	idStr := strconv.FormatInt(mood.ID, 10)
+
+	newDetailRequest := func(asUser int64) *http.Request {
+		r := newSessionRequest(t, http.MethodGet, "/mood/"+idStr, nil)
+		r.SetPathValue("id", idStr)
+		app.session.Put(r, "authenticatedUserID", asUser)
+		return r
+	}
+
+	t.Run("Owned", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.showMoodDetail(rr, newDetailRequest(userID))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		body := rr.Body.String()
+		for _, want := range []string{"Detail entry", "<strong>content</strong>", "just for me", "/mood/edit/" + idStr} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected body to contain %q", want)
+			}
+		}
+		if strings.Contains(body, "<script>alert(1)</script>") {
+			t.Error("Expected script tags to be sanitized out of the content")
+		}
+	})
+
+	t.Run("History", func(t *testing.T) {
+		edited := *mood
+		edited.Title, edited.Content = "Rewritten entry", "<p>New wording</p>"
+		if err := app.moods.Update(context.Background(), &edited); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		app.showMoodDetail(rr, newDetailRequest(userID))
+
+		body := rr.Body.String()
+		if !strings.Contains(body, "History (1 earlier version)") || !strings.Contains(body, "Detail entry") {
+			t.Errorf("Expected the original version in the history, got:\n%s", body)
+		}
+		if strings.Contains(body, "<script>alert(1)</script>") {
+			t.Error("Expected script tags to be sanitized out of the history too")
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.showMoodDetail(rr, newDetailRequest(otherUserID))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestShowStatsPage_NoEntries(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+
+	r := newSessionRequest(t, http.MethodGet, "/stats", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.showStatsPage(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`data-has-data="false"`,
+		`data-emotion-counts='[]'`,
+		`data-weekly-counts='[]'`,
+		`data-monthly-counts='[]'`,
+		`data-weekday-counts='[]'`,
+		`data-hourly-counts='[]'`,
+		"No data yet",
+		`href="/mood/new"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected stats page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "null") || strings.Contains(body, "<canvas") {
+		t.Errorf("Expected no chart canvases or null datasets for a user with no entries, got:\n%s", body)
+	}
+}
+
+func TestChartDatasetJSON(t *testing.T) {
+	var none []data.WeeklyCount
+	for _, tt := range []struct {
+		dataset any
+		want    string
+	}{
+		{none, "[]"},
+		{[]data.WeeklyCount{}, "[]"},
+		{[]data.WeeklyCount{{Week: "2024-23", Count: 2}}, `[{"week":"2024-23","count":2}]`},
+	} {
+		got, err := chartDatasetJSON(tt.dataset)
+		if err != nil || got != tt.want {
+			t.Errorf("chartDatasetJSON(%#v) = %q, %v; want %q", tt.dataset, got, err, tt.want)
+		}
+	}
+}
+
+func TestShowStatsData(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	if err := app.moods.Insert(context.Background(), &data.Mood{Title: "T", Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}); err != nil {
+		t.Fatalf("Failed to insert mood: %v", err)
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/stats/data.json", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.showStatsData(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	for _, key := range []string{"totalEntries", "emotionCounts", "weeklyCounts", "monthlyCounts", "weekdayCounts", "hourlyCounts", "sameDayPairs"} {
+		if _, ok := resp[key]; !ok {
+			t.Errorf("Expected key %q in stats payload", key)
+		}
+	}
+	var hourly []data.HourlyCount
+	if err := json.Unmarshal(resp["hourlyCounts"], &hourly); err != nil || len(hourly) != 24 {
+		t.Errorf("Expected 24 hourly buckets, got %d (err %v)", len(hourly), err)
+	}
+}
+
+func TestShowStatsHeatmap(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	for i := 0; i < 2; i++ {
+		if err := app.moods.Insert(context.Background(), &data.Mood{Title: "T", Content: "<p>c</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID}); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/stats/heatmap.json", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.showStatsHeatmap(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		From string            `json:"from"`
+		To   string            `json:"to"`
+		Days []data.DailyCount `json:"days"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if resp.To != today || resp.From != time.Now().UTC().AddDate(0, 0, -(statsHeatmapDays-1)).Format("2006-01-02") {
+		t.Errorf("Unexpected range %s to %s", resp.From, resp.To)
+	}
+	if len(resp.Days) != 1 || resp.Days[0].Date != today || resp.Days[0].Count != 2 {
+		t.Errorf("Expected today's two entries, got %+v", resp.Days)
+	}
+}
+
+func TestShowStatsHeatmap_Unauthenticated(t *testing.T) {
+	app := newTestApplication(t)
+	rr := httptest.NewRecorder()
+	app.showStatsHeatmap(rr, newSessionRequest(t, http.MethodGet, "/stats/heatmap.json", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestNewDisplayMoods_Sanitizes(t *testing.T) {
+	moods := []*data.Mood{{ID: 1, Title: "T", Content: `<p onclick="x()">Hello <strong>there</strong></p><script>alert(1)</script>`}}
+
+	got := newDisplayMoods(moods, data.DefaultPreviewLength)[0]
+	for field, value := range map[string]string{"Content": string(got.Content), "RawContent": got.RawContent} {
+		if value != "<p>Hello <strong>there</strong></p>" {
+			t.Errorf("Expected %s to be sanitized, got %q", field, value)
+		}
+	}
+	if got.ShortContent != "Hello there" {
+		t.Errorf("Expected a plain-text preview, got %q", got.ShortContent)
+	}
+}
+
+func TestIntensityTrends(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	entry := func(id int64, day, intensity int) *data.Mood {
+		return &data.Mood{ID: id, CreatedAt: base.AddDate(0, 0, day), Intensity: intensity}
+	}
+
+	// Intensities 3, 5, 5, 2, 4 logged on consecutive days, given in dashboard order
+	// (newest first) with the oldest one repeated as a fetched predecessor.
+	timeline := []*data.Mood{entry(5, 4, 4), entry(4, 3, 2), entry(3, 2, 5), entry(2, 1, 5), entry(1, 0, 3), entry(1, 0, 3)}
+	got := intensityTrends(timeline)
+	want := map[int64]string{2: "up", 3: "same", 4: "down", 5: "up"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("intensityTrends() = %v, want %v", got, want)
+	}
+	if _, ok := got[1]; ok {
+		t.Error("Expected no trend for the first-ever entry")
+	}
+
+	// Entries logged at the same moment are ordered by ID.
+	got = intensityTrends([]*data.Mood{entry(8, 0, 1), entry(7, 0, 4)})
+	if want := map[int64]string{8: "down"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("intensityTrends() with a tie = %v, want %v", got, want)
+	}
+}
+
+func TestParseIntensity(t *testing.T) {
+	for in, want := range map[string]int{"": data.DefaultMoodIntensity, " 4 ": 4, "1": 1, "9": 9, "high": 0} {
+		if got := parseIntensity(in); got != want {
+			t.Errorf("parseIntensity(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseMoodIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []int64
+		wantErr bool
+	}{
+		{name: "Repeated", values: []string{"3", "1"}, want: []int64{3, 1}},
+		{name: "CommaSeparated", values: []string{"1, 2,3"}, want: []int64{1, 2, 3}},
+		{name: "Mixed", values: []string{"1,2", "4"}, want: []int64{1, 2, 4}},
+		{name: "Duplicates", values: []string{"5,5", "5"}, want: []int64{5}},
+		{name: "Blank", values: []string{"", " , "}, want: []int64{}},
+		{name: "None", values: nil, want: []int64{}},
+		{name: "NotANumber", values: []string{"1,abc"}, wantErr: true},
+		{name: "Zero", values: []string{"0"}, wantErr: true},
+		{name: "Negative", values: []string{"-2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMoodIDs(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMoodIDs(%q): unexpected error state: %v", tt.values, err)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("parseMoodIDs(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkDeleteMoods(t *testing.T) {
+	t.Run("InvalidID", func(t *testing.T) {
+		app := newTestApplication(t)
+		r := newSessionRequest(t, http.MethodPost, "/mood/bulk-delete", strings.NewReader("ids=1,nope"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		app.session.Put(r, "authenticatedUserID", int64(1))
+		rr := httptest.NewRecorder()
+
+		app.bulkDeleteMoods(rr, r)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	insert := func(owner int64) *data.Mood {
+		mood := &data.Mood{Title: "Bulk", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: owner}
+		if err := app.moods.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Setup insert failed: %v", err)
+		}
+		return mood
+	}
+	first, second, kept, others := insert(userID), insert(userID), insert(userID), insert(otherUserID)
+
+	form := url.Values{"ids": {fmt.Sprintf("%d,%d", first.ID, second.ID), strconv.FormatInt(others.ID, 10)}}
+	r := newSessionRequest(t, http.MethodPost, "/mood/bulk-delete", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+
+	app.bulkDeleteMoods(rr, r)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if flash := app.session.GetString(r, "flash"); flash != "Moved 2 entries to the trash." {
+		t.Errorf("Unexpected flash %q", flash)
+	}
+	for _, mood := range []*data.Mood{first, second} {
+		if _, err := app.moods.Get(context.Background(), mood.ID, userID); !errors.Is(err, data.ErrRecordNotFound) {
+			t.Errorf("Expected entry %d to be in the trash, got %v", mood.ID, err)
+		}
+	}
+	if _, err := app.moods.Get(context.Background(), kept.ID, userID); err != nil {
+		t.Errorf("Unselected entry was deleted: %v", err)
+	}
+	if _, err := app.moods.Get(context.Background(), others.ID, otherUserID); err != nil {
+		t.Errorf("Another user's entry was deleted: %v", err)
+	}
+}
+
+func TestOneEntryPerDay(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+
+	newFormRequest := func() *http.Request {
+		r := newSessionRequest(t, http.MethodGet, "/mood/new", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		return r
+	}
+
+	mood := &data.Mood{Title: "Today", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFCA28", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+
+	t.Run("Off", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.showMoodForm(rr, newFormRequest())
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	if err := app.users.UpdateOneEntryPerDay(context.Background(), userID, true); err != nil {
+		t.Fatalf("UpdateOneEntryPerDay failed: %v", err)
+	}
+	wantLocation := "/mood/edit/" + strconv.FormatInt(mood.ID, 10)
+
+	t.Run("OnShowForm", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.showMoodForm(rr, newFormRequest())
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+		}
+		if loc := rr.Header().Get("Location"); loc != wantLocation {
+			t.Errorf("Expected redirect to %q, got %q", wantLocation, loc)
+		}
+	})
+
+	t.Run("OnCreate", func(t *testing.T) {
+		form := url.Values{"title": {"Second"}, "content": {"again"}, "emotion": {"Sad"}, "emoji": {"😢"}, "color": {"#5C8DDE"}}
+		r := newSessionRequest(t, http.MethodPost, "/mood/new", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+
+		app.createMood(rr, r)
+
+		if got := rr.Header().Get("HX-Redirect"); got != wantLocation {
+			t.Errorf("Expected HX-Redirect to %q, got %q", wantLocation, got)
+		}
+		total, err := app.moods.GetTotalMoodCount(context.Background(), userID)
+		if err != nil || total != 1 {
+			t.Errorf("Expected no second entry, got %d entries (%v)", total, err)
+		}
+	})
+}
+
+func TestUpdateUserTimeZone(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	post := func(timeZone string) *httptest.ResponseRecorder {
+		form := url.Values{"timezone": {timeZone}}
+		r := newSessionRequest(t, http.MethodPost, "/user/timezone", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.updateUserTimeZone(rr, r)
+		return rr
+	}
+
+	if rr := post("Mars/Olympus_Mons"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for an unknown zone, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if rr := post("Asia/Singapore"); rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if user.TimeZone != "Asia/Singapore" {
+		t.Errorf("Expected time zone Asia/Singapore, got %q", user.TimeZone)
+	}
+}
+
+func TestUpdateEmailReminders(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	post := func(form url.Values) {
+		t.Helper()
+		r := newSessionRequest(t, http.MethodPost, "/user/email-reminders", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.updateEmailReminders(rr, r)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+		}
+	}
+	emailReminders := func() bool {
+		t.Helper()
+		user, err := app.users.Get(context.Background(), userID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		return user.EmailReminders
+	}
+
+	if emailReminders() {
+		t.Fatal("Expected email reminders to be off by default")
+	}
+	post(url.Values{"email_reminders": {"on"}})
+	if !emailReminders() {
+		t.Error("Expected email reminders to be on after ticking the box")
+	}
+	post(url.Values{}) // An unticked checkbox isn't submitted.
+	if emailReminders() {
+		t.Error("Expected email reminders to be off after unticking the box")
+	}
+}
+
+func TestPreviewLength(t *testing.T) {
+	tests := []struct {
+		name string
+		user *data.User
+		want int
+	}{
+		{"NoUser", nil, data.DefaultPreviewLength},
+		{"Unset", &data.User{}, data.DefaultPreviewLength},
+		{"Chosen", &data.User{PreviewLength: 120}, 120},
+		{"OutOfRange", &data.User{PreviewLength: 5000}, data.DefaultPreviewLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previewLength(tt.user); got != tt.want {
+				t.Errorf("previewLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDisplayMoods_PreviewLength(t *testing.T) {
+	// Multibyte text is cut by runes, not bytes.
+	moods := []*data.Mood{{ID: 1, Title: "T", Content: "<p>" + strings.Repeat("é", 30) + "</p>"}}
+
+	if got := string(newDisplayMoods(moods, 20)[0].ShortContent); got != strings.Repeat("é", 20)+"..." {
+		t.Errorf("Expected 20 runes and an ellipsis, got %q", got)
+	}
+	if got := string(newDisplayMoods(moods, 35)[0].ShortContent); got != strings.Repeat("é", 30) {
+		t.Errorf("Expected the whole text, got %q", got)
+	}
+}
+
+func TestUpdatePreviewLength(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+
+	post := func(length string) *httptest.ResponseRecorder {
+		form := url.Values{"preview_length": {length}}
+		r := newSessionRequest(t, http.MethodPost, "/user/preview-length", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.updatePreviewLength(rr, r)
+		return rr
+	}
+
+	for _, invalid := range []string{"", "abc", "19", "201"} {
+		if rr := post(invalid); rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusUnprocessableEntity, invalid, rr.Code)
+		}
+	}
+	if rr := post("80"); rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if user.PreviewLength != 80 {
+		t.Errorf("Expected preview length 80, got %d", user.PreviewLength)
+	}
+}
+
+func TestUpdateMood_EditConflict(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+
+	mood := &data.Mood{Title: "Original", Content: "<p>first</p>", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	idStr := strconv.FormatInt(mood.ID, 10)
+	openedAt := strconv.Itoa(mood.Version) // Both "tabs" opened the form at this version.
+
+	save := func(title string) *httptest.ResponseRecorder {
+		form := url.Values{"title": {title}, "content": {"<p>edited</p>"}, "emotion": {"Calm"}, "emoji": {"😌"}, "color": {"#69B36C"}, "version": {openedAt}}
+		r := newSessionRequest(t, http.MethodPost, "/mood/edit/"+idStr, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		r.SetPathValue("id", idStr)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.updateMood(rr, r)
+		return rr
+	}
+
+	if rr := save("First tab"); rr.Header().Get("HX-Redirect") != "/dashboard" {
+		t.Fatalf("Expected the first save to redirect, got status %d", rr.Code)
+	}
+
+	rr := save("Second tab")
+	if rr.Code != http.StatusOK || rr.Header().Get("HX-Redirect") != "" {
+		t.Fatalf("Expected the stale save to re-render the form, got status %d", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"changed elsewhere", `value="Second tab"`, `name="version" value="2"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q", want)
+		}
+	}
+	current, err := app.moods.Get(context.Background(), mood.ID, userID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if current.Title != "First tab" {
+		t.Errorf("Expected the first save to survive, got title %q", current.Title)
+	}
+}
+
+func TestCSRFFailure(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+	handler := app.noSurf(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected a request without a CSRF token to be rejected")
+	}))
+
+	t.Run("Page", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/mood/new", nil))
+
+		if rr.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d", http.StatusForbidden, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "Your session expired") {
+			t.Error("Expected the friendly session-expired page")
+		}
+	})
+
+	t.Run("HTMX", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/mood/new", nil)
+		r.Header.Set("HX-Request", "true")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if got := rr.Header().Get("HX-Redirect"); got != "/user/login" {
+			t.Errorf("Expected HX-Redirect to /user/login, got %q", got)
+		}
+		if len(rr.Result().Cookies()) == 0 {
+			t.Error("Expected the flash to be saved in the session cookie")
+		}
+	})
+
+	t.Run("API", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/moods", nil))
+
+		if rr.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d", http.StatusForbidden, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %q", ct)
+		}
+	})
+}
+
+func TestDeleteUserAccount_RequiresPassword(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+
+	deleteWith := func(password string) *httptest.ResponseRecorder {
+		form := url.Values{"confirm_delete_password": {password}}
+		r := newSessionRequest(t, http.MethodPost, "/user/profile/delete-account", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.deleteUserAccount(rr, r)
+		return rr
+	}
+
+	for _, password := range []string{"", "wrong-password"} {
+		rr := deleteWith(password)
+		if rr.Code != http.StatusOK || rr.Header().Get("HX-Redirect") != "" {
+			t.Fatalf("password %q: expected the profile fragment (200, no redirect), got %d %q", password, rr.Code, rr.Header().Get("HX-Redirect"))
+		}
+		if !strings.Contains(rr.Body.String(), `id="confirm_delete_password" name="confirm_delete_password" required autocomplete="current-password" class="invalid"`) {
+			t.Errorf("password %q: expected a confirmation error in the fragment", password)
+		}
+		if _, err := app.users.Get(context.Background(), userID); err != nil {
+			t.Fatalf("password %q: account should still exist, got %v", password, err)
+		}
+	}
+
+	rr := deleteWith("pa55word123")
+	if got := rr.Header().Get("HX-Redirect"); got != "/landing" {
+		t.Fatalf("Expected HX-Redirect to /landing, got %q (status %d)", got, rr.Code)
+	}
+	if _, err := app.users.Get(context.Background(), userID); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected the account to be deleted, got %v", err)
+	}
+}
+
+func TestSaveMood_SanitizesContent(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	unsafe := `<p>Hi<img src="x" onerror="alert(1)"></p><script>alert(2)</script>`
+
+	form := url.Values{"title": {"Sneaky"}, "content": {unsafe}, "emotion": {"Calm"}, "emoji": {"😌"}, "color": {"#69B36C"}}
+	r := newSessionRequest(t, http.MethodPost, "/mood/new", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.createMood(rr, r)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+
+	moods, err := app.moods.GetAllForUser(context.Background(), userID)
+	if err != nil || len(moods) != 1 {
+		t.Fatalf("Expected one mood, got %d (err %v)", len(moods), err)
+	}
+	if got := moods[0].Content; got != "<p>Hi</p>" {
+		t.Errorf("Expected the stored content to be sanitized, got %q", got)
+	}
+
+	// Updates are sanitized too.
+	idStr := strconv.FormatInt(moods[0].ID, 10)
+	form.Set("content", `<p onclick="steal()">Edited</p><script>alert(3)</script>`)
+	form.Set("version", strconv.Itoa(moods[0].Version))
+	r = newSessionRequest(t, http.MethodPost, "/mood/edit/"+idStr, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetPathValue("id", idStr)
+	app.session.Put(r, "authenticatedUserID", userID)
+	app.updateMood(httptest.NewRecorder(), r)
+	updated, err := app.moods.Get(context.Background(), moods[0].ID, userID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if updated.Content != "<p>Edited</p>" {
+		t.Errorf("Expected the updated content to be sanitized, got %q", updated.Content)
+	}
+}
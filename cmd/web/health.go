@@ -0,0 +1,40 @@
+// mood/cmd/web/health.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// version is the build's version string, reported by /healthz, /readyz and -version.
+// Release builds set it with: go build -ldflags="-X main.version=v1.2.3" ./cmd/web
+var version = "dev"
+
+// readinessTimeout bounds the database ping in /readyz, so a hung database fails the
+// probe quickly instead of holding the request open.
+const readinessTimeout = 2 * time.Second
+
+// healthz handles GET /healthz, the liveness probe. It only shows that the process is
+// serving requests, so it never touches the database.
+func (app *application) healthz(w http.ResponseWriter, r *http.Request) {
+	app.writeJSON(w, http.StatusOK, map[string]string{"status": "available", "version": version}, nil)
+}
+
+// readyz handles GET /readyz, the readiness probe. It answers 503 while the database
+// can't be reached, so an orchestrator stops routing traffic to this instance.
+func (app *application) readyz(w http.ResponseWriter, r *http.Request) {
+	if app.db == nil {
+		app.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "version": version}, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := app.db.PingContext(ctx); err != nil {
+		app.logger.Warn("Readiness check failed: database unreachable", "error", err)
+		app.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "version": version}, nil)
+		return
+	}
+	app.writeJSON(w, http.StatusOK, map[string]string{"status": "available", "version": version}, nil)
+}
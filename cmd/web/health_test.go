@@ -0,0 +1,70 @@
+// mood/cmd/web/health_test.go
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthz(t *testing.T) {
+	app := newTestApplication(t)
+
+	// Routed through app.routes() to check the probe skips the session and CSRF middleware.
+	rr := httptest.NewRecorder()
+	app.routes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["status"] != "available" || resp["version"] != version {
+		t.Errorf("Unexpected body %v", resp)
+	}
+	if cookies := rr.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("Expected no cookies on a health probe, got %v", cookies)
+	}
+}
+
+func TestReadyz_DatabaseUnreachable(t *testing.T) {
+	// Nothing listens on port 1, so the ping fails quickly without a real database.
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 connect_timeout=1 sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for name, pool := range map[string]*sql.DB{"NoPool": nil, "PingFails": db} {
+		t.Run(name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.db = pool
+			rr := httptest.NewRecorder()
+			app.readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+			}
+			var resp map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp["status"] != "unavailable" {
+				t.Errorf("Unexpected body %s (err %v)", rr.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	app := newTestApplication(t)
+	app.db = newTestDB(t)
+
+	rr := httptest.NewRecorder()
+	app.readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+// mood/cmd/web/json.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxJSONBodyBytes caps the size of JSON request bodies accepted by readJSON (1MB).
+const maxJSONBodyBytes = 1_048_576
+
+// writeJSON marshals data and writes it with the given status code and any extra headers.
+// Every JSON response goes through it so the content type and formatting stay consistent.
+func (app *application) writeJSON(w http.ResponseWriter, status int, data any, headers http.Header) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		app.logger.Error("failed to marshal JSON response", "error", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	for key, values := range headers {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+}
+
+// errorJSON sends {"error": message}, the JSON counterpart of the plain-text pages used by
+// clientError. message is usually a string, or a map of field names to validation errors.
+func (app *application) errorJSON(w http.ResponseWriter, status int, message any) {
+	app.writeJSON(w, status, map[string]any{"error": message}, nil)
+}
+
+// readJSON decodes a single JSON object from the request body into dst.
+// Unknown fields, trailing data and bodies over maxJSONBodyBytes are rejected, and the
+// error message is safe to show to clients.
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown field %s", fieldName)
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		default:
+			return err
+		}
+	}
+
+	// Make sure the body only contained a single JSON value.
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+	return nil
+}
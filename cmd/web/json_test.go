@@ -0,0 +1,80 @@
+// mood/cmd/web/json_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	app := newTestApplication(t)
+	rr := httptest.NewRecorder()
+
+	app.writeJSON(rr, http.StatusCreated, map[string]int{"id": 7}, http.Header{"Location": {"/api/v1/moods/7"}})
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/api/v1/moods/7" {
+		t.Errorf("Expected the extra Location header, got %q", loc)
+	}
+	if body := rr.Body.String(); body != "{\"id\":7}\n" {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	app := newTestApplication(t)
+	rr := httptest.NewRecorder()
+
+	app.errorJSON(rr, http.StatusUnprocessableEntity, map[string]string{"title": "must be provided"})
+
+	var resp struct {
+		Error map[string]string `json:"error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusUnprocessableEntity || resp.Error["title"] != "must be provided" {
+		t.Errorf("Unexpected error response %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"Valid", `{"title":"ok"}`, ""},
+		{"Malformed", `{"title": "oops"`, "badly-formed JSON"},
+		{"WrongType", `{"title": 5}`, `incorrect JSON type for field "title"`},
+		{"UnknownField", `{"title":"x","mood_score":5}`, `unknown field "mood_score"`},
+		{"Empty", ``, "must not be empty"},
+		{"TwoValues", `{"title":"a"}{"title":"b"}`, "single JSON value"},
+		{"TooLarge", `{"title":"` + strings.Repeat("x", maxJSONBodyBytes) + `"}`, "must not be larger than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			var dst struct {
+				Title string `json:"title"`
+			}
+			err := app.readJSON(httptest.NewRecorder(), r, &dst)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -5,38 +5,95 @@ import (
 	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	_ "github.com/lib/pq"
 
 	"github.com/golangcollege/sessions"
 	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/mailer"
 )
 
 // application struct holds application-wide dependencies.
 type application struct {
 	logger        *slog.Logger
 	addr          string
-	moods         *data.MoodModel // Existing MoodModel
-	users         *data.UserModel // <-- UserModel field (already present in your provided code)
+	baseURL       string                   // Public origin used in robots.txt and the sitemap, e.g. https://feelflow.example
+	db            *sql.DB                  // Connection pool, pinged by the /readyz probe
+	moods         *data.MoodModel          // Existing MoodModel
+	users         *data.UserModel          // <-- UserModel field (already present in your provided code)
+	savedViews    *data.SavedViewModel     // Named dashboard filter combinations
+	emotions      *data.EmotionModel       // Custom emotions saved to each user's picker
+	resets        *data.PasswordResetModel // Emailed single-use password reset tokens
+	activations   *data.ActivationModel    // Emailed single-use account activation tokens
+	emailChanges  *data.EmailChangeModel   // Emailed single-use email change confirmation tokens
 	templateCache map[string]*template.Template
-	session       *sessions.Session // Existing session field
+	session       *sessions.Session  // Existing session field
+	mailer        mailer.Mailer      // Sends notification emails over SMTP, or only logs them in development
+	wg            sync.WaitGroup     // Tracks background goroutines such as email sends
+	globalTotals  *globalTotalsCache // App-wide counts for the About page, cached briefly
+	emotionCache  *emotionCache      // Each user's emotion dropdown options, until their entries change
+	csrfLimiter   *rateLimiter       // Per-IP limit for GET /csrf-token
+	exportLimiter *rateLimiter       // Per-user cooldown for journal and CSV downloads
+	authLimiter   *rateLimiter       // Per-IP limit for login, signup and password-reset submissions
+	loginFailures *rateLimiter       // Per-email allowance of failed logins
+	randomQuotes  bool               // Pick a new stats page quote on every visit instead of one a day
 }
 
 func main() {
 	// --- Configuration ---
 	addr := flag.String("addr", ":4000", "HTTP network address")
 	dsn := flag.String("dsn", os.Getenv("MOODNOTES_DB_DSN"), "PostgreSQL DSN (reads MOODNOTES_DB_DSN env var)")
+	baseURL := flag.String("base-url", "", "Public base URL for robots.txt and sitemap.xml (e.g. https://feelflow.example)")
 	secret := flag.String("secret", "Gm9zN!cRz&7$eL4qjV1@xPu!Zw5#Tb6K", "Secret key (must be 32 bytes)")
+	rejectCommonPasswords := flag.Bool("reject-common-passwords", true, "Reject new passwords found in the bundled common-passwords list")
+	bcryptCost := flag.Int("bcrypt-cost", data.DefaultBcryptCost, "bcrypt cost for new password hashes (4-31; each step doubles hashing time)")
+	displayVersion := flag.Bool("version", false, "Print the version and exit")
+	randomQuotes := flag.Bool("random-quotes", false, "Show a random stats page quote on every visit instead of one per day")
+	authLimiterBurst := flag.Int("auth-limiter-burst", 10, "Login, signup and password-reset submissions allowed per IP before throttling")
+	authLimiterInterval := flag.Duration("auth-limiter-interval", 6*time.Second, "Time for an IP to earn back one login, signup or password-reset submission")
+	loginFailureLimit := flag.Int("login-failure-limit", 5, "Failed logins allowed per email before it is locked out")
+	loginFailureInterval := flag.Duration("login-failure-interval", 5*time.Minute, "Time for an email to earn back one failed login attempt")
+	var dbCfg dbConfig
+	flag.IntVar(&dbCfg.maxOpenConns, "db-max-open-conns", 25, "Most open PostgreSQL connections the pool may hold")
+	flag.IntVar(&dbCfg.maxIdleConns, "db-max-idle-conns", 25, "Most idle PostgreSQL connections kept for reuse (at most -db-max-open-conns)")
+	flag.DurationVar(&dbCfg.maxIdleTime, "db-max-idle-time", 5*time.Minute, "How long an idle PostgreSQL connection is kept before it is closed")
+	smtpCfg := mailer.SMTPConfig{TemplateDir: "./ui/html/mail"}
+	flag.StringVar(&smtpCfg.Host, "smtp-host", "", "SMTP server host; emails are only logged when empty")
+	flag.IntVar(&smtpCfg.Port, "smtp-port", 587, "SMTP server port")
+	flag.StringVar(&smtpCfg.Username, "smtp-username", "", "SMTP login username (blank for no login)")
+	flag.StringVar(&smtpCfg.Password, "smtp-password", "", "SMTP login password")
+	flag.StringVar(&smtpCfg.Sender, "smtp-sender", "Feel Flow <no-reply@feelflow.example>", "From address for outgoing email")
 	flag.Parse()
 
+	if *displayVersion {
+		fmt.Printf("Version:\t%s\n", version)
+		os.Exit(0)
+	}
+	data.RejectCommonPasswords = *rejectCommonPasswords
+
 	// --- Logging ---
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
+	// --- Password Hashing Cost ---
+	if err := data.SetBcryptCost(*bcryptCost); err != nil {
+		logger.Error("invalid -bcrypt-cost", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// --- Validate Rate Limits ---
+	// A zero burst would block every request and a zero interval would never refill.
+	if *authLimiterBurst < 1 || *loginFailureLimit < 1 || *authLimiterInterval <= 0 || *loginFailureInterval <= 0 {
+		logger.Error("rate limiter bursts must be at least 1 and intervals must be positive")
+		os.Exit(1)
+	}
+
 	// --- Validate Secret Key Length ---
 	// A quick but important security check: ensure the session secret key is the correct length (32 bytes).
 
@@ -52,13 +109,20 @@ func main() {
 		logger.Error("database DSN must be provided via -dsn flag or MOODNOTES_DB_DSN environment variable")
 		os.Exit(1)
 	}
-	db, err := openDB(*dsn) // Call helper to open and configure DB pool.
+	if err := dbCfg.validate(); err != nil {
+		logger.Error("invalid database pool settings", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	db, err := openDB(*dsn, dbCfg) // Call helper to open and configure DB pool.
 	if err != nil {
 		logger.Error("failed to connect to database", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	defer db.Close() // Ensure database connection is closed when main exits.
-	logger.Info("database connection pool established")
+	logger.Info("database connection pool established",
+		slog.Int("max_open_conns", dbCfg.maxOpenConns),
+		slog.Int("max_idle_conns", dbCfg.maxIdleConns),
+		slog.Duration("max_idle_time", dbCfg.maxIdleTime))
 
 	// --- Template Cache ---
 	// To improve performance, HTML templates are parsed once at startup
@@ -72,16 +136,32 @@ func main() {
 
 	// --- Session Manager Initialization ---
 	// The session manager is configured here. We set a secret key for security,
-	// define a session lifetime (12 hours), and set cookie attributes like Secure, HttpOnly, and SameSite
-	// for better security and CSRF protection.
+	// define a session lifetime, and set cookie attributes like Secure, HttpOnly, and SameSite
+	// for better security and CSRF protection. The lifetime is the longest a login can last
+	// ("Remember me"); each login enforces its own, usually shorter, limit (see logIn).
 	sessionManager := sessions.New([]byte(*secret))
-	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Lifetime = sessionCookieLifetime
 	sessionManager.Secure = true
 	sessionManager.HttpOnly = true
 	sessionManager.SameSite = http.SameSiteLaxMode
 
 	logger.Info("session manager initialized")
 
+	// --- Mailer ---
+	// Without -smtp-host, emails are written to the log instead, which is all development needs.
+	appMailer := mailer.NewLogMailer(logger)
+	if smtpCfg.Host != "" {
+		appMailer, err = mailer.NewSMTPMailer(smtpCfg)
+		if err != nil {
+			logger.Error("failed to configure SMTP mailer", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		if *baseURL == "" {
+			logger.Warn("-smtp-host is set without -base-url; links in emails will be relative and won't open")
+		}
+		logger.Info("SMTP mailer configured", slog.String("host", smtpCfg.Host), slog.Int("port", smtpCfg.Port))
+	}
+
 	// --- Application Dependencies Injection ---
 	// All initialized components (logger, database models, template cache, session manager)
 	// are then bundled into our `application` struct. This struct is passed to our HTTP handlers,
@@ -89,27 +169,78 @@ func main() {
 	app := &application{
 		logger:        logger,
 		addr:          *addr,
+		baseURL:       *baseURL,
+		db:            db,
 		moods:         &data.MoodModel{DB: db}, // Initialize MoodModel
 		users:         &data.UserModel{DB: db}, // <-- Initialize UserModel, passing db
-		templateCache: templateCache,           // Initialize Template Cache
-		session:       sessionManager,          // Initialize Session Manager
+		savedViews:    &data.SavedViewModel{DB: db},
+		emotions:      &data.EmotionModel{DB: db},
+		resets:        &data.PasswordResetModel{DB: db},
+		activations:   &data.ActivationModel{DB: db},
+		emailChanges:  &data.EmailChangeModel{DB: db},
+		templateCache: templateCache,  // Initialize Template Cache
+		session:       sessionManager, // Initialize Session Manager
+		mailer:        appMailer,
+		randomQuotes:  *randomQuotes,
+	}
+	app.globalTotals = newGlobalTotalsCache(5*time.Minute, app.fetchGlobalTotals)
+	app.emotionCache = newEmotionCache(30*time.Minute, app.moods.GetDistinctEmotionDetails)
+	app.emotionCache.startEviction(10 * time.Minute)
+	app.csrfLimiter = newRateLimiter(6*time.Second, 10) // Bursts of 10, then 10 a minute.
+	app.exportLimiter = newRateLimiter(time.Minute, 3)  // The stats page's three CSVs at once, then one a minute.
+	app.authLimiter = newRateLimiter(*authLimiterInterval, *authLimiterBurst)
+	app.loginFailures = newRateLimiter(*loginFailureInterval, *loginFailureLimit)
+	for _, limiter := range []*rateLimiter{app.csrfLimiter, app.exportLimiter, app.authLimiter, app.loginFailures} {
+		limiter.startEviction(time.Minute)
 	}
+	app.startTrashPurge(time.Hour) // Entries trashed over 30 days ago are removed for good.
+	app.startReminders(time.Hour)  // Check-in reminder emails, at each user's local reminder time.
 
 	// --- Start Server ---
 	// Start the HTTP server using the `app.serve()` method (defined in server.go),
 	// which sets up routing and listens for incoming requests on the configured address.
-	logger.Info("starting server", slog.String("addr", app.addr))
+	logger.Info("starting server", slog.String("addr", app.addr), slog.String("version", version))
 	err = app.serve() // `app.serve()` configures and starts the HTTPS server.
 	if err != nil {   // If server fails to start.
 		logger.Error("server failed to start", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+
+	// --- Shutdown ---
+	// serve returns nil after a graceful shutdown. Emails queued by handlers and any
+	// reminder run in progress are tracked by app.wg, so wait for them before exiting.
+	logger.Info("waiting for background tasks to finish")
+	app.wg.Wait()
+	logger.Info("server stopped")
+}
+
+// dbConfig holds the connection pool limits set by the -db-* flags, so the pool can be
+// sized for the Postgres plan without recompiling.
+type dbConfig struct {
+	maxOpenConns int           // -db-max-open-conns
+	maxIdleConns int           // -db-max-idle-conns
+	maxIdleTime  time.Duration // -db-max-idle-time
+}
+
+// validate rejects settings database/sql would quietly treat as "unlimited" or adjust.
+func (c dbConfig) validate() error {
+	switch {
+	case c.maxOpenConns < 1:
+		return fmt.Errorf("-db-max-open-conns must be at least 1, got %d", c.maxOpenConns)
+	case c.maxIdleConns < 0:
+		return fmt.Errorf("-db-max-idle-conns must not be negative, got %d", c.maxIdleConns)
+	case c.maxIdleConns > c.maxOpenConns:
+		return fmt.Errorf("-db-max-idle-conns (%d) must not be more than -db-max-open-conns (%d)", c.maxIdleConns, c.maxOpenConns)
+	case c.maxIdleTime <= 0:
+		return fmt.Errorf("-db-max-idle-time must be positive, got %s", c.maxIdleTime)
+	}
+	return nil
 }
 
 // openDB establishes and configures a database connection pool.
 // This helper function connects to PostgreSQL and configures the connection pool settings
-// like max open connections and idle timeouts, crucial for robust database interaction.
-func openDB(dsn string) (*sql.DB, error) {
+// from cfg, like max open connections and idle timeouts, crucial for robust database interaction.
+func openDB(dsn string, cfg dbConfig) (*sql.DB, error) {
 	// 1. Open Connection: `sql.Open` doesn't immediately create a connection, just prepares it.
 	db, err := sql.Open("postgres", dsn) // "postgres" is the driver name.
 	if err != nil {
@@ -118,9 +249,9 @@ func openDB(dsn string) (*sql.DB, error) {
 
 	// 2. Configure Connection Pool:
 	//    These settings help manage database resources efficiently.
-	db.SetMaxOpenConns(25)                 // Max number of open connections to the database.
-	db.SetMaxIdleConns(25)                 // Max number of connections in the idle connection pool.
-	db.SetConnMaxIdleTime(5 * time.Minute) // Max amount of time a connection may be idle.
+	db.SetMaxOpenConns(cfg.maxOpenConns)   // Max number of open connections to the database.
+	db.SetMaxIdleConns(cfg.maxIdleConns)   // Max number of connections in the idle connection pool.
+	db.SetConnMaxIdleTime(cfg.maxIdleTime) // Max amount of time a connection may be idle.
 	db.SetConnMaxLifetime(2 * time.Hour)   // Max amount of time a connection may be reused.
 
 	// 3. Verify Connection: `PingContext` attempts to connect to the database to ensure it's reachable.
@@ -0,0 +1,29 @@
+// mood/cmd/web/main_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDBConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     dbConfig
+		wantErr bool
+	}{
+		{"Defaults", dbConfig{maxOpenConns: 25, maxIdleConns: 25, maxIdleTime: 5 * time.Minute}, false},
+		{"SmallPlan", dbConfig{maxOpenConns: 5, maxIdleConns: 0, maxIdleTime: time.Minute}, false},
+		{"ZeroOpen", dbConfig{maxOpenConns: 0, maxIdleConns: 0, maxIdleTime: time.Minute}, true},
+		{"NegativeIdle", dbConfig{maxOpenConns: 5, maxIdleConns: -1, maxIdleTime: time.Minute}, true},
+		{"MoreIdleThanOpen", dbConfig{maxOpenConns: 5, maxIdleConns: 10, maxIdleTime: time.Minute}, true},
+		{"ZeroIdleTime", dbConfig{maxOpenConns: 5, maxIdleConns: 5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
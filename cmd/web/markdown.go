@@ -0,0 +1,228 @@
+// mood/cmd/web/markdown.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// markdownEscaper backslash-escapes the characters that would otherwise start
+// emphasis, code or a link when plain text is written into Markdown.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+// markdownList is one open <ul> or <ol> while converting, with the next item number.
+type markdownList struct {
+	ordered bool
+	next    int
+}
+
+// markdownWriter accumulates Markdown blocks while htmlToMarkdown walks the tokens.
+type markdownWriter struct {
+	blocks  []string // Finished blocks; list items are joined to their list with single newlines.
+	inList  []bool   // Whether each finished block is a list item.
+	line    strings.Builder
+	prefix  string // Written before the current block, e.g. "### " or "- ".
+	quote   int    // Blockquote depth.
+	lists   []markdownList
+	hrefs   []string // Open links' targets; "" for links that aren't written as links.
+	skip    int      // Depth inside <script> or <style>, whose text is dropped.
+	pending bool     // A space is owed before the next text.
+}
+
+// flush finishes the current block, if it has any text.
+func (mw *markdownWriter) flush() {
+	text := strings.TrimSpace(mw.line.String())
+	mw.line.Reset()
+	mw.pending = false
+	if text != "" {
+		block := mw.prefix + text
+		if mw.quote > 0 {
+			quote := strings.Repeat("> ", mw.quote)
+			block = quote + strings.ReplaceAll(block, "\n", "\n"+quote)
+		}
+		mw.blocks = append(mw.blocks, block)
+		mw.inList = append(mw.inList, len(mw.lists) > 0)
+	}
+	mw.prefix = ""
+}
+
+// text appends text with HTML's whitespace collapsing and Markdown escaping.
+func (mw *markdownWriter) text(s string) {
+	if mw.skip > 0 {
+		return
+	}
+	leading := s != "" && strings.TrimLeft(s, " \t\r\n") != s
+	trailing := s != "" && strings.TrimRight(s, " \t\r\n") != s
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		mw.pending = mw.pending || leading
+		return
+	}
+	if (mw.pending || leading) && mw.line.Len() > 0 {
+		mw.line.WriteString(" ")
+	}
+	mw.line.WriteString(markdownEscaper.Replace(strings.Join(words, " ")))
+	mw.pending = trailing
+}
+
+// mark writes an inline delimiter such as "**", owing any pending space first.
+func (mw *markdownWriter) mark(delim string, opening bool) {
+	if mw.skip > 0 {
+		return
+	}
+	if opening && mw.pending && mw.line.Len() > 0 {
+		mw.line.WriteString(" ")
+		mw.pending = false
+	}
+	mw.line.WriteString(delim)
+}
+
+// String joins the blocks: paragraphs are separated by a blank line, consecutive
+// list items by a single newline.
+func (mw *markdownWriter) String() string {
+	var b strings.Builder
+	for i, block := range mw.blocks {
+		if i > 0 {
+			if mw.inList[i] && mw.inList[i-1] {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(block)
+	}
+	return b.String()
+}
+
+// htmlToMarkdown converts the editor's rich-text content to Markdown. It covers what
+// the editor produces: paragraphs, headings (nested under the entry's own heading,
+// so <h1> becomes ###), bold, italic, strikethrough, inline code, links, block
+// quotes and lists, including Quill's <li data-list="bullet"> inside an <ol>.
+// Anything else is reduced to its text. Only http(s) and mailto links are kept as
+// links, and the output is plain text, so unsafe markup can't survive into it.
+func htmlToMarkdown(content string) string {
+	mw := &markdownWriter{}
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			mw.text(tok.Data)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			mw.start(tok, tt == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			mw.end(tok)
+		}
+	}
+	mw.flush()
+	return mw.String()
+}
+
+func (mw *markdownWriter) start(tok html.Token, selfClosing bool) {
+	switch tok.Data {
+	case "script", "style":
+		if !selfClosing {
+			mw.skip++
+		}
+	case "p", "div", "pre":
+		mw.flush()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		mw.flush()
+		level := min(int(tok.Data[1]-'0')+2, 6)
+		mw.prefix = strings.Repeat("#", level) + " "
+	case "blockquote":
+		mw.flush()
+		mw.quote++
+	case "ul", "ol":
+		mw.flush()
+		mw.lists = append(mw.lists, markdownList{ordered: tok.Data == "ol", next: 1})
+	case "li":
+		mw.flush()
+		if len(mw.lists) == 0 {
+			mw.prefix = "- "
+			break
+		}
+		list := &mw.lists[len(mw.lists)-1]
+		indent := strings.Repeat("   ", len(mw.lists)-1)
+		ordered := list.ordered && attr(tok, "data-list") != "bullet"
+		if ordered {
+			mw.prefix = fmt.Sprintf("%s%d. ", indent, list.next)
+			list.next++
+		} else {
+			mw.prefix = indent + "- "
+		}
+	case "br":
+		if mw.line.Len() > 0 {
+			mw.line.WriteString("  \n")
+			mw.pending = false
+		}
+	case "strong", "b":
+		mw.mark("**", true)
+	case "em", "i":
+		mw.mark("_", true)
+	case "s", "strike", "del":
+		mw.mark("~~", true)
+	case "code":
+		mw.mark("`", true)
+	case "a":
+		href := attr(tok, "href")
+		lower := strings.ToLower(href)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "mailto:") {
+			href = ""
+		}
+		mw.hrefs = append(mw.hrefs, href)
+		if href != "" {
+			mw.mark("[", true)
+		}
+	}
+}
+
+func (mw *markdownWriter) end(tok html.Token) {
+	switch tok.Data {
+	case "script", "style":
+		mw.skip = max(mw.skip-1, 0)
+	case "p", "div", "pre", "h1", "h2", "h3", "h4", "h5", "h6", "li":
+		mw.flush()
+	case "blockquote":
+		mw.flush()
+		mw.quote = max(mw.quote-1, 0)
+	case "ul", "ol":
+		mw.flush()
+		if len(mw.lists) > 0 {
+			mw.lists = mw.lists[:len(mw.lists)-1]
+		}
+	case "strong", "b":
+		mw.mark("**", false)
+	case "em", "i":
+		mw.mark("_", false)
+	case "s", "strike", "del":
+		mw.mark("~~", false)
+	case "code":
+		mw.mark("`", false)
+	case "a":
+		if len(mw.hrefs) == 0 {
+			break
+		}
+		href := mw.hrefs[len(mw.hrefs)-1]
+		mw.hrefs = mw.hrefs[:len(mw.hrefs)-1]
+		if href != "" {
+			mw.mark("]("+strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(href)+")", false)
+		}
+	}
+}
+
+// attr returns the value of the named attribute on tok, or "".
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
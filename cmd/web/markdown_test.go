@@ -0,0 +1,35 @@
+// mood/cmd/web/markdown_test.go
+package main
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Paragraphs", "<p>One &amp; two.</p><p>Three</p>", "One & two.\n\nThree"},
+		{"Inline", "<p>A <strong>bold</strong> and <em>quiet</em> <s>bad</s> day</p>", "A **bold** and _quiet_ ~~bad~~ day"},
+		{"LineBreak", "<p>first<br>second</p>", "first  \nsecond"},
+		{"Heading", "<h1>Big</h1><p>text</p>", "### Big\n\ntext"},
+		{"BulletList", "<ul><li>tea</li><li>walk</li></ul><p>after</p>", "- tea\n- walk\n\nafter"},
+		{"OrderedList", "<ol><li>wake</li><li>stretch</li></ol>", "1. wake\n2. stretch"},
+		{"QuillBullets", `<ol><li data-list="bullet"><span class="ql-ui"></span>tea</li><li data-list="bullet">walk</li></ol>`, "- tea\n- walk"},
+		{"NestedList", "<ul><li>outer<ul><li>inner</li></ul></li></ul>", "- outer\n   - inner"},
+		{"Quote", "<blockquote>be kind</blockquote>", "> be kind"},
+		{"Link", `<p><a href="https://example.com/a b">site</a></p>`, "[site](https://example.com/a%20b)"},
+		{"UnsafeLink", `<p><a href="javascript:alert(1)">click</a></p>`, "click"},
+		{"ScriptDropped", "<p>hi</p><script>alert(1)</script>", "hi"},
+		{"EscapesMarkdown", "<p>2*3 = [six]_</p>", `2\*3 = \[six\]\_`},
+		{"Whitespace", "<p>  lots   of\n space </p>", "lots of space"},
+		{"Empty", "<p><br></p>", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.content); got != tt.want {
+				t.Errorf("htmlToMarkdown(%q) =\n%q\nwant\n%q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
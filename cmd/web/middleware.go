@@ -2,21 +2,131 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/justinas/nosurf"
 )
 
-// loggingMiddleware logs details about incoming HTTP requests.
+// contextKey is the type for values this package stores on a request's context.
+type contextKey string
+
+// requestIDContextKey holds the ID assigned to a request by requestID.
+const requestIDContextKey = contextKey("requestID")
+
+// requestID gives every request a random ID, stored on its context and sent back in
+// the X-Request-ID header, so one request's access log and error log lines can be
+// tied together (and to what a user reports seeing).
+func (app *application) requestID(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := newRequestID()
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// newRequestID returns 16 random hex characters. If the system's random source fails
+// the request still gets served, just without an ID to correlate by.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDFromContext returns the ID requestID assigned, or "" outside of it.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// requestLogger returns the application logger with the request's ID attached.
+func (app *application) requestLogger(r *http.Request) *slog.Logger {
+	if id := requestIDFromContext(r.Context()); id != "" {
+		return app.logger.With("request_id", id)
+	}
+	return app.logger
+}
+
+// recoverPanic turns a panicking handler into a logged 500 response instead of a
+// dropped connection. The connection is closed afterwards, since the handler may
+// have left it in an unknown state.
+func (app *application) recoverPanic(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler { // Deliberate abort; let net/http handle it.
+					panic(rec)
+				}
+				w.Header().Set("Connection", "close")
+				app.requestLogger(r).Error("panic recovered", "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+				app.serverError(w, r, fmt.Errorf("panic: %v", rec))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// statusRecorder remembers the status code a handler writes, for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. to flush).
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// loggingMiddleware logs each request as it arrives and again once it's been
+// answered, with the status and duration. Both lines carry the request ID.
 func (app *application) loggingMiddleware(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		app.logger.Info("received request",
+		logger := app.requestLogger(r)
+		logger.Info("received request",
 			"remote_ip", r.RemoteAddr,
 			"proto", r.Proto,
 			"method", r.Method,
 			"uri", r.URL.RequestURI(),
 		)
-		next.ServeHTTP(w, r)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 { // Nothing written: net/http sends an empty 200.
+			rec.status = http.StatusOK
+		}
+		logger.Info("completed request",
+			"method", r.Method,
+			"uri", r.URL.RequestURI(),
+			"status", rec.status,
+			"duration", time.Since(start),
+		)
 	}
 	return http.HandlerFunc(fn)
 }
@@ -34,7 +144,7 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		// Use the isAuthenticated helper we created earlier.
 		if !app.isAuthenticated(r) {
-			app.logger.Warn("Authentication required", "uri", r.URL.RequestURI()) // Log attempt
+			app.requestLogger(r).Warn("Authentication required", "uri", r.URL.RequestURI()) // Log attempt
 
 			// Add a flash message to be shown on the login page.
 			app.session.Put(r, "flash", "You must be logged in to view this page.")
@@ -54,8 +164,40 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// preserveFormOnExpiredSession wraps the mood create/edit routes. When the session
+// has expired and a form is POSTed, the submitted fields are stashed in the session
+// and the user is sent to log in, instead of losing their entry to a bare 401.
+// Every other request falls through to requireAuthentication as usual.
+func (app *application) preserveFormOnExpiredSession(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && !app.isAuthenticated(r) {
+			if err := r.ParseForm(); err != nil {
+				app.clientError(w, http.StatusBadRequest)
+				return
+			}
+			app.requestLogger(r).Warn("Session expired during form submission; stashing fields", "uri", r.URL.RequestURI())
+
+			if app.stashPendingSubmission(r, r.PostForm) {
+				app.session.Put(r, "flash", "Your session expired. Log in again and your entry will be restored.")
+			} else {
+				app.session.Put(r, "flash", "Your session expired. Log in again; your entry was too long to keep in full, so please check it.")
+			}
+
+			if r.Header.Get("HX-Request") == "true" {
+				w.Header().Set("HX-Redirect", "/user/login")
+				w.WriteHeader(http.StatusOK)
+			} else {
+				http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			}
+			return
+		}
+		app.requireAuthentication(next).ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
 // noSurf middleware adds CSRF protection to all non-safe methods (POST, PUT, DELETE, etc.)
-func noSurf(next http.Handler) http.Handler {
+func (app *application) noSurf(next http.Handler) http.Handler {
 	// Create a new CSRF handler
 	csrfHandler := nosurf.New(next)
 
@@ -69,8 +211,38 @@ func noSurf(next http.Handler) http.Handler {
 		// MaxAge and Domain can be set if needed, but defaults are often fine
 	})
 
-	// You can add custom error handling here if desired using csrfHandler.SetFailureHandler()
-	// For now, it will return a 403 Forbidden by default on failure.
+	// Failures get a friendly page instead of nosurf's bare 403. noSurf sits outside the
+	// session middleware, so the failure handler loads the session itself for the flash.
+	csrfHandler.SetFailureHandler(app.sessionMiddleware(http.HandlerFunc(app.csrfFailure)))
 
 	return csrfHandler
 }
+
+// csrfFailure handles requests rejected by noSurf, almost always a form left open until
+// its token went stale. HTMX requests are sent to log in with a flash explaining why;
+// API requests get a JSON error; anything else gets csrf_error.tmpl with a 403.
+func (app *application) csrfFailure(w http.ResponseWriter, r *http.Request) {
+	app.requestLogger(r).Warn("CSRF check failed",
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+		"reason", nosurf.Reason(r),
+	)
+
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		app.errorJSON(w, http.StatusForbidden, "CSRF token missing or invalid; fetch a new one from /csrf-token")
+		return
+	}
+	if r.Header.Get("HX-Request") == "true" {
+		app.session.Put(r, "flash", "Your session expired. Please log in and try again.")
+		w.Header().Set("HX-Redirect", "/user/login")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	templateData := app.newTemplateData(r)
+	templateData.Title = "Session Expired - Feel Flow"
+	err := app.render(w, http.StatusForbidden, "csrf_error.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
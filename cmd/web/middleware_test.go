@@ -0,0 +1,87 @@
+// mood/cmd/web/middleware_test.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	app := newTestApplication(t)
+	var logs bytes.Buffer
+	app.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.serverError(w, r, errors.New("boom"))
+	})
+	handler := app.requestID(app.loggingMiddleware(failing))
+
+	serve := func() (string, string) {
+		logs.Reset()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/mood/new", nil))
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		return rr.Header().Get("X-Request-ID"), logs.String()
+	}
+
+	id, output := serve()
+	if len(id) != 16 {
+		t.Fatalf("Expected a 16-character X-Request-ID, got %q", id)
+	}
+	// The access log lines and the error line all carry the same ID.
+	for _, msg := range []string{`msg="received request"`, `msg="server error encountered"`, `msg="completed request"`} {
+		found := false
+		for _, line := range strings.Split(output, "\n") {
+			if strings.Contains(line, msg) && strings.Contains(line, "request_id="+id) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected a %s log line with request_id=%s, got:\n%s", msg, id, output)
+		}
+	}
+	if !strings.Contains(output, "status=500") {
+		t.Errorf("Expected the completed line to record status=500, got:\n%s", output)
+	}
+
+	if next, _ := serve(); next == id {
+		t.Errorf("Expected a new ID per request, got %q twice", id)
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	app := newTestApplication(t)
+	var logs bytes.Buffer
+	app.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]string
+		m["boom"] = "nil map write" // Panics.
+	})
+	srv := httptest.NewServer(app.recoverPanic(panicking))
+	defer srv.Close()
+
+	// Through a real server, so a dropped connection would show up as a client error.
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected a response, got error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+	if !resp.Close { // The client consumes "Connection: close" into resp.Close.
+		t.Error("Expected the server to close the connection")
+	}
+	if output := logs.String(); !strings.Contains(output, `msg="panic recovered"`) || !strings.Contains(output, "stack=") {
+		t.Errorf("Expected the panic and stack to be logged, got:\n%s", output)
+	}
+}
@@ -0,0 +1,53 @@
+// mood/cmd/web/notifications.go
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// background runs fn in its own goroutine, tracked by app.wg and protected from panics.
+// Used for best-effort work (like sending email) that must not delay or fail the response.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error("background task panicked", "error", fmt.Sprintf("%v", err))
+			}
+		}()
+		fn()
+	}()
+}
+
+// sendNotification emails recipient in the background. Failures are logged, never returned,
+// since security notifications are informational and the triggering change already succeeded.
+func (app *application) sendNotification(recipient, templateName string, data map[string]any) {
+	if app.mailer == nil {
+		return
+	}
+	app.background(func() {
+		err := app.mailer.Send(recipient, templateName, data)
+		if err != nil {
+			app.logger.Error("failed to send notification email", "template", templateName, "error", err)
+		}
+	})
+}
+
+// notifyPasswordChanged tells the account's current address that its password was changed.
+func (app *application) notifyPasswordChanged(name, email string) {
+	app.sendNotification(email, "password_changed", map[string]any{
+		"Name":      name,
+		"ChangedAt": time.Now().UTC().Format(time.RFC1123),
+	})
+}
+
+// notifyEmailChanged tells the account's previous address that the login email was changed.
+func (app *application) notifyEmailChanged(name, oldEmail, newEmail string) {
+	app.sendNotification(oldEmail, "email_changed", map[string]any{
+		"Name":      name,
+		"NewEmail":  newEmail,
+		"ChangedAt": time.Now().UTC().Format(time.RFC1123),
+	})
+}
@@ -0,0 +1,188 @@
+// mood/cmd/web/password_reset.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// forgotPasswordFlash is shown after every valid forgot-password submission, whether or
+// not the email belongs to an account, so the form can't be used to discover accounts.
+const forgotPasswordFlash = "If an account exists for that email, we've sent a link to reset your password. It expires in 1 hour."
+
+// invalidResetTokenMessage is shown for unknown, used and expired reset links alike.
+const invalidResetTokenMessage = "This reset link is invalid or has expired."
+
+// forgotPasswordForm displays the form that requests a password reset email.
+func (app *application) forgotPasswordForm(w http.ResponseWriter, r *http.Request) {
+	templateData := app.newTemplateData(r)
+	templateData.Title = "Forgot Password - Feel Flow"
+	err := app.render(w, http.StatusOK, "forgot_password.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// forgotPassword handles POST /user/forgot-password. The account lookup, token and email
+// all happen in the background, so the response is the same redirect (and takes the same
+// time) whether or not the address is registered.
+func (app *application) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	// 1. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	email := r.PostForm.Get("email")
+
+	// 2. Validate. Only the address's shape is checked, which reveals nothing about accounts.
+	v := validator.NewValidator()
+	v.Check(validator.NotBlank(email), "email", "Email must be provided")
+	v.Check(validator.MaxLength(email, 254), "email", "Must not be more than 254 characters")
+	v.Check(validator.Matches(email, validator.EmailRX), "email", "Must be a valid email address")
+	if !v.ValidData() {
+		templateData := app.newTemplateData(r)
+		templateData.Title = "Forgot Password (Error) - Feel Flow"
+		templateData.FormData = map[string]string{"email": email}
+		templateData.FormErrors = v.Errors
+		app.renderFormBlock(w, r, "forgot_password.tmpl", "forgot-password-form-block", templateData)
+		return
+	}
+
+	// 3. Send the Link (if there is an account) without waiting for it.
+	app.background(func() {
+		app.sendPasswordReset(context.Background(), email)
+	})
+
+	// 4. Respond identically either way.
+	app.session.Put(r, "flash", forgotPasswordFlash)
+	app.redirectAfterForm(w, r, "/user/login")
+}
+
+// sendPasswordReset emails a reset link to email if it belongs to an account. An unknown
+// address is silently ignored; other failures are logged, since the caller has already responded.
+func (app *application) sendPasswordReset(ctx context.Context, email string) {
+	user, err := app.users.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.logger.Error("Failed to look up user for password reset", "error", err)
+		}
+		return
+	}
+
+	token, err := app.resets.New(ctx, user.ID, data.PasswordResetTTL)
+	if err != nil {
+		app.logger.Error("Failed to create password reset token", "error", err, "userID", user.ID)
+		return
+	}
+	if app.mailer == nil {
+		return
+	}
+	err = app.mailer.Send(user.Email, "password_reset", map[string]any{
+		"Name":      user.Name,
+		"ResetURL":  app.absoluteURL("/user/reset-password?token=" + url.QueryEscape(token)),
+		"ExpiresIn": "1 hour",
+	})
+	if err != nil {
+		app.logger.Error("Failed to send password reset email", "error", err, "userID", user.ID)
+	}
+}
+
+// resetPasswordForm handles GET /user/reset-password?token=. It checks the token before
+// showing the form, so a dead link says so straight away.
+func (app *application) resetPasswordForm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	templateData := app.newTemplateData(r)
+	templateData.Title = "Reset Password - Feel Flow"
+	templateData.FormData = map[string]string{"token": token}
+
+	if _, err := app.resets.GetUserID(r.Context(), token); err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverError(w, r, err)
+			return
+		}
+		templateData.FormErrors = map[string]string{"token": invalidResetTokenMessage}
+	}
+
+	err := app.render(w, http.StatusOK, "reset_password.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// resetPassword handles POST /user/reset-password. It sets the new password for the
+// token's owner, then deletes the user's reset tokens so the link can't be used again.
+func (app *application) resetPassword(w http.ResponseWriter, r *http.Request) {
+	// 1. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	token := r.PostForm.Get("token")
+	newPassword := r.PostForm.Get("new_password")
+	confirmPassword := r.PostForm.Get("confirm_password")
+
+	renderResetError := func(formErrors map[string]string) {
+		templateData := app.newTemplateData(r)
+		templateData.Title = "Reset Password (Error) - Feel Flow"
+		templateData.FormData = map[string]string{"token": token} // Never repopulate passwords
+		templateData.FormErrors = formErrors
+		app.renderFormBlock(w, r, "reset_password.tmpl", "reset-password-form-block", templateData)
+	}
+
+	// 2. Verify the Token.
+	userID, err := app.resets.GetUserID(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			renderResetError(map[string]string{"token": invalidResetTokenMessage})
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 3. Validate the New Password.
+	v := validator.NewValidator()
+	data.ValidatePasswordReset(v, newPassword, confirmPassword)
+	if !v.ValidData() {
+		renderResetError(v.Errors)
+		return
+	}
+
+	// 4. Hash and Store It.
+	hashedNewPassword, err := data.HashPassword(newPassword)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("error hashing new password: %w", err))
+		return
+	}
+	err = app.users.UpdatePassword(r.Context(), userID, hashedNewPassword)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			renderResetError(map[string]string{"token": invalidResetTokenMessage})
+		} else {
+			app.serverError(w, r, fmt.Errorf("error updating password in db: %w", err))
+		}
+		return
+	}
+
+	// 5. Make the Link Single-Use.
+	if err := app.resets.DeleteAllForUser(r.Context(), userID); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// 6. Notify and Send Them to Log In.
+	if user, err := app.users.Get(r.Context(), userID); err == nil {
+		app.notifyPasswordChanged(user.Name, user.Email)
+	} else {
+		app.logger.Error("Failed to fetch user for password reset notification", "error", err, "userID", userID)
+	}
+	app.session.Put(r, "flash", "Your password has been reset. Please log in.")
+	app.redirectAfterForm(w, r, "/user/login")
+}
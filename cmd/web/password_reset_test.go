@@ -0,0 +1,92 @@
+// mood/cmd/web/password_reset_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestForgotPassword_InvalidEmail(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+
+	rr := postForm(t, app, app.forgotPassword, "/user/forgot-password", url.Values{"email": {"not-an-email"}})
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Must be a valid email address") {
+		t.Error("Expected the email validation error in the response")
+	}
+}
+
+func TestForgotPassword_SameResponseForUnknownEmail(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	userID := insertTestUser(t, app)
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+
+	known := postForm(t, app, app.forgotPassword, "/user/forgot-password", url.Values{"email": {user.Email}})
+	unknown := postForm(t, app, app.forgotPassword, "/user/forgot-password", url.Values{"email": {"nobody@example.com"}})
+	app.wg.Wait()
+
+	if known.Code != unknown.Code || known.Header().Get("Location") != unknown.Header().Get("Location") || known.Body.String() != unknown.Body.String() {
+		t.Errorf("Responses differ: known %d %q, unknown %d %q",
+			known.Code, known.Header().Get("Location"), unknown.Code, unknown.Header().Get("Location"))
+	}
+	sent := stub.Sent()
+	if len(sent) != 1 || sent[0].Recipient != user.Email || sent[0].TemplateName != "password_reset" {
+		t.Fatalf("Expected exactly one reset email to %s, got %+v", user.Email, sent)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	app.mailer = &stubMailer{}
+	userID := insertTestUser(t, app)
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+	token, err := app.resets.New(context.Background(), userID, data.PasswordResetTTL)
+	if err != nil {
+		t.Fatalf("Failed to create reset token: %v", err)
+	}
+	form := url.Values{"token": {token}, "new_password": {"n3wPa55word!"}, "confirm_password": {"n3wPa55word!"}}
+
+	t.Run("Mismatch", func(t *testing.T) {
+		bad := url.Values{"token": {token}, "new_password": {"n3wPa55word!"}, "confirm_password": {"different1!"}}
+		rr := postForm(t, app, app.resetPassword, "/user/reset-password", bad)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		rr := postForm(t, app, app.resetPassword, "/user/reset-password", form)
+		app.wg.Wait()
+		if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/user/login" {
+			t.Fatalf("Expected a redirect to /user/login, got %d %q", rr.Code, rr.Header().Get("Location"))
+		}
+		if _, err := app.users.AuthenticateUser(context.Background(), user.Email, "n3wPa55word!"); err != nil {
+			t.Errorf("Expected to log in with the new password, got %v", err)
+		}
+	})
+
+	t.Run("TokenIsSingleUse", func(t *testing.T) {
+		rr := postForm(t, app, app.resetPassword, "/user/reset-password", form)
+		if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), invalidResetTokenMessage) {
+			t.Errorf("Expected the used token to be rejected, got %d", rr.Code)
+		}
+	})
+}
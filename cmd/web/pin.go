@@ -0,0 +1,65 @@
+// mood/cmd/web/pin.go
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// pinMood handles POST /mood/pin/{id}, pinning an entry to the top of the dashboard or
+// unpinning it. The form posts the state to set ("true" or "false") rather than relying on
+// a flip, so a double-submitted click can't undo itself.
+func (app *application) pinMood(w http.ResponseWriter, r *http.Request) {
+	// 1. Get Mood ID.
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	// 2. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 3. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	pinned, err := strconv.ParseBool(r.PostForm.Get("pinned"))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 4. Update (scoped to the user, so another user's entry is simply "not found").
+	err = app.moods.SetPinned(r.Context(), id, userID, pinned)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	app.logger.Info("Mood entry pin changed", "id", id, "userID", userID, "pinned", pinned)
+
+	flash := "Entry unpinned."
+	if pinned {
+		flash = "Entry pinned to the top."
+	}
+
+	// 5. Respond: HTMX swaps in the re-sorted dashboard, everyone else is redirected to it.
+	if r.Header.Get("HX-Request") == "true" {
+		app.renderDashboardAfterChange(w, r, userID, flash)
+		return
+	}
+	app.session.Put(r, "flash", flash)
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
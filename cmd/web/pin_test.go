@@ -0,0 +1,87 @@
+// mood/cmd/web/pin_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestPinMood_BadState(t *testing.T) {
+	app := newTestApplication(t)
+
+	form := url.Values{"pinned": {"maybe"}}
+	r := newSessionRequest(t, http.MethodPost, "/mood/pin/1", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetPathValue("id", "1")
+	app.session.Put(r, "authenticatedUserID", int64(1))
+	rr := httptest.NewRecorder()
+
+	app.pinMood(rr, r)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestPinMood(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	mood := &data.Mood{Title: "Keep this one", Content: "<p>x</p>", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	idStr := strconv.FormatInt(mood.ID, 10)
+
+	pin := func(asUser int64, pinned string, htmx bool) *httptest.ResponseRecorder {
+		form := url.Values{"pinned": {pinned}}
+		r := newSessionRequest(t, http.MethodPost, "/mood/pin/"+idStr, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if htmx {
+			r.Header.Set("HX-Request", "true")
+			r.Header.Set("Referer", "/dashboard")
+		}
+		r.SetPathValue("id", idStr)
+		app.session.Put(r, "authenticatedUserID", asUser)
+		rr := httptest.NewRecorder()
+		app.pinMood(rr, r)
+		return rr
+	}
+
+	if rr := pin(otherUserID, "true", false); rr.Code != http.StatusNotFound {
+		t.Fatalf("Pinning another user's entry: expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr := pin(userID, "true", false)
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/dashboard" {
+		t.Fatalf("Expected a redirect to /dashboard, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if got, _ := app.moods.Get(context.Background(), mood.ID, userID); !got.Pinned {
+		t.Fatal("Expected the entry to be pinned")
+	}
+
+	// HTMX gets the re-rendered dashboard, with the card offering to unpin.
+	rr = pin(userID, "true", true)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HTMX pin: expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `aria-pressed="true"`) || !strings.Contains(body, "Unpin this entry") {
+		t.Error("Expected the dashboard to show the entry as pinned")
+	}
+
+	if rr := pin(userID, "false", false); rr.Code != http.StatusSeeOther {
+		t.Fatalf("Unpin: expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got, _ := app.moods.Get(context.Background(), mood.ID, userID); got.Pinned {
+		t.Error("Expected the entry to be unpinned")
+	}
+}
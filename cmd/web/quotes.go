@@ -0,0 +1,39 @@
+// mood/cmd/web/quotes.go
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// statsQuotes are the short notes shown under the stats page charts, one per day.
+// Append new ones at the end: inserting shifts which quote every later day gets.
+var statsQuotes = []string{
+	"Every mood matters. Thanks for checking in 💖",
+	"Feelings are visitors. Let them come and go. 🌤️",
+	"You showed up for yourself today, and that counts. 🌱",
+	"Noticing how you feel is the first step to caring for it. 💭",
+	"Small check-ins add up to big understanding. 📈",
+	"Be as kind to yourself as you would be to a friend. 🤗",
+	"Hard days are part of the story, not the whole of it. 📖",
+	"There is no wrong way to feel. 🌈",
+	"Rest is productive too. 🛌",
+	"Celebrate the good moments, however small. 🎉",
+	"Your patterns are information, not judgments. 🔍",
+	"Breathe in, breathe out. You're doing fine. 🍃",
+}
+
+// quoteOfTheDay picks the quote for t's calendar day (in t's location) by day of year,
+// so it changes once a day and stays put across refreshes within that day.
+func quoteOfTheDay(t time.Time) string {
+	return statsQuotes[t.YearDay()%len(statsQuotes)]
+}
+
+// statsQuote returns the stats page quote: today's in the user's time zone, or a
+// random one on every visit when the app runs with -random-quotes.
+func (app *application) statsQuote(loc *time.Location) string {
+	if app.randomQuotes {
+		return statsQuotes[rand.IntN(len(statsQuotes))]
+	}
+	return quoteOfTheDay(time.Now().In(loc))
+}
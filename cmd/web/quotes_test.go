@@ -0,0 +1,44 @@
+// mood/cmd/web/quotes_test.go
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQuoteOfTheDay(t *testing.T) {
+	morning := time.Date(2024, 5, 10, 0, 5, 0, 0, time.UTC)
+	evening := time.Date(2024, 5, 10, 23, 55, 0, 0, time.UTC)
+	if quoteOfTheDay(morning) != quoteOfTheDay(evening) {
+		t.Error("Expected the same quote all day")
+	}
+	if quoteOfTheDay(evening) == quoteOfTheDay(evening.Add(10*time.Minute)) {
+		t.Error("Expected a different quote the next day")
+	}
+
+	// The day is the user's: 23:55 UTC is already tomorrow in Tokyo.
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if quoteOfTheDay(evening.In(tokyo)) != quoteOfTheDay(evening.Add(10*time.Minute)) {
+		t.Error("Expected the quote to follow the calendar day in the given location")
+	}
+
+	// Every quote comes round within a year.
+	seen := map[string]bool{}
+	for d := 0; d < 366; d++ {
+		seen[quoteOfTheDay(morning.AddDate(0, 0, d))] = true
+	}
+	if len(seen) != len(statsQuotes) {
+		t.Errorf("Expected all %d quotes over a year, saw %d", len(statsQuotes), len(seen))
+	}
+}
+
+func TestStatsQuote_Random(t *testing.T) {
+	app := newTestApplication(t)
+	app.randomQuotes = true
+	for i := 0; i < 20; i++ {
+		if q := app.statsQuote(time.UTC); !slices.Contains(statsQuotes, q) {
+			t.Fatalf("Unexpected quote %q", q)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// mood/cmd/web/ratelimit.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is an in-memory token bucket per key (usually a client IP).
+// Each key may make burst requests at once, then one more every interval.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // Time to earn back one token.
+	burst    int           // Bucket size.
+	now      func() time.Time
+	buckets  map[string]*tokenBucket
+}
+
+// tokenBucket is one key's remaining allowance.
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// newRateLimiter creates a limiter allowing burst requests per key, refilled at
+// one request per interval.
+func newRateLimiter(interval time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// Allow spends a token for key. If none is left it returns false and how long
+// until the next token is available.
+func (l *rateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, l.wait(b)
+}
+
+// Peek reports whether key has a token left, and if not how long until it will,
+// without spending one. Used where only failures should count, such as logins.
+func (l *rateLimiter) Peek(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		return true, 0
+	}
+	return false, l.wait(b)
+}
+
+// refill returns key's bucket, topped up for the time since it was last seen and
+// capped at the burst size. New keys start with a full bucket. l.mu must be held.
+func (l *rateLimiter) refill(key string) *tokenBucket {
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), lastSeen: now}
+		l.buckets[key] = b
+	}
+	elapsed := now.Sub(b.lastSeen)
+	b.tokens = math.Min(float64(l.burst), b.tokens+float64(elapsed)/float64(l.interval))
+	b.lastSeen = now
+	return b
+}
+
+// wait is how long until b has a whole token again. l.mu must be held.
+func (l *rateLimiter) wait(b *tokenBucket) time.Duration {
+	return time.Duration((1 - b.tokens) * float64(l.interval))
+}
+
+// evictIdle forgets keys idle long enough for their bucket to have refilled
+// completely. A full bucket behaves exactly like a new key, so this only frees memory.
+func (l *rateLimiter) evictIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	refillTime := l.interval * time.Duration(l.burst)
+	now := l.now()
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= refillTime {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// startEviction runs evictIdle every period for the life of the process, so limiters
+// keyed on IPs or emails don't grow without bound.
+func (l *rateLimiter) startEviction(period time.Duration) {
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			l.evictIdle()
+		}
+	}()
+}
+
+// clientIP returns the IP part of r.RemoteAddr, or the whole value if it has no port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimit rejects requests from an IP that has used up its allowance in limiter
+// with 429 Too Many Requests and a Retry-After header (in whole seconds).
+func (app *application) rateLimit(limiter *rateLimiter, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if ok, wait := limiter.Allow(ip); !ok {
+			app.logger.Warn("Rate limit exceeded", "remote_ip", ip, "uri", r.URL.RequestURI())
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			app.clientError(w, http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// rateLimitPerUser is like rateLimit, but keys the allowance on the logged-in user
+// rather than the IP. It guards the downloads, so the 429 says so in plain words.
+// It must run after requireAuthentication.
+func (app *application) rateLimitPerUser(limiter *rateLimiter, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		userID := app.getUserIDFromSession(r)
+		if ok, wait := limiter.Allow(strconv.FormatInt(userID, 10)); !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			app.logger.Warn("Per-user rate limit exceeded", "userID", userID, "uri", r.URL.RequestURI())
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, fmt.Sprintf("You're downloading a little too quickly. Please try again in %d %s.", seconds, pluralize(seconds, "second", "seconds")), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// loginFailureKey normalises an email for the per-email login limiter, so changing its
+// case or padding it with spaces doesn't buy an attacker a fresh allowance.
+func loginFailureKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,201 @@
+// mood/cmd/web/ratelimit_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(time.Second, 2)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	// The burst is available straight away.
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("1.2.3.4"); !ok {
+			t.Fatalf("Request %d: expected to be allowed within the burst", i+1)
+		}
+	}
+
+	// The next one must wait for a token.
+	ok, wait := limiter.Allow("1.2.3.4")
+	if ok {
+		t.Fatal("Expected the request after the burst to be limited")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("Expected a wait of up to 1s, got %v", wait)
+	}
+
+	// Other keys have their own bucket.
+	if ok, _ := limiter.Allow("5.6.7.8"); !ok {
+		t.Error("Expected a different key to be allowed")
+	}
+
+	// A token comes back after one interval.
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("1.2.3.4"); !ok {
+		t.Error("Expected a request to be allowed after the refill interval")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app := newTestApplication(t)
+	limiter := newRateLimiter(time.Minute, 1)
+	handler := app.rateLimit(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/csrf-token", nil)
+		r.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	if rr := send("10.0.0.1:5000"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to pass, got %d", rr.Code)
+	}
+	// A different port on the same IP shares the bucket.
+	rr := send("10.0.0.1:6000")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+	if rr := send("10.0.0.2:5000"); rr.Code != http.StatusOK {
+		t.Errorf("Expected another IP to pass, got %d", rr.Code)
+	}
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	app := newTestApplication(t)
+	limiter := newRateLimiter(time.Minute, 1)
+	handler := app.rateLimitPerUser(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(userID int64) *httptest.ResponseRecorder {
+		r := newSessionRequest(t, http.MethodGet, "/user/export/journal?year=2024&month=5", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	if rr := send(1); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the first export to pass, got %d", rr.Code)
+	}
+	rr := send(1)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for a second export inside the cooldown, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "try again in 60 seconds") {
+		t.Errorf("Expected a friendly wait message, got %q", body)
+	}
+	if rr := send(2); rr.Code != http.StatusOK {
+		t.Errorf("Expected another user's export to pass, got %d", rr.Code)
+	}
+}
+
+func TestRateLimiter_Peek(t *testing.T) {
+	limiter := newRateLimiter(time.Minute, 1)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Peek("a@example.com"); !ok {
+			t.Fatalf("Peek %d: expected a token, since peeking must not spend one", i+1)
+		}
+	}
+	limiter.Allow("a@example.com")
+	ok, wait := limiter.Peek("a@example.com")
+	if ok || wait != time.Minute {
+		t.Errorf("Peek after spending = (%v, %v), want (false, 1m)", ok, wait)
+	}
+}
+
+func TestRateLimiter_EvictIdle(t *testing.T) {
+	limiter := newRateLimiter(time.Second, 3)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("old")
+	now = now.Add(2 * time.Second)
+	limiter.Allow("recent")
+
+	// "old" has been idle for 3s, enough to refill all 3 tokens; "recent" hasn't.
+	now = now.Add(time.Second)
+	limiter.evictIdle()
+	if _, ok := limiter.buckets["old"]; ok {
+		t.Error("Expected the fully refilled key to be evicted")
+	}
+	if _, ok := limiter.buckets["recent"]; !ok {
+		t.Error("Expected the recently used key to be kept")
+	}
+}
+
+func TestLoginFailureKey(t *testing.T) {
+	if got := loginFailureKey("  Alice@Example.COM "); got != "alice@example.com" {
+		t.Errorf("loginFailureKey() = %q", got)
+	}
+}
+
+func TestLoginUser_LockedOut(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+	app.loginFailures = newRateLimiter(time.Minute, 1)
+	app.loginFailures.Allow("alice@example.com")
+
+	// Locked out before the password is even checked, so no database is needed.
+	rr := postForm(t, app, app.loginUser, "/user/login", url.Values{"email": {"ALICE@example.com"}, "password": {"whatever"}})
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+	if !strings.Contains(rr.Body.String(), "Too many failed login attempts") {
+		t.Error("Expected the lockout message in the login form")
+	}
+}
+
+func TestLoginUser_OnlyFailuresCount(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	app.loginFailures = newRateLimiter(time.Hour, 2)
+	userID := insertTestUser(t, app)
+	user, err := app.users.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Failed to fetch test user: %v", err)
+	}
+	login := func(password string) int {
+		return postForm(t, app, app.loginUser, "/user/login", url.Values{"email": {user.Email}, "password": {password}}).Code
+	}
+
+	// Successful logins never use up the allowance.
+	for i := 0; i < 3; i++ {
+		if code := login("pa55word123"); code != http.StatusSeeOther {
+			t.Fatalf("Login %d: expected %d, got %d", i+1, http.StatusSeeOther, code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if code := login("wrong-password"); code != http.StatusUnprocessableEntity {
+			t.Fatalf("Failure %d: expected %d, got %d", i+1, http.StatusUnprocessableEntity, code)
+		}
+	}
+	if code := login("pa55word123"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the account to be locked after 2 failures, got %d", code)
+	}
+}
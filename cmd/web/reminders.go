@@ -0,0 +1,60 @@
+// mood/cmd/web/reminders.go
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// sendDueReminders emails a check-in reminder to every user data.UserModel.GetDueReminders
+// says is due at now. A reminder is only marked sent once the mailer accepts it, so a
+// failed send is retried on the next run.
+func (app *application) sendDueReminders(ctx context.Context, now time.Time) {
+	if app.mailer == nil {
+		return
+	}
+	recipients, err := app.users.GetDueReminders(ctx, now)
+	if err != nil {
+		app.logger.Error("Failed to find due reminders", "error", err)
+		return
+	}
+
+	sent := 0
+	for _, recipient := range recipients {
+		err := app.mailer.Send(recipient.Email, "check_in_reminder", map[string]any{
+			"Name":        recipient.Name,
+			"Frequency":   recipient.Frequency,
+			"NewEntryURL": app.absoluteURL("/mood/new"),
+			"SettingsURL": app.absoluteURL("/user/profile"),
+		})
+		if err != nil {
+			app.logger.Error("Failed to send check-in reminder", "error", err, "userID", recipient.UserID)
+			continue
+		}
+		if err := app.users.MarkReminderSent(ctx, recipient.UserID, now); err != nil {
+			app.logger.Error("Failed to record check-in reminder", "error", err, "userID", recipient.UserID)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		app.logger.Info("Sent check-in reminders", "count", sent)
+	}
+}
+
+// startReminders runs sendDueReminders now and then every period for the life of the
+// process. With an hourly period a reminder goes out within the hour after its time.
+// Each run goes through app.background, so shutdown waits for a batch in progress.
+func (app *application) startReminders(period time.Duration) {
+	run := func() {
+		app.background(func() { app.sendDueReminders(context.Background(), time.Now()) })
+	}
+	go func() {
+		run()
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+// mood/cmd/web/reminders_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSendDueReminders(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	stub := &stubMailer{}
+	app.mailer = stub
+	userID := insertTestUser(t, app)
+	if err := app.users.UpdateReminder(context.Background(), userID, true, "09:00", "daily"); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+	now := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC) // 10:00 in the user's default UTC.
+
+	// The reminder alone is for the user's own devices; emails need their own opt-in.
+	app.sendDueReminders(context.Background(), now)
+	if sent := stub.Sent(); len(sent) != 0 {
+		t.Fatalf("Expected no email without email reminders on, got %+v", sent)
+	}
+	if err := app.users.UpdateEmailReminders(context.Background(), userID, true); err != nil {
+		t.Fatalf("UpdateEmailReminders failed: %v", err)
+	}
+
+	// A failed send is not recorded, so the next run tries again.
+	stub.err = errors.New("smtp down")
+	app.sendDueReminders(context.Background(), now)
+	stub.err = nil
+	app.sendDueReminders(context.Background(), now.Add(time.Hour))
+	if sent := stub.Sent(); len(sent) != 2 || sent[1].TemplateName != "check_in_reminder" {
+		t.Fatalf("Expected a retried check_in_reminder, got %+v", sent)
+	}
+
+	// Once sent, the same day's later runs send nothing more.
+	app.sendDueReminders(context.Background(), now.Add(2*time.Hour))
+	if sent := stub.Sent(); len(sent) != 2 {
+		t.Errorf("Expected one reminder per day, got %d sends", len(sent))
+	}
+}
@@ -9,6 +9,13 @@ import (
 
 // render retrieves a template, executes it, and writes to the response.
 func (app *application) render(w http.ResponseWriter, status int, page string, data *TemplateData) error {
+	return app.renderNamed(w, status, page, page, data)
+}
+
+// renderNamed is render for a single named block of a page (e.g., "dashboard-content"
+// in "dashboard.tmpl"), used for HTMX partial swaps. The block is executed into a buffer
+// first, so a template error never leaves a half-written response behind.
+func (app *application) renderNamed(w http.ResponseWriter, status int, page, block string, data *TemplateData) error {
 	ts, ok := app.templateCache[page]
 	if !ok {
 		err := fmt.Errorf("template %q does not exist", page)
@@ -19,11 +26,11 @@ func (app *application) render(w http.ResponseWriter, status int, page string, d
 
 	buf := new(bytes.Buffer)
 
-	// Execute the template associated with the page name (e.g., "login.tmpl", "dashboard.tmpl")
-	err := ts.ExecuteTemplate(buf, page, data) // Use the 'page' name which is the base name of the file
+	// Execute the named block; for full pages this is the page name itself (e.g., "login.tmpl").
+	err := ts.ExecuteTemplate(buf, block, data)
 	if err != nil {
-		err = fmt.Errorf("failed to execute template %q: %w", page, err)
-		app.logger.Error("template execution failed", "template", page, "error", err.Error())
+		err = fmt.Errorf("failed to execute template %q block %q: %w", page, block, err)
+		app.logger.Error("template execution failed", "template", page, "block", block, "error", err.Error())
 		return err
 	}
 
@@ -43,3 +50,27 @@ func (app *application) render(w http.ResponseWriter, status int, page string, d
 
 	return nil
 }
+
+// renderFormBlock re-renders a form after validation errors: just the named block for
+// HTMX swaps, or the full page with 422 otherwise.
+func (app *application) renderFormBlock(w http.ResponseWriter, r *http.Request, page, block string, templateData *TemplateData) {
+	var err error
+	if r.Header.Get("HX-Request") == "true" {
+		err = app.renderNamed(w, http.StatusOK, page, block, templateData)
+	} else {
+		err = app.render(w, http.StatusUnprocessableEntity, page, templateData)
+	}
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// redirectAfterForm sends the user to target, using HX-Redirect for HTMX requests.
+func (app *application) redirectAfterForm(w http.ResponseWriter, r *http.Request, target string) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", target)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
@@ -0,0 +1,55 @@
+// mood/cmd/web/render_test.go
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderNamed(t *testing.T) {
+	app := newTestApplication(t)
+	app.templateCache = newTestTemplateCache(t)
+
+	templateData := &TemplateData{Title: "About Feel Flow", Theme: "system", TimeFormat: "24h"}
+
+	t.Run("RendersOnlyTheBlock", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		err := app.renderNamed(rr, http.StatusAccepted, "about.tmpl", "page-content", templateData)
+		if err != nil {
+			t.Fatalf("renderNamed returned error: %v", err)
+		}
+		if rr.Code != http.StatusAccepted {
+			t.Errorf("Expected status %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("Unexpected Content-Type %q", ct)
+		}
+		body := rr.Body.String()
+		if body == "" {
+			t.Fatal("Expected block output, got empty body")
+		}
+		if strings.Contains(body, "<html") {
+			t.Errorf("Expected only the page-content block, got a full page")
+		}
+	})
+
+	t.Run("UnknownBlock", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		err := app.renderNamed(rr, http.StatusOK, "about.tmpl", "no-such-block", templateData)
+		if err == nil {
+			t.Fatal("Expected an error for an undefined block")
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("Expected nothing written on error, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("UnknownPage", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		if err := app.renderNamed(rr, http.StatusOK, "missing.tmpl", "page-content", templateData); err == nil {
+			t.Fatal("Expected an error for a missing page")
+		}
+	})
+}
@@ -16,32 +16,91 @@ func (app *application) routes() http.Handler {
 	mux.HandleFunc("GET /{$}", app.showLandingPage) // Assuming this is intended root
 	mux.HandleFunc("GET /landing", app.showLandingPage)
 	mux.HandleFunc("GET /about", app.showAboutPage)
+	mux.HandleFunc("GET /robots.txt", app.showRobotsTxt)
+	mux.HandleFunc("GET /sitemap.xml", app.showSitemap)
+	mux.HandleFunc("GET /csrf-token", app.rateLimit(app.csrfLimiter, http.HandlerFunc(app.showCSRFToken)).ServeHTTP)
 	mux.HandleFunc("GET /user/signup", app.signupUserForm)
-	mux.HandleFunc("POST /user/signup", app.signupUser)
+	mux.HandleFunc("POST /user/signup", app.rateLimit(app.authLimiter, http.HandlerFunc(app.signupUser)).ServeHTTP)
 	mux.HandleFunc("GET /user/login", app.loginUserForm)
-	mux.HandleFunc("POST /user/login", app.loginUser)
+	mux.HandleFunc("POST /user/login", app.rateLimit(app.authLimiter, http.HandlerFunc(app.loginUser)).ServeHTTP)
+	mux.HandleFunc("GET /user/forgot-password", app.forgotPasswordForm)
+	mux.HandleFunc("POST /user/forgot-password", app.rateLimit(app.authLimiter, http.HandlerFunc(app.forgotPassword)).ServeHTTP)
+	mux.HandleFunc("GET /user/reset-password", app.resetPasswordForm)
+	mux.HandleFunc("POST /user/reset-password", app.rateLimit(app.authLimiter, http.HandlerFunc(app.resetPassword)).ServeHTTP)
+	mux.HandleFunc("GET /user/activate", app.activateUser)
+	mux.HandleFunc("GET /user/confirm-email", app.confirmEmailChange)
+	mux.HandleFunc("GET /user/resend-activation", app.resendActivationForm)
+	mux.HandleFunc("POST /user/resend-activation", app.rateLimit(app.authLimiter, http.HandlerFunc(app.resendActivation)).ServeHTTP)
 
 	// --- Protected Application Routes ---
 	// Apply requireAuthentication middleware
 	mux.HandleFunc("GET /dashboard", app.requireAuthentication(http.HandlerFunc(app.showDashboardPage)).ServeHTTP)
 	mux.HandleFunc("GET /mood/new", app.requireAuthentication(http.HandlerFunc(app.showMoodForm)).ServeHTTP)
-	mux.HandleFunc("POST /mood/new", app.requireAuthentication(http.HandlerFunc(app.createMood)).ServeHTTP)
+	mux.HandleFunc("POST /mood/new", app.preserveFormOnExpiredSession(http.HandlerFunc(app.createMood)).ServeHTTP)
+	mux.HandleFunc("GET /mood/export", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.exportMoods))).ServeHTTP)
+	mux.HandleFunc("GET /mood/export.md", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.exportMarkdown))).ServeHTTP)
+	mux.HandleFunc("GET /mood/trash", app.requireAuthentication(http.HandlerFunc(app.showTrash)).ServeHTTP)
+	mux.HandleFunc("POST /mood/restore/{id}", app.requireAuthentication(http.HandlerFunc(app.restoreMood)).ServeHTTP)
+	mux.HandleFunc("GET /mood/{id}", app.requireAuthentication(http.HandlerFunc(app.showMoodDetail)).ServeHTTP)
 	mux.HandleFunc("GET /mood/edit/{id}", app.requireAuthentication(http.HandlerFunc(app.showEditMoodForm)).ServeHTTP)
-	mux.HandleFunc("POST /mood/edit/{id}", app.requireAuthentication(http.HandlerFunc(app.updateMood)).ServeHTTP)
+	mux.HandleFunc("POST /mood/edit/{id}", app.preserveFormOnExpiredSession(http.HandlerFunc(app.updateMood)).ServeHTTP)
 	mux.HandleFunc("POST /mood/delete/{id}", app.requireAuthentication(http.HandlerFunc(app.deleteMood)).ServeHTTP)
+	mux.HandleFunc("POST /mood/pin/{id}", app.requireAuthentication(http.HandlerFunc(app.pinMood)).ServeHTTP)
+	mux.HandleFunc("POST /mood/bulk-delete", app.requireAuthentication(http.HandlerFunc(app.bulkDeleteMoods)).ServeHTTP)
 	mux.HandleFunc("GET /stats", app.requireAuthentication(http.HandlerFunc(app.showStatsPage)).ServeHTTP)
+	mux.HandleFunc("GET /stats/data.json", app.requireAuthentication(http.HandlerFunc(app.showStatsData)).ServeHTTP)
+	mux.HandleFunc("GET /stats/heatmap.json", app.requireAuthentication(http.HandlerFunc(app.showStatsHeatmap)).ServeHTTP)
+	mux.HandleFunc("GET /stats/emotion", app.requireAuthentication(http.HandlerFunc(app.showStatsEmotionEntries)).ServeHTTP)
+	mux.HandleFunc("GET /stats/emotions.csv", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.statsEmotionsCSV))).ServeHTTP)
+	mux.HandleFunc("GET /stats/weekly.csv", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.statsWeeklyCSV))).ServeHTTP)
+	mux.HandleFunc("GET /stats/monthly.csv", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.statsMonthlyCSV))).ServeHTTP)
 	mux.HandleFunc("POST /user/logout", app.requireAuthentication(http.HandlerFunc(app.logoutUser)).ServeHTTP)
 
 	// --- NEW USER PROFILE ROUTES ---
 	mux.HandleFunc("GET /user/profile", app.requireAuthentication(http.HandlerFunc(app.showUserProfilePage)).ServeHTTP)
 	mux.HandleFunc("POST /user/profile/update", app.requireAuthentication(http.HandlerFunc(app.updateUserProfile)).ServeHTTP)
 	mux.HandleFunc("POST /user/profile/password", app.requireAuthentication(http.HandlerFunc(app.changeUserPassword)).ServeHTTP)
+	mux.HandleFunc("GET /user/export.json", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.exportJSON))).ServeHTTP)
+	mux.HandleFunc("GET /user/export/journal", app.requireAuthentication(app.rateLimitPerUser(app.exportLimiter, http.HandlerFunc(app.exportJournal))).ServeHTTP)
 	mux.HandleFunc("POST /user/profile/reset-entries", app.requireAuthentication(http.HandlerFunc(app.resetUserEntries)).ServeHTTP)
+	mux.HandleFunc("POST /user/time-format", app.requireAuthentication(http.HandlerFunc(app.updateUserTimeFormat)).ServeHTTP)
+	mux.HandleFunc("POST /user/reminder", app.requireAuthentication(http.HandlerFunc(app.updateUserReminder)).ServeHTTP)
+	mux.HandleFunc("POST /user/email-reminders", app.requireAuthentication(http.HandlerFunc(app.updateEmailReminders)).ServeHTTP)
+	mux.HandleFunc("POST /user/timezone", app.requireAuthentication(http.HandlerFunc(app.updateUserTimeZone)).ServeHTTP)
+	mux.HandleFunc("POST /user/preview-length", app.requireAuthentication(http.HandlerFunc(app.updatePreviewLength)).ServeHTTP)
+	mux.HandleFunc("POST /user/one-entry-per-day", app.requireAuthentication(http.HandlerFunc(app.updateOneEntryPerDay)).ServeHTTP)
+	mux.HandleFunc("POST /user/theme", app.requireAuthentication(http.HandlerFunc(app.updateUserTheme)).ServeHTTP)
 	mux.HandleFunc("POST /user/profile/delete-account", app.requireAuthentication(http.HandlerFunc(app.deleteUserAccount)).ServeHTTP)
 	// --- END NEW USER PROFILE ROUTES ---
 
-	standardMiddleware := app.sessionMiddleware(app.loggingMiddleware(mux))
-	csrfProtectedMiddleware := noSurf(standardMiddleware)
+	// --- Session Preference Routes ---
+	mux.HandleFunc("POST /user/privacy-mode", app.requireAuthentication(http.HandlerFunc(app.togglePrivacyMode)).ServeHTTP)
+	mux.HandleFunc("POST /user/views", app.requireAuthentication(http.HandlerFunc(app.createSavedView)).ServeHTTP)
+	mux.HandleFunc("POST /user/views/delete/{id}", app.requireAuthentication(http.HandlerFunc(app.deleteSavedView)).ServeHTTP)
+	mux.HandleFunc("POST /user/emotions", app.requireAuthentication(http.HandlerFunc(app.saveCustomEmotion)).ServeHTTP)
+	mux.HandleFunc("POST /user/emotions/delete", app.requireAuthentication(http.HandlerFunc(app.deleteCustomEmotion)).ServeHTTP)
+	mux.HandleFunc("POST /user/view-mode", app.requireAuthentication(http.HandlerFunc(app.toggleViewMode)).ServeHTTP)
 
-	return csrfProtectedMiddleware
+	// --- JSON API Routes ---
+	mux.HandleFunc("GET /api/v1/moods/schema", app.apiMoodSchema) // Public: describes the resource only.
+	mux.HandleFunc("GET /api/v1/me", app.requireAPIAuthentication(http.HandlerFunc(app.apiShowMe)).ServeHTTP)
+	mux.HandleFunc("GET /api/v1/moods", app.requireAPIAuthentication(http.HandlerFunc(app.apiListMoods)).ServeHTTP)
+	mux.HandleFunc("POST /api/v1/moods", app.requireAPIAuthentication(http.HandlerFunc(app.apiCreateMood)).ServeHTTP)
+	mux.HandleFunc("GET /api/v1/moods/{id}", app.requireAPIAuthentication(http.HandlerFunc(app.apiShowMood)).ServeHTTP)
+	mux.HandleFunc("PUT /api/v1/moods/{id}", app.requireAPIAuthentication(http.HandlerFunc(app.apiReplaceMood)).ServeHTTP)
+	mux.HandleFunc("PATCH /api/v1/moods/{id}", app.requireAPIAuthentication(http.HandlerFunc(app.apiPatchMood)).ServeHTTP)
+	mux.HandleFunc("DELETE /api/v1/moods/{id}", app.requireAPIAuthentication(http.HandlerFunc(app.apiDeleteMood)).ServeHTTP)
+
+	standardMiddleware := app.sessionMiddleware(app.expireAuthentication(app.loggingMiddleware(mux)))
+	csrfProtectedMiddleware := app.noSurf(standardMiddleware)
+
+	// --- Health Probes ---
+	// Served ahead of the session and CSRF middleware: probes send no cookies and
+	// shouldn't be handed any, or fill the request log.
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", app.healthz)
+	root.HandleFunc("GET /readyz", app.readyz)
+	root.Handle("/", app.requestID(app.recoverPanic(csrfProtectedMiddleware)))
+
+	return root
 }
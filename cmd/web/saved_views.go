@@ -0,0 +1,136 @@
+// mood/cmd/web/saved_views.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// savedViewLink is one saved view as the dashboard shows it: a named, one-click
+// link that applies the stored filters.
+type savedViewLink struct {
+	ID   int64
+	Name string
+	URL  string // e.g. "/dashboard?emotion=Anxious%3A%3A...&start_date=2024-05-01"
+}
+
+// savedViewLinks loads the user's saved views for the dashboard. A failure is
+// logged and shown as no views, since the list is secondary to the entries.
+func (app *application) savedViewLinks(ctx context.Context, userID int64) []savedViewLink {
+	views, err := app.savedViews.GetAllForUser(ctx, userID)
+	if err != nil {
+		app.logger.Error("Failed to fetch saved views", "error", err, "userID", userID)
+		return nil
+	}
+	links := make([]savedViewLink, 0, len(views))
+	for _, view := range views {
+		links = append(links, savedViewLink{ID: view.ID, Name: view.Name, URL: "/dashboard?" + view.Filters.Values().Encode()})
+	}
+	return links
+}
+
+// createSavedView handles POST /user/views. It stores the dashboard filters posted
+// alongside a name, then opens the dashboard with that view applied.
+func (app *application) createSavedView(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Form.
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// 3. Normalise the Filters the same way the dashboard reads them.
+	filters := data.SavedViewFilters{
+		Query:     r.PostForm.Get("query"),
+		Emotion:   r.PostForm.Get("emotion"),
+		StartDate: r.PostForm.Get("start_date"),
+		EndDate:   r.PostForm.Get("end_date"),
+	}
+	if name, emoji, ok := data.DecodeEmotionFilter(filters.Emotion); ok {
+		filters.Emotion = data.EncodeEmotionFilter(name, emoji)
+	}
+	v := validator.NewValidator()
+	weekday, err := parseWeekday(r.PostForm.Get("weekday"))
+	v.Check(err == nil, "weekday", "must be a day of the week")
+	filters.Weekday = weekdayParam(weekday)
+	minIntensity, maxIntensity, err := parseIntensityRange(r.PostForm.Get("min_intensity"), r.PostForm.Get("max_intensity"))
+	v.Check(err == nil, "intensity", fmt.Sprintf("must be a range from %d to %d", data.MoodIntensityMin, data.MoodIntensityMax))
+	filters.MinIntensity, filters.MaxIntensity = intensityParams(minIntensity, maxIntensity)
+
+	view := &data.SavedView{UserID: userID, Name: r.PostForm.Get("name"), Filters: filters}
+	dashboardURL := "/dashboard?" + filters.Values().Encode()
+
+	// 4. Validate.
+	if data.ValidateSavedView(v, view); !v.ValidData() {
+		first := v.OrderedErrors()[0]
+		problem := first.Field + " " + first.Message
+		if first.Field == "filters" { // Already a full sentence.
+			problem = first.Message
+		}
+		app.session.Put(r, "flash", fmt.Sprintf("Couldn't save view: %s.", problem))
+		app.redirectAfterForm(w, r, dashboardURL)
+		return
+	}
+
+	// 5. Insert.
+	err = app.savedViews.Insert(r.Context(), view)
+	switch {
+	case errors.Is(err, data.ErrDuplicateViewName):
+		app.session.Put(r, "flash", fmt.Sprintf("You already have a view called %q.", view.Name))
+	case errors.Is(err, data.ErrTooManySavedViews):
+		app.session.Put(r, "flash", fmt.Sprintf("You can keep up to %d saved views. Delete one to save another.", data.MaxSavedViews))
+	case err != nil:
+		app.serverError(w, r, fmt.Errorf("insert saved view: %w", err))
+		return
+	default:
+		app.logger.Info("Saved view created", "userID", userID, "viewID", view.ID)
+		app.session.Put(r, "flash", fmt.Sprintf("Saved view %q.", view.Name))
+	}
+
+	// 6. Show the Dashboard with the View Applied.
+	app.redirectAfterForm(w, r, dashboardURL)
+}
+
+// deleteSavedView handles POST /user/views/delete/{id}.
+func (app *application) deleteSavedView(w http.ResponseWriter, r *http.Request) {
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse ID.
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	// 3. Delete (scoped to the user, so another user's view is simply "not found").
+	err = app.savedViews.Delete(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, fmt.Errorf("delete saved view: %w", err))
+		}
+		return
+	}
+	app.logger.Info("Saved view deleted", "userID", userID, "viewID", id)
+	app.session.Put(r, "flash", "Saved view deleted.")
+
+	app.redirectAfterForm(w, r, "/dashboard")
+}
@@ -0,0 +1,85 @@
+// mood/cmd/web/saved_views_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSavedViews(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	ownerID := insertTestUser(t, app)
+	otherID := insertTestUser(t, app)
+
+	// Save the current filters. "Monday" is stored in its canonical short form.
+	form := url.Values{"name": {"Work Mondays"}, "query": {"work"}, "weekday": {"Monday"}}
+	r := newSessionRequest(t, http.MethodPost, "/user/views", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", ownerID)
+	rr := httptest.NewRecorder()
+	app.createSavedView(rr, r)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got, want := rr.Header().Get("Location"), "/dashboard?query=work&weekday=mon"; got != want {
+		t.Errorf("Expected redirect to %q, got %q", want, got)
+	}
+	if flash := app.session.GetString(r, "flash"); !strings.Contains(flash, "Work Mondays") {
+		t.Errorf("Expected a confirmation flash, got %q", flash)
+	}
+
+	// Applying: the dashboard lists the view as a link carrying the stored filters.
+	views, err := app.savedViews.GetAllForUser(context.Background(), ownerID)
+	if err != nil || len(views) != 1 {
+		t.Fatalf("Expected one saved view, got %d (err %v)", len(views), err)
+	}
+	r = newSessionRequest(t, http.MethodGet, "/dashboard", nil)
+	app.session.Put(r, "authenticatedUserID", ownerID)
+	r.Header.Set("HX-Request", "true")
+	rr = httptest.NewRecorder()
+	app.showDashboardPage(rr, r)
+	body := rr.Body.String()
+	if !strings.Contains(body, `href="/dashboard?query=work&amp;weekday=mon"`) || !strings.Contains(body, "Work Mondays") {
+		t.Errorf("Expected the dashboard to link the saved view, got:\n%s", body)
+	}
+
+	// Another user can neither see nor delete it.
+	r = newSessionRequest(t, http.MethodGet, "/dashboard", nil)
+	app.session.Put(r, "authenticatedUserID", otherID)
+	r.Header.Set("HX-Request", "true")
+	rr = httptest.NewRecorder()
+	app.showDashboardPage(rr, r)
+	if strings.Contains(rr.Body.String(), "Work Mondays") {
+		t.Error("Saved view leaked onto another user's dashboard")
+	}
+
+	id := strconv.FormatInt(views[0].ID, 10)
+	r = newSessionRequest(t, http.MethodPost, "/user/views/delete/"+id, nil)
+	r.SetPathValue("id", id)
+	app.session.Put(r, "authenticatedUserID", otherID)
+	rr = httptest.NewRecorder()
+	app.deleteSavedView(rr, r)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting another user's view, got %d", rr.Code)
+	}
+
+	// An invalid view is not stored.
+	form = url.Values{"name": {"Nothing"}}
+	r = newSessionRequest(t, http.MethodPost, "/user/views", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.session.Put(r, "authenticatedUserID", ownerID)
+	app.createSavedView(httptest.NewRecorder(), r)
+	if flash := app.session.GetString(r, "flash"); !strings.Contains(flash, "Couldn't save view") {
+		t.Errorf("Expected a validation flash, got %q", flash)
+	}
+	if views, _ := app.savedViews.GetAllForUser(context.Background(), ownerID); len(views) != 1 {
+		t.Errorf("Expected the invalid view not to be stored, got %d views", len(views))
+	}
+}
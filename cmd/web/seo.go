@@ -0,0 +1,72 @@
+// mood/cmd/web/seo.go
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// publicPaths are the marketing pages that crawlers may index and that the sitemap lists.
+var publicPaths = []string{"/", "/landing", "/about"}
+
+// disallowedPrefixes are the signed-in areas of the app that crawlers should skip.
+var disallowedPrefixes = []string{"/dashboard", "/user/", "/mood/", "/stats", "/api/"}
+
+// sitemapURLSet is the <urlset> root of a sitemaps.org sitemap.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is one <url> entry in the sitemap.
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// absoluteURL joins the configured base URL and a path, or returns the path
+// unchanged if no base URL is configured.
+func (app *application) absoluteURL(path string) string {
+	return strings.TrimRight(app.baseURL, "/") + path
+}
+
+// showRobotsTxt handles GET /robots.txt.
+// Only the public pages are allowed; every signed-in area is disallowed.
+func (app *application) showRobotsTxt(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, path := range publicPaths[1:] { // "/" on its own would allow everything.
+		fmt.Fprintf(&b, "Allow: %s\n", path)
+	}
+	for _, prefix := range disallowedPrefixes {
+		fmt.Fprintf(&b, "Disallow: %s\n", prefix)
+	}
+	if app.baseURL != "" {
+		fmt.Fprintf(&b, "\nSitemap: %s\n", app.absoluteURL("/sitemap.xml"))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
+
+// showSitemap handles GET /sitemap.xml, listing only the public pages.
+func (app *application) showSitemap(w http.ResponseWriter, r *http.Request) {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, path := range publicPaths {
+		set.URLs = append(set.URLs, sitemapURL{Loc: app.absoluteURL(path)})
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("marshal sitemap: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
@@ -0,0 +1,64 @@
+// mood/cmd/web/seo_test.go
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShowRobotsTxt(t *testing.T) {
+	app := newTestApplication(t)
+	app.baseURL = "https://feelflow.example/"
+
+	rr := httptest.NewRecorder()
+	app.showRobotsTxt(rr, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected a text/plain Content-Type, got %q", ct)
+	}
+	body := rr.Body.String()
+	for _, prefix := range []string{"/dashboard", "/user/", "/mood/", "/stats"} {
+		if !strings.Contains(body, "Disallow: "+prefix+"\n") {
+			t.Errorf("Expected robots.txt to disallow %s, got:\n%s", prefix, body)
+		}
+	}
+	for _, path := range []string{"/landing", "/about"} {
+		if !strings.Contains(body, "Allow: "+path+"\n") {
+			t.Errorf("Expected robots.txt to allow %s, got:\n%s", path, body)
+		}
+	}
+	if !strings.Contains(body, "Sitemap: https://feelflow.example/sitemap.xml") {
+		t.Errorf("Expected the sitemap line to use the base URL, got:\n%s", body)
+	}
+}
+
+func TestShowSitemap(t *testing.T) {
+	app := newTestApplication(t)
+	app.baseURL = "https://feelflow.example"
+
+	rr := httptest.NewRecorder()
+	app.showSitemap(rr, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("Expected an application/xml Content-Type, got %q", ct)
+	}
+	body := rr.Body.String()
+	for _, loc := range []string{"https://feelflow.example/", "https://feelflow.example/landing", "https://feelflow.example/about"} {
+		if !strings.Contains(body, "<loc>"+loc+"</loc>") {
+			t.Errorf("Expected sitemap to list %s, got:\n%s", loc, body)
+		}
+	}
+	for _, prefix := range []string{"/dashboard", "/user/", "/mood/", "/stats", "/api/"} {
+		if strings.Contains(body, prefix) {
+			t.Errorf("Sitemap must not list protected prefix %s, got:\n%s", prefix, body)
+		}
+	}
+}
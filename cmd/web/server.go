@@ -2,13 +2,23 @@
 package main
 
 import (
+	"context"
 	"crypto/tls" // Ensure this import is present
+	"errors"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
-// serve configures and starts the application's HTTP server.
+// shutdownTimeout is how long in-flight requests get to finish after SIGINT or SIGTERM.
+const shutdownTimeout = 20 * time.Second
+
+// serve configures and starts the application's HTTP server. It returns nil once
+// SIGINT or SIGTERM has shut the server down gracefully; main then waits for any
+// background work before exiting.
 func (app *application) serve() error {
 
 	// --- Define Advanced TLS Configuration ---
@@ -44,6 +54,20 @@ func (app *application) serve() error {
 		TLSConfig:    tlsConfig,                                                // <-- Assign the custom TLS configuration
 	}
 
+	// Shut down gracefully on SIGINT or SIGTERM: stop accepting connections and let
+	// in-flight requests finish, then ListenAndServeTLS returns http.ErrServerClosed.
+	shutdownError := make(chan error)
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-quit
+		app.logger.Info("shutting down server", slog.String("signal", sig.String()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownError <- srv.Shutdown(ctx)
+	}()
+
 	// Log the server start address (message now indicates HTTPS)
 	app.logger.Info("starting HTTPS server with advanced TLS config", slog.String("addr", srv.Addr))
 
@@ -52,5 +76,8 @@ func (app *application) serve() error {
 	// It requires the paths to the certificate and key files.
 	err := srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
 	// We don't need to log the Fatal error here as main.go handles it if serve() returns an error.
-	return err // Return the error to main.go
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err // Return the error to main.go
+	}
+	return <-shutdownError // nil unless in-flight requests outlasted shutdownTimeout.
 }
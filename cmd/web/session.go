@@ -0,0 +1,64 @@
+// mood/cmd/web/session.go
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// Login lifetimes. golangcollege/sessions has a single Lifetime for every session, so
+// it is set to the longest of these (sessionCookieLifetime) and each login records its
+// own deadline under "authExpiresAt" (Unix seconds, since the session's gob encoding
+// can't hold a time.Time without registering it), which expireAuthentication enforces.
+const (
+	defaultLoginLifetime    = 12 * time.Hour
+	rememberMeLoginLifetime = 30 * 24 * time.Hour
+	sessionCookieLifetime   = rememberMeLoginLifetime
+)
+
+// userSessionKeys are the session values that belong to the logged-in user. logOut
+// removes all of them so nothing carries over to whoever uses the browser next.
+var userSessionKeys = []string{"authenticatedUserID", "authExpiresAt", "pendingSubmission", "privacyMode", "viewMode"}
+
+// logIn marks the session as belonging to userID until the login lifetime runs out.
+//
+// Remember me trades security for convenience: the session cookie is persistent, so for
+// up to 30 days anyone with access to the browser (or a stolen cookie) is logged in as
+// the user, and because sessions live entirely in the signed cookie there is no server
+// side record to revoke early. Unchecked logins keep the old 12 hour limit. The cookie's
+// own expiry is fixed when the session is first created, so a session that existed
+// before logging in can end a little before the 30 days are up.
+func (app *application) logIn(r *http.Request, userID int64, rememberMe bool) {
+	lifetime := defaultLoginLifetime
+	if rememberMe {
+		lifetime = rememberMeLoginLifetime
+	}
+	app.session.Put(r, "authenticatedUserID", userID)
+	app.session.Put(r, "authExpiresAt", time.Now().Add(lifetime).Unix())
+}
+
+// logOut removes everything logIn and the logged-in pages stored in the session. The
+// session itself is kept (rather than destroyed) so a flash message can still be set.
+func (app *application) logOut(r *http.Request) {
+	for _, key := range userSessionKeys {
+		app.session.Remove(r, key)
+	}
+}
+
+// expireAuthentication logs out a session whose login deadline has passed, before any
+// handler sees it, so isAuthenticated and getUserIDFromSession never report a stale login.
+// A login from before deadlines were recorded has none, so it is given the default
+// lifetime from its first request instead of staying logged in for as long as the cookie.
+func (app *application) expireAuthentication(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		expiresAt, ok := app.session.Get(r, "authExpiresAt").(int64)
+		switch {
+		case !ok && app.session.Exists(r, "authenticatedUserID"):
+			app.session.Put(r, "authExpiresAt", time.Now().Add(defaultLoginLifetime).Unix())
+		case ok && time.Now().Unix() >= expiresAt:
+			app.logOut(r)
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
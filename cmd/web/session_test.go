@@ -0,0 +1,110 @@
+// mood/cmd/web/session_test.go
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogIn_Lifetime(t *testing.T) {
+	tests := []struct {
+		name       string
+		rememberMe bool
+		want       time.Duration
+	}{
+		{"Default", false, defaultLoginLifetime},
+		{"RememberMe", true, rememberMeLoginLifetime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			r := newSessionRequest(t, http.MethodPost, "/user/login", nil)
+
+			app.logIn(r, 42, tt.rememberMe)
+
+			if got := app.getUserIDFromSession(r); got != 42 {
+				t.Errorf("Expected user 42 to be logged in, got %d", got)
+			}
+			expiresAt, ok := app.session.Get(r, "authExpiresAt").(int64)
+			if !ok {
+				t.Fatal("Expected authExpiresAt to be an int64")
+			}
+			want := time.Now().Add(tt.want).Unix()
+			if diff := want - expiresAt; diff < 0 || diff > 5 {
+				t.Errorf("Expected authExpiresAt near %d, got %d", want, expiresAt)
+			}
+		})
+	}
+}
+
+// TestExpireAuthentication runs the real session middleware, so the login deadline
+// also has to survive being encoded into the cookie and read back.
+func TestExpireAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+
+	var setExpiry int64
+	var noExpiry bool
+	login := app.session.Enable(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.logIn(r, 7, true)
+		if setExpiry != 0 {
+			app.session.Put(r, "authExpiresAt", setExpiry)
+		}
+		if noExpiry {
+			app.session.Remove(r, "authExpiresAt")
+		}
+	}))
+	var authenticated bool
+	var expiresAt int64
+	check := app.session.Enable(app.expireAuthentication(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated = app.isAuthenticated(r)
+		expiresAt, _ = app.session.Get(r, "authExpiresAt").(int64)
+	})))
+
+	loginAndCheck := func() bool {
+		rr := httptest.NewRecorder()
+		login.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/user/login", nil))
+		r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		for _, cookie := range rr.Result().Cookies() {
+			r.AddCookie(cookie)
+		}
+		check.ServeHTTP(httptest.NewRecorder(), r)
+		return authenticated
+	}
+
+	if !loginAndCheck() {
+		t.Error("Expected a fresh remembered login to be authenticated")
+	}
+	setExpiry = time.Now().Add(-time.Minute).Unix()
+	if loginAndCheck() {
+		t.Error("Expected a login past its deadline to be logged out")
+	}
+
+	// A login saved before deadlines existed gets the default lifetime, not the cookie's.
+	setExpiry, noExpiry = 0, true
+	if !loginAndCheck() {
+		t.Error("Expected a login without a deadline to stay authenticated for now")
+	}
+	want := time.Now().Add(defaultLoginLifetime).Unix()
+	if diff := want - expiresAt; diff < 0 || diff > 5 {
+		t.Errorf("Expected the missing deadline to be backfilled near %d, got %d", want, expiresAt)
+	}
+}
+
+func TestLogOut_ClearsUserKeys(t *testing.T) {
+	app := newTestApplication(t)
+	r := newSessionRequest(t, http.MethodPost, "/user/logout", nil)
+	app.logIn(r, 42, true)
+	app.session.Put(r, "privacyMode", true)
+	app.session.Put(r, "viewMode", viewModeList)
+	app.session.Put(r, "pendingSubmission", `{"path":"/mood/new"}`)
+
+	app.logOut(r)
+
+	for _, key := range userSessionKeys {
+		if app.session.Exists(r, key) {
+			t.Errorf("Expected %q to be removed on logout", key)
+		}
+	}
+}
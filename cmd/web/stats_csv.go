@@ -0,0 +1,108 @@
+// mood/cmd/web/stats_csv.go
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// statsEmotionsCSV handles GET /stats/emotions.csv: one row per emotion with its count.
+func (app *application) statsEmotionsCSV(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	counts, err := app.moods.GetEmotionCounts(r.Context(), userID)
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get emotion counts for CSV: %w", err))
+		return
+	}
+
+	rows := make([][]string, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []string{c.Name, c.Emoji, c.Color, strconv.Itoa(c.Count)})
+	}
+	app.writeCSV(w, r, "feelflow-emotions.csv", []string{"emotion", "emoji", "color", "count"}, rows)
+}
+
+// statsWeeklyCSV handles GET /stats/weekly.csv: one row per ISO week with entries.
+func (app *application) statsWeeklyCSV(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	counts, err := app.moods.GetWeeklyEntryCounts(r.Context(), userID, app.userLocation(r).String())
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get weekly counts for CSV: %w", err))
+		return
+	}
+
+	rows := make([][]string, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []string{c.Week, strconv.Itoa(c.Count)})
+	}
+	app.writeCSV(w, r, "feelflow-weekly.csv", []string{"week", "count"}, rows)
+}
+
+// statsMonthlyCSV handles GET /stats/monthly.csv: one row per calendar month with entries.
+func (app *application) statsMonthlyCSV(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	counts, err := app.moods.GetMonthlyEntryCounts(r.Context(), userID, app.userLocation(r).String())
+	if err != nil {
+		app.serverError(w, r, fmt.Errorf("get monthly counts for CSV: %w", err))
+		return
+	}
+
+	rows := make([][]string, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []string{c.Month, strconv.Itoa(c.Count)})
+	}
+	app.writeCSV(w, r, "feelflow-monthly.csv", []string{"month", "count"}, rows)
+}
+
+// writeCSV sends header and rows as a CSV download named filename. The CSV is built
+// in a buffer first, so an encoding error can't leave a half-sent file.
+func (app *application) writeCSV(w http.ResponseWriter, r *http.Request, filename string, header []string, rows [][]string) {
+	buf := new(bytes.Buffer)
+	cw := csv.NewWriter(buf)
+	cw.Write(header)
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvSafe(row[i])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		app.serverError(w, r, fmt.Errorf("write %s: %w", filename, err))
+		return
+	}
+
+	app.logger.Info("Export downloaded", "userID", app.getUserIDFromSession(r), "file", filename, "rows", len(rows))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// csvSafe stops a spreadsheet from treating a user-entered value (like a custom
+// emotion name) as a formula, by prefixing formula-leading characters with a quote.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,73 @@
+// mood/cmd/web/stats_csv_test.go
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestCSVSafe(t *testing.T) {
+	tests := map[string]string{
+		"Happy":       "Happy",
+		"=SUM(A1:A2)": "'=SUM(A1:A2)",
+		"+1":          "'+1",
+		"-1":          "'-1",
+		"@cmd":        "'@cmd",
+		"":            "",
+		"😊 Feeling =": "😊 Feeling =",
+	}
+	for in, want := range tests {
+		if got := csvSafe(in); got != want {
+			t.Errorf("csvSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStatsEmotionsCSV(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	userID := insertTestUser(t, app)
+	for _, m := range []*data.Mood{
+		{Title: "A", Content: "<p>a</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID},
+		{Title: "B", Content: "<p>b</p>", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: userID},
+		{Title: "C", Content: "<p>c</p>", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: userID},
+	} {
+		if err := app.moods.Insert(context.Background(), m); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+
+	r := newSessionRequest(t, http.MethodGet, "/stats/emotions.csv", nil)
+	app.session.Put(r, "authenticatedUserID", userID)
+	rr := httptest.NewRecorder()
+	app.statsEmotionsCSV(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected a text/csv Content-Type, got %q", ct)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="feelflow-emotions.csv"`) {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 data rows, got %d rows: %v", len(records), records)
+	}
+	if got := strings.Join(records[0], ","); got != "emotion,emoji,color,count" {
+		t.Errorf("Unexpected header row %q", got)
+	}
+	if got := strings.Join(records[1], ","); got != "Happy,😊,#FFD700,2" {
+		t.Errorf("Unexpected first data row %q", got)
+	}
+}
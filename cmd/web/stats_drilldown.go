@@ -0,0 +1,120 @@
+// mood/cmd/web/stats_drilldown.go
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// statsDrilldownPageSize caps how many entries one emotion drill-down lists.
+const statsDrilldownPageSize = 20
+
+// showStatsEmotionEntries handles GET /stats/emotion?name=&emoji=&start=&end=, listing the
+// user's entries for one emotion, optionally within an inclusive YYYY-MM-DD date range in
+// their time zone. It responds with the "stats-emotion-entries" fragment, or with JSON when
+// the request's Accept header asks for application/json. Bad parameters get a 400.
+func (app *application) showStatsEmotionEntries(w http.ResponseWriter, r *http.Request) {
+	wantsJSON := strings.Contains(r.Header.Get("Accept"), "application/json")
+
+	// 1. Authentication.
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		if wantsJSON {
+			app.errorJSON(w, http.StatusUnauthorized, "you must be authenticated to access this resource")
+		} else {
+			app.clientError(w, http.StatusUnauthorized)
+		}
+		return
+	}
+
+	// 2. Parse & Validate Query Parameters.
+	qs := r.URL.Query()
+	v := validator.NewValidator()
+	name := strings.TrimSpace(qs.Get("name"))
+	emoji := strings.TrimSpace(qs.Get("emoji"))
+	v.Check(validator.NotBlank(name), "name", "must be provided")
+
+	user := app.currentUser(r)
+	location := app.locationForUser(r, user)
+	var startDate, endDate time.Time
+	if s := qs.Get("start"); s != "" {
+		var err error
+		if startDate, _, err = parseFilterDay(s, location); err != nil {
+			v.AddError("start", "must be a date in YYYY-MM-DD format")
+		}
+	}
+	if s := qs.Get("end"); s != "" {
+		var err error
+		if _, endDate, err = parseFilterDay(s, location); err != nil {
+			v.AddError("end", "must be a date in YYYY-MM-DD format")
+		}
+	}
+	v.Check(startDate.IsZero() || endDate.IsZero() || !endDate.Before(startDate), "end", "must not be before start")
+
+	page := apiReadInt(qs.Get("page"), 1, v, "page")
+	v.Check(page > 0, "page", "must be greater than zero")
+	v.Check(page <= 10_000_000, "page", "must be less than 10 million")
+
+	if !v.ValidData() {
+		if wantsJSON {
+			app.errorJSON(w, http.StatusBadRequest, v.Errors)
+		} else {
+			app.clientError(w, http.StatusBadRequest)
+		}
+		return
+	}
+
+	// 3. Fetch the Entries. An emoji narrows the match to that exact emotion, like the
+	//    dashboard's emotion dropdown; without one every entry with the name matches.
+	emotion := name
+	if emoji != "" {
+		emotion = data.EncodeEmotionFilter(name, emoji)
+	}
+	criteria := data.FilterCriteria{
+		Emotion:   emotion,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Weekday:   data.AnyWeekday,
+		Location:  location,
+		Sort:      data.DefaultSort,
+		Page:      page,
+		PageSize:  statsDrilldownPageSize,
+		UserID:    userID,
+	}
+	moods, metadata, err := app.moods.GetFiltered(r.Context(), criteria)
+	if err != nil {
+		app.logger.Error("Failed to fetch emotion drill-down", "error", err, "userID", userID, "emotion", name)
+		if wantsJSON {
+			app.errorJSON(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// 4. Respond.
+	if wantsJSON {
+		app.writeJSON(w, http.StatusOK, map[string]any{
+			"emotion":  map[string]string{"name": name, "emoji": emoji},
+			"start":    qs.Get("start"),
+			"end":      qs.Get("end"),
+			"metadata": metadata,
+			"moods":    moods,
+		}, nil)
+		return
+	}
+
+	templateData := app.newTemplateData(r)
+	templateData.DrilldownEmotion = data.EmotionDetail{Name: name, Emoji: emoji}
+	templateData.FilterStartDate = qs.Get("start")
+	templateData.FilterEndDate = qs.Get("end")
+	templateData.DisplayMoods = newDisplayMoods(moods, previewLength(user))
+	templateData.Metadata = metadata
+	if err := app.renderNamed(w, http.StatusOK, "stats.tmpl", "stats-emotion-entries", templateData); err != nil {
+		app.serverError(w, r, err)
+	}
+}
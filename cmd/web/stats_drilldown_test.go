@@ -0,0 +1,79 @@
+// mood/cmd/web/stats_drilldown_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestShowStatsEmotionEntries(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	otherID := insertTestUser(t, app)
+	for _, m := range []*data.Mood{
+		{Title: "Sunny walk", Emotion: "Happy", Emoji: "😊", UserID: userID},
+		{Title: "Good news", Emotion: "Happy", Emoji: "😊", UserID: userID},
+		{Title: "Rainy day", Emotion: "Sad", Emoji: "😢", UserID: userID},
+		{Title: "Someone else's", Emotion: "Happy", Emoji: "😊", UserID: otherID},
+	} {
+		m.Content, m.Color = "<p>c</p>", "#FFD700"
+		if err := app.moods.Insert(context.Background(), m); err != nil {
+			t.Fatalf("Failed to insert mood: %v", err)
+		}
+	}
+	get := func(query url.Values, accept string) *httptest.ResponseRecorder {
+		r := newSessionRequest(t, http.MethodGet, "/stats/emotion?"+query.Encode(), nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		app.showStatsEmotionEntries(rr, r)
+		return rr
+	}
+
+	// The fragment lists only this user's entries for the emotion.
+	rr := get(url.Values{"name": {"Happy"}, "emoji": {"😊"}}, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Sunny walk") || !strings.Contains(body, "Good news") || !strings.Contains(body, "2 entries") {
+		t.Errorf("Expected both Happy entries in the fragment, got:\n%s", body)
+	}
+	if strings.Contains(body, "Rainy day") || strings.Contains(body, "Someone else") {
+		t.Errorf("Expected only this user's Happy entries, got:\n%s", body)
+	}
+
+	// JSON, with a date range that ends before today, finds nothing.
+	rr = get(url.Values{"name": {"Happy"}, "emoji": {"😊"}, "start": {"2020-01-01"}, "end": {"2020-12-31"}}, "application/json")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Moods []data.Mood `json:"moods"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || len(resp.Moods) != 0 {
+		t.Errorf("Expected no moods in 2020, got %d (err %v)", len(resp.Moods), err)
+	}
+
+	// Bad parameters are rejected, naming the field for JSON clients.
+	for field, query := range map[string]url.Values{
+		"name":  {"emoji": {"😊"}},
+		"start": {"name": {"Happy"}, "start": {"01/05/2024"}},
+		"end":   {"name": {"Happy"}, "start": {"2024-05-02"}, "end": {"2024-05-01"}},
+	} {
+		rr := get(query, "application/json")
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"`+field+`"`) {
+			t.Errorf("%s: expected a 400 naming the field, got %d %s", field, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -2,13 +2,16 @@
 package main
 
 import (
+	"cmp"
 	"errors"
 	"html/template"
 	"net/http" // Ensure this is imported
+	"slices"
 	"time"
 
 	"github.com/justinas/nosurf" // <-- Import nosurf
 	"github.com/mickali02/mood/internal/data"
+	"github.com/mickali02/mood/internal/validator"
 )
 
 // displayMood struct definition (unchanged)
@@ -17,60 +20,177 @@ type displayMood struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Title        string
-	Content      template.HTML
-	ShortContent template.HTML
-	RawContent   string
+	Content      template.HTML // Sanitized with Mood.SanitizeContent.
+	ShortContent template.HTML // Plain-text preview, truncated for the card.
+	PreviewText  string        // ShortContent before escaping, for the highlight template function.
+	RawContent   string        // Same sanitized HTML as Content, for the "View More" modal.
 	Emotion      string
 	Emoji        string
-	Color        string
+	Color        string // The emotion's own color, as used by the filter and legend.
+	DisplayColor string // Color shaded for the entry's intensity, see intensityShade.
+	Pinned       bool
+	DeletedAt    *time.Time // Set only for entries listed on the trash page.
+	// TrendVsPrevious is "up", "down" or "same": this entry's intensity against the entry
+	// logged just before it. Empty for the first-ever entry and outside the dashboard.
+	TrendVsPrevious string
+}
+
+// newDisplayMoods converts moods for the dashboard, applying the shared sanitization
+// policies so every view of an entry follows the same rules. Each card previews the
+// first previewLength characters of the entry's text.
+func newDisplayMoods(moods []*data.Mood, previewLength int) []displayMood {
+	displayMoods := make([]displayMood, len(moods))
+	for i, moodEntry := range moods {
+		content := moodEntry.SanitizeContent()
+		preview := truncateTextWithEllipsis(moodEntry.Content, previewLength)
+		displayMoods[i] = displayMood{
+			ID:           moodEntry.ID,
+			CreatedAt:    moodEntry.CreatedAt,
+			UpdatedAt:    moodEntry.UpdatedAt,
+			Title:        moodEntry.Title,
+			Content:      template.HTML(content),
+			ShortContent: template.HTML(template.HTMLEscapeString(preview)),
+			PreviewText:  preview,
+			RawContent:   content,
+			Emotion:      moodEntry.Emotion,
+			Emoji:        moodEntry.Emoji,
+			Color:        moodEntry.Color,
+			DisplayColor: intensityShade(moodEntry.Color, moodEntry.Intensity),
+			Pinned:       moodEntry.Pinned,
+			DeletedAt:    moodEntry.DeletedAt,
+		}
+	}
+	return displayMoods
+}
+
+// intensityTrends compares each entry's intensity with the entry logged just before it,
+// returning "up", "down" or "same" keyed by ID. timeline must contain every entry that
+// needs a trend plus its predecessor (see MoodModel.GetPredecessors); it is put in
+// chronological order here, so any order and duplicates are fine. The oldest entry in
+// it gets no trend, since its predecessor, if any, isn't in the timeline.
+func intensityTrends(timeline []*data.Mood) map[int64]string {
+	sorted := slices.Clone(timeline)
+	slices.SortFunc(sorted, func(a, b *data.Mood) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	sorted = slices.CompactFunc(sorted, func(a, b *data.Mood) bool { return a.ID == b.ID })
+
+	trends := make(map[int64]string, len(sorted))
+	for i := 1; i < len(sorted); i++ {
+		switch current, previous := sorted[i].Intensity, sorted[i-1].Intensity; {
+		case current > previous:
+			trends[sorted[i].ID] = "up"
+		case current < previous:
+			trends[sorted[i].ID] = "down"
+		default:
+			trends[sorted[i].ID] = "same"
+		}
+	}
+	return trends
+}
+
+// displayRevision is an earlier version of an entry, listed in the detail page's history.
+type displayRevision struct {
+	Version    int
+	Title      string
+	Content    template.HTML // Sanitized like displayMood.Content.
+	Emotion    string
+	Emoji      string
+	Color      string
+	Intensity  int
+	SavedAt    time.Time
+	ReplacedAt time.Time
+}
+
+// newDisplayRevisions converts revisions for the detail page, sanitizing their content.
+func newDisplayRevisions(revisions []*data.MoodRevision) []displayRevision {
+	displayRevisions := make([]displayRevision, len(revisions))
+	for i, rev := range revisions {
+		displayRevisions[i] = displayRevision{
+			Version:    rev.Version,
+			Title:      rev.Title,
+			Content:    template.HTML(rev.SanitizeContent()),
+			Emotion:    rev.Emotion,
+			Emoji:      rev.Emoji,
+			Color:      rev.Color,
+			Intensity:  rev.Intensity,
+			SavedAt:    rev.SavedAt,
+			ReplacedAt: rev.ReplacedAt,
+		}
+	}
+	return displayRevisions
 }
 
 // EmotionDetails struct definition (unchanged)
 type EmotionDetails struct {
-	Name  string
-	Emoji string
-	Color string
+	Name   string
+	Emoji  string
+	Color  string
+	Custom bool // One of the user's saved custom emotions rather than a built-in one.
 }
 
-// EmotionMap definition (unchanged)
-var EmotionMap = map[string]EmotionDetails{
-	"Happy":   {Name: "Happy", Emoji: "😊", Color: "#FFCA28"},
-	"Sad":     {Name: "Sad", Emoji: "😢", Color: "#5C8DDE"},
-	"Angry":   {Name: "Angry", Emoji: "😠", Color: "#E53935"},
-	"Anxious": {Name: "Anxious", Emoji: "😟", Color: "#FFA000"},
-	"Calm":    {Name: "Calm", Emoji: "😌", Color: "#69B36C"},
-	"Excited": {Name: "Excited", Emoji: "🤩", Color: "#F06292"},
-	"Neutral": {Name: "Neutral", Emoji: "😐", Color: "#A4B8D0"},
-}
+// EmotionMap is data.BuiltInEmotions in the templates' shape, so the picker offers
+// exactly the emoji and color ValidateMood accepts for each built-in emotion.
+var EmotionMap = func() map[string]EmotionDetails {
+	m := make(map[string]EmotionDetails, len(data.BuiltInEmotions))
+	for key, details := range data.BuiltInEmotions {
+		m[key] = EmotionDetails{Name: details.Name, Emoji: details.Emoji, Color: details.Color}
+	}
+	return m
+}()
 
 // TemplateData holds data passed to HTML templates
 type TemplateData struct {
-	Title           string
-	HeaderText      string
-	HasMoodEntries  bool
-	SearchQuery     string
-	FilterEmotion   string
-	FilterStartDate string
-	FilterEndDate   string
-	UserName        string
-
-	FormErrors map[string]string
-	FormData   map[string]string
+	Title              string
+	HeaderText         string
+	HasMoodEntries     bool
+	SearchQuery        string
+	FilterEmotion      string
+	FilterStartDate    string
+	FilterEndDate      string
+	FilterWeekday      string          // Canonical weekday filter ("mon", "tue", ...) or "" for any day
+	FilterMinIntensity string          // Lowest intensity shown ("1"-"5"), or "" for no lower bound
+	FilterMaxIntensity string          // Highest intensity shown ("1"-"5"), or "" for no upper bound
+	SortOrder          string          // Dashboard sort order, e.g. "created_at_desc"; see data.ValidSort
+	FilterChips        []filterChip    // Active filters rendered as removable chips
+	SavedViews         []savedViewLink // The user's saved filter combinations, as one-click links
+	UserName           string
+
+	FormErrors        map[string]string
+	OrderedFormErrors []validator.FieldError // Same errors as FormErrors, in the order they were found.
+	FormData          map[string]string
 
 	// Page-specific data
 	DisplayMoods      []displayMood
+	Memories          []displayMood // Entries from this calendar day in earlier years, see MoodModel.GetOnThisDay.
 	Mood              *data.Mood
-	DefaultEmotions   []EmotionDetails
+	MoodHTML          template.HTML     // Sanitized full content for the mood detail page.
+	Revisions         []displayRevision // Earlier versions of Mood, newest first.
+	DefaultEmotions   []EmotionDetails  // Mood form picker: built-in emotions, then the user's saved ones.
 	AvailableEmotions []data.EmotionDetail
+	CustomEmotions    []data.EmotionDetail // The user's saved custom emotions, for the profile page.
 	Metadata          data.Metadata
 
 	Flash string // Flash field for session messages
 
 	// --- Fields for Stats Page ---
 	Stats             *data.MoodStats
-	EmotionCountsJSON string
+	HasStats          bool   // False for a user with no entries; the page shows an onboarding prompt instead of charts.
+	EmotionCountsJSON string // Chart datasets as JSON arrays; always "[]" rather than "null" when empty.
+	WeeklyCountsJSON  string
+	EmotionTrendJSON  string
+	MonthlyCountsJSON string
+	WeekdayCountsJSON string
+	HourlyCountsJSON  string
+	Insight           string             // Human-readable summary sentence, see data.GenerateInsight.
+	MissingDays       []time.Time        // Recent days with no entries, oldest first.
+	MissingDaysWindow int                // How many recent days MissingDays covers.
+	DrilldownEmotion  data.EmotionDetail // Emotion listed by the stats drill-down fragment (no Color).
 	Quote             string
 
+	// --- Field for About Page ---
+	GlobalTotals *globalTotals // App-wide counts; nil if they couldn't be loaded.
+
 	// --- Field for Authentication State ---
 	IsAuthenticated bool `json:"is_authenticated"`
 
@@ -80,6 +200,14 @@ type TemplateData struct {
 	// --- Fields for Profile Page Pagination ---
 	ProfileCurrentPage int
 	ProfileTotalPages  int
+
+	// --- User Preferences ---
+	Theme      string // "light", "dark" or "system"; set on <html> so the right theme applies on first paint.
+	TimeFormat string // "12h" or "24h"; passed to FormatDate in templates.
+
+	// --- Session-Scoped Display Preferences ---
+	PrivacyMode bool   // When true, dashboard content previews are masked.
+	ViewMode    string // "cards" or "list"; picks the dashboard layout.
 }
 
 // NewTemplateData creates a *basic* default TemplateData instance.
@@ -108,12 +236,22 @@ func NewTemplateData() *TemplateData {
 		Flash:             "",    // Populated later
 		IsAuthenticated:   false, // Populated later
 		CSRFToken:         "",    // Populated later
+		PrivacyMode:       false, // Populated later
+		ViewMode:          viewModeCards,
+		SortOrder:         data.DefaultSort,
+		Theme:             "system",
+		TimeFormat:        "24h", // Matches the original HumanDate output.
 		UserName:          "",
 		User:              nil, // Initialize User as nil
 
 		// --- Initialize Stats Fields ---
 		Stats:             nil,
 		EmotionCountsJSON: "[]",
+		WeeklyCountsJSON:  "[]",
+		EmotionTrendJSON:  "[]",
+		MonthlyCountsJSON: "[]",
+		WeekdayCountsJSON: "[]",
+		HourlyCountsJSON:  "[]",
 		Quote:             "",
 
 		// --- Initialize Profile Pagination Fields ---
@@ -137,14 +275,24 @@ func (app *application) newTemplateData(r *http.Request) *TemplateData {
 	td.IsAuthenticated = app.isAuthenticated(r)
 	td.Flash = app.session.PopString(r, "flash")
 	td.CSRFToken = nosurf.Token(r)
+	td.PrivacyMode = app.session.GetBool(r, "privacyMode")
+	if app.session.GetString(r, "viewMode") == viewModeList {
+		td.ViewMode = viewModeList
+	}
 
 	if td.IsAuthenticated {
 		userID := app.getUserIDFromSession(r)
 		if userID > 0 { // Ensure userID is valid before fetching
-			user, err := app.users.Get(userID)
+			user, err := app.users.Get(r.Context(), userID)
 			if err == nil {
 				td.User = user
 				td.UserName = user.Name // Keep UserName populated for convenience if templates use it
+				if user.Theme != "" {
+					td.Theme = user.Theme
+				}
+				if user.TimeFormat != "" {
+					td.TimeFormat = user.TimeFormat
+				}
 			} else if !errors.Is(err, data.ErrRecordNotFound) {
 				app.logger.Error("Failed to get user for template data", "userID", userID, "error", err)
 			}
@@ -6,7 +6,12 @@ import (
 	"html/template"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/mickali02/mood/internal/data"
 )
 
 // isZero is a helper function for the 'default' template function.
@@ -46,6 +51,67 @@ func isZero(v interface{}) bool {
 	return false // Default to false if type not handled or not obviously zero
 }
 
+// humanDate formats t for display using the user's clock preference:
+// "12h" gives "Jan 02, 2006 at 3:04 PM", anything else the default 24-hour "15:04".
+func humanDate(t time.Time, timeFormat string) string {
+	if t.IsZero() {
+		return ""
+	}
+	if timeFormat == "12h" {
+		return t.Format("Jan 02, 2006 at 3:04 PM")
+	}
+	return t.Format("Jan 02, 2006 at 15:04") // Standard format
+}
+
+// highlight escapes text for HTML and wraps each case-insensitive match of any word in
+// query in <mark>. Escaping happens before the marks are added, piece by piece, so
+// neither the text nor the query can inject markup.
+func highlight(text, query string) template.HTML {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	re, err := regexp.Compile(`(?i)` + strings.Join(words, "|"))
+	if err != nil { // Can't happen with quoted words, but never fail the page over it.
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:match[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[match[0]:match[1]]))
+		b.WriteString("</mark>")
+		last = match[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(b.String())
+}
+
+// commonTimeZones are the zones offered on the profile page. Any IANA name is accepted
+// by the server; this list just keeps the selector a manageable length.
+var commonTimeZones = []string{
+	"UTC",
+	"America/Belize", "America/Los_Angeles", "America/Denver", "America/Chicago",
+	"America/New_York", "America/Mexico_City", "America/Bogota", "America/Sao_Paulo",
+	"Europe/London", "Europe/Paris", "Europe/Berlin", "Europe/Moscow",
+	"Africa/Lagos", "Africa/Nairobi", "Asia/Dubai", "Asia/Kolkata",
+	"Asia/Singapore", "Asia/Shanghai", "Asia/Tokyo", "Australia/Sydney", "Pacific/Auckland",
+}
+
+// timeZoneOptions returns commonTimeZones with current added if it isn't already in
+// the list, so a zone saved some other way still shows as selected.
+func timeZoneOptions(current string) []string {
+	if current == "" || slices.Contains(commonTimeZones, current) {
+		return commonTimeZones
+	}
+	return append(slices.Clone(commonTimeZones), current)
+}
+
 var functions = template.FuncMap{
 	"GetEmotionDetails": func(emotionName string) EmotionDetails {
 		if details, ok := EmotionMap[emotionName]; ok {
@@ -54,11 +120,20 @@ var functions = template.FuncMap{
 		return EmotionDetails{Name: emotionName, Emoji: "❓", Color: "emotion-unknown"}
 	},
 	"HumanDate": func(t time.Time) string {
-		if t.IsZero() {
-			return ""
-		}
-		return t.Format("Jan 02, 2006 at 15:04") // Standard format
+		return humanDate(t, "24h")
 	},
+	// FormatDate is HumanDate with the user's clock preference.
+	// Usage: {{FormatDate .CreatedAt $.TimeFormat}}
+	"FormatDate": humanDate,
+	// EmotionFilterValue encodes an emotion/emoji pair for the dashboard emotion filter.
+	// Usage: {{EmotionFilterValue .Name .Emoji}}
+	"EmotionFilterValue": data.EncodeEmotionFilter,
+	// highlight marks search matches in plain text, escaping everything else.
+	// Usage: {{highlight .PreviewText $.SearchQuery}}
+	"highlight": highlight,
+	// TimeZoneOptions lists the zones for the profile's time zone selector.
+	// Usage: {{range TimeZoneOptions .User.TimeZone}}
+	"TimeZoneOptions": timeZoneOptions,
 	"AddMinutes": func(t time.Time, minutes int) time.Time {
 		return t.Add(time.Duration(minutes) * time.Minute)
 	},
@@ -0,0 +1,56 @@
+// mood/cmd/web/templates_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanDate(t *testing.T) {
+	tm := time.Date(2024, 3, 17, 15, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		tm         time.Time
+		timeFormat string
+		want       string
+	}{
+		{"24h", tm, "24h", "Mar 17, 2024 at 15:04"},
+		{"12h", tm, "12h", "Mar 17, 2024 at 3:04 PM"},
+		{"12hMorning", tm.Add(-12 * time.Hour), "12h", "Mar 17, 2024 at 3:04 AM"},
+		{"UnknownFallsBackTo24h", tm, "", "Mar 17, 2024 at 15:04"},
+		{"Zero", time.Time{}, "12h", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := humanDate(tt.tm, tt.timeFormat); got != tt.want {
+				t.Errorf("humanDate(%v, %q) = %q, want %q", tt.tm, tt.timeFormat, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{"EmptyQuery", "a <b> day", "", "a &lt;b&gt; day"},
+		{"CaseInsensitive", "Sunny day, sunny mood", "SUNNY", "<mark>Sunny</mark> day, <mark>sunny</mark> mood"},
+		{"MultipleWords", "calm and happy", "happy calm", "<mark>calm</mark> and <mark>happy</mark>"},
+		{"EscapesText", "<script>alert(1)</script> ok", "ok", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>ok</mark>"},
+		{"EscapesMatch", "x <b> y", "<b>", "x <mark>&lt;b&gt;</mark> y"},
+		{"RegexMetacharacters", "cost (a+b)", "(a+b)", "cost <mark>(a+b)</mark>"},
+		{"Multibyte", "Café CAFÉ", "café", "<mark>Café</mark> <mark>CAFÉ</mark>"},
+		{"NoMatch", "rainy", "sun", "rainy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(highlight(tt.text, tt.query)); got != tt.want {
+				t.Errorf("highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+			}
+		})
+	}
+}
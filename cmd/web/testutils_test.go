@@ -0,0 +1,167 @@
+// mood/cmd/web/testutils_test.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golangcollege/sessions"
+	_ "github.com/lib/pq"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// newTestApplication builds an application with just enough dependencies
+// (logger + session manager) for handler tests that don't touch the database.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	sessionManager := sessions.New([]byte("s6Ndh+pPbnzHbS*+9Pk8qGWhTzbpa@ge"))
+	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Secure = true
+
+	return &application{
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		session: sessionManager,
+	}
+}
+
+// sentEmail records one call to stubMailer.Send.
+type sentEmail struct {
+	Recipient    string
+	TemplateName string
+	Data         any
+}
+
+// stubMailer is a mailer.Mailer that records messages instead of sending them.
+// If err is set, Send records the message and then returns err.
+type stubMailer struct {
+	mu   sync.Mutex
+	sent []sentEmail
+	err  error
+}
+
+func (m *stubMailer) Send(recipient, templateName string, data any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentEmail{Recipient: recipient, TemplateName: templateName, Data: data})
+	return m.err
+}
+
+// Sent returns a copy of the messages recorded so far.
+func (m *stubMailer) Sent() []sentEmail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]sentEmail(nil), m.sent...)
+}
+
+// newTestTemplateCache parses the real templates under ui/html. newTemplateCache uses
+// paths relative to the repo root, so the working directory is switched while it runs.
+func newTestTemplateCache(t *testing.T) map[string]*template.Template {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cache, err := newTemplateCache()
+	if err != nil {
+		t.Fatalf("Failed to build template cache: %v", err)
+	}
+	return cache
+}
+
+// newSessionRequest creates a request with an in-memory session attached,
+// so handlers can be called directly without the session middleware.
+func newSessionRequest(t *testing.T, method, target string, body io.Reader) *http.Request {
+	t.Helper()
+	return sessions.MockRequest(httptest.NewRequest(method, target, body))
+}
+
+// postForm calls handler with a form-encoded POST and returns the recorder.
+func postForm(t *testing.T, app *application, handler http.HandlerFunc, target string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	r := newSessionRequest(t, http.MethodPost, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler(rr, r)
+	return rr
+}
+
+// newTestDB connects to the test database defined by MOODNOTES_TEST_DB_DSN.
+// Handler tests that need real queries are integration tests, like the ones in internal/data.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	dsn := os.Getenv("MOODNOTES_TEST_DB_DSN")
+	if dsn == "" {
+		t.Fatal("MOODNOTES_TEST_DB_DSN environment variable not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open test database connection: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		t.Fatalf("Failed to ping test database: %s", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("TRUNCATE TABLE moods RESTART IDENTITY CASCADE")
+		db.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE")
+		db.Close()
+	})
+	return db
+}
+
+// newTestApplicationWithDB is newTestApplication plus the database-backed models.
+func newTestApplicationWithDB(t *testing.T) *application {
+	t.Helper()
+	db := newTestDB(t)
+	app := newTestApplication(t)
+	app.moods = &data.MoodModel{DB: db}
+	app.users = &data.UserModel{DB: db}
+	app.savedViews = &data.SavedViewModel{DB: db}
+	app.emotions = &data.EmotionModel{DB: db}
+	app.resets = &data.PasswordResetModel{DB: db}
+	app.activations = &data.ActivationModel{DB: db}
+	app.emailChanges = &data.EmailChangeModel{DB: db}
+	app.emotionCache = newEmotionCache(time.Minute, app.moods.GetDistinctEmotionDetails)
+	return app
+}
+
+// insertTestUser creates an activated user and returns its ID.
+func insertTestUser(t *testing.T, app *application) int64 {
+	t.Helper()
+	user := &data.User{
+		Name:      "Test User",
+		Email:     fmt.Sprintf("testuser_%d@example.com", time.Now().UnixNano()),
+		Activated: true,
+	}
+	if err := user.Password.Set("pa55word123"); err != nil {
+		t.Fatalf("Failed to set test user password: %v", err)
+	}
+	if err := app.users.Insert(context.Background(), user); err != nil {
+		t.Fatalf("Failed to insert test user: %v", err)
+	}
+	return user.ID
+}
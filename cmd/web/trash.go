@@ -0,0 +1,91 @@
+// mood/cmd/web/trash.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+// showTrash handles GET /mood/trash, listing the user's deleted entries with a
+// restore button for each.
+func (app *application) showTrash(w http.ResponseWriter, r *http.Request) {
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	moods, err := app.moods.GetDeleted(r.Context(), userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	templateData := app.newTemplateData(r)
+	templateData.Title = "Trash - Feel Flow"
+	templateData.DisplayMoods = newDisplayMoods(moods, previewLength(app.currentUser(r)))
+	err = app.render(w, http.StatusOK, "trash.tmpl", templateData)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// restoreMood handles POST /mood/restore/{id}, taking an entry back out of the trash.
+// Entries that aren't in the user's trash are a 404, like editing someone else's entry.
+func (app *application) restoreMood(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	userID := app.getUserIDFromSession(r)
+	if userID == 0 {
+		app.clientError(w, http.StatusUnauthorized)
+		return
+	}
+
+	err = app.moods.Restore(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	app.invalidateEmotions(userID)
+	app.logger.Info("Mood entry restored from trash", "id", id, "userID", userID)
+
+	app.session.Put(r, "flash", "Mood entry restored.")
+	app.redirectAfterForm(w, r, "/mood/trash")
+}
+
+// purgeTrash permanently removes entries trashed more than data.TrashRetention ago.
+func (app *application) purgeTrash(ctx context.Context) {
+	purged, err := app.moods.PurgeDeleted(ctx, data.TrashRetention)
+	if err != nil {
+		app.logger.Error("Failed to purge trashed mood entries", "error", err)
+		return
+	}
+	if purged > 0 {
+		app.logger.Info("Purged trashed mood entries", "count", purged)
+	}
+}
+
+// startTrashPurge runs purgeTrash now and then every period for the life of the process.
+func (app *application) startTrashPurge(period time.Duration) {
+	go func() {
+		app.purgeTrash(context.Background())
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			app.purgeTrash(context.Background())
+		}
+	}()
+}
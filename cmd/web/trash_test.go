@@ -0,0 +1,87 @@
+// mood/cmd/web/trash_test.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mickali02/mood/internal/data"
+)
+
+func TestShowTrash_Unauthenticated(t *testing.T) {
+	app := newTestApplication(t)
+	r := newSessionRequest(t, http.MethodGet, "/mood/trash", nil)
+	rr := httptest.NewRecorder()
+
+	app.requireAuthentication(http.HandlerFunc(app.showTrash)).ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, rr.Code)
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	app := newTestApplicationWithDB(t)
+	app.templateCache = newTestTemplateCache(t)
+	userID := insertTestUser(t, app)
+	otherUserID := insertTestUser(t, app)
+
+	mood := &data.Mood{Title: "Trashed entry", Content: "<p>oops</p>", Emotion: "Sad", Emoji: "😢", Color: "#5C8DDE", UserID: userID}
+	if err := app.moods.Insert(context.Background(), mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	if err := app.moods.Delete(context.Background(), mood.ID, userID); err != nil {
+		t.Fatalf("Setup delete failed: %v", err)
+	}
+	idStr := strconv.FormatInt(mood.ID, 10)
+
+	newRestoreRequest := func(asUser int64) *http.Request {
+		r := newSessionRequest(t, http.MethodPost, "/mood/restore/"+idStr, nil)
+		r.SetPathValue("id", idStr)
+		app.session.Put(r, "authenticatedUserID", asUser)
+		return r
+	}
+
+	t.Run("ListsTrashedEntries", func(t *testing.T) {
+		r := newSessionRequest(t, http.MethodGet, "/mood/trash", nil)
+		app.session.Put(r, "authenticatedUserID", userID)
+		rr := httptest.NewRecorder()
+		app.showTrash(rr, r)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		body := rr.Body.String()
+		for _, want := range []string{"Trashed entry", "/mood/restore/" + idStr} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected body to contain %q", want)
+			}
+		}
+	})
+
+	t.Run("RestoreNotOwned", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.restoreMood(rr, newRestoreRequest(otherUserID))
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		app.restoreMood(rr, newRestoreRequest(userID))
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+		}
+		if loc := rr.Header().Get("Location"); loc != "/mood/trash" {
+			t.Errorf("Expected redirect to /mood/trash, got %q", loc)
+		}
+		if _, err := app.moods.Get(context.Background(), mood.ID, userID); err != nil {
+			t.Errorf("Expected the entry to be live again, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+// mood/internal/data/activation.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ActivationTTL is how long an emailed activation link stays valid.
+const ActivationTTL = 3 * 24 * time.Hour
+
+// ActivationModel wraps the database pool for activation_tokens queries.
+type ActivationModel struct {
+	DB *sql.DB
+}
+
+func (m *ActivationModel) store() tokenStore {
+	return tokenStore{db: m.DB, table: "activation_tokens"}
+}
+
+// New creates an activation token for userID that expires after ttl and returns its
+// plaintext, which should only ever be emailed to the user. Only the token's hash is stored.
+func (m *ActivationModel) New(ctx context.Context, userID int64, ttl time.Duration) (string, error) {
+	return m.store().new(ctx, userID, ttl)
+}
+
+// GetUserID returns the ID of the user a token was issued to. It returns ErrRecordNotFound
+// if the token is unknown, already used or expired.
+func (m *ActivationModel) GetUserID(ctx context.Context, plaintext string) (int64, error) {
+	return m.store().getUserID(ctx, plaintext)
+}
+
+// DeleteAllForUser removes every activation token issued to userID, used once the
+// account is activated so no activation link can be replayed.
+func (m *ActivationModel) DeleteAllForUser(ctx context.Context, userID int64) error {
+	return m.store().deleteAllForUser(ctx, userID)
+}
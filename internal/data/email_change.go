@@ -0,0 +1,39 @@
+// mood/internal/data/email_change.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// EmailChangeTTL is how long an emailed email-change confirmation link stays valid.
+const EmailChangeTTL = 24 * time.Hour
+
+// EmailChangeModel wraps the database pool for email_change_tokens queries. Each token
+// confirms the users.pending_email of the user it was issued to.
+type EmailChangeModel struct {
+	DB *sql.DB
+}
+
+func (m *EmailChangeModel) store() tokenStore {
+	return tokenStore{db: m.DB, table: "email_change_tokens"}
+}
+
+// New creates an email change token for userID that expires after ttl and returns its
+// plaintext, which should only ever be emailed to the new address. Only the token's hash is stored.
+func (m *EmailChangeModel) New(ctx context.Context, userID int64, ttl time.Duration) (string, error) {
+	return m.store().new(ctx, userID, ttl)
+}
+
+// GetUserID returns the ID of the user a token was issued to. It returns ErrRecordNotFound
+// if the token is unknown, already used or expired.
+func (m *EmailChangeModel) GetUserID(ctx context.Context, plaintext string) (int64, error) {
+	return m.store().getUserID(ctx, plaintext)
+}
+
+// DeleteAllForUser removes every email change token issued to userID, used once a change
+// is confirmed or replaced by a newer request, so only the latest link can work.
+func (m *EmailChangeModel) DeleteAllForUser(ctx context.Context, userID int64) error {
+	return m.store().deleteAllForUser(ctx, userID)
+}
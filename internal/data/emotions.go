@@ -0,0 +1,127 @@
+// mood/internal/data/emotions.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// ErrTooManyEmotions is returned when a user already has MaxCustomEmotions saved.
+var ErrTooManyEmotions = errors.New("too many custom emotions")
+
+// MaxCustomEmotions caps how many custom emotions a user can save, so the picker stays usable.
+const MaxCustomEmotions = 30
+
+// ValidateCustomEmotion checks a custom emotion before it is saved. Besides the rules
+// every entry's emotion follows, its name must not shadow one of the ValidEmotions.
+func ValidateCustomEmotion(v *validator.Validator, emotion *EmotionDetail) {
+	validateEmotionFields(v, emotion.Name, emotion.Emoji, emotion.Color)
+	for _, builtIn := range ValidEmotions {
+		if strings.EqualFold(strings.TrimSpace(emotion.Name), builtIn) {
+			v.AddError("emotion", "is already one of the built-in emotions")
+			break
+		}
+	}
+}
+
+// EmotionModel wraps the database pool for user_emotions: the custom emotions a user
+// has saved to their picker. They're kept independently of entries, so a custom
+// emotion stays available after every entry using it has been deleted.
+type EmotionModel struct {
+	DB *sql.DB
+}
+
+// Save stores a custom emotion for the user. Saving a name the user already has
+// replaces its emoji and color. It returns ErrTooManyEmotions once the user has
+// MaxCustomEmotions and the name is new.
+func (m *EmotionModel) Save(ctx context.Context, userID int64, emotion EmotionDetail) error {
+	if userID < 1 {
+		return errors.New("invalid user ID provided for custom emotion save")
+	}
+
+	// The limit only counts other names, so updating an existing emotion always works.
+	query := `
+        INSERT INTO user_emotions (user_id, name, emoji, color)
+        SELECT $1::bigint, $2::text, $3::text, $4::text
+        WHERE (SELECT COUNT(*) FROM user_emotions WHERE user_id = $1 AND name <> $2) < $5
+        ON CONFLICT (user_id, name) DO UPDATE SET emoji = EXCLUDED.emoji, color = EXCLUDED.color
+        RETURNING id`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var id int64
+	err := m.DB.QueryRowContext(ctx, query, userID, emotion.Name, emotion.Emoji, emotion.Color, MaxCustomEmotions).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTooManyEmotions
+		}
+		return fmt.Errorf("custom emotion save: %w", err)
+	}
+	return nil
+}
+
+// GetAllForUser returns the user's saved custom emotions ordered by name.
+func (m *EmotionModel) GetAllForUser(ctx context.Context, userID int64) ([]EmotionDetail, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT name, emoji, color
+        FROM user_emotions
+        WHERE user_id = $1
+        ORDER BY name ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("custom emotions query: %w", err)
+	}
+	defer rows.Close()
+
+	emotions := []EmotionDetail{}
+	for rows.Next() {
+		var emotion EmotionDetail
+		if err := rows.Scan(&emotion.Name, &emotion.Emoji, &emotion.Color); err != nil {
+			return nil, fmt.Errorf("custom emotions scan: %w", err)
+		}
+		emotions = append(emotions, emotion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("custom emotions rows: %w", err)
+	}
+	return emotions, nil
+}
+
+// Delete removes one of the user's custom emotions by name. Entries that use it are
+// untouched. It returns ErrRecordNotFound if the user has no emotion by that name.
+func (m *EmotionModel) Delete(ctx context.Context, userID int64, name string) error {
+	if userID < 1 || name == "" {
+		return ErrRecordNotFound
+	}
+	query := `DELETE FROM user_emotions WHERE user_id = $1 AND name = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, name)
+	if err != nil {
+		return fmt.Errorf("custom emotion delete: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("custom emotion delete rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
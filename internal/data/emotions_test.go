@@ -0,0 +1,105 @@
+// internal/data/emotions_test.go
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mickali02/mood/internal/validator"
+)
+
+func TestValidateCustomEmotion(t *testing.T) {
+	tests := []struct {
+		name      string
+		emotion   EmotionDetail
+		wantField string // "" means valid
+	}{
+		{"Valid", EmotionDetail{Name: "Grateful", Emoji: "🙏", Color: "#8E7CC3"}, ""},
+		{"MissingName", EmotionDetail{Name: " ", Emoji: "🙏", Color: "#8E7CC3"}, "emotion"},
+		{"BuiltInName", EmotionDetail{Name: "happy", Emoji: "🙏", Color: "#8E7CC3"}, "emotion"},
+		{"NotAnEmoji", EmotionDetail{Name: "Grateful", Emoji: "ab", Color: "#8E7CC3"}, "emoji"},
+		{"BadColor", EmotionDetail{Name: "Grateful", Emoji: "🙏", Color: "purple"}, "color"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.NewValidator()
+			ValidateCustomEmotion(v, &tt.emotion)
+			if tt.wantField == "" {
+				if !v.ValidData() {
+					t.Errorf("Expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.wantField]; !ok {
+				t.Errorf("Expected an error for %q, got %v", tt.wantField, v.Errors)
+			}
+		})
+	}
+}
+
+func TestEmotionModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	ownerID := insertTestUser(t, db)
+	otherID := insertTestUser(t, db)
+	model := EmotionModel{DB: db}
+	ctx := context.Background()
+
+	grateful := EmotionDetail{Name: "Grateful", Emoji: "🙏", Color: "#8E7CC3"}
+	if err := model.Save(ctx, ownerID, grateful); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := model.Save(ctx, ownerID, EmotionDetail{Name: "Bored", Emoji: "🥱", Color: "#999999"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Saving an existing name updates it instead of failing.
+	grateful.Color = "#6A4FB3"
+	if err := model.Save(ctx, ownerID, grateful); err != nil {
+		t.Fatalf("Save (update): %v", err)
+	}
+
+	got, err := model.GetAllForUser(ctx, ownerID)
+	if err != nil {
+		t.Fatalf("GetAllForUser: %v", err)
+	}
+	want := []EmotionDetail{{Name: "Bored", Emoji: "🥱", Color: "#999999"}, grateful}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllForUser = %+v, want %+v", got, want)
+	}
+
+	// Emotions are per user.
+	if others, _ := model.GetAllForUser(ctx, otherID); len(others) != 0 {
+		t.Errorf("Expected no emotions for another user, got %+v", others)
+	}
+	if err := model.Delete(ctx, otherID, "Grateful"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Delete by another user = %v, want ErrRecordNotFound", err)
+	}
+
+	if err := model.Delete(ctx, ownerID, "Grateful"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := model.Delete(ctx, ownerID, "Grateful"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Second Delete = %v, want ErrRecordNotFound", err)
+	}
+
+	// The limit applies to new names only.
+	for i := 0; i < MaxCustomEmotions; i++ {
+		if err := model.Save(ctx, otherID, EmotionDetail{Name: fmt.Sprintf("Custom %d", i), Emoji: "🙂", Color: "#cccccc"}); err != nil {
+			t.Fatalf("Save #%d: %v", i, err)
+		}
+	}
+	if err := model.Save(ctx, otherID, EmotionDetail{Name: "One too many", Emoji: "🙂", Color: "#cccccc"}); !errors.Is(err, ErrTooManyEmotions) {
+		t.Errorf("Save over the limit = %v, want ErrTooManyEmotions", err)
+	}
+	if err := model.Save(ctx, otherID, EmotionDetail{Name: "Custom 1", Emoji: "😀", Color: "#cccccc"}); err != nil {
+		t.Errorf("Updating at the limit = %v, want nil", err)
+	}
+}
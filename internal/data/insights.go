@@ -0,0 +1,90 @@
+// mood/internal/data/insights.go
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateInsight turns a user's stats into a short, human-readable summary for the
+// stats page, e.g. "You've logged 12 entries and felt Happy most often."
+// It is a pure function of stats so it can be tested without a database.
+func GenerateInsight(stats *MoodStats) string {
+	// 1. No Data: Nudge the user to start logging.
+	if stats == nil || stats.TotalEntries == 0 {
+		return "Log your first mood to start seeing insights."
+	}
+
+	// 2. Single Entry: Too early for patterns, just acknowledge it.
+	if stats.TotalEntries == 1 {
+		if name := insightEmotion(stats); name != "" {
+			return fmt.Sprintf("You've logged your first mood and felt %s. Keep checking in to see patterns emerge.", name)
+		}
+		return "You've logged your first mood. Keep checking in to see patterns emerge."
+	}
+
+	// 3. Summary: Entry count and the most common emotion(s).
+	var b strings.Builder
+	fmt.Fprintf(&b, "You've logged %d entries", stats.TotalEntries)
+	if top := topEmotions(stats.EmotionCounts); len(top) > 0 {
+		fmt.Fprintf(&b, " and felt %s most often", joinWithAnd(top))
+	}
+	b.WriteString(".")
+
+	// 4. Trend: Compare the two most recent weeks that have entries.
+	if n := len(stats.WeeklyCounts); n >= 2 {
+		latest, previous := stats.WeeklyCounts[n-1].Count, stats.WeeklyCounts[n-2].Count
+		switch {
+		case latest > previous:
+			b.WriteString(" You're checking in more often than the week before.")
+		case latest < previous:
+			b.WriteString(" You've checked in a little less than the week before.")
+		}
+	}
+
+	// 5. Streak: Celebrate an unbroken run of days, once there is more than one.
+	if stats.CurrentStreak >= 2 {
+		fmt.Fprintf(&b, " You've logged %d days in a row.", stats.CurrentStreak)
+	}
+
+	return b.String()
+}
+
+// insightEmotion returns the best available emotion name for a single-entry summary.
+func insightEmotion(stats *MoodStats) string {
+	if stats.MostCommonEmotion != nil {
+		return stats.MostCommonEmotion.Name
+	}
+	if stats.LatestMood != nil {
+		return stats.LatestMood.Emotion
+	}
+	return ""
+}
+
+// topEmotions returns the names of every emotion tied for the highest count,
+// assuming counts are ordered by frequency (as GetEmotionCounts returns them).
+func topEmotions(counts []EmotionCount) []string {
+	if len(counts) == 0 || counts[0].Count == 0 {
+		return nil
+	}
+	names := []string{counts[0].Name}
+	for _, c := range counts[1:] {
+		if c.Count != counts[0].Count {
+			break
+		}
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// joinWithAnd joins names as "A", "A and B", or "A, B and C".
+func joinWithAnd(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+	}
+}
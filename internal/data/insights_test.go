@@ -0,0 +1,117 @@
+// internal/data/insights_test.go
+package data
+
+import "testing"
+
+func TestGenerateInsight(t *testing.T) {
+	happy := EmotionCount{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 5}
+
+	tests := []struct {
+		name  string
+		stats *MoodStats
+		want  string
+	}{
+		{
+			name:  "NilStats",
+			stats: nil,
+			want:  "Log your first mood to start seeing insights.",
+		},
+		{
+			name:  "NoEntries",
+			stats: &MoodStats{},
+			want:  "Log your first mood to start seeing insights.",
+		},
+		{
+			name: "SingleEntry",
+			stats: &MoodStats{
+				TotalEntries:      1,
+				MostCommonEmotion: &EmotionCount{Name: "Calm", Count: 1},
+				EmotionCounts:     []EmotionCount{{Name: "Calm", Count: 1}},
+			},
+			want: "You've logged your first mood and felt Calm. Keep checking in to see patterns emerge.",
+		},
+		{
+			name:  "SingleEntryFromLatestMood",
+			stats: &MoodStats{TotalEntries: 1, LatestMood: &Mood{Emotion: "Anxious"}},
+			want:  "You've logged your first mood and felt Anxious. Keep checking in to see patterns emerge.",
+		},
+		{
+			name:  "SingleEntryNoEmotion",
+			stats: &MoodStats{TotalEntries: 1},
+			want:  "You've logged your first mood. Keep checking in to see patterns emerge.",
+		},
+		{
+			name: "MostCommonNoTrend",
+			stats: &MoodStats{
+				TotalEntries:      7,
+				MostCommonEmotion: &happy,
+				EmotionCounts:     []EmotionCount{happy, {Name: "Sad", Count: 2}},
+				WeeklyCounts:      []WeeklyCount{{Week: "2024-19", Count: 7}},
+			},
+			want: "You've logged 7 entries and felt Happy most often.",
+		},
+		{
+			name: "TiedMostCommon",
+			stats: &MoodStats{
+				TotalEntries:  9,
+				EmotionCounts: []EmotionCount{{Name: "Calm", Count: 3}, {Name: "Happy", Count: 3}, {Name: "Sad", Count: 3}},
+			},
+			want: "You've logged 9 entries and felt Calm, Happy and Sad most often.",
+		},
+		{
+			name: "TrendUp",
+			stats: &MoodStats{
+				TotalEntries:  6,
+				EmotionCounts: []EmotionCount{{Name: "Happy", Count: 4}, {Name: "Calm", Count: 2}},
+				WeeklyCounts:  []WeeklyCount{{Week: "2024-18", Count: 2}, {Week: "2024-19", Count: 4}},
+			},
+			want: "You've logged 6 entries and felt Happy most often. You're checking in more often than the week before.",
+		},
+		{
+			name: "TrendDown",
+			stats: &MoodStats{
+				TotalEntries:  6,
+				EmotionCounts: []EmotionCount{{Name: "Happy", Count: 4}, {Name: "Calm", Count: 2}},
+				WeeklyCounts:  []WeeklyCount{{Week: "2024-17", Count: 1}, {Week: "2024-18", Count: 4}, {Week: "2024-19", Count: 1}},
+			},
+			want: "You've logged 6 entries and felt Happy most often. You've checked in a little less than the week before.",
+		},
+		{
+			name: "TrendFlat",
+			stats: &MoodStats{
+				TotalEntries:  4,
+				EmotionCounts: []EmotionCount{{Name: "Sad", Count: 3}, {Name: "Calm", Count: 1}},
+				WeeklyCounts:  []WeeklyCount{{Week: "2024-18", Count: 2}, {Week: "2024-19", Count: 2}},
+			},
+			want: "You've logged 4 entries and felt Sad most often.",
+		},
+		{
+			name: "Streak",
+			stats: &MoodStats{
+				TotalEntries:  5,
+				EmotionCounts: []EmotionCount{{Name: "Calm", Count: 5}},
+				WeeklyCounts:  []WeeklyCount{{Week: "2024-18", Count: 1}, {Week: "2024-19", Count: 4}},
+				CurrentStreak: 4,
+			},
+			want: "You've logged 5 entries and felt Calm most often. You're checking in more often than the week before. You've logged 4 days in a row.",
+		},
+		{
+			name:  "SingleDayIsNotAStreak",
+			stats: &MoodStats{TotalEntries: 2, CurrentStreak: 1},
+			want:  "You've logged 2 entries.",
+		},
+		{
+			name:  "NoEmotionCounts",
+			stats: &MoodStats{TotalEntries: 3},
+			want:  "You've logged 3 entries.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateInsight(tt.stats); got != tt.want {
+				t.Errorf("GenerateInsight() =\n  %q\nwant\n  %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,20 +6,46 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
 	"math"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/lib/pq"
 	"github.com/mickali02/mood/internal/validator"
-	"github.com/microcosm-cc/bluemonday"
 )
 
 // ValidEmotions defines a list of pre-approved emotion names.
 // Presentation Point: "A predefined set of emotions, could be used for default choices or validation."
 var ValidEmotions = []string{"Happy", "Sad", "Angry", "Anxious", "Calm", "Excited", "Neutral"}
 
+// BuiltInEmotions holds the canonical emoji and color for each of the ValidEmotions.
+// ValidateMood holds entries to these, so only custom emotions can pick their own.
+var BuiltInEmotions = map[string]EmotionDetail{
+	"Happy":   {Name: "Happy", Emoji: "😊", Color: "#FFCA28"},
+	"Sad":     {Name: "Sad", Emoji: "😢", Color: "#5C8DDE"},
+	"Angry":   {Name: "Angry", Emoji: "😠", Color: "#E53935"},
+	"Anxious": {Name: "Anxious", Emoji: "😟", Color: "#FFA000"},
+	"Calm":    {Name: "Calm", Emoji: "😌", Color: "#69B36C"},
+	"Excited": {Name: "Excited", Emoji: "🤩", Color: "#F06292"},
+	"Neutral": {Name: "Neutral", Emoji: "😐", Color: "#A4B8D0"},
+}
+
+// builtInEmotion returns the canonical details of the built-in emotion called name,
+// ignoring case and surrounding spaces the same way ValidateCustomEmotion does.
+func builtInEmotion(name string) (EmotionDetail, bool) {
+	name = strings.TrimSpace(name)
+	for key, details := range BuiltInEmotions {
+		if strings.EqualFold(name, key) {
+			return details, true
+		}
+	}
+	return EmotionDetail{}, false
+}
+
 // --- Struct Definitions ---
 // These structs define the shape of our data, both for database interaction and for display (like stats).
 
@@ -47,52 +73,189 @@ type WeeklyCount struct {
 	Count int    `json:"count"`
 }
 
+// WeeklyEmotionCount stores how many times an emotion was logged in a specific week.
+// Used for the emotion trend over time, the weekly version of EmotionCount.
+type WeeklyEmotionCount struct {
+	Week    string `json:"week"` // e.g., "2024-23" (Year-WeekNumber), as in WeeklyCount.
+	Emotion string `json:"emotion"`
+	Count   int    `json:"count"`
+}
+
+// MonthlyCount stores the count of mood entries for a calendar month.
+type MonthlyCount struct {
+	Month string `json:"month"` // e.g., "2024-05" (Year-Month)
+	Count int    `json:"count"`
+}
+
+// WeekdayCount stores the count of mood entries logged on a day of the week.
+type WeekdayCount struct {
+	Weekday int    `json:"weekday"` // 0 = Sunday ... 6 = Saturday, matching FilterCriteria.Weekday.
+	Name    string `json:"name"`    // e.g., "Monday"
+	Count   int    `json:"count"`
+}
+
+// HourlyCount stores the count of mood entries logged in an hour of the day, on the user's clock.
+type HourlyCount struct {
+	Hour  int `json:"hour"` // 0-23
+	Count int `json:"count"`
+}
+
+// DailyCount stores the number of entries logged on one calendar day, for the heatmap.
+type DailyCount struct {
+	Date  string `json:"date"` // e.g., "2024-05-10" (calendar day in the requested time zone)
+	Count int    `json:"count"`
+}
+
+// EmotionPairCount stores how many days two different emotions were both logged.
+// Used for the "often felt together" insight on the stats page.
+type EmotionPairCount struct {
+	First  string `json:"first"`  // Alphabetically first emotion of the pair.
+	Second string `json:"second"` // The other emotion.
+	Days   int    `json:"days"`   // Number of days both were logged.
+}
+
 // MoodStats aggregates all statistics for the stats page.
 // This struct is populated and passed to the stats template.
 type MoodStats struct {
-	TotalEntries      int            `json:"totalEntries"`      // Total number of mood entries.
-	MostCommonEmotion *EmotionCount  `json:"mostCommonEmotion"` // Pointer to the most frequent emotion.
-	EmotionCounts     []EmotionCount `json:"emotionCounts"`     // Slice of all emotion counts.
-	WeeklyCounts      []WeeklyCount  `json:"weeklyCounts"`      // Mood entries count per week.
-	LatestMood        *Mood          `json:"latestMood"`        // Pointer to the most recently logged mood.
-	AvgEntriesPerWeek float64        `json:"avgEntriesPerWeek"` // Average number of entries logged per week.
+	TotalEntries      int                  `json:"totalEntries"`      // Total number of mood entries.
+	MostCommonEmotion *EmotionCount        `json:"mostCommonEmotion"` // Pointer to the most frequent emotion.
+	EmotionCounts     []EmotionCount       `json:"emotionCounts"`     // Slice of all emotion counts.
+	WeeklyCounts      []WeeklyCount        `json:"weeklyCounts"`      // Mood entries count per week.
+	EmotionTrend      []WeeklyEmotionCount `json:"emotionTrend"`      // Count of each emotion per week.
+	LatestMood        *Mood                `json:"latestMood"`        // Pointer to the most recently logged mood.
+	AvgEntriesPerWeek float64              `json:"avgEntriesPerWeek"` // Average number of entries logged per week.
+	SameDayPairs      []EmotionPairCount   `json:"sameDayPairs"`      // Emotions most often logged on the same day.
+	MonthlyCounts     []MonthlyCount       `json:"monthlyCounts"`     // Mood entries count per month.
+	WeekdayCounts     []WeekdayCount       `json:"weekdayCounts"`     // Entries per day of the week, always 7 (Sunday first).
+	HourlyCounts      []HourlyCount        `json:"hourlyCounts"`      // Entries per hour of the day, always 24.
+	TotalWords        int                  `json:"totalWords"`        // Words written across all entries' content.
+	AvgWordsPerEntry  float64              `json:"avgWordsPerEntry"`  // TotalWords divided by the number of entries.
+	CurrentStreak     int                  `json:"currentStreak"`     // Consecutive days with an entry, ending today or yesterday.
+	LongestStreak     int                  `json:"longestStreak"`     // Most consecutive days with an entry, ever.
+	EntriesThisWeek   int                  `json:"entriesThisWeek"`   // Entries since Monday, in the user's time zone.
+	EntriesThisMonth  int                  `json:"entriesThisMonth"`  // Entries since the 1st of the month.
+	EntriesThisYear   int                  `json:"entriesThisYear"`   // Entries since 1 January.
 }
 
 // FilterCriteria holds parameters for filtering mood entries on the dashboard.
 // This struct encapsulates all criteria used for searching and filtering moods.
 type FilterCriteria struct {
-	TextQuery string    // Search term for title, content, or emotion.
-	Emotion   string    // Specific emotion to filter by (e.g., "Happy::😊").
-	StartDate time.Time // Start of the date range for filtering.
-	EndDate   time.Time // End of the date range.
-	Page      int       // Current page number for pagination.
-	PageSize  int       // Number of entries per page.
-	UserID    int64     // ID of the user whose moods are being filtered (ensures data privacy).
+	TextQuery string         // Search term for title, content, or emotion.
+	Emotion   string         // Specific emotion to filter by (e.g., "Happy::😊").
+	StartDate time.Time      // Start of the date range for filtering.
+	EndDate   time.Time      // End of the date range.
+	Page      int            // Current page number for pagination.
+	PageSize  int            // Number of entries per page.
+	UserID    int64          // ID of the user whose moods are being filtered (ensures data privacy).
+	Weekday   int            // Day of the week to filter by (0 = Sunday ... 6 = Saturday), or AnyWeekday.
+	Location  *time.Location // User's location the date boundaries and weekday are computed in; nil means UTC.
+	Sort      string         // One of the SortOptions keys; empty or unknown means DefaultSort.
+
+	MinIntensity int // Lowest intensity to include; 0 means MoodIntensityMin.
+	MaxIntensity int // Highest intensity to include; 0 means MoodIntensityMax.
+}
+
+// DefaultSort is the dashboard's usual order: newest entries first.
+const DefaultSort = "created_at_desc"
+
+// sortClauses maps each accepted FilterCriteria.Sort value to its ORDER BY clause.
+// GetFiltered interpolates the clause into SQL, so only these fixed strings are
+// ever used; the user's value is just a key. Pinned entries always come first, then
+// ties fall back to newest first, so pagination stays stable.
+var sortClauses = map[string]string{
+	"created_at_desc": "pinned DESC, created_at DESC, id DESC",
+	"created_at_asc":  "pinned DESC, created_at ASC, id ASC",
+	"title_asc":       "pinned DESC, LOWER(title) ASC, created_at DESC, id DESC",
+	"emotion_asc":     "pinned DESC, LOWER(emotion) ASC, created_at DESC, id DESC",
+}
+
+// ValidSort reports whether sort is one of the accepted FilterCriteria.Sort values.
+func ValidSort(sort string) bool {
+	_, ok := sortClauses[sort]
+	return ok
+}
+
+// SortOrders returns the accepted FilterCriteria.Sort values in alphabetical order,
+// for listing them in error messages.
+func SortOrders() []string {
+	orders := make([]string, 0, len(sortClauses))
+	for order := range sortClauses {
+		orders = append(orders, order)
+	}
+	slices.Sort(orders)
+	return orders
+}
+
+// emotionFilterSeparator joins the name and emoji parts of an encoded emotion filter.
+const emotionFilterSeparator = "::"
+
+// EncodeEmotionFilter builds the FilterCriteria.Emotion value for an emotion/emoji pair,
+// e.g. "Happy::%F0%9F%98%8A". Both parts are query-escaped, so a ':' inside a custom
+// emotion name or emoji can never be mistaken for the separator.
+func EncodeEmotionFilter(name, emoji string) string {
+	return url.QueryEscape(name) + emotionFilterSeparator + url.QueryEscape(emoji)
+}
+
+// DecodeEmotionFilter splits a value built by EncodeEmotionFilter back into its parts.
+// ok is false when value has no separator (a plain emotion name) or a part is not
+// validly escaped. Unescaped values like "Happy::😊" from older links still decode.
+func DecodeEmotionFilter(value string) (name, emoji string, ok bool) {
+	rawName, rawEmoji, found := strings.Cut(value, emotionFilterSeparator)
+	if !found {
+		return "", "", false
+	}
+	name, err := url.QueryUnescape(rawName)
+	if err != nil {
+		return "", "", false
+	}
+	emoji, err = url.QueryUnescape(rawEmoji)
+	if err != nil {
+		return "", "", false
+	}
+	return name, emoji, true
 }
 
+// AnyWeekday disables the FilterCriteria.Weekday filter.
+// The zero value of Weekday is Sunday, so callers must set this explicitly.
+const AnyWeekday = -1
+
 // Metadata holds pagination information calculated based on filtered results.
 // Used by templates to render pagination controls (like 'Page 1 of 5').
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`  // Current page being displayed.
-	PageSize     int `json:"page_size,omitempty"`     // Number of items per page.
-	FirstPage    int `json:"first_page,omitempty"`    // Always 1.
-	LastPage     int `json:"last_page,omitempty"`     // The total number of pages.
-	TotalRecords int `json:"total_records,omitempty"` // Total number of records matching the filter.
+	CurrentPage  int  `json:"current_page,omitempty"`  // Current page being displayed.
+	PageSize     int  `json:"page_size,omitempty"`     // Number of items per page.
+	FirstPage    int  `json:"first_page,omitempty"`    // Always 1.
+	LastPage     int  `json:"last_page,omitempty"`     // The total number of pages.
+	TotalRecords int  `json:"total_records,omitempty"` // Total number of records matching the filter.
+	HasPrevious  bool `json:"has_previous"`            // Whether there is a page before CurrentPage.
+	HasNext      bool `json:"has_next"`                // Whether there is a page after CurrentPage.
+	PrevPage     int  `json:"prev_page,omitempty"`     // CurrentPage - 1, or 0 if HasPrevious is false.
+	NextPage     int  `json:"next_page,omitempty"`     // CurrentPage + 1, or 0 if HasNext is false.
 }
 
 // calculateMetadata computes pagination metadata.
 // Helper function to determine total pages, current page, etc., for pagination.
+// page must already be within range; GetFiltered clamps it first.
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
 	if totalRecords == 0 {
 		return Metadata{}
 	}
-	return Metadata{
+	metadata := Metadata{
 		CurrentPage:  page,
 		PageSize:     pageSize,
 		FirstPage:    1,
 		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
 		TotalRecords: totalRecords,
 	}
+	if page > metadata.FirstPage {
+		metadata.HasPrevious = true
+		metadata.PrevPage = page - 1
+	}
+	if page < metadata.LastPage {
+		metadata.HasNext = true
+		metadata.NextPage = page + 1
+	}
+	return metadata
 }
 
 // Mood struct defines the structure of a single mood entry, mapping to the 'moods' database table.
@@ -107,7 +270,42 @@ type Mood struct {
 	Emotion   string    `json:"emotion"`    // Name of the emotion.
 	Emoji     string    `json:"emoji"`      // Emoji representing the emotion.
 	Color     string    `json:"color"`      // Hex color code for the emotion.
+	Intensity int       `json:"intensity"`  // How strongly it was felt, MoodIntensityMin to MoodIntensityMax.
 	UserID    int64     `json:"user_id"`    // Foreign key linking to the 'users' table.
+	Version   int       `json:"version"`    // Incremented on every update, for optimistic locking.
+	Pinned    bool      `json:"pinned"`     // Pinned entries are listed before all others on the dashboard.
+	// PrivateNote is only shown in the owner's edit view. The `json:"-"` tag keeps it out of
+	// every JSON payload, and export queries must not select the private_note column.
+	PrivateNote string `json:"-"`
+	// DeletedAt is when the entry was moved to the trash; nil for live entries.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Field limits enforced by ValidateMood. They are exported so other descriptions of a
+// mood (such as the API schema endpoint) read the same values and can't drift.
+const (
+	MoodTitleMaxLength       = 100    // Max characters in a title.
+	MoodContentMaxLength     = 10000  // Max characters of content text, once HTML is stripped.
+	MoodContentMaxBytes      = 200000 // Max bytes of raw content HTML, checked before sanitizing.
+	MoodEmotionMaxLength     = 50     // Max characters in an emotion name.
+	MoodEmojiMaxRunes        = 4      // Max runes in an emoji (allows ZWJ/variation sequences).
+	MoodPrivateNoteMaxLength = 1000   // Max characters in a private note.
+	MoodIntensityMin         = 1      // Mildest intensity.
+	MoodIntensityMax         = 5      // Strongest intensity.
+	DefaultMoodIntensity     = 3      // Used when no intensity is given; also the column default.
+)
+
+// validateEmotionFields checks an emotion's name, emoji and color, recording errors
+// under "emotion", "emoji" and "color". Shared by entries and saved custom emotions.
+func validateEmotionFields(v *validator.Validator, name, emoji, color string) {
+	v.Check(validator.NotBlank(name), "emotion", "name must be provided")
+	v.Check(validator.MaxLength(name, MoodEmotionMaxLength), "emotion", fmt.Sprintf("name must not be more than %d characters long", MoodEmotionMaxLength))
+	v.Check(validator.NotBlank(emoji), "emoji", "must be provided")
+	v.Check(utf8.RuneCountInString(emoji) >= 1, "emoji", "must contain at least one character")
+	v.Check(utf8.RuneCountInString(emoji) <= MoodEmojiMaxRunes, "emoji", "is too long for a typical emoji")
+	v.Check(validator.ContainsEmoji(emoji), "emoji", "must be an emoji, not letters or punctuation")
+	v.Check(validator.NotBlank(color), "color", "must be provided")
+	v.Check(validator.Matches(color, validator.HexColorRX), "color", "must be a valid hex color code (e.g., #FFD700)")
 }
 
 // ValidateMood checks the mood struct for adherence to business rules (e.g., non-empty fields, max lengths).
@@ -116,55 +314,90 @@ type Mood struct {
 func ValidateMood(v *validator.Validator, mood *Mood) {
 	// Validate Title: must be provided and within length limits.
 	v.Check(validator.NotBlank(mood.Title), "title", "must be provided")
-	v.Check(validator.MaxLength(mood.Title, 100), "title", "must not be more than 100 characters long")
-
-	// Validate Content: Sanitize HTML first, then check if plain text is not blank.
-	p := bluemonday.StrictPolicy() // Use HTML sanitizer.
-	plainTextContent := p.Sanitize(mood.Content)
-	v.Check(validator.NotBlank(plainTextContent), "content", "must be provided")
+	v.Check(validator.MaxLength(mood.Title, MoodTitleMaxLength), "title", fmt.Sprintf("must not be more than %d characters long", MoodTitleMaxLength))
+
+	// Validate Content: Reject oversized HTML before the sanitizer has to parse it, then
+	// strip the HTML and check the text that's left (entities decoded, so "&amp;" is one character).
+	if len(mood.Content) > MoodContentMaxBytes {
+		v.AddError("content", fmt.Sprintf("is too large; entries are limited to %d characters of text", MoodContentMaxLength))
+	} else {
+		plainTextContent := html.UnescapeString(SanitizePreview(mood.Content))
+		v.Check(validator.NotBlank(plainTextContent), "content", "must be provided")
+		v.Check(validator.MaxLength(plainTextContent, MoodContentMaxLength), "content", fmt.Sprintf("must not be more than %d characters long", MoodContentMaxLength))
+	}
 
 	// Validate Emotion fields: name, emoji, color.
-	v.Check(validator.NotBlank(mood.Emotion), "emotion", "name must be provided")
-	v.Check(validator.MaxLength(mood.Emotion, 50), "emotion", "name must not be more than 50 characters long")
-	v.Check(validator.NotBlank(mood.Emoji), "emoji", "must be provided")
-	v.Check(utf8.RuneCountInString(mood.Emoji) >= 1, "emoji", "must contain at least one character")
-	v.Check(utf8.RuneCountInString(mood.Emoji) <= 4, "emoji", "is too long for a typical emoji")
-	v.Check(validator.NotBlank(mood.Color), "color", "must be provided")
-	v.Check(validator.Matches(mood.Color, validator.HexColorRX), "color", "must be a valid hex color code (e.g., #FFD700)")
+	validateEmotionFields(v, mood.Emotion, mood.Emoji, mood.Color)
+
+	// A built-in emotion keeps its own emoji and color, so stats don't split one emotion
+	// into several variants. The emoji comparison ignores the U+FE0F variation selector.
+	if builtIn, ok := builtInEmotion(mood.Emotion); ok {
+		sameEmoji := strings.ReplaceAll(mood.Emoji, "\uFE0F", "") == strings.ReplaceAll(builtIn.Emoji, "\uFE0F", "")
+		v.Check(sameEmoji, "emoji", fmt.Sprintf("must be %s for the built-in %s emotion", builtIn.Emoji, builtIn.Name))
+		v.Check(strings.EqualFold(mood.Color, builtIn.Color), "color", fmt.Sprintf("must be %s for the built-in %s emotion", builtIn.Color, builtIn.Name))
+	}
+
+	// Validate Intensity: a whole number on the 1-5 scale.
+	v.Check(mood.Intensity >= MoodIntensityMin && mood.Intensity <= MoodIntensityMax, "intensity", fmt.Sprintf("must be between %d and %d", MoodIntensityMin, MoodIntensityMax))
+
+	// Validate Private Note: optional, but capped in length.
+	v.Check(validator.MaxLength(mood.PrivateNote, MoodPrivateNoteMaxLength), "private_note", fmt.Sprintf("must not be more than %d characters long", MoodPrivateNoteMaxLength))
+}
+
+// ValidateIntensityRange checks an intensity filter: both bounds on the 1-5 scale, and
+// the lower bound not above the upper one.
+func ValidateIntensityRange(v *validator.Validator, minIntensity, maxIntensity int) {
+	msg := fmt.Sprintf("must be between %d and %d", MoodIntensityMin, MoodIntensityMax)
+	v.Check(minIntensity >= MoodIntensityMin && minIntensity <= MoodIntensityMax, "min_intensity", msg)
+	v.Check(maxIntensity >= MoodIntensityMin && maxIntensity <= MoodIntensityMax, "max_intensity", msg)
+	v.Check(minIntensity <= maxIntensity, "max_intensity", "must not be less than min_intensity")
 }
 
 // MoodModel provides methods for database operations on mood entries.
 // It embeds a `*sql.DB` connection pool.
 // This 'MoodModel' encapsulates all database logic for moods (CRUD operations).
+// Every method takes the caller's context (usually the request's), so a client
+// disconnecting cancels the query; each method still caps it with its own timeout.
 type MoodModel struct {
 	DB *sql.DB
 }
 
 // Insert adds a new mood entry to the database.
 // The 'Create' part of CRUD. Inserts a new mood, returning its generated ID and timestamps.
-func (m *MoodModel) Insert(mood *Mood) error {
+// The write runs in its own transaction via insertMood; callers writing related rows
+// alongside the mood should call insertMood inside withTx instead, so all commit together.
+func (m *MoodModel) Insert(ctx context.Context, mood *Mood) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		return insertMood(ctx, tx, mood)
+	})
+}
+
+// insertMood is Insert's statement, run on the caller's transaction.
+func insertMood(ctx context.Context, tx *sql.Tx, mood *Mood) error {
 	// 1. Validate UserID: Ensure a valid user is associated.
 	if mood.UserID < 1 {
 		return errors.New("invalid user ID provided for mood insert")
 	}
+	// An unset (zero) intensity gets the default, like the column does.
+	if mood.Intensity == 0 {
+		mood.Intensity = DefaultMoodIntensity
+	}
 
 	// 2. SQL Query: Defines the INSERT statement.
 	//    `RETURNING id, created_at, updated_at` gets back DB-generated values.
 	query := `
-        INSERT INTO moods (title, content, emotion, emoji, color, user_id)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id, created_at, updated_at`
+        INSERT INTO moods (title, content, emotion, emoji, color, user_id, private_note, intensity)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at, updated_at, version`
 
 	// 3. Arguments: Prepare arguments for the SQL query.
-	args := []any{mood.Title, mood.Content, mood.Emotion, mood.Emoji, mood.Color, mood.UserID}
+	args := []any{mood.Title, mood.Content, mood.Emotion, mood.Emoji, mood.Color, mood.UserID, mood.PrivateNote, mood.Intensity}
 
-	// 4. Execute Query: Use a context with timeout for resilience.
-	//    `QueryRowContext` executes the query and expects one row in return.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// 5. Scan Results: Populate the mood struct's ID and timestamps from the returned row.
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&mood.ID, &mood.CreatedAt, &mood.UpdatedAt)
+	// 4. Scan Results: Populate the mood struct's ID and timestamps from the returned row.
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&mood.ID, &mood.CreatedAt, &mood.UpdatedAt, &mood.Version)
 	if err != nil {
 		// Handle specific PostgreSQL errors, like foreign key violation (user_id doesn't exist).
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // "23503" is foreign_key_violation.
@@ -178,19 +411,19 @@ func (m *MoodModel) Insert(mood *Mood) error {
 // Get retrieves a specific mood entry by its ID and the owner's UserID.
 // Including UserID ensures users can only access their own moods.
 // The 'Read' part of CRUD. Fetches a single mood, ensuring user ownership.
-func (m *MoodModel) Get(id int64, userID int64) (*Mood, error) {
+func (m *MoodModel) Get(ctx context.Context, id int64, userID int64) (*Mood, error) {
 	// 1. Validate Inputs: Ensure IDs are positive.
 	if id < 1 || userID < 1 {
 		return nil, ErrRecordNotFound // Invalid IDs imply record won't be found.
 	}
 	// 2. SQL Query: Selects a mood by its ID and the user_id.
 	query := `
-        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, user_id
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned, private_note
         FROM moods
-        WHERE id = $1 AND user_id = $2` // Ownership check.
+        WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL` // Ownership check; trashed entries are gone.
 
 	// 3. Execute Query with Context:
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	var mood Mood // Struct to hold the fetched data.
@@ -198,7 +431,8 @@ func (m *MoodModel) Get(id int64, userID int64) (*Mood, error) {
 	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(
 		&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
 		&mood.Title, &mood.Content, &mood.Emotion,
-		&mood.Emoji, &mood.Color, &mood.UserID,
+		&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
+		&mood.PrivateNote,
 	)
 
 	// 5. Handle Errors:
@@ -214,46 +448,171 @@ func (m *MoodModel) Get(id int64, userID int64) (*Mood, error) {
 // Update modifies an existing mood entry in the database.
 // It requires the Mood ID and the owner's UserID for an ownership check.
 // The 'Update' part of CRUD. Modifies an existing mood, again checking ownership.
-func (m *MoodModel) Update(mood *Mood) error {
+//
+// mood.Version must be the version the caller read. If the entry has been updated since,
+// nothing is written and ErrEditConflict is returned, so a stale form (say, in a second
+// tab) can't silently overwrite newer changes. On success mood.Version is the new version.
+// The version being replaced is kept in mood_revisions (see GetRevisions), in the same
+// transaction, so history and entry never disagree.
+func (m *MoodModel) Update(ctx context.Context, mood *Mood) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		return updateMood(ctx, tx, mood)
+	})
+	if err != nil && !errors.Is(err, ErrEditConflict) && !errors.Is(err, ErrRecordNotFound) {
+		return fmt.Errorf("mood update: %w", err)
+	}
+	return err
+}
+
+// updateMood is Update's snapshot and statement, run on the caller's transaction.
+// If either fails the caller must roll back, or a revision could outlive its edit.
+func updateMood(ctx context.Context, tx *sql.Tx, mood *Mood) error {
 	// 1. Validate IDs: Ensure mood and user IDs are valid.
 	if mood.ID < 1 || mood.UserID < 1 {
 		return ErrRecordNotFound
 	}
-	// 2. SQL Query: Updates specified fields, sets `updated_at` to current time.
-	//    `WHERE` clause includes both `id` and `user_id` for security.
+
+	// 2. Snapshot the version being replaced. No row means the entry is gone (or not
+	//    the user's), or its version moved on. Only the latter is a conflict.
+	copied, err := snapshotMood(ctx, tx, mood.ID, mood.UserID, mood.Version)
+	if err != nil {
+		return err
+	}
+	if !copied {
+		exists, existsErr := moodExists(ctx, tx, mood.ID, mood.UserID)
+		if existsErr != nil {
+			return existsErr
+		}
+		if exists {
+			return ErrEditConflict
+		}
+		return ErrRecordNotFound
+	}
+
+	// 3. SQL Query: Updates specified fields, sets `updated_at` to current time.
+	//    `WHERE` clause includes both `id` and `user_id` for security, and `version`
+	//    so the update only applies to the version the caller saw.
 	query := `
         UPDATE moods
-        SET title = $1, content = $2, emotion = $3, emoji = $4, color = $5, updated_at = NOW()
-        WHERE id = $6 AND user_id = $7
-        RETURNING updated_at` // Return the new `updated_at` timestamp.
+        SET title = $1, content = $2, emotion = $3, emoji = $4, color = $5, private_note = $8, intensity = $9,
+            version = version + 1, updated_at = NOW()
+        WHERE id = $6 AND user_id = $7 AND version = $10 AND deleted_at IS NULL
+        RETURNING updated_at, version` // Return the new `updated_at` timestamp and version.
+
+	args := []any{mood.Title, mood.Content, mood.Emotion, mood.Emoji, mood.Color, mood.ID, mood.UserID, mood.PrivateNote, mood.Intensity, mood.Version}
+
+	// 4. Execute and Scan: Update the `UpdatedAt` and `Version` fields in the mood struct.
+	//    A concurrent edit committed since the snapshot leaves no row, which is a conflict.
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&mood.UpdatedAt, &mood.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEditConflict
+	}
+	return err
+}
+
+// moodExists reports whether the user has a live (not trashed) mood with the given ID.
+// It runs on the caller's transaction, so it never waits for a second pool connection.
+func moodExists(ctx context.Context, tx *sql.Tx, id, userID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM moods WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	var exists bool
+	err := tx.QueryRowContext(ctx, query, id, userID).Scan(&exists)
+	return exists, err
+}
+
+// partialUpdateColumns is the safelist of Mood fields UpdatePartial may write,
+// mapped to their column names. Anything else (id, user_id, timestamps) is rejected.
+var partialUpdateColumns = map[string]string{
+	"title":     "title",
+	"content":   "content",
+	"emotion":   "emotion",
+	"emoji":     "emoji",
+	"color":     "color",
+	"intensity": "intensity",
+}
+
+// UpdatePartial writes only the named fields of mood, leaving other columns untouched.
+// Field names must be in partialUpdateColumns; the caller is expected to have validated
+// the merged mood already. Like Update, it enforces ownership, refreshes UpdatedAt and
+// bumps Version, but it doesn't check the version: only the named fields are written.
+// The replaced version is kept in mood_revisions, as with Update.
+func (m *MoodModel) UpdatePartial(ctx context.Context, mood *Mood, fields []string) error {
+	// 1. Validate IDs.
+	if mood.ID < 1 || mood.UserID < 1 {
+		return ErrRecordNotFound
+	}
+	if len(fields) == 0 {
+		return errors.New("mood partial update: no fields to update")
+	}
+
+	// 2. Build the SET clause from safelisted columns only.
+	values := map[string]any{
+		"title":     mood.Title,
+		"content":   mood.Content,
+		"emotion":   mood.Emotion,
+		"emoji":     mood.Emoji,
+		"color":     mood.Color,
+		"intensity": mood.Intensity,
+	}
+	setClauses := make([]string, 0, len(fields)+1)
+	args := make([]any, 0, len(fields)+2)
+	for _, field := range fields {
+		column, ok := partialUpdateColumns[field]
+		if !ok {
+			return fmt.Errorf("mood partial update: field %q is not updatable", field)
+		}
+		args = append(args, values[field])
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	setClauses = append(setClauses, "version = version + 1", "updated_at = NOW()")
+	args = append(args, mood.ID, mood.UserID)
 
-	args := []any{mood.Title, mood.Content, mood.Emotion, mood.Emoji, mood.Color, mood.ID, mood.UserID}
+	query := fmt.Sprintf(`
+        UPDATE moods
+        SET %s
+        WHERE id = $%d AND user_id = $%d AND deleted_at IS NULL
+        RETURNING updated_at, version`, strings.Join(setClauses, ", "), len(args)-1, len(args))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	// 3. Execute and Scan: Update the `UpdatedAt` field in the mood struct.
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&mood.UpdatedAt)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) { // If no row was updated (ID/UserID mismatch).
+	// 3. Snapshot the current version, then Execute and Scan the new `UpdatedAt` and `Version`.
+	//    runInTx turns a missing row into ErrRecordNotFound.
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		copied, err := snapshotMood(ctx, tx, mood.ID, mood.UserID, 0)
+		if err != nil {
+			return err
+		}
+		if !copied {
 			return ErrRecordNotFound
 		}
-		return fmt.Errorf("mood update: %w", err)
+		return tx.QueryRowContext(ctx, query, args...).Scan(&mood.UpdatedAt, &mood.Version)
+	})
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return fmt.Errorf("mood partial update: %w", err)
 	}
-	return nil
+	return err
 }
 
-// Delete removes a mood entry from the database by its ID and owner's UserID.
-// The 'Delete' part of CRUD. Removes a mood entry, with ownership check.
-func (m *MoodModel) Delete(id int64, userID int64) error {
+// TrashRetention is how long a deleted entry stays in the trash before PurgeDeleted
+// removes it for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+// Delete moves a mood entry to the trash by its ID and owner's UserID. The row is kept
+// with deleted_at set, so Restore can bring it back until PurgeDeleted removes it.
+// The 'Delete' part of CRUD. Soft-deletes a mood entry, with ownership check.
+func (m *MoodModel) Delete(ctx context.Context, id int64, userID int64) error {
 	// 1. Validate IDs.
 	if id < 1 || userID < 1 {
 		return ErrRecordNotFound
 	}
-	// 2. SQL Query: Deletes based on ID and UserID.
-	query := `DELETE FROM moods WHERE id = $1 AND user_id = $2`
+	// 2. SQL Query: Marks the entry deleted based on ID and UserID. An entry already
+	//    in the trash counts as not found, like any other missing entry.
+	query := `UPDATE moods SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	// 3. Execute Deletion: `ExecContext` is used as we don't expect rows back.
@@ -274,9 +633,147 @@ func (m *MoodModel) Delete(id int64, userID int64) error {
 	return nil
 }
 
+// DeleteMany moves several of a user's entries to the trash in one statement and
+// reports how many it moved. IDs the user doesn't own, or that are already in the
+// trash, are skipped rather than treated as an error.
+func (m *MoodModel) DeleteMany(ctx context.Context, ids []int64, userID int64) (int64, error) {
+	if userID < 1 {
+		return 0, errors.New("invalid user ID provided for deleting moods")
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query := `
+        UPDATE moods SET deleted_at = NOW()
+        WHERE id = ANY($1) AND user_id = $2 AND deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, pq.Array(ids), userID)
+	if err != nil {
+		return 0, fmt.Errorf("mood delete many exec: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("mood delete many rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+// Restore takes a mood entry back out of the trash. Entries that aren't in the
+// user's trash (including ones already purged) return ErrRecordNotFound.
+func (m *MoodModel) Restore(ctx context.Context, id int64, userID int64) error {
+	if id < 1 || userID < 1 {
+		return ErrRecordNotFound
+	}
+	query := `UPDATE moods SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("mood restore exec: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("mood restore rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetPinned pins or unpins one of the user's live entries. Pinning isn't an edit, so
+// updated_at and version are left alone and an open edit form won't see a conflict.
+// It returns ErrRecordNotFound if the entry isn't the user's or is in the trash.
+func (m *MoodModel) SetPinned(ctx context.Context, id int64, userID int64, pinned bool) error {
+	if id < 1 || userID < 1 {
+		return ErrRecordNotFound
+	}
+	query := `UPDATE moods SET pinned = $3 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID, pinned)
+	if err != nil {
+		return fmt.Errorf("mood set pinned exec: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("mood set pinned rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetDeleted lists the user's trashed entries, most recently deleted first, for the
+// trash page. Like the export queries it leaves out private_note.
+func (m *MoodModel) GetDeleted(ctx context.Context, userID int64) ([]*Mood, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned, deleted_at
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC, id DESC`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("deleted moods query: %w", err)
+	}
+	defer rows.Close()
+
+	moods := []*Mood{}
+	for rows.Next() {
+		var mood Mood
+		err := rows.Scan(
+			&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
+			&mood.Title, &mood.Content, &mood.Emotion,
+			&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
+			&mood.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("deleted moods scan: %w", err)
+		}
+		moods = append(moods, &mood)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("deleted moods rows iteration: %w", err)
+	}
+	return moods, nil
+}
+
+// PurgeDeleted permanently removes every user's entries that have been in the trash
+// for longer than olderThan, and reports how many it removed.
+func (m *MoodModel) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM moods WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("mood purge deleted exec: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("mood purge deleted rows affected: %w", err)
+	}
+	return purged, nil
+}
+
 // GetFiltered retrieves a paginated and filtered list of moods for a specific user.
 // Powers the dashboard. Dynamically builds SQL for filtering by text, emotion, date, and handles pagination.
-func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, error) {
+func (m *MoodModel) GetFiltered(ctx context.Context, filters FilterCriteria) ([]*Mood, Metadata, error) {
 	// 1. Validate UserID.
 	if filters.UserID < 1 {
 		return []*Mood{}, Metadata{}, errors.New("invalid user ID provided for filtering moods")
@@ -285,26 +782,23 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 	// 2. Dynamic Query Building: Start with a base query and append conditions.
 	baseQuery := `
         FROM moods
-        WHERE user_id = $1` // Always filter by the logged-in user.
+        WHERE user_id = $1 AND deleted_at IS NULL` // Always filter by the logged-in user; skip the trash.
 	args := []any{filters.UserID}
 	paramIndex := 2
 
 	// 2a. Add Text Search Filter (if provided).
-	//     Searches title, content, and emotion fields case-insensitively.
-	if filters.TextQuery != "" {
-		searchTerm := "%" + strings.TrimSpace(filters.TextQuery) + "%"
-		// ILIKE for case-insensitive search.
-		baseQuery += fmt.Sprintf(" AND (title ILIKE $%d OR content ILIKE $%d OR emotion ILIKE $%d)", paramIndex, paramIndex, paramIndex)
-		args = append(args, searchTerm)
-		paramIndex++
+	//     Title and content use full-text search on the indexed search_vector column,
+	//     so "running" matches "run". Emotion names are short labels rather than
+	//     prose, so they are still matched as a case-insensitive substring.
+	if textQuery := strings.TrimSpace(filters.TextQuery); textQuery != "" {
+		baseQuery += fmt.Sprintf(" AND (search_vector @@ plainto_tsquery('english', $%d) OR emotion ILIKE $%d)", paramIndex, paramIndex+1)
+		args = append(args, textQuery, "%"+textQuery+"%")
+		paramIndex += 2
 	}
 	// 2b. Add Emotion Filter (if provided).
-	//     Handles combined "EmotionName::Emoji" format from dropdown.
+	//     Handles the combined "EmotionName::Emoji" format from the dropdown (see EncodeEmotionFilter).
 	if filters.Emotion != "" {
-		parts := strings.SplitN(filters.Emotion, "::", 2)
-		if len(parts) == 2 {
-			emotionName := parts[0]
-			emotionEmoji := parts[1]
+		if emotionName, emotionEmoji, ok := DecodeEmotionFilter(filters.Emotion); ok {
 			if emotionName != "" && emotionEmoji != "" {
 				baseQuery += fmt.Sprintf(" AND emotion = $%d AND emoji = $%d", paramIndex, paramIndex+1)
 				args = append(args, emotionName, emotionEmoji)
@@ -319,19 +813,44 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 	// 2c. Add Date Filters (if provided).
 	if !filters.StartDate.IsZero() {
 		baseQuery += fmt.Sprintf(" AND created_at >= $%d", paramIndex)
-		args = append(args, filters.StartDate)
+		args = append(args, filters.StartDate.UTC())
 		paramIndex++
 	}
 	if !filters.EndDate.IsZero() {
 		baseQuery += fmt.Sprintf(" AND created_at <= $%d", paramIndex)
-		args = append(args, filters.EndDate)
+		args = append(args, filters.EndDate.UTC())
 		paramIndex++
 	}
+	// 2d. Add Weekday Filter (if provided).
+	//     created_at is converted to the user's zone first, so an entry logged late
+	//     on a Sunday evening doesn't show up as a Monday just because the DB runs in UTC.
+	if filters.Weekday >= 0 && filters.Weekday <= 6 {
+		timeZone := DefaultTimeZone
+		if filters.Location != nil {
+			timeZone = filters.Location.String()
+		}
+		baseQuery += fmt.Sprintf(" AND EXTRACT(DOW FROM created_at AT TIME ZONE $%d) = $%d", paramIndex, paramIndex+1)
+		args = append(args, timeZone, filters.Weekday)
+		paramIndex += 2
+	}
+	// 2e. Add Intensity Range Filter (only when narrower than the full scale).
+	minIntensity, maxIntensity := filters.MinIntensity, filters.MaxIntensity
+	if minIntensity == 0 {
+		minIntensity = MoodIntensityMin
+	}
+	if maxIntensity == 0 {
+		maxIntensity = MoodIntensityMax
+	}
+	if minIntensity > MoodIntensityMin || maxIntensity < MoodIntensityMax {
+		baseQuery += fmt.Sprintf(" AND intensity BETWEEN $%d AND $%d", paramIndex, paramIndex+1)
+		args = append(args, minIntensity, maxIntensity)
+		paramIndex += 2
+	}
 
 	// 3. Get Total Record Count (for pagination).
 	//    Executes a `COUNT(*)` query with the same filters.
 	totalRecordsQuery := `SELECT count(*) ` + baseQuery
-	ctxCount, cancelCount := context.WithTimeout(context.Background(), 3*time.Second)
+	ctxCount, cancelCount := context.WithTimeout(ctx, 3*time.Second)
 	defer cancelCount()
 
 	var totalRecords int
@@ -347,21 +866,27 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 	if filters.Page <= 0 {
 		filters.Page = 1
 	}
-	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
-	// If no records or requested page is beyond last page, return empty results.
+	// If no records, return empty results.
 	if totalRecords == 0 {
-		return []*Mood{}, metadata, nil
-	}
-	if filters.Page > metadata.LastPage {
-		return []*Mood{}, metadata, nil
+		return []*Mood{}, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
 	}
+	// Clamp a page beyond the last page (e.g. ?page=9999999) to the last page,
+	// so a hand-crafted URL can't force Postgres to scan and discard a huge OFFSET.
+	// The metadata is computed from the clamped page, so its links never point at itself.
+	lastPage := (totalRecords + filters.PageSize - 1) / filters.PageSize
+	filters.Page = min(filters.Page, lastPage)
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	// 5. Construct Final Select Query with Ordering, Limit, and Offset.
-	//    Orders by `created_at DESC` to show newest first.
+	//    The order comes from the sortClauses safelist (newest first by default).
 	//    `LIMIT` for page size, `OFFSET` for current page.
-	selectQuery := `SELECT id, created_at, updated_at, title, content, emotion, emoji, color, user_id ` +
+	orderBy, ok := sortClauses[filters.Sort]
+	if !ok {
+		orderBy = sortClauses[DefaultSort]
+	}
+	selectQuery := `SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned ` +
 		baseQuery + // Filter conditions.
-		` ORDER BY created_at DESC LIMIT $` + fmt.Sprint(paramIndex) + // ORDER BY and LIMIT.
+		` ORDER BY ` + orderBy + ` LIMIT $` + fmt.Sprint(paramIndex) + // ORDER BY and LIMIT.
 		` OFFSET $` + fmt.Sprint(paramIndex+1) // OFFSET.
 
 	limit := filters.PageSize
@@ -369,7 +894,7 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 	queryArgs := append(args, limit, offset) // Add limit and offset to arguments.
 
 	// 6. Execute Paginated Query.
-	ctxQuery, cancelQuery := context.WithTimeout(context.Background(), 5*time.Second)
+	ctxQuery, cancelQuery := context.WithTimeout(ctx, 5*time.Second)
 	defer cancelQuery()
 
 	rows, err := m.DB.QueryContext(ctxQuery, selectQuery, queryArgs...)
@@ -385,7 +910,7 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 		err := rows.Scan(
 			&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
 			&mood.Title, &mood.Content, &mood.Emotion,
-			&mood.Emoji, &mood.Color, &mood.UserID,
+			&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
 		)
 		if err != nil {
 			return nil, metadata, fmt.Errorf("paginated scan row: %w", err)
@@ -401,32 +926,98 @@ func (m *MoodModel) GetFiltered(filters FilterCriteria) ([]*Mood, Metadata, erro
 
 }
 
-// GetDistinctEmotionDetails fetches unique emotion, emoji, and color combinations logged by a user.
-// Used to populate the emotion filter dropdown on the dashboard.
-// Helper to get unique emotions for the filter dropdown, making it user-specific.
-func (m *MoodModel) GetDistinctEmotionDetails(userID int64) ([]EmotionDetail, error) {
-	// 1. Validate UserID.
+// GetPredecessors returns, for the user's entries with the given IDs, the live entry
+// logged just before each one (by created_at, then id), oldest first and without
+// duplicates. The first entry the user ever logged has no predecessor. The dashboard
+// uses it to compare a page's entries with neighbours that fall off the page.
+func (m *MoodModel) GetPredecessors(ctx context.Context, userID int64, ids []int64) ([]*Mood, error) {
 	if userID < 1 {
-		return nil, errors.New("invalid user ID provided for distinct emotions")
+		return nil, errors.New("invalid user ID")
+	}
+	if len(ids) == 0 {
+		return []*Mood{}, nil
 	}
-	// 2. SQL Query: Selects distinct combinations, ordered by emotion name.
 	query := `
+        SELECT DISTINCT p.id, p.created_at, p.updated_at, p.title, p.content, p.emotion,
+               p.emoji, p.color, p.intensity, p.version, p.user_id, p.pinned
+        FROM moods cur
+        CROSS JOIN LATERAL (
+            SELECT * FROM moods prev
+            WHERE prev.user_id = cur.user_id AND prev.deleted_at IS NULL
+              AND (prev.created_at, prev.id) < (cur.created_at, cur.id)
+            ORDER BY prev.created_at DESC, prev.id DESC
+            LIMIT 1
+        ) p
+        WHERE cur.user_id = $1 AND cur.id = ANY($2)
+        ORDER BY p.created_at ASC, p.id ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("mood predecessors: %w", err)
+	}
+	defer rows.Close()
+
+	moods := []*Mood{}
+	for rows.Next() {
+		var mood Mood
+		err := rows.Scan(
+			&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
+			&mood.Title, &mood.Content, &mood.Emotion,
+			&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("mood predecessors scan: %w", err)
+		}
+		moods = append(moods, &mood)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("mood predecessors rows: %w", err)
+	}
+	return moods, nil
+}
+
+// The emotion dropdown and emotion counts run on every dashboard and stats load. Both
+// read only a user's live entries and the emotion columns, so the partial index
+// moods_user_emotion_idx (user_id, emotion, emoji, color) answers them without the table;
+// TestEmotionQueries_UseIndex checks the planner can use it.
+const (
+	distinctEmotionsQuery = `
         SELECT DISTINCT emotion, emoji, color FROM moods
         WHERE emotion IS NOT NULL AND emoji IS NOT NULL AND color IS NOT NULL
-          AND user_id = $1
+          AND user_id = $1 AND deleted_at IS NULL
         ORDER BY emotion ASC`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	emotionCountsQuery = `
+        SELECT emotion, emoji, color, COUNT(*)
+        FROM moods
+        WHERE emotion IS NOT NULL AND emoji IS NOT NULL AND color IS NOT NULL
+          AND user_id = $1 AND deleted_at IS NULL
+        GROUP BY emotion, emoji, color
+        ORDER BY COUNT(*) DESC, emotion ASC`
+)
+
+// GetDistinctEmotionDetails fetches unique emotion, emoji, and color combinations logged by a user.
+// Used to populate the emotion filter dropdown on the dashboard.
+// Helper to get unique emotions for the filter dropdown, making it user-specific.
+func (m *MoodModel) GetDistinctEmotionDetails(ctx context.Context, userID int64) ([]EmotionDetail, error) {
+	// 1. Validate UserID.
+	if userID < 1 {
+		return nil, errors.New("invalid user ID provided for distinct emotions")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	// 3. Execute Query.
-	rows, err := m.DB.QueryContext(ctx, query, userID)
+	// 2. Execute Query: distinctEmotionsQuery selects distinct combinations, ordered by emotion name.
+	rows, err := m.DB.QueryContext(ctx, distinctEmotionsQuery, userID)
 	if err != nil {
 		return nil, fmt.Errorf("distinct emotion query: %w", err)
 	}
 	defer rows.Close()
 
-	// 4. Scan Results into EmotionDetail structs.
+	// 3. Scan Results into EmotionDetail structs.
 	emotionDetailsList := make([]EmotionDetail, 0)
 	for rows.Next() {
 		var detail EmotionDetail
@@ -447,13 +1038,13 @@ func (m *MoodModel) GetDistinctEmotionDetails(userID int64) ([]EmotionDetail, er
 
 // GetTotalMoodCount returns the total number of mood entries for a user
 
-func (m *MoodModel) GetTotalMoodCount(userID int64) (int, error) {
+func (m *MoodModel) GetTotalMoodCount(ctx context.Context, userID int64) (int, error) {
 	// ... (Implementation with UserID check, SQL query, context, scan) ...
 	if userID < 1 {
 		return 0, errors.New("invalid user ID")
 	}
-	query := `SELECT COUNT(*) FROM moods WHERE user_id = $1`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	query := `SELECT COUNT(*) FROM moods WHERE user_id = $1 AND deleted_at IS NULL`
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 	var total int
 	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&total)
@@ -463,22 +1054,29 @@ func (m *MoodModel) GetTotalMoodCount(userID int64) (int, error) {
 	return total, nil
 }
 
+// GetGlobalTotals returns the number of mood entries across all users.
+// Only the aggregate is returned, so it is safe to show on public pages like About.
+func (m *MoodModel) GetGlobalTotals(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM moods WHERE deleted_at IS NULL`
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	var totalMoods int
+	err := m.DB.QueryRowContext(ctx, query).Scan(&totalMoods)
+	if err != nil {
+		return 0, fmt.Errorf("global mood count query: %w", err)
+	}
+	return totalMoods, nil
+}
+
 // GetEmotionCounts returns a list of emotions and their counts for a user, ordered by frequency.
-func (m *MoodModel) GetEmotionCounts(userID int64) ([]EmotionCount, error) {
+func (m *MoodModel) GetEmotionCounts(ctx context.Context, userID int64) ([]EmotionCount, error) {
 	// ... (Implementation with UserID check, SQL query with GROUP BY and ORDER BY, context, scan loop) ...
 	if userID < 1 {
 		return nil, errors.New("invalid user ID")
 	}
-	query := `
-        SELECT emotion, emoji, color, COUNT(*)
-        FROM moods
-        WHERE emotion IS NOT NULL AND emoji IS NOT NULL AND color IS NOT NULL
-          AND user_id = $1
-        GROUP BY emotion, emoji, color
-        ORDER BY COUNT(*) DESC, emotion ASC`
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	rows, err := m.DB.QueryContext(ctx, query, userID)
+	rows, err := m.DB.QueryContext(ctx, emotionCountsQuery, userID)
 	if err != nil {
 		return nil, fmt.Errorf("emotion counts query: %w", err)
 	}
@@ -498,35 +1096,102 @@ func (m *MoodModel) GetEmotionCounts(userID int64) ([]EmotionCount, error) {
 	return counts, nil
 }
 
-// GetWeeklyEntryCounts fetches mood entry counts grouped by ISO week for a user.
-func (m *MoodModel) GetWeeklyEntryCounts(userID int64) ([]WeeklyCount, error) {
-	// ... (Implementation with UserID check, SQL query using TO_CHAR for week, GROUP BY, context, scan loop) ...
+// maxSameDayPairs is how many co-occurring emotion pairs the stats page shows.
+const maxSameDayPairs = 5
+
+// GetSameDayEmotionPairs finds which pairs of distinct emotions were logged on the same day,
+// counting each day once per pair, and returns the most frequent pairs first.
+// Days are calendar days in timeZone (an IANA name; empty means UTC); a day with only
+// one emotion contributes nothing.
+func (m *MoodModel) GetSameDayEmotionPairs(ctx context.Context, userID int64, timeZone string) ([]EmotionPairCount, error) {
 	if userID < 1 {
 		return nil, errors.New("invalid user ID")
 	}
+	// The CTE reduces entries to distinct (day, emotion) rows; the self-join then forms
+	// each unordered pair once via a.emotion < b.emotion.
 	query := `
-        SELECT
-            TO_CHAR(created_at, 'IYYY-IW') AS week_year,
-            COUNT(*) as count
-        FROM
-            moods
-        WHERE
-            user_id = $1
-        GROUP BY
-            week_year,
-            date_trunc('week', created_at)
-        ORDER BY
-            date_trunc('week', created_at) ASC;
-    `
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        WITH day_emotions AS (
+            SELECT DISTINCT date_trunc('day', created_at AT TIME ZONE $3) AS day, emotion
+            FROM moods
+            WHERE user_id = $1 AND emotion IS NOT NULL AND deleted_at IS NULL
+        )
+        SELECT a.emotion, b.emotion, COUNT(*) AS days
+        FROM day_emotions a
+        JOIN day_emotions b ON a.day = b.day AND a.emotion < b.emotion
+        GROUP BY a.emotion, b.emotion
+        ORDER BY days DESC, a.emotion ASC, b.emotion ASC
+        LIMIT $2`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-
-	rows, err := m.DB.QueryContext(ctx, query, userID)
+	rows, err := m.DB.QueryContext(ctx, query, userID, maxSameDayPairs, zoneOrDefault(timeZone))
 	if err != nil {
-		return nil, fmt.Errorf("weekly counts query: %w", err)
+		return nil, fmt.Errorf("same-day emotion pairs query: %w", err)
 	}
 	defer rows.Close()
-
+	pairs := []EmotionPairCount{}
+	for rows.Next() {
+		var p EmotionPairCount
+		err := rows.Scan(&p.First, &p.Second, &p.Days)
+		if err != nil {
+			return nil, fmt.Errorf("same-day emotion pairs scan: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("same-day emotion pairs rows iteration: %w", err)
+	}
+	return pairs, nil
+}
+
+// zoneOrDefault returns timeZone, or DefaultTimeZone if it is empty.
+func zoneOrDefault(timeZone string) string {
+	if timeZone == "" {
+		return DefaultTimeZone
+	}
+	return timeZone
+}
+
+// locationOrUTC loads timeZone (empty means UTC) for date arithmetic done in Go. Zones
+// are validated when saved, so an unknown one falls back to UTC rather than failing a page.
+func locationOrUTC(timeZone string) *time.Location {
+	loc, err := time.LoadLocation(zoneOrDefault(timeZone))
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// GetWeeklyEntryCounts fetches mood entry counts grouped by ISO week for a user.
+// Weeks follow the calendar in timeZone (an IANA name; empty means UTC), so an entry
+// logged late on a Sunday evening counts toward that week rather than the next.
+func (m *MoodModel) GetWeeklyEntryCounts(ctx context.Context, userID int64, timeZone string) ([]WeeklyCount, error) {
+	// ... (Implementation with UserID check, SQL query using TO_CHAR for week, GROUP BY, context, scan loop) ...
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT
+            TO_CHAR(created_at AT TIME ZONE $2, 'IYYY-IW') AS week_year,
+            COUNT(*) as count
+        FROM
+            moods
+        WHERE
+            user_id = $1 AND deleted_at IS NULL
+        GROUP BY
+            week_year,
+            date_trunc('week', created_at AT TIME ZONE $2)
+        ORDER BY
+            date_trunc('week', created_at AT TIME ZONE $2) ASC;
+    `
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("weekly counts query: %w", err)
+	}
+	defer rows.Close()
+
 	counts := []WeeklyCount{}
 	for rows.Next() {
 		var wc WeeklyCount
@@ -542,25 +1207,443 @@ func (m *MoodModel) GetWeeklyEntryCounts(userID int64) ([]WeeklyCount, error) {
 	return counts, nil
 }
 
+// GetEmotionCountsByWeek fetches how often each emotion was logged per ISO week, oldest
+// week first and, within a week, most frequent emotion first. It is the time-series
+// version of GetEmotionCounts; weeks follow timeZone as in GetWeeklyEntryCounts.
+func (m *MoodModel) GetEmotionCountsByWeek(ctx context.Context, userID int64, timeZone string) ([]WeeklyEmotionCount, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT
+            TO_CHAR(created_at AT TIME ZONE $2, 'IYYY-IW') AS week_year,
+            emotion,
+            COUNT(*) AS count
+        FROM
+            moods
+        WHERE
+            user_id = $1 AND deleted_at IS NULL
+        GROUP BY
+            week_year,
+            date_trunc('week', created_at AT TIME ZONE $2),
+            emotion
+        ORDER BY
+            date_trunc('week', created_at AT TIME ZONE $2) ASC,
+            count DESC,
+            emotion ASC;
+    `
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("weekly emotion counts query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []WeeklyEmotionCount{}
+	for rows.Next() {
+		var wc WeeklyEmotionCount
+		err := rows.Scan(&wc.Week, &wc.Emotion, &wc.Count)
+		if err != nil {
+			return nil, fmt.Errorf("weekly emotion counts scan: %w", err)
+		}
+		counts = append(counts, wc)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("weekly emotion counts rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// GetMonthlyEntryCounts fetches mood entry counts grouped by calendar month (in timeZone,
+// an IANA name; empty means UTC) for a user, oldest month first. Months without entries
+// are omitted, like GetWeeklyEntryCounts.
+func (m *MoodModel) GetMonthlyEntryCounts(ctx context.Context, userID int64, timeZone string) ([]MonthlyCount, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT TO_CHAR(date_trunc('month', created_at AT TIME ZONE $2), 'YYYY-MM') AS month, COUNT(*)
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+        GROUP BY date_trunc('month', created_at AT TIME ZONE $2)
+        ORDER BY date_trunc('month', created_at AT TIME ZONE $2) ASC`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("monthly counts query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []MonthlyCount{}
+	for rows.Next() {
+		var mc MonthlyCount
+		if err := rows.Scan(&mc.Month, &mc.Count); err != nil {
+			return nil, fmt.Errorf("monthly counts scan: %w", err)
+		}
+		counts = append(counts, mc)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("monthly counts rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// GetWeekdayEntryCounts counts a user's entries per day of the week in timeZone (an IANA
+// name; empty means UTC), so pass the same zone the dashboard weekday filter uses. All 7
+// days are returned, Sunday first, with zero counts filled in so charts always have a full axis.
+func (m *MoodModel) GetWeekdayEntryCounts(ctx context.Context, userID int64, timeZone string) ([]WeekdayCount, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT EXTRACT(DOW FROM created_at AT TIME ZONE $2)::int AS dow, COUNT(*)
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+        GROUP BY dow`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("weekday counts query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]WeekdayCount, 7)
+	for i := range counts {
+		counts[i] = WeekdayCount{Weekday: i, Name: time.Weekday(i).String()}
+	}
+	for rows.Next() {
+		var day, count int
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("weekday counts scan: %w", err)
+		}
+		if day >= 0 && day < len(counts) {
+			counts[day].Count = count
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("weekday counts rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// GetHourlyEntryCounts counts a user's entries per hour of the day on the clock in
+// timeZone (an IANA name; empty means UTC). All 24 hours are returned in order, with
+// zero counts filled in.
+func (m *MoodModel) GetHourlyEntryCounts(ctx context.Context, userID int64, timeZone string) ([]HourlyCount, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT EXTRACT(HOUR FROM created_at AT TIME ZONE $2)::int AS hour, COUNT(*)
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+        GROUP BY hour`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("hourly counts query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]HourlyCount, 24)
+	for i := range counts {
+		counts[i].Hour = i
+	}
+	for rows.Next() {
+		var hour, count int
+		if err := rows.Scan(&hour, &count); err != nil {
+			return nil, fmt.Errorf("hourly counts scan: %w", err)
+		}
+		if hour >= 0 && hour < len(counts) {
+			counts[hour].Count = count
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("hourly counts rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// GetDailyCounts returns how many entries the user logged on each calendar day (in
+// timeZone, an IANA name; empty means UTC) with created_at in [from, to), oldest first.
+// Days without entries are omitted; callers that need them can fill the gaps from the
+// range they asked for.
+func (m *MoodModel) GetDailyCounts(ctx context.Context, userID int64, timeZone string, from, to time.Time) ([]DailyCount, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT TO_CHAR(date_trunc('day', created_at AT TIME ZONE $4), 'YYYY-MM-DD') AS day, COUNT(*)
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+        GROUP BY day
+        ORDER BY day ASC`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, from, to, zoneOrDefault(timeZone))
+	if err != nil {
+		return nil, fmt.Errorf("daily counts query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []DailyCount{}
+	for rows.Next() {
+		var day DailyCount
+		if err := rows.Scan(&day.Date, &day.Count); err != nil {
+			return nil, fmt.Errorf("daily counts scan: %w", err)
+		}
+		counts = append(counts, day)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("daily counts rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// GetByMonth fetches all of a user's entries created in the given calendar month in
+// timeZone (an IANA name; empty means UTC), oldest first. It is used for exports, so
+// private_note is deliberately not selected.
+func (m *MoodModel) GetByMonth(ctx context.Context, userID int64, timeZone string, year int, month time.Month) ([]*Mood, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	start := time.Date(year, month, 1, 0, 0, 0, 0, locationOrUTC(timeZone))
+	end := start.AddDate(0, 1, 0)
+
+	query := `
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+        ORDER BY created_at ASC, id ASC`
+	return m.listMoods(ctx, "month entries", query, userID, start, end)
+}
+
+// GetAllForUser fetches every one of a user's entries, oldest first, for the full
+// CSV export. Like GetByMonth, it leaves out private_note.
+func (m *MoodModel) GetAllForUser(ctx context.Context, userID int64) ([]*Mood, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+        ORDER BY created_at ASC, id ASC`
+	return m.listMoods(ctx, "all entries", query, userID)
+}
+
+// OnThisDayLimit caps how many memories GetOnThisDay returns.
+const OnThisDayLimit = 10
+
+// GetOnThisDay fetches the user's entries from today's calendar day (month and day of
+// now in timeZone, an IANA name; empty means UTC) in other years, newest first and at
+// most OnThisDayLimit. Entries from 29 February only come back on 29 February.
+func (m *MoodModel) GetOnThisDay(ctx context.Context, userID int64, timeZone string, now time.Time) ([]*Mood, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	today := now.In(locationOrUTC(timeZone))
+	query := `
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+          AND EXTRACT(MONTH FROM created_at AT TIME ZONE $2) = $3
+          AND EXTRACT(DAY FROM created_at AT TIME ZONE $2) = $4
+          AND EXTRACT(YEAR FROM created_at AT TIME ZONE $2) <> $5
+        ORDER BY created_at DESC, id DESC
+        LIMIT $6`
+	return m.listMoods(ctx, "on this day", query,
+		userID, zoneOrDefault(timeZone), int(today.Month()), today.Day(), today.Year(), OnThisDayLimit)
+}
+
+// listMoods runs a query selecting the columns GetByMonth, GetAllForUser and
+// GetOnThisDay share (everything but private_note and deleted_at), and scans the
+// rows. label prefixes any error.
+func (m *MoodModel) listMoods(ctx context.Context, label, query string, args ...any) ([]*Mood, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s query: %w", label, err)
+	}
+	defer rows.Close()
+
+	moods := []*Mood{}
+	for rows.Next() {
+		var mood Mood
+		err := rows.Scan(
+			&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
+			&mood.Title, &mood.Content, &mood.Emotion,
+			&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s scan: %w", label, err)
+		}
+		moods = append(moods, &mood)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s rows iteration: %w", label, err)
+	}
+	return moods, nil
+}
+
+// MaxMissingDaysWindow caps how far back GetMissingDays looks.
+const MaxMissingDaysWindow = 90
+
+// GetMissingDays returns the dates in the last `days` days (including today) on which
+// the user logged no entries, oldest first. Days are calendar days in timeZone (an IANA
+// name; empty means UTC), and each date is midnight in that zone. days is capped at
+// MaxMissingDaysWindow; a non-positive window returns no dates.
+func (m *MoodModel) GetMissingDays(ctx context.Context, userID int64, timeZone string, days int) ([]time.Time, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	if days < 1 {
+		return []time.Time{}, nil
+	}
+	if days > MaxMissingDaysWindow {
+		days = MaxMissingDaysWindow
+	}
+
+	loc := locationOrUTC(timeZone)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	// 1. Find the days in the window that do have entries, on the same calendar.
+	query := `
+        SELECT DISTINCT TO_CHAR(created_at AT TIME ZONE $3, 'YYYY-MM-DD')
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, start, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("logged days query: %w", err)
+	}
+	defer rows.Close()
+
+	logged := make(map[string]bool)
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("logged days scan: %w", err)
+		}
+		logged[day] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("logged days rows iteration: %w", err)
+	}
+
+	// 2. Every other day in the window is a gap.
+	missing := []time.Time{}
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if !logged[day.Format("2006-01-02")] {
+			missing = append(missing, day)
+		}
+	}
+	return missing, nil
+}
+
+// ExistsOnDate looks for a live entry the user logged on the calendar day containing
+// date, where the day runs midnight to midnight in date's location (so pass a time in
+// the user's zone, or UTC). It returns the ID of the latest such entry.
+func (m *MoodModel) ExistsOnDate(ctx context.Context, userID int64, date time.Time) (int64, bool, error) {
+	if userID < 1 {
+		return 0, false, errors.New("invalid user ID")
+	}
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1) // AddDate, not 24h, so DST days are the right length.
+
+	query := `
+        SELECT id
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+        ORDER BY created_at DESC
+        LIMIT 1`
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var id int64
+	err := m.DB.QueryRowContext(ctx, query, userID, start.UTC(), end.UTC()).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("entry on date query: %w", err)
+	}
+	return id, true, nil
+}
+
+// periodStarts returns the start of the ISO week (Monday), month and year containing now,
+// at midnight in now's location.
+func periodStarts(now time.Time) (week, month, year time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	daysSinceMonday := (int(today.Weekday()) + 6) % 7
+	week = today.AddDate(0, 0, -daysSinceMonday) // AddDate, not 24h multiples, so DST weeks line up.
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	year = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	return week, month, year
+}
+
+// GetPeriodCounts returns how many live entries the user has logged so far this week
+// (from Monday), this month and this year. Periods follow the calendar in now's location,
+// so pass the current time in the user's zone, or UTC.
+func (m *MoodModel) GetPeriodCounts(ctx context.Context, userID int64, now time.Time) (week, month, year int, err error) {
+	if userID < 1 {
+		return 0, 0, 0, errors.New("invalid user ID")
+	}
+	weekStart, monthStart, yearStart := periodStarts(now)
+
+	// The year scan bounds the other two, except early in January when the week can
+	// start in December, hence the LEAST.
+	query := `
+        SELECT
+            COUNT(*) FILTER (WHERE created_at >= $2),
+            COUNT(*) FILTER (WHERE created_at >= $3),
+            COUNT(*) FILTER (WHERE created_at >= $4)
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= LEAST($2::timestamptz, $4::timestamptz)`
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, userID, weekStart.UTC(), monthStart.UTC(), yearStart.UTC()).Scan(&week, &month, &year)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("period counts query: %w", err)
+	}
+	return week, month, year, nil
+}
+
 // GetLatestMood fetches the most recent mood entry for a user.
-func (m *MoodModel) GetLatestMood(userID int64) (*Mood, error) {
+func (m *MoodModel) GetLatestMood(ctx context.Context, userID int64) (*Mood, error) {
 	// ... (Implementation with UserID check, SQL query with ORDER BY created_at DESC LIMIT 1, context, scan) ...
 	if userID < 1 {
 		return nil, errors.New("invalid user ID")
 	}
 	query := `
-        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, user_id
+        SELECT id, created_at, updated_at, title, content, emotion, emoji, color, intensity, version, user_id, pinned
         FROM moods
-        WHERE user_id = $1
+        WHERE user_id = $1 AND deleted_at IS NULL
         ORDER BY created_at DESC
         LIMIT 1`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 	var mood Mood
 	err := m.DB.QueryRowContext(ctx, query, userID).Scan(
 		&mood.ID, &mood.CreatedAt, &mood.UpdatedAt,
 		&mood.Title, &mood.Content, &mood.Emotion,
-		&mood.Emoji, &mood.Color, &mood.UserID,
+		&mood.Emoji, &mood.Color, &mood.Intensity, &mood.Version, &mood.UserID, &mood.Pinned,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -571,15 +1654,118 @@ func (m *MoodModel) GetLatestMood(userID int64) (*Mood, error) {
 	return &mood, nil
 }
 
+// GetStreaks returns the user's current and longest runs of consecutive calendar days
+// with at least one entry. Days are calendar days in timeZone (an IANA name; empty means
+// UTC), like GetMissingDays. The current streak counts back from today, or from yesterday
+// if nothing has been logged yet today, so the streak isn't shown as broken until a whole
+// day has been missed.
+func (m *MoodModel) GetStreaks(ctx context.Context, userID int64, timeZone string) (current, longest int, err error) {
+	if userID < 1 {
+		return 0, 0, errors.New("invalid user ID")
+	}
+	loc := locationOrUTC(timeZone)
+	query := `
+        SELECT DISTINCT (created_at AT TIME ZONE $2)::date AS day
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL
+        ORDER BY day`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, loc.String())
+	if err != nil {
+		return 0, 0, fmt.Errorf("streak days query: %w", err)
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return 0, 0, fmt.Errorf("streak days scan: %w", err)
+		}
+		days = append(days, day)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("streak days rows iteration: %w", err)
+	}
+
+	current, longest = countStreaks(days, time.Now().In(loc))
+	return current, longest, nil
+}
+
+// GetWordStats counts the words a user has written across their entries' content, and
+// the average per entry. Content is HTML, so the counting happens in Go after stripping
+// the markup; entries with empty content count as entries with zero words.
+func (m *MoodModel) GetWordStats(ctx context.Context, userID int64) (total int, avg float64, err error) {
+	if userID < 1 {
+		return 0, 0, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT content
+        FROM moods
+        WHERE user_id = $1 AND deleted_at IS NULL`
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("word stats query: %w", err)
+	}
+	defer rows.Close()
+
+	entries := 0
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return 0, 0, fmt.Errorf("word stats scan: %w", err)
+		}
+		total += countWords(content)
+		entries++
+	}
+	if err = rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("word stats rows iteration: %w", err)
+	}
+
+	if entries > 0 {
+		avg = float64(total) / float64(entries)
+	}
+	return total, avg, nil
+}
+
+// countStreaks measures runs of consecutive days in days, which must be distinct
+// calendar dates in ascending order. now decides which run, if any, is current; its
+// date is read in its own location, so pass it in the zone the days were bucketed in.
+func countStreaks(days []time.Time, now time.Time) (current, longest int) {
+	run := 0
+	var previous time.Time
+	for i, day := range days {
+		day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+		if i > 0 && previous.AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		previous = day
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if len(days) > 0 && (previous.Equal(today) || previous.AddDate(0, 0, 1).Equal(today)) {
+		current = run
+	}
+	return current, longest
+}
+
 // GetFirstEntryDate fetches the timestamp of the user's very first mood entry.
 // Used to calculate the duration for average entries per week.
-func (m *MoodModel) GetFirstEntryDate(userID int64) (time.Time, error) {
+func (m *MoodModel) GetFirstEntryDate(ctx context.Context, userID int64) (time.Time, error) {
 	// ... (Implementation with UserID check, SQL query with MIN(created_at), context, scan into sql.NullTime) ...
 	if userID < 1 {
 		return time.Time{}, errors.New("invalid user ID")
 	}
-	query := `SELECT MIN(created_at) FROM moods WHERE user_id = $1`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	query := `SELECT MIN(created_at) FROM moods WHERE user_id = $1 AND deleted_at IS NULL`
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 	var firstDate sql.NullTime
 	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&firstDate)
@@ -595,15 +1781,17 @@ func (m *MoodModel) GetFirstEntryDate(userID int64) (time.Time, error) {
 	return firstDate.Time, nil
 }
 
-// GetAllStats - Fetches all stats, now using weekly counts
-func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
+// GetAllStats - Fetches all stats, now using weekly counts. Every day, week, month and
+// hour is taken from the calendar in timeZone (an IANA name; empty means UTC), so all
+// the charts on the stats page agree with each other and with the dashboard filters.
+func (m *MoodModel) GetAllStats(ctx context.Context, userID int64, timeZone string) (*MoodStats, error) {
 	// 1. Validate UserID.
 	if userID < 1 {
 		return nil, errors.New("invalid user ID for getting stats")
 	}
 
 	// 2. Get Total Entries.
-	total, err := m.GetTotalMoodCount(userID)
+	total, err := m.GetTotalMoodCount(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
@@ -613,7 +1801,12 @@ func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
 		TotalEntries:      total,
 		EmotionCounts:     []EmotionCount{},
 		WeeklyCounts:      []WeeklyCount{},
+		EmotionTrend:      []WeeklyEmotionCount{},
 		AvgEntriesPerWeek: 0.0,
+		SameDayPairs:      []EmotionPairCount{},
+		MonthlyCounts:     []MonthlyCount{},
+		WeekdayCounts:     []WeekdayCount{},
+		HourlyCounts:      []HourlyCount{},
 	}
 
 	// 4. Early Exit if No Entries: If no moods, no further stats to calculate.
@@ -622,7 +1815,7 @@ func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
 	}
 
 	// 5. Fetch Latest Mood.
-	latestMood, err := m.GetLatestMood(userID)
+	latestMood, err := m.GetLatestMood(ctx, userID)
 	if err != nil {
 		// Don't return error if it's just sql.ErrNoRows
 		if !errors.Is(err, sql.ErrNoRows) {
@@ -633,7 +1826,7 @@ func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
 	stats.LatestMood = latestMood
 
 	// 6. Fetch Emotion Counts and Determine Most Common.
-	emotionCounts, err := m.GetEmotionCounts(userID)
+	emotionCounts, err := m.GetEmotionCounts(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get emotion counts: %w", err)
 	}
@@ -643,14 +1836,64 @@ func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
 	}
 
 	// 7. Fetch Weekly Counts.
-	weeklyCounts, err := m.GetWeeklyEntryCounts(userID)
+	weeklyCounts, err := m.GetWeeklyEntryCounts(ctx, userID, timeZone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get weekly counts: %w", err)
 	}
 	stats.WeeklyCounts = weeklyCounts
 
+	emotionTrend, err := m.GetEmotionCountsByWeek(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly emotion counts: %w", err)
+	}
+	stats.EmotionTrend = emotionTrend
+
+	// 7b. Fetch Emotions Often Logged on the Same Day.
+	sameDayPairs, err := m.GetSameDayEmotionPairs(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get same-day emotion pairs: %w", err)
+	}
+	stats.SameDayPairs = sameDayPairs
+
+	// 7c. Fetch Monthly, Weekday and Hour-of-Day Breakdowns.
+	monthlyCounts, err := m.GetMonthlyEntryCounts(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly counts: %w", err)
+	}
+	stats.MonthlyCounts = monthlyCounts
+
+	weekdayCounts, err := m.GetWeekdayEntryCounts(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekday counts: %w", err)
+	}
+	stats.WeekdayCounts = weekdayCounts
+
+	hourlyCounts, err := m.GetHourlyEntryCounts(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly counts: %w", err)
+	}
+	stats.HourlyCounts = hourlyCounts
+
+	// 7d. Fetch Daily Streaks.
+	stats.CurrentStreak, stats.LongestStreak, err = m.GetStreaks(ctx, userID, timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+
+	// 7e. Fetch This Week's, Month's and Year's Counts.
+	stats.EntriesThisWeek, stats.EntriesThisMonth, stats.EntriesThisYear, err = m.GetPeriodCounts(ctx, userID, time.Now().In(locationOrUTC(timeZone)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get period counts: %w", err)
+	}
+
+	// 7f. Fetch Word Counts.
+	stats.TotalWords, stats.AvgWordsPerEntry, err = m.GetWordStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word stats: %w", err)
+	}
+
 	// 8. Fetch First Entry Date (for calculating average).
-	firstEntryDate, err := m.GetFirstEntryDate(userID)
+	firstEntryDate, err := m.GetFirstEntryDate(ctx, userID)
 	if err != nil { // GetFirstEntryDate handles ErrNoRows by returning zero time.
 		return nil, fmt.Errorf("failed to get first entry date: %w", err)
 	}
@@ -672,10 +1915,11 @@ func (m *MoodModel) GetAllStats(userID int64) (*MoodStats, error) {
 	return stats, nil
 }
 
-// DeleteAllByUserID removes all mood entries for a specific user.
+// DeleteAllByUserID removes all mood entries for a specific user, including any in the
+// trash. Unlike Delete this is permanent: nothing is left to restore.
 // Used for the "Reset Entries" feature on the profile page.
 // Data management: Allows a user to clear all their mood data.
-func (m *MoodModel) DeleteAllByUserID(userID int64) error {
+func (m *MoodModel) DeleteAllByUserID(ctx context.Context, userID int64) error {
 	// 1. Validate UserID.
 	if userID < 1 {
 		return errors.New("invalid user ID provided for deleting moods")
@@ -684,7 +1928,7 @@ func (m *MoodModel) DeleteAllByUserID(userID int64) error {
 	// 2. SQL Query: Deletes all moods where user_id matches.
 	query := `DELETE FROM moods WHERE user_id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // Longer timeout for potentially many deletes.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second) // Longer timeout for potentially many deletes.
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, userID)
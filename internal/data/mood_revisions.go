@@ -0,0 +1,88 @@
+// mood/internal/data/mood_revisions.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MoodRevision is a snapshot of a mood entry as it was before an edit replaced it.
+// Update and UpdatePartial record one per save, so every earlier version is kept.
+type MoodRevision struct {
+	ID         int64     `json:"id"`
+	MoodID     int64     `json:"mood_id"`
+	Version    int       `json:"version"` // The mood's version this snapshot was of.
+	Title      string    `json:"title"`
+	Content    string    `json:"content"` // HTML as stored; sanitize with SanitizeContent before display.
+	Emotion    string    `json:"emotion"`
+	Emoji      string    `json:"emoji"`
+	Color      string    `json:"color"`
+	Intensity  int       `json:"intensity"`
+	SavedAt    time.Time `json:"saved_at"`    // When this version was written.
+	ReplacedAt time.Time `json:"replaced_at"` // When an edit replaced it.
+}
+
+// SanitizeContent returns the revision's content cleaned with the same policy as
+// Mood.SanitizeContent, so history renders exactly like the live entry.
+func (r *MoodRevision) SanitizeContent() string {
+	return SanitizeHTML(r.Content)
+}
+
+// snapshotMood copies the user's live mood into mood_revisions inside tx. A version of 0
+// snapshots whatever version is current; otherwise only that version is copied. It
+// reports whether a row was copied, so the caller can tell a missing or stale entry apart.
+func snapshotMood(ctx context.Context, tx *sql.Tx, id, userID int64, version int) (bool, error) {
+	query := `
+        INSERT INTO mood_revisions (mood_id, user_id, version, title, content, emotion, emoji, color, intensity, saved_at)
+        SELECT id, user_id, version, title, content, emotion, emoji, color, intensity, updated_at
+        FROM moods
+        WHERE id = $1 AND user_id = $2 AND ($3 = 0 OR version = $3) AND deleted_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, id, userID, version)
+	if err != nil {
+		return false, fmt.Errorf("mood revision snapshot: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mood revision snapshot: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// GetRevisions returns the earlier versions of a user's mood, newest first.
+// Another user's mood has no revisions as far as the caller can tell.
+func (m *MoodModel) GetRevisions(ctx context.Context, id, userID int64) ([]*MoodRevision, error) {
+	if id < 1 || userID < 1 {
+		return nil, ErrRecordNotFound
+	}
+	query := `
+        SELECT id, mood_id, version, title, content, emotion, emoji, color, intensity, saved_at, replaced_at
+        FROM mood_revisions
+        WHERE mood_id = $1 AND user_id = $2
+        ORDER BY version DESC, id DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("mood revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*MoodRevision{}
+	for rows.Next() {
+		var r MoodRevision
+		if err := rows.Scan(&r.ID, &r.MoodID, &r.Version, &r.Title, &r.Content, &r.Emotion, &r.Emoji,
+			&r.Color, &r.Intensity, &r.SavedAt, &r.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("mood revisions scan: %w", err)
+		}
+		revisions = append(revisions, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("mood revisions rows: %w", err)
+	}
+	return revisions, nil
+}
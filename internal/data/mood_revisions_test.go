@@ -0,0 +1,68 @@
+// mood/internal/data/mood_revisions_test.go
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMoodModel_Revisions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	ownerID := insertTestUser(t, db)
+	otherID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	mood := &Mood{Title: "First", Content: "<p>original wording</p>", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: ownerID}
+	if err := model.Insert(ctx, mood); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if revisions, err := model.GetRevisions(ctx, mood.ID, ownerID); err != nil || len(revisions) != 0 {
+		t.Fatalf("Expected no revisions for a new entry, got %d (err %v)", len(revisions), err)
+	}
+
+	// Each save keeps the version it replaced.
+	stale := *mood
+	mood.Title, mood.Content = "Second", "<p>rewritten</p>"
+	if err := model.Update(ctx, mood); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	mood.Title = "Third"
+	if err := model.UpdatePartial(ctx, mood, []string{"title"}); err != nil {
+		t.Fatalf("UpdatePartial failed: %v", err)
+	}
+
+	// A conflicting save writes nothing, not even a revision.
+	stale.Title = "Stale"
+	if err := model.Update(ctx, &stale); !errors.Is(err, ErrEditConflict) {
+		t.Fatalf("Expected ErrEditConflict, got %v", err)
+	}
+
+	revisions, err := model.GetRevisions(ctx, mood.ID, ownerID)
+	if err != nil {
+		t.Fatalf("GetRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Title != "Second" || revisions[1].Title != "First" || revisions[1].Content != "<p>original wording</p>" {
+		t.Errorf("Expected Second then First, got %q then %q", revisions[0].Title, revisions[1].Title)
+	}
+	if revisions[0].Version <= revisions[1].Version {
+		t.Errorf("Expected newest first, got versions %d then %d", revisions[0].Version, revisions[1].Version)
+	}
+
+	// Another user sees no history, and missing entries still report ErrRecordNotFound.
+	if revisions, err := model.GetRevisions(ctx, mood.ID, otherID); err != nil || len(revisions) != 0 {
+		t.Errorf("Expected no revisions for another user, got %d (err %v)", len(revisions), err)
+	}
+	if err := model.Update(ctx, &Mood{ID: mood.ID, UserID: otherID, Title: "x", Version: mood.Version}); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for another user's entry, got %v", err)
+	}
+}
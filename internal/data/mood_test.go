@@ -4,10 +4,12 @@ package data
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -74,11 +76,11 @@ func insertTestUser(t *testing.T, db *sql.DB) int64 {
 		t.Fatalf("Failed to set test user password: %v", err)
 	}
 	userModel := UserModel{DB: db}
-	err = userModel.Insert(user)
+	err = userModel.Insert(context.Background(), user)
 	// Handle potential duplicate email error gracefully during setup
 	if err != nil && errors.Is(err, ErrDuplicateEmail) {
 		// If duplicate, try fetching the existing user
-		existingUser, getErr := userModel.GetByEmail(email)
+		existingUser, getErr := userModel.GetByEmail(context.Background(), email)
 		if getErr != nil {
 			t.Fatalf("Failed to insert test user (%v) and failed to fetch existing user (%v)", err, getErr)
 		}
@@ -106,7 +108,7 @@ func TestMoodModel_GetTotalMoodCount(t *testing.T) {
 	model := MoodModel{DB: db}
 
 	t.Run("NoEntries", func(t *testing.T) {
-		count, err := model.GetTotalMoodCount(testUserID)
+		count, err := model.GetTotalMoodCount(context.Background(), testUserID)
 		if err != nil {
 			t.Fatalf("Expected no error, got %s", err)
 		}
@@ -119,7 +121,7 @@ func TestMoodModel_GetTotalMoodCount(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to insert test data: %s", err)
 		}
-		count, err := model.GetTotalMoodCount(testUserID)
+		count, err := model.GetTotalMoodCount(context.Background(), testUserID)
 		if err != nil {
 			t.Fatalf("Expected no error, got %s", err)
 		}
@@ -129,44 +131,85 @@ func TestMoodModel_GetTotalMoodCount(t *testing.T) {
 	})
 }
 
-func TestMoodModel_GetEmotionCounts(t *testing.T) {
+func TestMoodModel_GetGlobalTotals(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
 	db := newTestDB(t)
 	defer db.Close()
 	defer cleanupTestDB(t, db)
-	testUserID := insertTestUser(t, db)
+	user1 := insertTestUser(t, db)
+	user2 := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	t.Run("NoEntries", func(t *testing.T) {
-		counts, err := model.GetEmotionCounts(testUserID)
-		if err != nil {
-			t.Fatalf("Expected no error, got %s", err)
-		}
-		if len(counts) != 0 {
-			t.Errorf("Expected 0 emotion counts, got %d", len(counts))
-		}
-	})
-	t.Run("WithEntries", func(t *testing.T) {
-		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES ('T1','','Happy','😊','#FFD700', $1),('T2','','Sad','😢','#6495ED', $1),('T3','','Happy','😊','#FFD700', $1),('T4','','Calm','😌','#90EE90', $1),('T5','','Happy','😊','#FFD700', $1)`, testUserID)
-		if err != nil {
-			t.Fatalf("Failed to insert test data: %s", err)
-		}
-		counts, err := model.GetEmotionCounts(testUserID)
-		if err != nil {
-			t.Fatalf("Expected no error, got %s", err)
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES
+        ('T1','','H','h','#fff', $1), ('T2','','S','s','#000', $1), ('T3','','H','h','#fff', $2)`, user1, user2)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	total, err := model.GetGlobalTotals(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 moods across all users, got %d", total)
+	}
+}
+
+func TestCalculateMetadata(t *testing.T) {
+	tests := []struct {
+		name                     string
+		totalRecords, page, size int
+		want                     Metadata
+	}{
+		{"ZeroRecords", 0, 1, 4, Metadata{}},
+		{"OnePage", 3, 1, 4, Metadata{CurrentPage: 1, PageSize: 4, FirstPage: 1, LastPage: 1, TotalRecords: 3}},
+		{"ExactlyFullPage", 4, 1, 4, Metadata{CurrentPage: 1, PageSize: 4, FirstPage: 1, LastPage: 1, TotalRecords: 4}},
+		{"FirstOfMany", 9, 1, 4, Metadata{CurrentPage: 1, PageSize: 4, FirstPage: 1, LastPage: 3, TotalRecords: 9, HasNext: true, NextPage: 2}},
+		{"Middle", 9, 2, 4, Metadata{CurrentPage: 2, PageSize: 4, FirstPage: 1, LastPage: 3, TotalRecords: 9, HasPrevious: true, PrevPage: 1, HasNext: true, NextPage: 3}},
+		{"Last", 9, 3, 4, Metadata{CurrentPage: 3, PageSize: 4, FirstPage: 1, LastPage: 3, TotalRecords: 9, HasPrevious: true, PrevPage: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateMetadata(tt.totalRecords, tt.page, tt.size); got != tt.want {
+				t.Errorf("calculateMetadata(%d, %d, %d) =\n%+v\nwant\n%+v", tt.totalRecords, tt.page, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmotionFilterEncoding(t *testing.T) {
+	tests := []struct {
+		name, emoji string
+	}{
+		{name: "Happy", emoji: "😊"},
+		{name: "Note: tired", emoji: ":-)"},
+		{name: "a::b", emoji: "::"},
+		{name: "100% done", emoji: "+"},
+	}
+	for _, tt := range tests {
+		encoded := EncodeEmotionFilter(tt.name, tt.emoji)
+		if strings.Count(encoded, "::") != 1 {
+			t.Errorf("EncodeEmotionFilter(%q, %q) = %q: expected exactly one separator", tt.name, tt.emoji, encoded)
 		}
-		// Expected order is by count DESC, then name ASC
-		expected := []EmotionCount{{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}, {Name: "Calm", Emoji: "😌", Color: "#90EE90", Count: 1}, {Name: "Sad", Emoji: "😢", Color: "#6495ED", Count: 1}}
-		if !reflect.DeepEqual(counts, expected) {
-			t.Errorf("Mismatch in emotion counts.\nExpected: %+v\nGot:      %+v", expected, counts)
+		name, emoji, ok := DecodeEmotionFilter(encoded)
+		if !ok || name != tt.name || emoji != tt.emoji {
+			t.Errorf("Round trip of (%q, %q) gave (%q, %q, %v)", tt.name, tt.emoji, name, emoji, ok)
 		}
-	})
+	}
+
+	// Older links carried the raw emoji.
+	if name, emoji, ok := DecodeEmotionFilter("Happy::😊"); !ok || name != "Happy" || emoji != "😊" {
+		t.Errorf("Expected legacy value to decode, got (%q, %q, %v)", name, emoji, ok)
+	}
+	// A plain emotion name has no separator.
+	if _, _, ok := DecodeEmotionFilter("Happy"); ok {
+		t.Error("Expected a plain emotion name not to decode as a pair")
+	}
 }
 
-// ** UPDATED Test for GetAllStats **
-func TestMoodModel_GetAllStats(t *testing.T) {
+func TestMoodModel_GetFiltered_EmotionWithColon(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
@@ -176,86 +219,60 @@ func TestMoodModel_GetAllStats(t *testing.T) {
 	testUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	t.Run("NoEntries", func(t *testing.T) {
-		stats, err := model.GetAllStats(testUserID)
-		if err != nil {
-			t.Fatalf("Expected no error, got %s", err)
-		}
-		if stats == nil {
-			t.Fatal("Expected stats struct, got nil")
-		}
-		if stats.TotalEntries != 0 {
-			t.Errorf("Expected TotalEntries 0, got %d", stats.TotalEntries)
-		}
-		if stats.MostCommonEmotion != nil {
-			t.Errorf("Expected MostCommonEmotion nil, got %+v", stats.MostCommonEmotion)
-		}
-		if len(stats.EmotionCounts) != 0 {
-			t.Errorf("Expected 0 EmotionCounts, got %d", len(stats.EmotionCounts))
-		}
-		// Check WeeklyCounts is empty
-		if len(stats.WeeklyCounts) != 0 {
-			t.Errorf("Expected 0 WeeklyCounts, got %d", len(stats.WeeklyCounts))
-		}
-		if stats.LatestMood != nil {
-			t.Errorf("Expected LatestMood nil, got %+v", stats.LatestMood)
-		}
-		if stats.AvgEntriesPerWeek != 0.0 {
-			t.Errorf("Expected AvgEntriesPerWeek 0.0, got %f", stats.AvgEntriesPerWeek)
-		}
-	})
-	t.Run("WithEntries", func(t *testing.T) {
-		// Insert data similar to GetWeeklyEntryCounts test
-		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
-            ('Week1-1','','Happy','😊','#FFD700','2024-01-01 10:00:00+00', $1),
-            ('Week1-2','','Sad','😢','#6495ED','2024-01-03 11:00:00+00', $1),
-            ('Week2-1','','Happy','😊','#FFD700','2024-01-08 12:00:00+00', $1),
-            ('Week2-2','','Happy','😊','#FFD700','2024-01-10 13:00:00+00', $1),
-            ('Week2-3','','Calm','😌','#90EE90','2024-01-14 14:00:00+00', $1)`,
-			testUserID)
-		if err != nil {
-			t.Fatalf("Failed to insert test data: %s", err)
-		}
-		stats, err := model.GetAllStats(testUserID)
-		if err != nil {
-			t.Fatalf("Expected no error, got %s", err)
-		}
-		if stats == nil {
-			t.Fatal("Expected stats struct, got nil")
-		}
-		if stats.TotalEntries != 5 {
-			t.Errorf("Expected TotalEntries 5, got %d", stats.TotalEntries)
-		}
-		expectedMostCommon := EmotionCount{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}
-		if stats.MostCommonEmotion == nil {
-			t.Fatalf("Expected MostCommonEmotion %+v, got nil", expectedMostCommon)
-		}
-		if !reflect.DeepEqual(*stats.MostCommonEmotion, expectedMostCommon) {
-			t.Errorf("Mismatch in MostCommonEmotion.\nExpected: %+v\nGot:      %+v", expectedMostCommon, *stats.MostCommonEmotion)
-		}
-		expectedEmotionCounts := []EmotionCount{{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}, {Name: "Calm", Emoji: "😌", Color: "#90EE90", Count: 1}, {Name: "Sad", Emoji: "😢", Color: "#6495ED", Count: 1}}
-		if !reflect.DeepEqual(stats.EmotionCounts, expectedEmotionCounts) {
-			t.Errorf("Mismatch in EmotionCounts.\nExpected: %+v\nGot:      %+v", expectedEmotionCounts, stats.EmotionCounts)
-		}
-		// Verify WeeklyCounts
-		expectedWeeklyCounts := []WeeklyCount{{Week: "2024-01", Count: 2}, {Week: "2024-02", Count: 3}}
-		if !reflect.DeepEqual(stats.WeeklyCounts, expectedWeeklyCounts) {
-			t.Errorf("Mismatch in WeeklyCounts.\nExpected: %+v\nGot:      %+v", expectedWeeklyCounts, stats.WeeklyCounts)
-		}
-		// Verify Latest Mood (should be the last one inserted chronologically)
-		if stats.LatestMood == nil || stats.LatestMood.Title != "Week2-3" {
-			t.Errorf("Expected LatestMood 'Week2-3', got %+v", stats.LatestMood)
-		}
-		// Basic check for AvgEntriesPerWeek (value depends on when test is run vs '2024-01-01')
-		if stats.AvgEntriesPerWeek <= 0 {
-			t.Errorf("Expected positive AvgEntriesPerWeek, got %f", stats.AvgEntriesPerWeek)
-		}
-	})
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES
+        ('Colon', '', 'Note: tired', ':-)', '#fff', $1), ('Other', '', 'Note', ' tired::-)', '#fff', $1)`, testUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	filters := FilterCriteria{Emotion: EncodeEmotionFilter("Note: tired", ":-)"), Weekday: AnyWeekday, Page: 1, PageSize: 10, UserID: testUserID}
+	moods, _, err := model.GetFiltered(context.Background(), filters)
+	if err != nil {
+		t.Fatalf("GetFiltered failed: %v", err)
+	}
+	if len(moods) != 1 || moods[0].Title != "Colon" {
+		t.Errorf("Expected only the 'Colon' entry, got %d moods", len(moods))
+	}
 }
 
-// --- CRUD Tests (Unchanged - already handle UserID) ---
+func TestMoodModel_GetAllForUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
 
-func TestMoodModel_Insert(t *testing.T) {
+	moods, err := model.GetAllForUser(ctx, testUserID)
+	if err != nil || len(moods) != 0 {
+		t.Fatalf("Expected no entries for a new user, got %d (err %v)", len(moods), err)
+	}
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err = db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at, private_note) VALUES
+        ('Second','','H','h','#fff', $1, $3, 'secret'), ('First','','H','h','#fff', $1, $4, ''), ('Other','','H','h','#fff', $2, $4, '')`,
+		testUserID, otherUserID, day, day.AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	moods, err = model.GetAllForUser(ctx, testUserID)
+	if err != nil {
+		t.Fatalf("GetAllForUser failed: %v", err)
+	}
+	if len(moods) != 2 || moods[0].Title != "First" || moods[1].Title != "Second" {
+		t.Fatalf("Expected the user's two entries oldest first, got %+v", moods)
+	}
+	if moods[1].PrivateNote != "" {
+		t.Error("Expected private_note to be left out of the export query")
+	}
+}
+
+func TestMoodModel_GetByMonth(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
@@ -265,43 +282,74 @@ func TestMoodModel_Insert(t *testing.T) {
 	testUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	t.Run("InsertValidMood", func(t *testing.T) {
-		mood := &Mood{
-			Title: "Test Insert", Content: "<p>This is content</p>",
-			Emotion: "Neutral", Emoji: "😐", Color: "#B0C4DE",
-			UserID: testUserID,
+	// 30 April 20:00 UTC is already 1 May in Singapore (UTC+8); 31 May 20:00 UTC is
+	// already June there.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('LateApril','','H','h','#fff', $1, '2024-04-30 20:00:00+00'),
+        ('MidMay','','H','h','#fff', $1, '2024-05-15 12:00:00+00'),
+        ('LateMay','','H','h','#fff', $1, '2024-05-31 20:00:00+00')`, testUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	titles := func(moods []*Mood) []string {
+		names := []string{}
+		for _, m := range moods {
+			names = append(names, m.Title)
 		}
-		err := model.Insert(mood)
+		return names
+	}
+
+	t.Run("UTC", func(t *testing.T) {
+		moods, err := model.GetByMonth(context.Background(), testUserID, "", 2024, time.May)
 		if err != nil {
-			t.Fatalf("Insert failed: %v", err)
-		}
-		if mood.ID == 0 {
-			t.Errorf("Expected non-zero ID after insert, got 0")
+			t.Fatalf("GetByMonth failed: %v", err)
 		}
-		if mood.CreatedAt.IsZero() {
-			t.Errorf("Expected non-zero CreatedAt after insert")
-		}
-		if mood.UpdatedAt.IsZero() {
-			t.Errorf("Expected non-zero UpdatedAt after insert")
+		if got, want := titles(moods), []string{"MidMay", "LateMay"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
 		}
+	})
 
-		fetchedMood, errGet := model.Get(mood.ID, testUserID)
-		if errGet != nil {
-			t.Fatalf("Failed to fetch inserted mood: %v", errGet)
-		}
-		if fetchedMood == nil {
-			t.Fatal("Fetched mood is nil after insert")
-		}
-		if fetchedMood.Title != mood.Title {
-			t.Errorf("Expected Title %q, got %q", mood.Title, fetchedMood.Title)
+	t.Run("UserTimeZone", func(t *testing.T) {
+		moods, err := model.GetByMonth(context.Background(), testUserID, "Asia/Singapore", 2024, time.May)
+		if err != nil {
+			t.Fatalf("GetByMonth failed: %v", err)
 		}
-		if fetchedMood.UserID != testUserID {
-			t.Errorf("Expected UserID %d, got %d", testUserID, fetchedMood.UserID)
+		if got, want := titles(moods), []string{"LateApril", "MidMay"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
 		}
 	})
 }
 
-func TestMoodModel_Get(t *testing.T) {
+func TestCountStreaks(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.UTC)
+	day := func(daysAgo int) time.Time { return time.Date(2024, 5, 10-daysAgo, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name                     string
+		days                     []time.Time
+		wantCurrent, wantLongest int
+	}{
+		{"NoEntries", nil, 0, 0},
+		{"SingleDayToday", []time.Time{day(0)}, 1, 1},
+		{"SingleDayLongAgo", []time.Time{day(30)}, 0, 1},
+		{"RunEndingToday", []time.Time{day(2), day(1), day(0)}, 3, 3},
+		{"RunEndingYesterdayStillCurrent", []time.Time{day(3), day(2), day(1)}, 3, 3},
+		{"RunEndingTwoDaysAgoIsBroken", []time.Time{day(4), day(3), day(2)}, 0, 3},
+		{"GapSplitsRuns", []time.Time{day(9), day(8), day(7), day(6), day(3), day(1), day(0)}, 2, 4},
+		{"AcrossMonthBoundary", []time.Time{time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := countStreaks(tt.days, now)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("countStreaks() = (%d, %d), want (%d, %d)", current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
+
+func TestMoodModel_GetStreaks(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
@@ -312,60 +360,158 @@ func TestMoodModel_Get(t *testing.T) {
 	otherUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	moodUser1 := &Mood{Title: "User1 Mood", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: testUserID}
-	err := model.Insert(moodUser1)
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return today.AddDate(0, 0, -n) }
+
+	t.Run("NoEntries", func(t *testing.T) {
+		current, longest, err := model.GetStreaks(context.Background(), testUserID, "UTC")
+		if err != nil || current != 0 || longest != 0 {
+			t.Errorf("GetStreaks() = (%d, %d, %v), want (0, 0, nil)", current, longest, err)
+		}
+	})
+
+	// Three entries today and two yesterday count once per day. A three-day run ended a
+	// week ago. The other user's entry two days ago must not bridge the gap.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('A','','H','h','#fff', $1, $3), ('B','','H','h','#fff', $1, $4), ('C','','H','h','#fff', $1, $5),
+        ('D','','H','h','#fff', $1, $6), ('E','','H','h','#fff', $1, $7),
+        ('F','','H','h','#fff', $1, $8), ('G','','H','h','#fff', $1, $9), ('H','','H','h','#fff', $1, $10),
+        ('I','','H','h','#fff', $2, $11)`,
+		testUserID, otherUserID,
+		today.Add(time.Minute), today.Add(2*time.Minute), today.Add(3*time.Minute),
+		daysAgo(1).Add(time.Hour), daysAgo(1).Add(23*time.Hour),
+		daysAgo(9).Add(12*time.Hour), daysAgo(8).Add(12*time.Hour), daysAgo(7).Add(12*time.Hour),
+		daysAgo(2).Add(12*time.Hour))
 	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+		t.Fatalf("Failed to insert test data: %s", err)
 	}
-	moodUser2 := &Mood{Title: "User2 Mood", Content: "...", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: otherUserID}
-	err = model.Insert(moodUser2)
+
+	current, longest, err := model.GetStreaks(context.Background(), testUserID, "UTC")
 	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+		t.Fatalf("GetStreaks failed: %v", err)
+	}
+	if current != 2 || longest != 3 {
+		t.Errorf("GetStreaks() = (%d, %d), want (2, 3)", current, longest)
 	}
 
-	t.Run("GetExistingOwned", func(t *testing.T) {
-		fetchedMood, err := model.Get(moodUser1.ID, testUserID)
+	stats, err := model.GetAllStats(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetAllStats failed: %v", err)
+	}
+	if stats.CurrentStreak != 2 || stats.LongestStreak != 3 {
+		t.Errorf("GetAllStats streaks = (%d, %d), want (2, 3)", stats.CurrentStreak, stats.LongestStreak)
+	}
+}
+
+func TestPeriodStarts(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	tests := []struct {
+		name              string
+		now               time.Time
+		week, month, year time.Time
+	}{
+		{"Midweek", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"SundayBelongsToWeekBefore", time.Date(2024, 5, 19, 23, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"MondayStartsWeek", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"WeekStartsLastYear", time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"UserZone", time.Date(2024, 3, 12, 8, 0, 0, 0, newYork),
+			time.Date(2024, 3, 11, 0, 0, 0, 0, newYork), time.Date(2024, 3, 1, 0, 0, 0, 0, newYork), time.Date(2024, 1, 1, 0, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			week, month, year := periodStarts(tt.now)
+			if !week.Equal(tt.week) || !month.Equal(tt.month) || !year.Equal(tt.year) {
+				t.Errorf("periodStarts(%v) = (%v, %v, %v), want (%v, %v, %v)", tt.now, week, month, year, tt.week, tt.month, tt.year)
+			}
+		})
+	}
+}
+
+func TestMoodModel_GetPeriodCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	januaryUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	// now is Wednesday 15 May 2024; the week started on Monday the 13th. Each entry sits
+	// just inside or outside one of the boundaries.
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	insert := func(userID int64, createdAt time.Time) int64 {
+		t.Helper()
+		var id int64
+		err := db.QueryRow(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at)
+            VALUES ('T', '', 'H', 'h', '#fff', $1, $2) RETURNING id`, userID, createdAt).Scan(&id)
 		if err != nil {
-			t.Fatalf("Get failed for existing owned ID %d: %v", moodUser1.ID, err)
+			t.Fatalf("Failed to insert test data: %s", err)
 		}
-		if fetchedMood == nil {
-			t.Fatal("Get returned nil for existing owned ID")
+		return id
+	}
+	insert(testUserID, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC))    // This week.
+	insert(testUserID, time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC))   // This week.
+	insert(testUserID, time.Date(2024, 5, 12, 23, 59, 0, 0, time.UTC))  // Last week, this month.
+	insert(testUserID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))     // This month.
+	insert(testUserID, time.Date(2024, 4, 30, 23, 59, 0, 0, time.UTC))  // Last month, this year.
+	insert(testUserID, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))     // This year.
+	insert(testUserID, time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC)) // Last year.
+	trashed := insert(testUserID, time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC))
+	if err := model.Delete(ctx, trashed, testUserID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	t.Run("UTC", func(t *testing.T) {
+		week, month, year, err := model.GetPeriodCounts(ctx, testUserID, now)
+		if err != nil {
+			t.Fatalf("GetPeriodCounts failed: %v", err)
 		}
-		if fetchedMood.ID != moodUser1.ID || fetchedMood.UserID != testUserID {
-			t.Errorf("Mismatch in fetched mood: Got ID %d, UserID %d", fetchedMood.ID, fetchedMood.UserID)
+		if week != 2 || month != 4 || year != 6 {
+			t.Errorf("GetPeriodCounts() = (%d, %d, %d), want (2, 4, 6)", week, month, year)
 		}
 	})
 
-	t.Run("GetExistingNotOwned", func(t *testing.T) {
-		_, err := model.Get(moodUser2.ID, testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when getting non-owned mood, got %v", err)
+	t.Run("UserZone", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skipf("time zone data unavailable: %v", err)
 		}
-	})
-
-	t.Run("GetNonExistentPositiveID", func(t *testing.T) {
-		_, err := model.Get(int64(999999), testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound for non-existent ID, got %v", err)
+		// In New York, midnight UTC on 13 May is still Sunday evening, 1 May at midnight
+		// UTC is still April, and 1 January at midnight UTC is still last year.
+		week, month, year, err := model.GetPeriodCounts(ctx, testUserID, now.In(newYork))
+		if err != nil {
+			t.Fatalf("GetPeriodCounts failed: %v", err)
 		}
-	})
-
-	t.Run("GetZeroID", func(t *testing.T) {
-		_, err := model.Get(0, testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound for ID 0, got %v", err)
+		if week != 1 || month != 3 || year != 5 {
+			t.Errorf("GetPeriodCounts() = (%d, %d, %d), want (1, 3, 5)", week, month, year)
 		}
 	})
 
-	t.Run("GetNegativeID", func(t *testing.T) {
-		_, err := model.Get(-1, testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound for ID -1, got %v", err)
+	t.Run("WeekStartsLastYear", func(t *testing.T) {
+		insert(januaryUserID, time.Date(2024, 12, 30, 10, 0, 0, 0, time.UTC))
+		insert(januaryUserID, time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC))
+		week, month, year, err := model.GetPeriodCounts(ctx, januaryUserID, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("GetPeriodCounts failed: %v", err)
+		}
+		if week != 2 || month != 1 || year != 1 {
+			t.Errorf("GetPeriodCounts() = (%d, %d, %d), want (2, 1, 1)", week, month, year)
 		}
 	})
 }
 
-func TestMoodModel_Update(t *testing.T) {
+func TestMoodModel_GetMissingDays(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
@@ -376,71 +522,1444 @@ func TestMoodModel_Update(t *testing.T) {
 	otherUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	originalMood := &Mood{Title: "Original Title", Content: "...", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: testUserID}
-	err := model.Insert(originalMood)
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return today.AddDate(0, 0, -n) }
+
+	// Entries today, 2 days ago (twice) and 5 days ago; another user's entry 1 day ago
+	// must not fill that gap. The entry 9 days ago is outside the 7-day window.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('A','','H','h','#fff', $1, $3), ('B','','H','h','#fff', $1, $4), ('C','','H','h','#fff', $1, $5),
+        ('D','','H','h','#fff', $1, $6), ('E','','H','h','#fff', $2, $7), ('F','','H','h','#fff', $1, $8)`,
+		testUserID, otherUserID,
+		today.Add(time.Minute), daysAgo(2).Add(9*time.Hour), daysAgo(2).Add(23*time.Hour), daysAgo(5).Add(12*time.Hour),
+		daysAgo(1).Add(12*time.Hour), daysAgo(9).Add(12*time.Hour))
 	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+		t.Fatalf("Failed to insert test data: %s", err)
 	}
-	otherUserMood := &Mood{Title: "Other User Mood", Content: "...", Emotion: "Angry", Emoji: "😠", Color: "#DC143C", UserID: otherUserID}
-	err = model.Insert(otherUserMood)
+
+	missing, err := model.GetMissingDays(context.Background(), testUserID, "", 7)
 	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+		t.Fatalf("GetMissingDays failed: %v", err)
+	}
+	expected := []time.Time{daysAgo(6), daysAgo(4), daysAgo(3), daysAgo(1)}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Missing days mismatch.\nExpected: %v\nGot:      %v", expected, missing)
 	}
-	originalUpdatedAt := originalMood.UpdatedAt
-	time.Sleep(10 * time.Millisecond) // Ensure time difference for UpdatedAt check
 
-	t.Run("UpdateOwned", func(t *testing.T) {
-		moodToUpdate := &Mood{
-			ID: originalMood.ID, Title: "Updated Title", Content: "Updated Content",
-			Emotion: "Excited", Emoji: "🤩", Color: "#FF69B4",
-			UserID: testUserID,
+	t.Run("NonPositiveWindow", func(t *testing.T) {
+		missing, err := model.GetMissingDays(context.Background(), testUserID, "", 0)
+		if err != nil || len(missing) != 0 {
+			t.Errorf("Expected no days for a zero window, got %v (err %v)", missing, err)
 		}
-		err := model.Update(moodToUpdate)
-		if err != nil {
+	})
+
+	t.Run("WindowCapped", func(t *testing.T) {
+		missing, err := model.GetMissingDays(context.Background(), otherUserID, "", 10_000)
+		if err != nil {
+			t.Fatalf("GetMissingDays failed: %v", err)
+		}
+		if len(missing) != MaxMissingDaysWindow-1 { // Only the day with the other user's entry is logged.
+			t.Errorf("Expected %d missing days in the capped window, got %d", MaxMissingDaysWindow-1, len(missing))
+		}
+	})
+
+	t.Run("UserTimeZone", func(t *testing.T) {
+		// 00:30 in Singapore (UTC+8) is 16:30 the previous day in UTC, so bucketing in
+		// UTC would leave the user's local today looking missed.
+		loc, err := time.LoadLocation("Asia/Singapore")
+		if err != nil {
+			t.Skipf("time zone data unavailable: %v", err)
+		}
+		zoneUserID := insertTestUser(t, db)
+		local := time.Now().In(loc)
+		localToday := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		_, err = db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at)
+            VALUES ('Early','','H','h','#fff', $1, $2)`, zoneUserID, localToday.Add(30*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %s", err)
+		}
+
+		missing, err := model.GetMissingDays(context.Background(), zoneUserID, "Asia/Singapore", 2)
+		if err != nil {
+			t.Fatalf("GetMissingDays failed: %v", err)
+		}
+		expected := []time.Time{localToday.AddDate(0, 0, -1)}
+		if len(missing) != 1 || !missing[0].Equal(expected[0]) {
+			t.Errorf("Expected only yesterday (local) to be missing.\nExpected: %v\nGot:      %v", expected, missing)
+		}
+	})
+}
+
+func TestMoodModel_GetMonthlyEntryCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Mid-month timestamps across a year boundary, inserted out of order, with a gap
+	// in January and another user's entry in the same months.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('A','','H','h','#fff', $1, '2024-02-14 12:00:00+00'),
+        ('B','','H','h','#fff', $1, '2023-11-15 12:00:00+00'),
+        ('C','','H','h','#fff', $1, '2023-12-10 12:00:00+00'),
+        ('D','','H','h','#fff', $1, '2023-12-20 12:00:00+00'),
+        ('E','','H','h','#fff', $1, '2023-11-01 12:00:00+00'),
+        ('F','','H','h','#fff', $1, '2023-11-28 12:00:00+00'),
+        ('G','','H','h','#fff', $2, '2023-12-15 12:00:00+00')`, testUserID, otherUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	monthly, err := model.GetMonthlyEntryCounts(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetMonthlyEntryCounts failed: %v", err)
+	}
+	expected := []MonthlyCount{{Month: "2023-11", Count: 3}, {Month: "2023-12", Count: 2}, {Month: "2024-02", Count: 1}}
+	if !reflect.DeepEqual(monthly, expected) {
+		t.Errorf("Monthly mismatch.\nExpected: %+v\nGot:      %+v", expected, monthly)
+	}
+
+	t.Run("NoEntries", func(t *testing.T) {
+		empty := insertTestUser(t, db)
+		monthly, err := model.GetMonthlyEntryCounts(context.Background(), empty, "UTC")
+		if err != nil || len(monthly) != 0 {
+			t.Errorf("Expected no months, got %+v (err %v)", monthly, err)
+		}
+	})
+}
+
+func TestMoodModel_GetDailyCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Two entries on May 10 (UTC), one on May 12, one on the exclusive end boundary
+	// and one before the range; plus another user's entry on May 11.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('A','','H','h','#fff', $1, '2024-05-10 00:30:00+00'),
+        ('B','','H','h','#fff', $1, '2024-05-10 23:30:00+00'),
+        ('C','','H','h','#fff', $1, '2024-05-12 12:00:00+00'),
+        ('D','','H','h','#fff', $1, '2024-05-20 00:00:00+00'),
+        ('E','','H','h','#fff', $1, '2024-05-09 23:59:59+00'),
+        ('F','','H','h','#fff', $2, '2024-05-11 12:00:00+00')`, testUserID, otherUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	from := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+	counts, err := model.GetDailyCounts(context.Background(), testUserID, "UTC", from, to)
+	if err != nil {
+		t.Fatalf("GetDailyCounts failed: %v", err)
+	}
+	expected := []DailyCount{{Date: "2024-05-10", Count: 2}, {Date: "2024-05-12", Count: 1}}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Daily mismatch.\nExpected: %+v\nGot:      %+v", expected, counts)
+	}
+}
+
+func TestMoodModel_GetOnThisDay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at, deleted_at) VALUES
+        ('2023', '', 'H','h','#fff', $1, '2023-05-10 09:00:00+00', NULL),
+        ('2021', '', 'H','h','#fff', $1, '2021-05-10 09:00:00+00', NULL),
+        ('Late 2022', '', 'H','h','#fff', $1, '2022-05-10 20:00:00+00', NULL),
+        ('This year', '', 'H','h','#fff', $1, '2024-05-10 08:00:00+00', NULL),
+        ('Next day', '', 'H','h','#fff', $1, '2023-05-11 09:00:00+00', NULL),
+        ('Other month', '', 'H','h','#fff', $1, '2023-06-10 09:00:00+00', NULL),
+        ('Trashed', '', 'H','h','#fff', $1, '2022-05-10 09:00:00+00', NOW()),
+        ('Not mine', '', 'H','h','#fff', $2, '2023-05-10 09:00:00+00', NULL)`, testUserID, otherUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	titles := func(moods []*Mood) []string {
+		out := []string{}
+		for _, m := range moods {
+			out = append(out, m.Title)
+		}
+		return out
+	}
+
+	t.Run("UTC", func(t *testing.T) {
+		moods, err := model.GetOnThisDay(context.Background(), testUserID, "", time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("GetOnThisDay failed: %v", err)
+		}
+		if got, want := titles(moods), []string{"2023", "Late 2022", "2021"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("TimeZone", func(t *testing.T) {
+		// 20:00 UTC on 10 May 2022 was already 11 May in Singapore, and so is 20:00 UTC
+		// on 10 May 2024: the day comes from the zone name, not now's location.
+		moods, err := model.GetOnThisDay(context.Background(), testUserID, "Asia/Singapore", time.Date(2024, 5, 10, 20, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("GetOnThisDay failed: %v", err)
+		}
+		if got, want := titles(moods), []string{"Next day", "Late 2022"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+}
+
+func TestMoodModel_GetWordStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	t.Run("NoEntries", func(t *testing.T) {
+		total, avg, err := model.GetWordStats(context.Background(), testUserID)
+		if err != nil || total != 0 || avg != 0 {
+			t.Errorf("Expected (0, 0), got (%d, %f) (err %v)", total, avg, err)
+		}
+	})
+
+	t.Run("WithEntries", func(t *testing.T) {
+		// 4 words + 2 words + an empty entry = 6 words over 3 entries; the trashed one is ignored.
+		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, deleted_at) VALUES
+            ('A','<p>A <strong>really</strong> good day</p>','H','h','#fff', $1, NULL),
+            ('B','<p>so</p><p>tired</p>','S','s','#000', $1, NULL),
+            ('C','','H','h','#fff', $1, NULL),
+            ('D','<p>not counted at all</p>','H','h','#fff', $1, NOW())`, testUserID)
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %s", err)
+		}
+		total, avg, err := model.GetWordStats(context.Background(), testUserID)
+		if err != nil {
+			t.Fatalf("GetWordStats failed: %v", err)
+		}
+		if total != 6 || avg != 2 {
+			t.Errorf("Expected (6, 2), got (%d, %f)", total, avg)
+		}
+	})
+}
+
+func TestMoodModel_GetEmotionCountsByWeek(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Week 2024-01 (Jan 1-7): Happy x2, Sad x1. Week 2024-02 (Jan 8-14): Sad x2, Happy x1.
+	// Plus a trashed entry and another user's entry, neither of which should count.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at, deleted_at) VALUES
+        ('A','','Happy','😊','#FFD700', $1, '2024-01-01 10:00:00+00', NULL),
+        ('B','','Sad','😢','#6495ED', $1, '2024-01-03 10:00:00+00', NULL),
+        ('C','','Happy','😊','#FFD700', $1, '2024-01-07 10:00:00+00', NULL),
+        ('D','','Sad','😢','#6495ED', $1, '2024-01-08 10:00:00+00', NULL),
+        ('E','','Happy','😊','#FFD700', $1, '2024-01-09 10:00:00+00', NULL),
+        ('F','','Sad','😢','#6495ED', $1, '2024-01-14 10:00:00+00', NULL),
+        ('G','','Sad','😢','#6495ED', $1, '2024-01-10 10:00:00+00', NOW()),
+        ('H','','Happy','😊','#FFD700', $2, '2024-01-10 10:00:00+00', NULL)`, testUserID, otherUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	counts, err := model.GetEmotionCountsByWeek(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetEmotionCountsByWeek failed: %v", err)
+	}
+	expected := []WeeklyEmotionCount{
+		{Week: "2024-01", Emotion: "Happy", Count: 2},
+		{Week: "2024-01", Emotion: "Sad", Count: 1},
+		{Week: "2024-02", Emotion: "Sad", Count: 2},
+		{Week: "2024-02", Emotion: "Happy", Count: 1},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Mismatch.\nExpected: %+v\nGot:      %+v", expected, counts)
+	}
+}
+
+// TestMoodModel_TimeZoneDayBoundary checks that an entry late on Sunday evening UTC is
+// grouped into the following Monday's week and day for a user east of UTC.
+func TestMoodModel_TimeZoneDayBoundary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Sunday 2024-05-12 20:00 UTC is Monday 2024-05-13 04:00 in Singapore (UTC+8).
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('Late','','H','h','#fff', $1, '2024-05-12 20:00:00+00')`, testUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	tests := []struct {
+		timeZone string
+		week     string
+		day      string
+	}{
+		{"UTC", "2024-19", "2024-05-12"},
+		{"Asia/Singapore", "2024-20", "2024-05-13"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeZone, func(t *testing.T) {
+			weekly, err := model.GetWeeklyEntryCounts(context.Background(), testUserID, tt.timeZone)
+			if err != nil {
+				t.Fatalf("GetWeeklyEntryCounts failed: %v", err)
+			}
+			if want := []WeeklyCount{{Week: tt.week, Count: 1}}; !reflect.DeepEqual(weekly, want) {
+				t.Errorf("Weekly mismatch.\nExpected: %+v\nGot:      %+v", want, weekly)
+			}
+
+			loc, _ := time.LoadLocation(tt.timeZone)
+			from := time.Date(2024, 5, 12, 0, 0, 0, 0, loc)
+			daily, err := model.GetDailyCounts(context.Background(), testUserID, tt.timeZone, from, from.AddDate(0, 0, 2))
+			if err != nil {
+				t.Fatalf("GetDailyCounts failed: %v", err)
+			}
+			if want := []DailyCount{{Date: tt.day, Count: 1}}; !reflect.DeepEqual(daily, want) {
+				t.Errorf("Daily mismatch.\nExpected: %+v\nGot:      %+v", want, daily)
+			}
+		})
+	}
+}
+
+func TestMoodModel_TimeBreakdowns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Two entries on Friday 2024-05-10 at 09:xx UTC, one on Monday 2024-06-03 at 21:00 UTC.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('A','','H','h','#fff', $1, '2024-05-10 09:15:00+00'),
+        ('B','','H','h','#fff', $1, '2024-05-10 09:45:00+00'),
+        ('C','','S','s','#000', $1, '2024-06-03 21:00:00+00')`, testUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	monthly, err := model.GetMonthlyEntryCounts(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetMonthlyEntryCounts failed: %v", err)
+	}
+	expectedMonthly := []MonthlyCount{{Month: "2024-05", Count: 2}, {Month: "2024-06", Count: 1}}
+	if !reflect.DeepEqual(monthly, expectedMonthly) {
+		t.Errorf("Monthly mismatch.\nExpected: %+v\nGot:      %+v", expectedMonthly, monthly)
+	}
+
+	weekday, err := model.GetWeekdayEntryCounts(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetWeekdayEntryCounts failed: %v", err)
+	}
+	if len(weekday) != 7 || weekday[time.Friday].Count != 2 || weekday[time.Monday].Count != 1 || weekday[time.Sunday].Count != 0 {
+		t.Errorf("Unexpected weekday counts: %+v", weekday)
+	}
+	if weekday[time.Monday].Name != "Monday" {
+		t.Errorf("Expected weekday names, got %q", weekday[time.Monday].Name)
+	}
+
+	hourly, err := model.GetHourlyEntryCounts(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetHourlyEntryCounts failed: %v", err)
+	}
+	if len(hourly) != 24 || hourly[9].Count != 2 || hourly[21].Count != 1 || hourly[0].Count != 0 {
+		t.Errorf("Unexpected hourly counts: %+v", hourly)
+	}
+
+	t.Run("UserTimeZone", func(t *testing.T) {
+		// At UTC+10 the Monday 21:00 UTC entry is Tuesday 07:00, and still in June.
+		const zone = "Australia/Brisbane"
+		weekday, err := model.GetWeekdayEntryCounts(context.Background(), testUserID, zone)
+		if err != nil {
+			t.Fatalf("GetWeekdayEntryCounts failed: %v", err)
+		}
+		if weekday[time.Monday].Count != 0 || weekday[time.Tuesday].Count != 1 || weekday[time.Friday].Count != 2 {
+			t.Errorf("Unexpected local weekday counts: %+v", weekday)
+		}
+
+		hourly, err := model.GetHourlyEntryCounts(context.Background(), testUserID, zone)
+		if err != nil {
+			t.Fatalf("GetHourlyEntryCounts failed: %v", err)
+		}
+		if hourly[19].Count != 2 || hourly[7].Count != 1 || hourly[21].Count != 0 {
+			t.Errorf("Unexpected local hourly counts: %+v", hourly)
+		}
+
+		monthly, err := model.GetMonthlyEntryCounts(context.Background(), testUserID, zone)
+		if err != nil {
+			t.Fatalf("GetMonthlyEntryCounts failed: %v", err)
+		}
+		if !reflect.DeepEqual(monthly, expectedMonthly) {
+			t.Errorf("Local monthly mismatch.\nExpected: %+v\nGot:      %+v", expectedMonthly, monthly)
+		}
+	})
+}
+
+func TestMoodModel_GetEmotionCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	t.Run("NoEntries", func(t *testing.T) {
+		counts, err := model.GetEmotionCounts(context.Background(), testUserID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if len(counts) != 0 {
+			t.Errorf("Expected 0 emotion counts, got %d", len(counts))
+		}
+	})
+	t.Run("WithEntries", func(t *testing.T) {
+		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES ('T1','','Happy','😊','#FFD700', $1),('T2','','Sad','😢','#6495ED', $1),('T3','','Happy','😊','#FFD700', $1),('T4','','Calm','😌','#90EE90', $1),('T5','','Happy','😊','#FFD700', $1)`, testUserID)
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %s", err)
+		}
+		counts, err := model.GetEmotionCounts(context.Background(), testUserID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		// Expected order is by count DESC, then name ASC
+		expected := []EmotionCount{{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}, {Name: "Calm", Emoji: "😌", Color: "#90EE90", Count: 1}, {Name: "Sad", Emoji: "😢", Color: "#6495ED", Count: 1}}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Errorf("Mismatch in emotion counts.\nExpected: %+v\nGot:      %+v", expected, counts)
+		}
+	})
+}
+
+// ** UPDATED Test for GetAllStats **
+func TestMoodModel_GetSameDayEmotionPairs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	// Anxious+Excited share 3 days (one day logs Anxious twice, which must count once),
+	// Calm+Sad share 1 day, and a single-emotion day contributes no pair.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
+        ('d1a','x','Anxious','😟','#FF8C00','2024-03-01 09:00:00+00', $1),
+        ('d1b','x','Excited','🤩','#FF69B4','2024-03-01 18:00:00+00', $1),
+        ('d1c','x','Anxious','😟','#FF8C00','2024-03-01 20:00:00+00', $1),
+        ('d2a','x','Anxious','😟','#FF8C00','2024-03-02 09:00:00+00', $1),
+        ('d2b','x','Excited','🤩','#FF69B4','2024-03-02 12:00:00+00', $1),
+        ('d3a','x','Excited','🤩','#FF69B4','2024-03-03 10:00:00+00', $1),
+        ('d3b','x','Anxious','😟','#FF8C00','2024-03-03 11:00:00+00', $1),
+        ('d4a','x','Calm','😌','#90EE90','2024-03-04 10:00:00+00', $1),
+        ('d4b','x','Sad','😢','#6495ED','2024-03-04 11:00:00+00', $1),
+        ('d5a','x','Happy','😊','#FFD700','2024-03-05 10:00:00+00', $1)`,
+		testUserID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	pairs, err := model.GetSameDayEmotionPairs(context.Background(), testUserID, "UTC")
+	if err != nil {
+		t.Fatalf("GetSameDayEmotionPairs failed: %v", err)
+	}
+	expected := []EmotionPairCount{
+		{First: "Anxious", Second: "Excited", Days: 3},
+		{First: "Calm", Second: "Sad", Days: 1},
+	}
+	if !reflect.DeepEqual(pairs, expected) {
+		t.Errorf("Mismatch in pairs.\nExpected: %+v\nGot:      %+v", expected, pairs)
+	}
+
+	if _, err := model.GetSameDayEmotionPairs(context.Background(), 0, "UTC"); err == nil {
+		t.Error("Expected error for invalid user ID")
+	}
+}
+
+func TestMoodModel_GetAllStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	t.Run("NoEntries", func(t *testing.T) {
+		stats, err := model.GetAllStats(context.Background(), testUserID, "UTC")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if stats == nil {
+			t.Fatal("Expected stats struct, got nil")
+		}
+		if stats.TotalEntries != 0 {
+			t.Errorf("Expected TotalEntries 0, got %d", stats.TotalEntries)
+		}
+		if stats.MostCommonEmotion != nil {
+			t.Errorf("Expected MostCommonEmotion nil, got %+v", stats.MostCommonEmotion)
+		}
+		if len(stats.EmotionCounts) != 0 {
+			t.Errorf("Expected 0 EmotionCounts, got %d", len(stats.EmotionCounts))
+		}
+		// Check WeeklyCounts is empty
+		if len(stats.WeeklyCounts) != 0 {
+			t.Errorf("Expected 0 WeeklyCounts, got %d", len(stats.WeeklyCounts))
+		}
+		if stats.LatestMood != nil {
+			t.Errorf("Expected LatestMood nil, got %+v", stats.LatestMood)
+		}
+		if stats.AvgEntriesPerWeek != 0.0 {
+			t.Errorf("Expected AvgEntriesPerWeek 0.0, got %f", stats.AvgEntriesPerWeek)
+		}
+	})
+	t.Run("WithEntries", func(t *testing.T) {
+		// Insert data similar to GetWeeklyEntryCounts test
+		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
+            ('Week1-1','','Happy','😊','#FFD700','2024-01-01 10:00:00+00', $1),
+            ('Week1-2','','Sad','😢','#6495ED','2024-01-03 11:00:00+00', $1),
+            ('Week2-1','','Happy','😊','#FFD700','2024-01-08 12:00:00+00', $1),
+            ('Week2-2','','Happy','😊','#FFD700','2024-01-10 13:00:00+00', $1),
+            ('Week2-3','','Calm','😌','#90EE90','2024-01-14 14:00:00+00', $1)`,
+			testUserID)
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %s", err)
+		}
+		stats, err := model.GetAllStats(context.Background(), testUserID, "UTC")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if stats == nil {
+			t.Fatal("Expected stats struct, got nil")
+		}
+		if stats.TotalEntries != 5 {
+			t.Errorf("Expected TotalEntries 5, got %d", stats.TotalEntries)
+		}
+		expectedMostCommon := EmotionCount{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}
+		if stats.MostCommonEmotion == nil {
+			t.Fatalf("Expected MostCommonEmotion %+v, got nil", expectedMostCommon)
+		}
+		if !reflect.DeepEqual(*stats.MostCommonEmotion, expectedMostCommon) {
+			t.Errorf("Mismatch in MostCommonEmotion.\nExpected: %+v\nGot:      %+v", expectedMostCommon, *stats.MostCommonEmotion)
+		}
+		expectedEmotionCounts := []EmotionCount{{Name: "Happy", Emoji: "😊", Color: "#FFD700", Count: 3}, {Name: "Calm", Emoji: "😌", Color: "#90EE90", Count: 1}, {Name: "Sad", Emoji: "😢", Color: "#6495ED", Count: 1}}
+		if !reflect.DeepEqual(stats.EmotionCounts, expectedEmotionCounts) {
+			t.Errorf("Mismatch in EmotionCounts.\nExpected: %+v\nGot:      %+v", expectedEmotionCounts, stats.EmotionCounts)
+		}
+		// Verify WeeklyCounts
+		expectedWeeklyCounts := []WeeklyCount{{Week: "2024-01", Count: 2}, {Week: "2024-02", Count: 3}}
+		if !reflect.DeepEqual(stats.WeeklyCounts, expectedWeeklyCounts) {
+			t.Errorf("Mismatch in WeeklyCounts.\nExpected: %+v\nGot:      %+v", expectedWeeklyCounts, stats.WeeklyCounts)
+		}
+		// Verify Latest Mood (should be the last one inserted chronologically)
+		if stats.LatestMood == nil || stats.LatestMood.Title != "Week2-3" {
+			t.Errorf("Expected LatestMood 'Week2-3', got %+v", stats.LatestMood)
+		}
+		// Basic check for AvgEntriesPerWeek (value depends on when test is run vs '2024-01-01')
+		if stats.AvgEntriesPerWeek <= 0 {
+			t.Errorf("Expected positive AvgEntriesPerWeek, got %f", stats.AvgEntriesPerWeek)
+		}
+	})
+}
+
+// --- CRUD Tests (Unchanged - already handle UserID) ---
+
+func TestMoodModel_Insert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	t.Run("InsertValidMood", func(t *testing.T) {
+		mood := &Mood{
+			Title: "Test Insert", Content: "<p>This is content</p>",
+			Emotion: "Neutral", Emoji: "😐", Color: "#B0C4DE",
+			UserID: testUserID,
+		}
+		err := model.Insert(context.Background(), mood)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if mood.ID == 0 {
+			t.Errorf("Expected non-zero ID after insert, got 0")
+		}
+		if mood.CreatedAt.IsZero() {
+			t.Errorf("Expected non-zero CreatedAt after insert")
+		}
+		if mood.UpdatedAt.IsZero() {
+			t.Errorf("Expected non-zero UpdatedAt after insert")
+		}
+
+		fetchedMood, errGet := model.Get(context.Background(), mood.ID, testUserID)
+		if errGet != nil {
+			t.Fatalf("Failed to fetch inserted mood: %v", errGet)
+		}
+		if fetchedMood == nil {
+			t.Fatal("Fetched mood is nil after insert")
+		}
+		if fetchedMood.Title != mood.Title {
+			t.Errorf("Expected Title %q, got %q", mood.Title, fetchedMood.Title)
+		}
+		if fetchedMood.UserID != testUserID {
+			t.Errorf("Expected UserID %d, got %d", testUserID, fetchedMood.UserID)
+		}
+	})
+}
+
+func TestMoodModel_Get(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	moodUser1 := &Mood{Title: "User1 Mood", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: testUserID}
+	err := model.Insert(context.Background(), moodUser1)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	moodUser2 := &Mood{Title: "User2 Mood", Content: "...", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: otherUserID}
+	err = model.Insert(context.Background(), moodUser2)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+
+	t.Run("GetExistingOwned", func(t *testing.T) {
+		fetchedMood, err := model.Get(context.Background(), moodUser1.ID, testUserID)
+		if err != nil {
+			t.Fatalf("Get failed for existing owned ID %d: %v", moodUser1.ID, err)
+		}
+		if fetchedMood == nil {
+			t.Fatal("Get returned nil for existing owned ID")
+		}
+		if fetchedMood.ID != moodUser1.ID || fetchedMood.UserID != testUserID {
+			t.Errorf("Mismatch in fetched mood: Got ID %d, UserID %d", fetchedMood.ID, fetchedMood.UserID)
+		}
+	})
+
+	t.Run("GetExistingNotOwned", func(t *testing.T) {
+		_, err := model.Get(context.Background(), moodUser2.ID, testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when getting non-owned mood, got %v", err)
+		}
+	})
+
+	t.Run("GetNonExistentPositiveID", func(t *testing.T) {
+		_, err := model.Get(context.Background(), int64(999999), testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound for non-existent ID, got %v", err)
+		}
+	})
+
+	t.Run("GetZeroID", func(t *testing.T) {
+		_, err := model.Get(context.Background(), 0, testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound for ID 0, got %v", err)
+		}
+	})
+
+	t.Run("GetNegativeID", func(t *testing.T) {
+		_, err := model.Get(context.Background(), -1, testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound for ID -1, got %v", err)
+		}
+	})
+
+	t.Run("PrivateNoteReadButNeverSerialized", func(t *testing.T) {
+		secret := "for my eyes only"
+		mood := &Mood{Title: "Private", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: testUserID, PrivateNote: secret}
+		if err := model.Insert(context.Background(), mood); err != nil {
+			t.Fatalf("Setup insert failed: %v", err)
+		}
+		fetchedMood, err := model.Get(context.Background(), mood.ID, testUserID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if fetchedMood.PrivateNote != secret {
+			t.Errorf("Expected PrivateNote %q from Get, got %q", secret, fetchedMood.PrivateNote)
+		}
+		js, err := json.Marshal(fetchedMood)
+		if err != nil {
+			t.Fatalf("json.Marshal failed: %v", err)
+		}
+		if strings.Contains(string(js), secret) || strings.Contains(string(js), "private_note") {
+			t.Errorf("PrivateNote leaked into JSON serialization: %s", js)
+		}
+	})
+}
+
+func TestMoodModel_Update(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	originalMood := &Mood{Title: "Original Title", Content: "...", Emotion: "Sad", Emoji: "😢", Color: "#6495ED", UserID: testUserID}
+	err := model.Insert(context.Background(), originalMood)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	otherUserMood := &Mood{Title: "Other User Mood", Content: "...", Emotion: "Angry", Emoji: "😠", Color: "#DC143C", UserID: otherUserID}
+	err = model.Insert(context.Background(), otherUserMood)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	originalUpdatedAt := originalMood.UpdatedAt
+	time.Sleep(10 * time.Millisecond) // Ensure time difference for UpdatedAt check
+
+	t.Run("UpdateOwned", func(t *testing.T) {
+		moodToUpdate := &Mood{
+			ID: originalMood.ID, Title: "Updated Title", Content: "Updated Content",
+			Emotion: "Excited", Emoji: "🤩", Color: "#FF69B4", Intensity: 5,
+			UserID: testUserID, Version: originalMood.Version,
+		}
+		err := model.Update(context.Background(), moodToUpdate)
+		if err != nil {
 			t.Fatalf("Update failed for owned ID %d: %v", moodToUpdate.ID, err)
 		}
+		if moodToUpdate.Version != originalMood.Version+1 {
+			t.Errorf("Expected version %d after update, got %d", originalMood.Version+1, moodToUpdate.Version)
+		}
+
+		updatedMood, errGet := model.Get(context.Background(), originalMood.ID, testUserID)
+		if errGet != nil {
+			t.Fatalf("Failed to fetch mood after update: %v", errGet)
+		}
+		if updatedMood.Title != moodToUpdate.Title {
+			t.Errorf("Title mismatch after update")
+		}
+		if updatedMood.Intensity != 5 {
+			t.Errorf("Expected intensity 5 after update, got %d", updatedMood.Intensity)
+		}
+		if !updatedMood.UpdatedAt.After(originalUpdatedAt) {
+			t.Errorf("Expected UpdatedAt to be newer")
+		}
+	})
+
+	t.Run("UpdateStaleVersion", func(t *testing.T) {
+		// A second tab still holding the version from before UpdateOwned.
+		moodToUpdate := &Mood{
+			ID: originalMood.ID, Title: "Stale Title", Content: "...",
+			Emotion: "Sad", Emoji: "😢", Color: "#6495ED",
+			UserID: testUserID, Version: originalMood.Version,
+		}
+		err := model.Update(context.Background(), moodToUpdate)
+		if !errors.Is(err, ErrEditConflict) {
+			t.Fatalf("Expected ErrEditConflict for a stale version, got %v", err)
+		}
+		current, _ := model.Get(context.Background(), originalMood.ID, testUserID)
+		if current.Title != "Updated Title" {
+			t.Errorf("Expected the newer edit to survive, got title %q", current.Title)
+		}
+	})
+
+	t.Run("UpdateNotOwned", func(t *testing.T) {
+		moodToUpdate := &Mood{
+			ID:    otherUserMood.ID,
+			Title: "Attempted Update Title", Content: "...", Emotion: "Neutral", Emoji: "😐", Color: "#ccc",
+			UserID: testUserID, // Try update as wrong user
+		}
+		err := model.Update(context.Background(), moodToUpdate)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when updating non-owned mood, got %v", err)
+		}
+		fetchedOther, _ := model.Get(context.Background(), otherUserMood.ID, otherUserID)
+		if fetchedOther.Title == moodToUpdate.Title {
+			t.Error("Non-owned mood was incorrectly updated")
+		}
+	})
+
+	t.Run("UpdateNonExistent", func(t *testing.T) {
+		moodToUpdate := &Mood{
+			ID: 999999, Title: "...", Content: "...", Emotion: "Neutral", Emoji: "😐", Color: "#ccc",
+			UserID: testUserID,
+		}
+		err := model.Update(context.Background(), moodToUpdate)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when updating non-existent ID, got %v", err)
+		}
+	})
+}
+
+func TestMoodModel_Delete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	moodToDelete := &Mood{Title: "To Be Deleted", Content: "...", Emotion: "Angry", Emoji: "😠", Color: "#DC143C", UserID: testUserID}
+	err := model.Insert(context.Background(), moodToDelete)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	moodToKeep := &Mood{Title: "Keep Me", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: testUserID}
+	err = model.Insert(context.Background(), moodToKeep)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	otherUserMood := &Mood{Title: "Other Keep", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: otherUserID}
+	err = model.Insert(context.Background(), otherUserMood)
+	if err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+
+	t.Run("DeleteOwned", func(t *testing.T) {
+		err := model.Delete(context.Background(), moodToDelete.ID, testUserID)
+		if err != nil {
+			t.Fatalf("Delete failed for owned ID %d: %v", moodToDelete.ID, err)
+		}
+		_, errGet := model.Get(context.Background(), moodToDelete.ID, testUserID)
+		if !errors.Is(errGet, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound after deleting owned ID, got %v", errGet)
+		}
+		keptMood, errGetKeep := model.Get(context.Background(), moodToKeep.ID, testUserID)
+		if errGetKeep != nil || keptMood == nil {
+			t.Errorf("Owned mood that should have been kept was affected")
+		}
+		otherKeptMood, errGetOther := model.Get(context.Background(), otherUserMood.ID, otherUserID)
+		if errGetOther != nil || otherKeptMood == nil {
+			t.Errorf("Other user's mood was affected")
+		}
+	})
+
+	t.Run("DeleteNotOwned", func(t *testing.T) {
+		err := model.Delete(context.Background(), otherUserMood.ID, testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when deleting non-owned mood, got %v", err)
+		}
+		otherKeptMood, errGetOther := model.Get(context.Background(), otherUserMood.ID, otherUserID)
+		if errGetOther != nil || otherKeptMood == nil {
+			t.Errorf("Non-owned mood was incorrectly deleted")
+		}
+	})
+
+	t.Run("DeleteNonExistent", func(t *testing.T) {
+		err := model.Delete(context.Background(), int64(999999), testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when deleting non-existent ID, got %v", err)
+		}
+	})
+
+	t.Run("DeleteZeroID", func(t *testing.T) {
+		err := model.Delete(context.Background(), 0, testUserID)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound when deleting ID 0, got %v", err)
+		}
+	})
+}
+
+func TestMoodModel_ExistsOnDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	userID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	// 03:00 UTC on 10 May is still 9 May in New York.
+	mood := &Mood{Title: "Late", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: userID}
+	if err := model.Insert(ctx, mood); err != nil {
+		t.Fatalf("Setup insert failed: %v", err)
+	}
+	loggedAt := time.Date(2024, time.May, 10, 3, 0, 0, 0, time.UTC)
+	if _, err := db.Exec("UPDATE moods SET created_at = $1 WHERE id = $2", loggedAt, mood.ID); err != nil {
+		t.Fatalf("Setup backdate failed: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{"SameUTCDay", time.Date(2024, time.May, 10, 23, 0, 0, 0, time.UTC), true},
+		{"OtherUTCDay", time.Date(2024, time.May, 9, 12, 0, 0, 0, time.UTC), false},
+		{"LocalDayBefore", time.Date(2024, time.May, 9, 20, 0, 0, 0, newYork), true},
+		{"LocalDayOf", time.Date(2024, time.May, 10, 9, 0, 0, 0, newYork), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, exists, err := model.ExistsOnDate(ctx, userID, tt.date)
+			if err != nil {
+				t.Fatalf("ExistsOnDate failed: %v", err)
+			}
+			if exists != tt.want {
+				t.Errorf("ExistsOnDate(%v) = %v, want %v", tt.date, exists, tt.want)
+			}
+			if exists && id != mood.ID {
+				t.Errorf("ExistsOnDate returned ID %d, want %d", id, mood.ID)
+			}
+		})
+	}
+
+	t.Run("IgnoresTrash", func(t *testing.T) {
+		if err := model.Delete(ctx, mood.ID, userID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, exists, err := model.ExistsOnDate(ctx, userID, loggedAt); err != nil || exists {
+			t.Errorf("ExistsOnDate after delete = %v, %v; want false", exists, err)
+		}
+	})
+}
+
+func TestMoodModel_DeleteMany(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	userID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	insert := func(owner int64) *Mood {
+		mood := &Mood{Title: "Bulk", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", UserID: owner}
+		if err := model.Insert(ctx, mood); err != nil {
+			t.Fatalf("Setup insert failed: %v", err)
+		}
+		return mood
+	}
+	first, second, others := insert(userID), insert(userID), insert(otherUserID)
+
+	deleted, err := model.DeleteMany(ctx, []int64{first.ID, second.ID, others.ID, 999999}, userID)
+	if err != nil {
+		t.Fatalf("DeleteMany failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteMany deleted %d, want 2 (only the user's own entries)", deleted)
+	}
+	if _, err := model.Get(ctx, others.ID, otherUserID); err != nil {
+		t.Errorf("Other user's entry was deleted: %v", err)
+	}
+
+	// Entries already in the trash aren't counted again.
+	deleted, err = model.DeleteMany(ctx, []int64{first.ID}, userID)
+	if err != nil || deleted != 0 {
+		t.Errorf("DeleteMany of a trashed entry = %d, %v; want 0", deleted, err)
+	}
+	deleted, err = model.DeleteMany(ctx, nil, userID)
+	if err != nil || deleted != 0 {
+		t.Errorf("DeleteMany with no IDs = %d, %v; want 0", deleted, err)
+	}
+}
+
+func TestMoodModel_Trash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	userID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	trashed := &Mood{Title: "Trashed", Content: "...", Emotion: "Sad", Emoji: "😢", Color: "#5C8DDE", UserID: userID}
+	kept := &Mood{Title: "Kept", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFCA28", UserID: userID}
+	for _, mood := range []*Mood{trashed, kept} {
+		if err := model.Insert(ctx, mood); err != nil {
+			t.Fatalf("Setup insert failed: %v", err)
+		}
+	}
+	if err := model.Delete(ctx, trashed.ID, userID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	t.Run("HiddenFromLiveQueries", func(t *testing.T) {
+		moods, metadata, err := model.GetFiltered(ctx, FilterCriteria{UserID: userID, Weekday: AnyWeekday, PageSize: 10})
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if metadata.TotalRecords != 1 || len(moods) != 1 || moods[0].ID != kept.ID {
+			t.Errorf("GetFiltered returned %d of %d entries, want only the kept entry", len(moods), metadata.TotalRecords)
+		}
+		total, err := model.GetTotalMoodCount(ctx, userID)
+		if err != nil || total != 1 {
+			t.Errorf("GetTotalMoodCount = %d, %v; want 1", total, err)
+		}
+		if err := model.Delete(ctx, trashed.ID, userID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Deleting a trashed entry again: got %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("ListedInTrash", func(t *testing.T) {
+		moods, err := model.GetDeleted(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetDeleted failed: %v", err)
+		}
+		if len(moods) != 1 || moods[0].ID != trashed.ID || moods[0].DeletedAt == nil {
+			t.Fatalf("GetDeleted = %+v, want just the trashed entry with DeletedAt set", moods)
+		}
+		others, err := model.GetDeleted(ctx, otherUserID)
+		if err != nil || len(others) != 0 {
+			t.Errorf("Other user's trash = %d entries, %v; want empty", len(others), err)
+		}
+	})
 
-		updatedMood, errGet := model.Get(originalMood.ID, testUserID)
-		if errGet != nil {
-			t.Fatalf("Failed to fetch mood after update: %v", errGet)
+	t.Run("RestoreNotOwned", func(t *testing.T) {
+		if err := model.Restore(ctx, trashed.ID, otherUserID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Restore by another user: got %v, want ErrRecordNotFound", err)
 		}
-		if updatedMood.Title != moodToUpdate.Title {
-			t.Errorf("Title mismatch after update")
+		if err := model.Restore(ctx, kept.ID, userID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Restore of a live entry: got %v, want ErrRecordNotFound", err)
 		}
-		if !updatedMood.UpdatedAt.After(originalUpdatedAt) {
-			t.Errorf("Expected UpdatedAt to be newer")
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		if err := model.Restore(ctx, trashed.ID, userID); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if _, err := model.Get(ctx, trashed.ID, userID); err != nil {
+			t.Errorf("Get after restore failed: %v", err)
 		}
 	})
 
-	t.Run("UpdateNotOwned", func(t *testing.T) {
-		moodToUpdate := &Mood{
-			ID:    otherUserMood.ID,
-			Title: "Attempted Update Title", Content: "...", Emotion: "Neutral", Emoji: "😐", Color: "#ccc",
-			UserID: testUserID, // Try update as wrong user
+	t.Run("PurgeDeleted", func(t *testing.T) {
+		if err := model.Delete(ctx, trashed.ID, userID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
 		}
-		err := model.Update(moodToUpdate)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when updating non-owned mood, got %v", err)
+		purged, err := model.PurgeDeleted(ctx, TrashRetention)
+		if err != nil || purged != 0 {
+			t.Fatalf("PurgeDeleted of a fresh trash = %d, %v; want 0", purged, err)
 		}
-		fetchedOther, _ := model.Get(otherUserMood.ID, otherUserID)
-		if fetchedOther.Title == moodToUpdate.Title {
-			t.Error("Non-owned mood was incorrectly updated")
+		_, err = db.Exec("UPDATE moods SET deleted_at = NOW() - INTERVAL '31 days' WHERE id = $1", trashed.ID)
+		if err != nil {
+			t.Fatalf("Backdating deleted_at failed: %v", err)
+		}
+		purged, err = model.PurgeDeleted(ctx, TrashRetention)
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeDeleted = %d, %v; want 1", purged, err)
+		}
+		if err := model.Restore(ctx, trashed.ID, userID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Restore after purge: got %v, want ErrRecordNotFound", err)
+		}
+		if _, err := model.Get(ctx, kept.ID, userID); err != nil {
+			t.Errorf("Live entry was purged: %v", err)
 		}
 	})
+}
 
-	t.Run("UpdateNonExistent", func(t *testing.T) {
-		moodToUpdate := &Mood{
-			ID: 999999, Title: "...", Content: "...", Emotion: "Neutral", Emoji: "😐", Color: "#ccc",
-			UserID: testUserID,
+func TestEmotionQueries_UseIndex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+
+	// A near-empty test table is cheapest to scan, so rule out sequential scans to see
+	// whether the planner can answer each query from moods_user_emotion_idx.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`SET LOCAL enable_seqscan = off`); err != nil {
+		t.Fatalf("SET failed: %v", err)
+	}
+	for name, query := range map[string]string{"distinct emotions": distinctEmotionsQuery, "emotion counts": emotionCountsQuery} {
+		rows, err := tx.Query("EXPLAIN " + strings.Replace(query, "$1", fmt.Sprint(testUserID), 1))
+		if err != nil {
+			t.Fatalf("EXPLAIN %s failed: %v", name, err)
 		}
-		err := model.Update(moodToUpdate)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when updating non-existent ID, got %v", err)
+		var plan strings.Builder
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			plan.WriteString(line + "\n")
+		}
+		rows.Close()
+		if !strings.Contains(plan.String(), "moods_user_emotion_idx") {
+			t.Errorf("Expected %s to use moods_user_emotion_idx, got plan:\n%s", name, plan.String())
+		}
+	}
+}
+
+func TestMoodModel_GetDistinctEmotionDetails(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID1 := insertTestUser(t, db)
+	testUserID2 := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	t.Run("NoEntriesForUser", func(t *testing.T) {
+		details, err := model.GetDistinctEmotionDetails(context.Background(), testUserID1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(details) != 0 {
+			t.Errorf("Expected empty slice, got %d items", len(details))
+		}
+	})
+
+	t.Run("WithEntriesAndDuplicatesForUser", func(t *testing.T) {
+		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES
+            ('U1E1', '', 'Happy', '😊', '#FFD700', $1), ('U1E2', '', 'Sad', '😢', '#6495ED', $1),
+            ('U1E3', '', 'Happy', '😊', '#FFD700', $1), ('U1E4', '', 'Calm', '😌', '#90EE90', $1),
+            ('U2E1', '', 'Angry', '😠', '#DC143C', $2), ('U2E2', '', 'Happy', '😊', '#FFD700', $2)`,
+			testUserID1, testUserID2)
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+
+		details1, err1 := model.GetDistinctEmotionDetails(context.Background(), testUserID1)
+		if err1 != nil {
+			t.Fatalf("Expected no error for user 1, got %v", err1)
+		}
+		// Expected order is alphabetical by emotion name
+		expected1 := []EmotionDetail{
+			{Name: "Calm", Emoji: "😌", Color: "#90EE90"},
+			{Name: "Happy", Emoji: "😊", Color: "#FFD700"},
+			{Name: "Sad", Emoji: "😢", Color: "#6495ED"},
+		}
+		if !reflect.DeepEqual(details1, expected1) {
+			t.Errorf("Mismatch for user 1.\nExpected: %+v\nGot:      %+v", expected1, details1)
+		}
+
+		details2, err2 := model.GetDistinctEmotionDetails(context.Background(), testUserID2)
+		if err2 != nil {
+			t.Fatalf("Expected no error for user 2, got %v", err2)
+		}
+		expected2 := []EmotionDetail{
+			{Name: "Angry", Emoji: "😠", Color: "#DC143C"},
+			{Name: "Happy", Emoji: "😊", Color: "#FFD700"},
+		}
+		if !reflect.DeepEqual(details2, expected2) {
+			t.Errorf("Mismatch for user 2.\nExpected: %+v\nGot:      %+v", expected2, details2)
+		}
+	})
+}
+
+func TestMoodModel_GetFiltered(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID1 := insertTestUser(t, db)
+	testUserID2 := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	baseTime := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
+        ('U1 Day 5 Calm', 'Relax', 'Calm', '😌', '#90EE90', $1, $6), ('U1 Day 4 Happy', 'Good', 'Happy', '😊', '#FFD700', $2, $6),
+        ('U1 Day 3 Sad', 'Down', 'Sad', '😢', '#6495ED', $3, $6), ('U1 Day 2 Target', 'Tgt', 'Calm', '😌', '#90EE90', $4, $6),
+        ('U2 Day 1 Happy', 'Start', 'Happy', '😊', '#FFD700', $5, $7)`,
+		baseTime.AddDate(0, 0, -0), baseTime.AddDate(0, 0, -1), baseTime.AddDate(0, 0, -2),
+		baseTime.AddDate(0, 0, -3), baseTime.AddDate(0, 0, -4),
+		testUserID1, testUserID2)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not insert moods: %v", err)
+	}
+	totalUser1Records := 4
+
+	t.Run("NoFilters_User1_Page1", func(t *testing.T) {
+		filters := FilterCriteria{Page: 1, PageSize: 3, UserID: testUserID1, Weekday: AnyWeekday}
+		moods, metadata, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 3 {
+			t.Errorf("Expected 3 moods for user 1, got %d", len(moods))
+		}
+		if moods[0].Title != "U1 Day 5 Calm" || moods[1].Title != "U1 Day 4 Happy" || moods[2].Title != "U1 Day 3 Sad" {
+			t.Errorf("Unexpected mood order/content")
+		}
+		expectedMeta := Metadata{CurrentPage: 1, PageSize: 3, FirstPage: 1, LastPage: 2, TotalRecords: totalUser1Records, HasNext: true, NextPage: 2}
+		if !reflect.DeepEqual(metadata, expectedMeta) {
+			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
+		}
+	})
+
+	t.Run("NoFilters_User2", func(t *testing.T) {
+		filters := FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID2, Weekday: AnyWeekday}
+		moods, metadata, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 1 {
+			t.Errorf("Expected 1 mood for user 2, got %d", len(moods))
+		}
+		if moods[0].Title != "U2 Day 1 Happy" {
+			t.Errorf("Unexpected mood for user 2")
+		}
+		expectedMeta := Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 1, TotalRecords: 1}
+		if !reflect.DeepEqual(metadata, expectedMeta) {
+			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
+		}
+	})
+
+	t.Run("FilterText_User1", func(t *testing.T) {
+		filters := FilterCriteria{TextQuery: "Target", Page: 1, PageSize: 10, UserID: testUserID1, Weekday: AnyWeekday}
+		moods, _, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 1 {
+			t.Errorf("Expected 1 mood matching 'Target' for user 1, got %d", len(moods))
+		}
+		if moods[0].Title != "U1 Day 2 Target" {
+			t.Errorf("Expected target mood")
+		}
+	})
+
+	t.Run("PageBeyondLast_ClampedToLastPage", func(t *testing.T) {
+		filters := FilterCriteria{Page: 9999999, PageSize: 3, UserID: testUserID1, Weekday: AnyWeekday}
+		moods, metadata, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 1 {
+			t.Fatalf("Expected 1 mood on the clamped last page, got %d", len(moods))
+		}
+		if moods[0].Title != "U1 Day 2 Target" {
+			t.Errorf("Expected oldest mood on last page, got %q", moods[0].Title)
+		}
+		expectedMeta := Metadata{CurrentPage: 2, PageSize: 3, FirstPage: 1, LastPage: 2, TotalRecords: totalUser1Records, HasPrevious: true, PrevPage: 1}
+		if !reflect.DeepEqual(metadata, expectedMeta) {
+			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
+		}
+
+		// With a single page there is nothing before the clamped page either.
+		filters.PageSize = 10
+		_, metadata, err = model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		expectedMeta = Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 1, TotalRecords: totalUser1Records}
+		if !reflect.DeepEqual(metadata, expectedMeta) {
+			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
+		}
+	})
+
+	// baseTime (2024-05-10) is a Friday, so user 1 has one entry on each of Tue–Fri
+	// and user 2 has the only Monday entry.
+	t.Run("FilterWeekday_User1", func(t *testing.T) {
+		filters := FilterCriteria{Weekday: int(time.Wednesday), Page: 1, PageSize: 10, UserID: testUserID1}
+		moods, metadata, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 1 || moods[0].Title != "U1 Day 3 Sad" {
+			t.Fatalf("Expected only the Wednesday entry, got %d moods", len(moods))
+		}
+		if metadata.TotalRecords != 1 {
+			t.Errorf("Expected TotalRecords 1, got %d", metadata.TotalRecords)
+		}
+
+		// Another user's Monday entry must not leak into user 1's results.
+		filters.Weekday = int(time.Monday)
+		moods, _, err = model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 0 {
+			t.Errorf("Expected no Monday entries for user 1, got %d", len(moods))
+		}
+	})
+
+	t.Run("FilterWeekday_TimeZone", func(t *testing.T) {
+		// Friday 12:00 UTC is already Saturday 02:00 at UTC+14.
+		kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+		if err != nil {
+			t.Fatalf("LoadLocation failed: %v", err)
+		}
+		filters := FilterCriteria{Weekday: int(time.Saturday), Location: kiritimati, Page: 1, PageSize: 10, UserID: testUserID1}
+		moods, _, err := model.GetFiltered(context.Background(), filters)
+		if err != nil {
+			t.Fatalf("GetFiltered failed: %v", err)
+		}
+		if len(moods) != 1 || moods[0].Title != "U1 Day 5 Calm" {
+			t.Errorf("Expected the Friday-UTC entry to count as Saturday, got %d moods", len(moods))
 		}
 	})
+
+	// Add more filter tests specific to user 1...
+}
+
+func TestValidSort(t *testing.T) {
+	for _, sort := range []string{"created_at_desc", "created_at_asc", "title_asc", "emotion_asc"} {
+		if !ValidSort(sort) {
+			t.Errorf("Expected %q to be a valid sort", sort)
+		}
+	}
+	for _, sort := range []string{"", "title", "created_at; DROP TABLE moods", "TITLE_ASC"} {
+		if ValidSort(sort) {
+			t.Errorf("Expected %q to be rejected", sort)
+		}
+	}
+	if got, want := strings.Join(SortOrders(), ","), "created_at_asc,created_at_desc,emotion_asc,title_asc"; got != want {
+		t.Errorf("SortOrders() = %q, want %q", got, want)
+	}
+}
+
+func TestMoodModel_GetFiltered_Sort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	baseTime := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
+        ('b newest', 'x', 'Sad', '😢', '#6495ED', $1, $4), ('C middle', 'x', 'calm', '😌', '#90EE90', $2, $4),
+        ('a oldest', 'x', 'Happy', '😊', '#FFD700', $3, $4)`,
+		baseTime, baseTime.AddDate(0, 0, -1), baseTime.AddDate(0, 0, -2), testUserID)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not insert moods: %v", err)
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"", []string{"b newest", "C middle", "a oldest"}},
+		{"created_at_desc", []string{"b newest", "C middle", "a oldest"}},
+		{"created_at_asc", []string{"a oldest", "C middle", "b newest"}},
+		{"title_asc", []string{"a oldest", "b newest", "C middle"}},
+		{"emotion_asc", []string{"C middle", "a oldest", "b newest"}},
+		{"title_asc; DROP TABLE moods", []string{"b newest", "C middle", "a oldest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			filters := FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID, Weekday: AnyWeekday, Sort: tt.sort}
+			moods, _, err := model.GetFiltered(context.Background(), filters)
+			if err != nil {
+				t.Fatalf("GetFiltered failed: %v", err)
+			}
+			var got []string
+			for _, m := range moods {
+				got = append(got, m.Title)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Sort %q: got %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoodModel_GetFiltered_IntensityRange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, intensity, user_id) VALUES
+        ('mild', 'x', 'Calm', '😌', '#90EE90', 1, $1), ('middling', 'x', 'Calm', '😌', '#90EE90', 3, $1),
+        ('strong', 'x', 'Calm', '😌', '#90EE90', 5, $1)`, testUserID)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not insert moods: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		min, max int
+		want     []string
+	}{
+		{"Unset", 0, 0, []string{"mild", "middling", "strong"}},
+		{"FullRange", 1, 5, []string{"mild", "middling", "strong"}},
+		{"AtLeast3", 3, 0, []string{"middling", "strong"}},
+		{"AtMost3", 0, 3, []string{"mild", "middling"}},
+		{"Exactly5", 5, 5, []string{"strong"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID, Weekday: AnyWeekday,
+				Sort: "title_asc", MinIntensity: tt.min, MaxIntensity: tt.max}
+			moods, metadata, err := model.GetFiltered(context.Background(), filters)
+			if err != nil {
+				t.Fatalf("GetFiltered failed: %v", err)
+			}
+			var got []string
+			for _, m := range moods {
+				got = append(got, m.Title)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || metadata.TotalRecords != len(tt.want) {
+				t.Errorf("Range %d-%d: got %v (total %d), want %v", tt.min, tt.max, got, metadata.TotalRecords, tt.want)
+			}
+		})
+	}
 }
 
-func TestMoodModel_Delete(t *testing.T) {
+func TestMoodModel_SetPinned(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
@@ -450,202 +1969,296 @@ func TestMoodModel_Delete(t *testing.T) {
 	testUserID := insertTestUser(t, db)
 	otherUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
+	ctx := context.Background()
 
-	moodToDelete := &Mood{Title: "To Be Deleted", Content: "...", Emotion: "Angry", Emoji: "😠", Color: "#DC143C", UserID: testUserID}
-	err := model.Insert(moodToDelete)
-	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+	baseTime := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i, title := range []string{"b newest", "C middle", "a oldest"} {
+		var id int64
+		err := db.QueryRow(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id)
+            VALUES ($1, 'x', 'Calm', '😌', '#90EE90', $2, $3) RETURNING id`, title, baseTime.AddDate(0, 0, -i), testUserID).Scan(&id)
+		if err != nil {
+			t.Fatalf("Setup failed: Could not insert mood: %v", err)
+		}
+		ids = append(ids, id)
 	}
-	moodToKeep := &Mood{Title: "Keep Me", Content: "...", Emotion: "Happy", Emoji: "😊", Color: "#FFD700", UserID: testUserID}
-	err = model.Insert(moodToKeep)
-	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+	oldest := ids[2]
+
+	if err := model.SetPinned(ctx, oldest, otherUserID, true); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound pinning another user's entry, got %v", err)
 	}
-	otherUserMood := &Mood{Title: "Other Keep", Content: "...", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: otherUserID}
-	err = model.Insert(otherUserMood)
-	if err != nil {
-		t.Fatalf("Setup insert failed: %v", err)
+	if err := model.SetPinned(ctx, oldest, testUserID, true); err != nil {
+		t.Fatalf("SetPinned failed: %v", err)
 	}
 
-	t.Run("DeleteOwned", func(t *testing.T) {
-		err := model.Delete(moodToDelete.ID, testUserID)
-		if err != nil {
-			t.Fatalf("Delete failed for owned ID %d: %v", moodToDelete.ID, err)
-		}
-		_, errGet := model.Get(moodToDelete.ID, testUserID)
-		if !errors.Is(errGet, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound after deleting owned ID, got %v", errGet)
-		}
-		keptMood, errGetKeep := model.Get(moodToKeep.ID, testUserID)
-		if errGetKeep != nil || keptMood == nil {
-			t.Errorf("Owned mood that should have been kept was affected")
-		}
-		otherKeptMood, errGetOther := model.Get(otherUserMood.ID, otherUserID)
-		if errGetOther != nil || otherKeptMood == nil {
-			t.Errorf("Other user's mood was affected")
-		}
-	})
+	// The pinned entry leads every sort order; the rest keep their usual order.
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"created_at_desc", []string{"a oldest", "b newest", "C middle"}},
+		{"created_at_asc", []string{"a oldest", "C middle", "b newest"}},
+		{"title_asc", []string{"a oldest", "b newest", "C middle"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			filters := FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID, Weekday: AnyWeekday, Sort: tt.sort}
+			moods, _, err := model.GetFiltered(ctx, filters)
+			if err != nil {
+				t.Fatalf("GetFiltered failed: %v", err)
+			}
+			var got []string
+			for _, m := range moods {
+				got = append(got, m.Title)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Sort %q: got %v, want %v", tt.sort, got, tt.want)
+			}
+			if !moods[0].Pinned || moods[1].Pinned {
+				t.Errorf("Expected only the first entry to be pinned, got %v and %v", moods[0].Pinned, moods[1].Pinned)
+			}
+		})
+	}
 
-	t.Run("DeleteNotOwned", func(t *testing.T) {
-		err := model.Delete(otherUserMood.ID, testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when deleting non-owned mood, got %v", err)
-		}
-		otherKeptMood, errGetOther := model.Get(otherUserMood.ID, otherUserID)
-		if errGetOther != nil || otherKeptMood == nil {
-			t.Errorf("Non-owned mood was incorrectly deleted")
-		}
-	})
+	// Pinning isn't an edit, so the version an open edit form holds stays valid.
+	mood, err := model.Get(ctx, oldest, testUserID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !mood.Pinned || mood.Version != 1 {
+		t.Errorf("Expected pinned entry at version 1, got pinned=%v version=%d", mood.Pinned, mood.Version)
+	}
 
-	t.Run("DeleteNonExistent", func(t *testing.T) {
-		err := model.Delete(int64(999999), testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when deleting non-existent ID, got %v", err)
-		}
-	})
+	if err := model.SetPinned(ctx, oldest, testUserID, false); err != nil {
+		t.Fatalf("SetPinned (unpin) failed: %v", err)
+	}
+	if mood, _ := model.Get(ctx, oldest, testUserID); mood.Pinned {
+		t.Error("Expected the entry to be unpinned")
+	}
 
-	t.Run("DeleteZeroID", func(t *testing.T) {
-		err := model.Delete(0, testUserID)
-		if !errors.Is(err, ErrRecordNotFound) {
-			t.Errorf("Expected ErrRecordNotFound when deleting ID 0, got %v", err)
-		}
-	})
+	if err := model.Delete(ctx, oldest, testUserID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := model.SetPinned(ctx, oldest, testUserID, true); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound pinning a trashed entry, got %v", err)
+	}
 }
 
-func TestMoodModel_GetDistinctEmotionDetails(t *testing.T) {
+func TestMoodModel_GetPredecessors(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
 	db := newTestDB(t)
 	defer db.Close()
 	defer cleanupTestDB(t, db)
-	testUserID1 := insertTestUser(t, db)
-	testUserID2 := insertTestUser(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	t.Run("NoEntriesForUser", func(t *testing.T) {
-		details, err := model.GetDistinctEmotionDetails(testUserID1)
-		if err != nil {
-			t.Fatalf("Expected no error, got %v", err)
-		}
-		if len(details) != 0 {
-			t.Errorf("Expected empty slice, got %d items", len(details))
-		}
-	})
-
-	t.Run("WithEntriesAndDuplicatesForUser", func(t *testing.T) {
-		_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES
-            ('U1E1', '', 'Happy', '😊', '#FFD700', $1), ('U1E2', '', 'Sad', '😢', '#6495ED', $1),
-            ('U1E3', '', 'Happy', '😊', '#FFD700', $1), ('U1E4', '', 'Calm', '😌', '#90EE90', $1),
-            ('U2E1', '', 'Angry', '😠', '#DC143C', $2), ('U2E2', '', 'Happy', '😊', '#FFD700', $2)`,
-			testUserID1, testUserID2)
-		if err != nil {
-			t.Fatalf("Failed to insert test data: %v", err)
+	// "trashed" sits between "second" and "third" but is deleted, and another user's
+	// entry in the same gap belongs to them, so neither counts as third's predecessor.
+	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, intensity, user_id, created_at, deleted_at) VALUES
+        ('first',   'x', 'Calm', '😌', '#69B36C', 2, $1, '2024-05-01 09:00:00+00', NULL),
+        ('second',  'x', 'Calm', '😌', '#69B36C', 4, $1, '2024-05-02 09:00:00+00', NULL),
+        ('trashed', 'x', 'Calm', '😌', '#69B36C', 1, $1, '2024-05-02 12:00:00+00', NOW()),
+        ('other',   'x', 'Calm', '😌', '#69B36C', 5, $2, '2024-05-02 13:00:00+00', NULL),
+        ('third',   'x', 'Calm', '😌', '#69B36C', 3, $1, '2024-05-03 09:00:00+00', NULL)`, testUserID, otherUserID)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not insert moods: %v", err)
+	}
+	ids := map[string]int64{}
+	rows, err := db.Query(`SELECT title, id FROM moods`)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	for rows.Next() {
+		var title string
+		var id int64
+		if err := rows.Scan(&title, &id); err != nil {
+			t.Fatalf("Setup failed: %v", err)
 		}
+		ids[title] = id
+	}
+	rows.Close()
 
-		details1, err1 := model.GetDistinctEmotionDetails(testUserID1)
-		if err1 != nil {
-			t.Fatalf("Expected no error for user 1, got %v", err1)
-		}
-		// Expected order is alphabetical by emotion name
-		expected1 := []EmotionDetail{
-			{Name: "Calm", Emoji: "😌", Color: "#90EE90"},
-			{Name: "Happy", Emoji: "😊", Color: "#FFD700"},
-			{Name: "Sad", Emoji: "😢", Color: "#6495ED"},
-		}
-		if !reflect.DeepEqual(details1, expected1) {
-			t.Errorf("Mismatch for user 1.\nExpected: %+v\nGot:      %+v", expected1, details1)
-		}
+	predecessors, err := model.GetPredecessors(context.Background(), testUserID, []int64{ids["third"], ids["second"], ids["first"]})
+	if err != nil {
+		t.Fatalf("GetPredecessors failed: %v", err)
+	}
+	var got []string
+	for _, m := range predecessors {
+		got = append(got, m.Title)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected predecessors %v, got %v", want, got)
+	}
+	if len(predecessors) == 2 && predecessors[1].Intensity != 4 {
+		t.Errorf("Expected the predecessor's intensity to be scanned, got %d", predecessors[1].Intensity)
+	}
 
-		details2, err2 := model.GetDistinctEmotionDetails(testUserID2)
-		if err2 != nil {
-			t.Fatalf("Expected no error for user 2, got %v", err2)
-		}
-		expected2 := []EmotionDetail{
-			{Name: "Angry", Emoji: "😠", Color: "#DC143C"},
-			{Name: "Happy", Emoji: "😊", Color: "#FFD700"},
-		}
-		if !reflect.DeepEqual(details2, expected2) {
-			t.Errorf("Mismatch for user 2.\nExpected: %+v\nGot:      %+v", expected2, details2)
-		}
-	})
+	// Another user's IDs yield nothing.
+	predecessors, err = model.GetPredecessors(context.Background(), otherUserID, []int64{ids["third"]})
+	if err != nil || len(predecessors) != 0 {
+		t.Errorf("Expected no predecessors for another user's entry, got %d (err %v)", len(predecessors), err)
+	}
 }
 
-func TestMoodModel_GetFiltered(t *testing.T) {
+func TestMoodModel_GetFiltered_FullTextSearch(t *testing.T) {
 	if testing.Short() {
 		t.Skip("postgres: skipping integration test in short mode")
 	}
 	db := newTestDB(t)
 	defer db.Close()
 	defer cleanupTestDB(t, db)
-	testUserID1 := insertTestUser(t, db)
-	testUserID2 := insertTestUser(t, db)
+	testUserID := insertTestUser(t, db)
+	otherUserID := insertTestUser(t, db)
 	model := MoodModel{DB: db}
 
-	baseTime := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
 	_, err := db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, created_at, user_id) VALUES
-        ('U1 Day 5 Calm', 'Relax', 'Calm', '😌', '#90EE90', $1, $6), ('U1 Day 4 Happy', 'Good', 'Happy', '😊', '#FFD700', $2, $6),
-        ('U1 Day 3 Sad', 'Down', 'Sad', '😢', '#6495ED', $3, $6), ('U1 Day 2 Target', 'Tgt', 'Calm', '😌', '#90EE90', $4, $6),
-        ('U2 Day 1 Happy', 'Start', 'Happy', '😊', '#FFD700', $5, $7)`,
-		baseTime.AddDate(0, 0, -0), baseTime.AddDate(0, 0, -1), baseTime.AddDate(0, 0, -2),
-		baseTime.AddDate(0, 0, -3), baseTime.AddDate(0, 0, -4),
-		testUserID1, testUserID2)
+        ('Morning', '<p>Went for a <strong>run</strong> by the river</p>', 'Calm', '😌', '#90EE90', $1, $3),
+        ('Runs again', '<p>Legs hurt</p>', 'Sad', '😢', '#6495ED', $2, $3),
+        ('Quiet day', '<p>Read a book</p>', 'Calm', '😌', '#90EE90', $2, $3),
+        ('Run club', '<p>Fun</p>', 'Happy', '😊', '#FFD700', $1, $4)`,
+		day, day.AddDate(0, 0, -3), testUserID, otherUserID)
 	if err != nil {
 		t.Fatalf("Setup failed: Could not insert moods: %v", err)
 	}
-	totalUser1Records := 4
 
-	t.Run("NoFilters_User1_Page1", func(t *testing.T) {
-		filters := FilterCriteria{Page: 1, PageSize: 3, UserID: testUserID1}
-		moods, metadata, err := model.GetFiltered(filters)
+	search := func(t *testing.T, criteria FilterCriteria) []string {
+		t.Helper()
+		criteria.Page, criteria.PageSize, criteria.UserID, criteria.Weekday = 1, 10, testUserID, AnyWeekday
+		moods, _, err := model.GetFiltered(context.Background(), criteria)
 		if err != nil {
 			t.Fatalf("GetFiltered failed: %v", err)
 		}
-		if len(moods) != 3 {
-			t.Errorf("Expected 3 moods for user 1, got %d", len(moods))
-		}
-		if moods[0].Title != "U1 Day 5 Calm" || moods[1].Title != "U1 Day 4 Happy" || moods[2].Title != "U1 Day 3 Sad" {
-			t.Errorf("Unexpected mood order/content")
-		}
-		expectedMeta := Metadata{CurrentPage: 1, PageSize: 3, FirstPage: 1, LastPage: 2, TotalRecords: totalUser1Records}
-		if !reflect.DeepEqual(metadata, expectedMeta) {
-			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
+		var titles []string
+		for _, m := range moods {
+			titles = append(titles, m.Title)
 		}
-	})
+		return titles
+	}
 
-	t.Run("NoFilters_User2", func(t *testing.T) {
-		filters := FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID2}
-		moods, metadata, err := model.GetFiltered(filters)
-		if err != nil {
-			t.Fatalf("GetFiltered failed: %v", err)
-		}
-		if len(moods) != 1 {
-			t.Errorf("Expected 1 mood for user 2, got %d", len(moods))
-		}
-		if moods[0].Title != "U2 Day 1 Happy" {
-			t.Errorf("Unexpected mood for user 2")
-		}
-		expectedMeta := Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 1, TotalRecords: 1}
-		if !reflect.DeepEqual(metadata, expectedMeta) {
-			t.Errorf("Metadata mismatch.\nExpected: %+v\nGot:      %+v", expectedMeta, metadata)
-		}
-	})
+	tests := []struct {
+		name     string
+		criteria FilterCriteria
+		want     []string
+	}{
+		{"StemmedWordInContentAndTitle", FilterCriteria{TextQuery: "running"}, []string{"Morning", "Runs again"}},
+		{"MarkupIsNotSearched", FilterCriteria{TextQuery: "strong"}, nil},
+		{"EmotionNameStillMatches", FilterCriteria{TextQuery: "cal"}, []string{"Morning", "Quiet day"}},
+		{"AndedWithEmotionFilter", FilterCriteria{TextQuery: "running", Emotion: EncodeEmotionFilter("Sad", "😢")}, []string{"Runs again"}},
+		{"AndedWithDateFilter", FilterCriteria{TextQuery: "running", StartDate: day.AddDate(0, 0, -1)}, []string{"Morning"}},
+		{"BlankQueryIgnored", FilterCriteria{TextQuery: "   "}, []string{"Morning", "Runs again", "Quiet day"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search(t, tt.criteria); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-	t.Run("FilterText_User1", func(t *testing.T) {
-		filters := FilterCriteria{TextQuery: "Target", Page: 1, PageSize: 10, UserID: testUserID1}
-		moods, _, err := model.GetFiltered(filters)
-		if err != nil {
-			t.Fatalf("GetFiltered failed: %v", err)
-		}
-		if len(moods) != 1 {
-			t.Errorf("Expected 1 mood matching 'Target' for user 1, got %d", len(moods))
-		}
-		if moods[0].Title != "U1 Day 2 Target" {
-			t.Errorf("Expected target mood")
+func TestMoodModel_GetFiltered_LocalDateBoundaries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// 00:30 on 10 May in New York is 04:30 UTC; 23:30 on 9 May is 03:30 UTC on 10 May.
+	// Treating the filter day as a UTC midnight would put both on 10 May.
+	_, err = db.Exec(`INSERT INTO moods (title, content, emotion, emoji, color, user_id, created_at) VALUES
+        ('Just After Midnight','','Calm','😌','#fff', $1, $2), ('Late Night Before','','Calm','😌','#fff', $1, $3)`,
+		testUserID,
+		time.Date(2024, 5, 10, 0, 30, 0, 0, newYork),
+		time.Date(2024, 5, 9, 23, 30, 0, 0, newYork))
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %s", err)
+	}
+
+	dayStart := time.Date(2024, 5, 10, 0, 0, 0, 0, newYork)
+	filters := FilterCriteria{
+		StartDate: dayStart,
+		EndDate:   dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond),
+		Location:  newYork,
+		Weekday:   AnyWeekday,
+		Page:      1, PageSize: 10, UserID: testUserID,
+	}
+	moods, _, err := model.GetFiltered(context.Background(), filters)
+	if err != nil {
+		t.Fatalf("GetFiltered failed: %v", err)
+	}
+	if len(moods) != 1 || moods[0].Title != "Just After Midnight" {
+		t.Fatalf("Expected only the local 00:30 entry on 10 May, got %d moods", len(moods))
+	}
+
+	// Location also sets the zone the weekday filter uses: 10 May 2024 was a Friday in New York.
+	filters.StartDate, filters.EndDate = time.Time{}, time.Time{}
+	filters.Weekday = int(time.Thursday)
+	moods, _, err = model.GetFiltered(context.Background(), filters)
+	if err != nil {
+		t.Fatalf("GetFiltered failed: %v", err)
+	}
+	if len(moods) != 1 || moods[0].Title != "Late Night Before" {
+		t.Errorf("Expected only the local Thursday entry, got %d moods", len(moods))
+	}
+}
+
+func TestModels_CancelledContext(t *testing.T) {
+	// sql.Open doesn't connect, and database/sql checks the context before dialling,
+	// so no database is needed to see a cancelled request abort the query.
+	db, err := sql.Open("postgres", "postgres://nobody@127.0.0.1:1/none?sslmode=disable")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	defer db.Close()
+	moods := MoodModel{DB: db}
+	users := UserModel{DB: db}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"MoodModel.Get": func() error { _, err := moods.Get(ctx, 1, 1); return err },
+		"MoodModel.GetFiltered": func() error {
+			_, _, err := moods.GetFiltered(ctx, FilterCriteria{UserID: 1, Page: 1, PageSize: 4, Weekday: AnyWeekday})
+			return err
+		},
+		"MoodModel.GetAllStats": func() error { _, err := moods.GetAllStats(ctx, 1, "UTC"); return err },
+		"UserModel.Get":         func() error { _, err := users.Get(ctx, 1); return err },
+		"UserModel.Count":       func() error { _, err := users.Count(ctx); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
 		}
-	})
+	}
+}
 
-	// Add more filter tests specific to user 1...
+func TestMoodModel_UpdatePartial_Safelist(t *testing.T) {
+	// The safelist is checked before any query runs, so no database is needed.
+	model := MoodModel{}
+	mood := &Mood{ID: 1, UserID: 1, Title: "x"}
+
+	if err := model.UpdatePartial(context.Background(), mood, []string{"user_id"}); err == nil {
+		t.Error("Expected an error for a non-updatable field")
+	}
+	if err := model.UpdatePartial(context.Background(), mood, nil); err == nil {
+		t.Error("Expected an error when no fields are given")
+	}
+	if err := model.UpdatePartial(context.Background(), &Mood{ID: 0, UserID: 1}, []string{"title"}); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for invalid ID, got %v", err)
+	}
 }
 
 // --- Validator Tests (Unchanged) ---
@@ -665,6 +2278,77 @@ func TestValidator_NotBlank(t *testing.T) {
 	}
 }
 
+func TestValidator_OrderedErrors(t *testing.T) {
+	v := validator.NewValidator()
+	v.AddError("title", "must be provided")
+	v.Check(false, "content", "must be provided")
+	v.Check(true, "emoji", "never added")
+	v.AddError("color", "must be a valid hex color code")
+	v.AddError("title", "duplicate is ignored")
+
+	got := v.OrderedErrors()
+	want := []validator.FieldError{
+		{Field: "title", Message: "must be provided"},
+		{Field: "content", Message: "must be provided"},
+		{Field: "color", Message: "must be a valid hex color code"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderedErrors mismatch.\nExpected: %+v\nGot:      %+v", want, got)
+	}
+	if len(v.Errors) != len(want) {
+		t.Errorf("Expected %d entries in the Errors map, got %d", len(want), len(v.Errors))
+	}
+}
+
+func TestValidateMood_Emoji(t *testing.T) {
+	valid := []string{"😊", "❤️", "☺", "👍🏽", "❤️‍🔥", "🇬🇧", "1️⃣", "⭐", "✨"}
+	for _, emoji := range valid {
+		v := validator.NewValidator()
+		ValidateMood(v, &Mood{Title: "T", Content: "<p>c</p>", Emotion: "Grateful", Emoji: emoji, Color: "#8E7CC3", Intensity: 3})
+		if msg, ok := v.Errors["emoji"]; ok {
+			t.Errorf("Expected %q to be accepted, got error %q", emoji, msg)
+		}
+	}
+
+	invalid := []string{"abcd", "hi!", ":-)", "x"}
+	for _, emoji := range invalid {
+		v := validator.NewValidator()
+		ValidateMood(v, &Mood{Title: "T", Content: "<p>c</p>", Emotion: "Grateful", Emoji: emoji, Color: "#8E7CC3", Intensity: 3})
+		if got, want := v.Errors["emoji"], "must be an emoji, not letters or punctuation"; got != want {
+			t.Errorf("Expected %q to be rejected with %q, got %q", emoji, want, got)
+		}
+	}
+}
+
+func TestValidateMood_BuiltInEmotionTrio(t *testing.T) {
+	tests := []struct {
+		name               string
+		emotion, emoji     string
+		color              string
+		emojiErr, colorErr string
+	}{
+		{name: "Canonical", emotion: "Happy", emoji: "😊", color: "#FFCA28"},
+		{name: "LowercaseColor", emotion: "Sad", emoji: "😢", color: "#5c8dde"},
+		{name: "VariationSelector", emotion: "Calm", emoji: "😌\uFE0F", color: "#69B36C"},
+		{name: "WrongEmoji", emotion: "Happy", emoji: "😢", color: "#FFCA28", emojiErr: "must be 😊 for the built-in Happy emotion"},
+		{name: "WrongColor", emotion: "Happy", emoji: "😊", color: "#000000", colorErr: "must be #FFCA28 for the built-in Happy emotion"},
+		{name: "NameCaseIgnored", emotion: " angry ", emoji: "😊", color: "#E53935", emojiErr: "must be 😠 for the built-in Angry emotion"},
+		{name: "CustomIsFreeForm", emotion: "Grateful", emoji: "😢", color: "#000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.NewValidator()
+			ValidateMood(v, &Mood{Title: "T", Content: "<p>c</p>", Emotion: tt.emotion, Emoji: tt.emoji, Color: tt.color, Intensity: 3})
+			if got := v.Errors["emoji"]; got != tt.emojiErr {
+				t.Errorf("Expected emoji error %q, got %q", tt.emojiErr, got)
+			}
+			if got := v.Errors["color"]; got != tt.colorErr {
+				t.Errorf("Expected color error %q, got %q", tt.colorErr, got)
+			}
+		})
+	}
+}
+
 func TestValidator_MatchesHexColor(t *testing.T) {
 	valid := []string{"#fff", "#FFF", "#ff00ff", "#FF00FF", "#000000aa", "#12345678"}
 	invalid := []string{"#ff", "fff", "#gggggg", "#12345", "#1234567", "#123456789"}
@@ -679,3 +2363,106 @@ func TestValidator_MatchesHexColor(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMood_PrivateNote(t *testing.T) {
+	base := Mood{Title: "T", Content: "C", Emotion: "Calm", Emoji: "😌", Color: "#69B36C", Intensity: 3}
+
+	v := validator.NewValidator()
+	empty := base
+	ValidateMood(v, &empty)
+	if !v.ValidData() {
+		t.Errorf("Expected empty private note to be valid, got errors %v", v.Errors)
+	}
+
+	v = validator.NewValidator()
+	long := base
+	long.PrivateNote = strings.Repeat("a", 1001)
+	ValidateMood(v, &long)
+	if _, ok := v.Errors["private_note"]; !ok {
+		t.Error("Expected a private_note error for a note over 1000 characters")
+	}
+}
+
+func TestValidateMood_ContentLength(t *testing.T) {
+	base := Mood{Title: "T", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", Intensity: 3}
+	// Padding markup that adds bytes but no text, to reach the byte cap with little text.
+	padding := "<p><br></p>"
+
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"TextAtLimit", "<p>" + strings.Repeat("é", MoodContentMaxLength) + "</p>", true},
+		{"TextOverLimit", "<p>" + strings.Repeat("é", MoodContentMaxLength+1) + "</p>", false},
+		{"EntitiesCountOnce", "<p>" + strings.Repeat("&amp;", MoodContentMaxLength) + "</p>", true},
+		{"BytesAtLimit", "<p>x</p>" + strings.Repeat(padding, (MoodContentMaxBytes-len("<p>x</p>"))/len(padding)), true},
+		{"BytesOverLimit", "<p>x</p>" + strings.Repeat(padding, (MoodContentMaxBytes-len("<p>x</p>"))/len(padding)+1), false},
+		{"OnlyNonBreakingSpace", "<p>&nbsp;</p>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mood := base
+			mood.Content = tt.content
+			v := validator.NewValidator()
+			ValidateMood(v, &mood)
+			if _, hasErr := v.Errors["content"]; hasErr == tt.valid {
+				t.Errorf("Expected valid=%v for %d bytes, got errors %v", tt.valid, len(tt.content), v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateMood_Intensity(t *testing.T) {
+	for _, tt := range []struct {
+		intensity int
+		valid     bool
+	}{
+		{0, false}, {1, true}, {3, true}, {5, true}, {6, false}, {-1, false},
+	} {
+		v := validator.NewValidator()
+		ValidateMood(v, &Mood{Title: "T", Content: "C", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", Intensity: tt.intensity})
+		if _, failed := v.Errors["intensity"]; failed == tt.valid {
+			t.Errorf("Intensity %d: expected valid=%v, got errors %v", tt.intensity, tt.valid, v.Errors)
+		}
+	}
+}
+
+func TestMoodModel_Intensity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	unset := &Mood{Title: "Unset", Content: "c", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: testUserID}
+	strong := &Mood{Title: "Strong", Content: "c", Emotion: "Angry", Emoji: "😠", Color: "#DC143C", UserID: testUserID, Intensity: 5}
+	for _, m := range []*Mood{unset, strong} {
+		if err := model.Insert(ctx, m); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	got, err := model.Get(ctx, unset.ID, testUserID)
+	if err != nil || got.Intensity != DefaultMoodIntensity {
+		t.Errorf("Expected an unset intensity to default to %d, got %+v (err %v)", DefaultMoodIntensity, got, err)
+	}
+	moods, _, err := model.GetFiltered(ctx, FilterCriteria{Page: 1, PageSize: 10, UserID: testUserID, Weekday: AnyWeekday})
+	if err != nil {
+		t.Fatalf("GetFiltered failed: %v", err)
+	}
+	for _, m := range moods {
+		if m.ID == strong.ID && m.Intensity != 5 {
+			t.Errorf("Expected GetFiltered to return intensity 5, got %d", m.Intensity)
+		}
+	}
+
+	// The column rejects values outside 1-5 even if validation is skipped.
+	if _, err := db.Exec(`UPDATE moods SET intensity = 6 WHERE id = $1`, strong.ID); err == nil {
+		t.Error("Expected the database to reject intensity 6")
+	}
+}
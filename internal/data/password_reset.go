@@ -0,0 +1,38 @@
+// mood/internal/data/password_reset.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PasswordResetTTL is how long an emailed reset link stays valid.
+const PasswordResetTTL = time.Hour
+
+// PasswordResetModel wraps the database pool for password_reset_tokens queries.
+type PasswordResetModel struct {
+	DB *sql.DB
+}
+
+func (m *PasswordResetModel) store() tokenStore {
+	return tokenStore{db: m.DB, table: "password_reset_tokens"}
+}
+
+// New creates a reset token for userID that expires after ttl and returns its plaintext,
+// which should only ever be emailed to the user. Only the token's hash is stored.
+func (m *PasswordResetModel) New(ctx context.Context, userID int64, ttl time.Duration) (string, error) {
+	return m.store().new(ctx, userID, ttl)
+}
+
+// GetUserID returns the ID of the user a token was issued to. It returns ErrRecordNotFound
+// if the token is unknown, already used or expired.
+func (m *PasswordResetModel) GetUserID(ctx context.Context, plaintext string) (int64, error) {
+	return m.store().getUserID(ctx, plaintext)
+}
+
+// DeleteAllForUser removes every reset token issued to userID, used once a reset succeeds
+// so neither that link nor any older one can be replayed.
+func (m *PasswordResetModel) DeleteAllForUser(ctx context.Context, userID int64) error {
+	return m.store().deleteAllForUser(ctx, userID)
+}
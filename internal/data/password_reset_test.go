@@ -0,0 +1,97 @@
+// internal/data/password_reset_test.go
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mickali02/mood/internal/validator"
+)
+
+func TestValidatePasswordReset(t *testing.T) {
+	tests := []struct {
+		name, newPassword, confirm, wantField string // wantField "" means valid
+	}{
+		{"Valid", "n3wPa55word!", "n3wPa55word!", ""},
+		{"TooShort", "short", "short", "new_password"},
+		{"Mismatch", "n3wPa55word!", "n3wPa55word?", "confirm_password"},
+		{"MissingConfirm", "n3wPa55word!", "", "confirm_password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.NewValidator()
+			ValidatePasswordReset(v, tt.newPassword, tt.confirm)
+			if tt.wantField == "" {
+				if !v.ValidData() {
+					t.Errorf("Expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.wantField]; !ok {
+				t.Errorf("Expected an error for %q, got %v", tt.wantField, v.Errors)
+			}
+		})
+	}
+}
+
+func TestPasswordResetModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	userID := insertTestUser(t, db)
+	model := PasswordResetModel{DB: db}
+	ctx := context.Background()
+
+	token, err := model.New(ctx, userID, PasswordResetTTL)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Run("OnlyHashIsStored", func(t *testing.T) {
+		var stored []byte
+		if err := db.QueryRow(`SELECT hash FROM password_reset_tokens WHERE user_id = $1`, userID).Scan(&stored); err != nil {
+			t.Fatalf("Failed to read stored token: %v", err)
+		}
+		if bytes.Contains(stored, []byte(token)) || !bytes.Equal(stored, hashToken(token)) {
+			t.Error("Expected only the token's SHA-256 hash to be stored")
+		}
+	})
+
+	t.Run("ValidToken", func(t *testing.T) {
+		got, err := model.GetUserID(ctx, token)
+		if err != nil || got != userID {
+			t.Errorf("GetUserID = %d, %v; want %d, nil", got, err, userID)
+		}
+	})
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		if _, err := model.GetUserID(ctx, "NOTATOKEN"); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		expired, err := model.New(ctx, userID, -time.Minute)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if _, err := model.GetUserID(ctx, expired); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound for an expired token, got %v", err)
+		}
+	})
+
+	t.Run("DeleteAllForUser", func(t *testing.T) {
+		if err := model.DeleteAllForUser(ctx, userID); err != nil {
+			t.Fatalf("DeleteAllForUser failed: %v", err)
+		}
+		if _, err := model.GetUserID(ctx, token); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected the token to be gone, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+// mood/internal/data/reminders.go
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReminderRecipient is a user owed a check-in reminder email.
+type ReminderRecipient struct {
+	UserID    int64
+	Name      string
+	Email     string
+	TimeZone  string
+	Frequency string // "daily" or "weekly"
+}
+
+// GetDueReminders returns the activated users who opted in to reminder emails
+// (User.EmailReminders) and whose reminder is due at now. A reminder is due once the
+// user's local clock has passed their reminder time, and only if they have no entry for
+// the period (today for "daily", the last seven local days for "weekly") and weren't
+// already reminded in it. Everything is compared on the user's own calendar, so a 09:00
+// reminder means 9am where they are.
+func (m *UserModel) GetDueReminders(ctx context.Context, now time.Time) ([]*ReminderRecipient, error) {
+	query := `
+        SELECT u.id, u.name, u.email, u.timezone, u.reminder_frequency
+        FROM users u
+        CROSS JOIN LATERAL (
+            SELECT ($1::timestamptz AT TIME ZONE u.timezone) AS local_now,
+                   CASE u.reminder_frequency WHEN 'weekly' THEN 7 ELSE 1 END AS period_days
+        ) p
+        WHERE u.email_reminders AND u.activated AND u.reminder_time IS NOT NULL
+          AND p.local_now::time >= u.reminder_time
+          AND (u.reminder_sent_at IS NULL
+               OR (u.reminder_sent_at AT TIME ZONE u.timezone)::date <= p.local_now::date - p.period_days)
+          AND NOT EXISTS (
+              SELECT 1 FROM moods m
+              WHERE m.user_id = u.id AND m.deleted_at IS NULL
+                AND (m.created_at AT TIME ZONE u.timezone)::date > p.local_now::date - p.period_days
+          )
+        ORDER BY u.id`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []*ReminderRecipient{}
+	for rows.Next() {
+		var r ReminderRecipient
+		if err := rows.Scan(&r.UserID, &r.Name, &r.Email, &r.TimeZone, &r.Frequency); err != nil {
+			return nil, fmt.Errorf("due reminders scan: %w", err)
+		}
+		recipients = append(recipients, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("due reminders rows: %w", err)
+	}
+	return recipients, nil
+}
+
+// MarkReminderSent records that the user was sent a reminder at sentAt, so
+// GetDueReminders skips them until their next period.
+func (m *UserModel) MarkReminderSent(ctx context.Context, userID int64, sentAt time.Time) error {
+	query := `
+        UPDATE users
+        SET reminder_sent_at = $1
+        WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, sentAt, userID)
+	if err != nil {
+		return fmt.Errorf("mark reminder sent: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("mark reminder sent: %w", err)
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
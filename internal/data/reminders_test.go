@@ -0,0 +1,94 @@
+// mood/internal/data/reminders_test.go
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUserModel_GetDueReminders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	users := UserModel{DB: db}
+	moods := MoodModel{DB: db}
+	ctx := context.Background()
+
+	// A user in Belize (UTC-6, no DST) with a 09:00 reminder.
+	userID := insertTestUser(t, db)
+	if err := users.UpdateTimeZone(ctx, userID, "America/Belize"); err != nil {
+		t.Fatalf("UpdateTimeZone failed: %v", err)
+	}
+	if err := users.UpdateReminder(ctx, userID, true, "09:00", "daily"); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+	if err := users.UpdateEmailReminders(ctx, userID, true); err != nil {
+		t.Fatalf("UpdateEmailReminders failed: %v", err)
+	}
+	// Another user with a reminder but without email reminders is never due.
+	offID := insertTestUser(t, db)
+	if err := users.UpdateReminder(ctx, offID, true, "09:00", "daily"); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+
+	isDue := func(now time.Time) bool {
+		t.Helper()
+		due, err := users.GetDueReminders(ctx, now)
+		if err != nil {
+			t.Fatalf("GetDueReminders failed: %v", err)
+		}
+		for _, r := range due {
+			if r.UserID == offID {
+				t.Errorf("Reminder without email reminders reported as due")
+			}
+		}
+		return len(due) == 1 && due[0].UserID == userID
+	}
+
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	if isDue(day.Add(14 * time.Hour)) { // 08:00 in Belize.
+		t.Error("Expected no reminder before the local reminder time")
+	}
+	if !isDue(day.Add(15*time.Hour + 30*time.Minute)) { // 09:30 in Belize.
+		t.Fatal("Expected a reminder after the local reminder time")
+	}
+
+	// Once sent, it isn't due again until the next local day.
+	if err := users.MarkReminderSent(ctx, userID, day.Add(15*time.Hour)); err != nil {
+		t.Fatalf("MarkReminderSent failed: %v", err)
+	}
+	if isDue(day.Add(20 * time.Hour)) {
+		t.Error("Expected no second reminder the same day")
+	}
+	nextDay := day.AddDate(0, 0, 1)
+	if !isDue(nextDay.Add(16 * time.Hour)) {
+		t.Error("Expected a reminder the next day")
+	}
+
+	// An entry that day means no reminder.
+	mood := &Mood{Title: "Logged", Content: "<p>x</p>", Emotion: "Calm", Emoji: "😌", Color: "#90EE90", UserID: userID}
+	if err := moods.Insert(ctx, mood); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := db.Exec("UPDATE moods SET created_at = $1 WHERE id = $2", nextDay.Add(13*time.Hour), mood.ID); err != nil {
+		t.Fatalf("Backdating entry failed: %v", err)
+	}
+	if isDue(nextDay.Add(16 * time.Hour)) {
+		t.Error("Expected no reminder on a day with an entry")
+	}
+
+	// Weekly: the same entry covers the following six days.
+	if err := users.UpdateReminder(ctx, userID, true, "09:00", "weekly"); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+	if isDue(nextDay.AddDate(0, 0, 6).Add(16 * time.Hour)) {
+		t.Error("Expected no weekly reminder within a week of an entry")
+	}
+	if !isDue(nextDay.AddDate(0, 0, 7).Add(16 * time.Hour)) {
+		t.Error("Expected a weekly reminder after a week without entries")
+	}
+}
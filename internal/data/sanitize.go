@@ -0,0 +1,60 @@
+// mood/internal/data/sanitize.go
+package data
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// The two HTML policies used for mood content. Policies are safe for concurrent
+// use once built, so they are created once and shared.
+var (
+	// contentPolicy allows only the formatting the rich-text editor produces (bold,
+	// lists, links...) and drops scripts, event handlers and all other markup.
+	contentPolicy = newContentPolicy()
+	// previewPolicy strips every tag, leaving escaped plain text.
+	previewPolicy = bluemonday.StrictPolicy()
+	// wordsPolicy is previewPolicy but leaves a space where each tag was, so words in
+	// adjacent paragraphs ("<p>one</p><p>two</p>") aren't run together when counted.
+	wordsPolicy = bluemonday.StrictPolicy().AddSpaceWhenStrippingTag(true)
+)
+
+// newContentPolicy builds the allowlist for entry content: the elements Quill emits,
+// links limited to http(s), mailto and relative URLs, and the few Quill attributes
+// that carry meaning (list type, indentation and alignment).
+func newContentPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "b", "strong", "i", "em", "u", "s", "strike",
+		"ul", "ol", "li", "h1", "h2", "h3", "blockquote", "pre", "code")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowStandardURLs() // http, https, mailto and relative links, with rel="nofollow".
+	// Quill 2 writes every list as <ol> and marks bullet items with data-list.
+	p.AllowAttrs("data-list").Matching(regexp.MustCompile(`^(bullet|ordered|checked|unchecked)$`)).OnElements("li")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^ql-(indent-[1-8]|align-(center|right|justify))$`)).OnElements("p", "li", "h1", "h2", "h3", "blockquote")
+	return p
+}
+
+// SanitizeHTML returns content with only the editor's safe formatting left in. Entry
+// content is passed through it before it's stored, and again when it's rendered.
+func SanitizeHTML(content string) string {
+	return contentPolicy.Sanitize(content)
+}
+
+// SanitizeContent returns the mood's content with only safe formatting left in,
+// ready to render as HTML (the detail page and the View More modal).
+func (m *Mood) SanitizeContent() string {
+	return SanitizeHTML(m.Content)
+}
+
+// SanitizePreview strips all markup from html, leaving plain text with HTML
+// entities escaped. Use it for previews and for checking content isn't blank.
+func SanitizePreview(html string) string {
+	return previewPolicy.Sanitize(html)
+}
+
+// countWords returns the number of whitespace-separated words in html's text.
+func countWords(html string) int {
+	return len(strings.Fields(wordsPolicy.Sanitize(html)))
+}
@@ -0,0 +1,84 @@
+// internal/data/sanitize_test.go
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMood_SanitizeContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"KeepsFormatting", "<p>A <strong>good</strong> <em>day</em></p><ul><li>walk</li></ul>", "<p>A <strong>good</strong> <em>day</em></p><ul><li>walk</li></ul>"},
+		{"DropsScript", "<p>Hi</p><script>alert(1)</script>", "<p>Hi</p>"},
+		{"DropsEventHandler", `<p onclick="steal()">Hi</p>`, "<p>Hi</p>"},
+		{"DropsJavascriptLink", `<a href="javascript:alert(1)">x</a>`, "x"},
+		{"DropsImageWithOnerror", `<p>Hi<img src="x" onerror="alert(1)"></p>`, "<p>Hi</p>"},
+		{"DropsIframe", `<iframe src="https://evil.example"></iframe><p>Hi</p>`, "<p>Hi</p>"},
+		{"KeepsQuillListAndIndent", `<ol><li data-list="bullet" class="ql-indent-1">tea</li></ol>`, `<ol><li data-list="bullet" class="ql-indent-1">tea</li></ol>`},
+		{"DropsOtherClassesAndStyles", `<p class="evil" style="position:fixed">Hi</p>`, "<p>Hi</p>"},
+		{"HeadingsBeyondH3BecomeText", "<h4>Small</h4>", "Small"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mood := &Mood{Content: tt.content}
+			if got := mood.SanitizeContent(); got != tt.want {
+				t.Errorf("SanitizeContent() = %q, want %q", got, tt.want)
+			}
+			if mood.Content != tt.content {
+				t.Error("SanitizeContent should not modify the mood")
+			}
+		})
+	}
+
+	// Links are kept, with rel="nofollow" added.
+	got := (&Mood{Content: `<a href="https://example.com">site</a>`}).SanitizeContent()
+	if !strings.Contains(got, `href="https://example.com"`) || !strings.Contains(got, `rel="nofollow"`) {
+		t.Errorf("Expected a safe link with rel=nofollow, got %q", got)
+	}
+}
+
+func TestSanitizePreview(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"StripsTags", "<p>A <strong>good</strong> day</p>", "A good day"},
+		{"DropsScript", "<p>Hi</p><script>alert(1)</script>", "Hi"},
+		{"EscapesText", "<p>Tom &amp; Jerry</p>", "Tom &amp; Jerry"},
+		{"EmptyEditorOutput", "<p><br></p>", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizePreview(tt.html); got != tt.want {
+				t.Errorf("SanitizePreview(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want int
+	}{
+		{"Empty", "", 0},
+		{"EmptyEditorOutput", "<p><br></p>", 0},
+		{"InlineTags", "<p>A <strong>good</strong> day</p>", 3},
+		{"AdjacentParagraphs", "<p>one</p><p>two</p>", 2},
+		{"ListItems", "<ul><li>tea</li><li>a walk</li></ul>", 3},
+		{"ExtraWhitespace", "<p>  spaced \n\t out  </p>", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countWords(tt.html); got != tt.want {
+				t.Errorf("countWords(%q) = %d, want %d", tt.html, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// mood/internal/data/saved_views.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mickali02/mood/internal/validator"
+)
+
+// Saved view errors.
+var (
+	ErrDuplicateViewName = errors.New("duplicate saved view name") // The user already has a view with this name.
+	ErrTooManySavedViews = errors.New("too many saved views")      // The user has reached MaxSavedViews.
+)
+
+// Saved view limits.
+const (
+	SavedViewNameMaxLength = 50 // Max characters in a saved view's name.
+	MaxSavedViews          = 20 // Max saved views per user, so the dashboard list stays short.
+)
+
+// savedViewsUserNameConstraint is the UNIQUE (user_id, name) constraint on saved_views.
+const savedViewsUserNameConstraint = "saved_views_user_id_name_key"
+
+// SavedViewFilters are the dashboard filters a saved view applies, stored exactly as
+// the dashboard's query parameters. Only these safe fields are serialized; the owner
+// comes from SavedView.UserID and is never read from the stored JSON.
+type SavedViewFilters struct {
+	Query     string `json:"query,omitempty"`
+	Emotion   string `json:"emotion,omitempty"`    // Encoded with EncodeEmotionFilter.
+	StartDate string `json:"start_date,omitempty"` // YYYY-MM-DD
+	EndDate   string `json:"end_date,omitempty"`   // YYYY-MM-DD
+	Weekday   string `json:"weekday,omitempty"`    // Short day name, e.g. "mon".
+
+	MinIntensity string `json:"min_intensity,omitempty"` // "2"-"5"; blank means from 1.
+	MaxIntensity string `json:"max_intensity,omitempty"` // "1"-"4"; blank means up to 5.
+}
+
+// Values returns the filters as dashboard query parameters, skipping empty ones.
+func (f SavedViewFilters) Values() url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"query": f.Query, "emotion": f.Emotion, "start_date": f.StartDate, "end_date": f.EndDate, "weekday": f.Weekday,
+		"min_intensity": f.MinIntensity, "max_intensity": f.MaxIntensity,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values
+}
+
+// IsEmpty reports whether no filter is set.
+func (f SavedViewFilters) IsEmpty() bool {
+	return f == SavedViewFilters{}
+}
+
+// SavedView is a named dashboard filter combination belonging to one user.
+type SavedView struct {
+	ID        int64            `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UserID    int64            `json:"-"`
+	Name      string           `json:"name"`
+	Filters   SavedViewFilters `json:"filters"`
+}
+
+// ValidateSavedView checks a view's name and filters before it is stored.
+func ValidateSavedView(v *validator.Validator, view *SavedView) {
+	v.Check(validator.NotBlank(view.Name), "name", "must be provided")
+	v.Check(validator.MaxLength(view.Name, SavedViewNameMaxLength), "name", fmt.Sprintf("must not be more than %d characters long", SavedViewNameMaxLength))
+
+	f := view.Filters
+	v.Check(!f.IsEmpty(), "filters", "choose at least one filter before saving a view")
+	v.Check(validator.MaxLength(f.Query, 200), "query", "must not be more than 200 characters long")
+	v.Check(validator.MaxLength(f.Emotion, 500), "emotion", "is too long")
+	for field, value := range map[string]string{"start_date": f.StartDate, "end_date": f.EndDate} {
+		if value == "" {
+			continue
+		}
+		_, err := time.Parse("2006-01-02", value)
+		v.Check(err == nil, field, "must be a date in YYYY-MM-DD format")
+	}
+	v.Check(f.StartDate == "" || f.EndDate == "" || f.StartDate <= f.EndDate, "end_date", "must not be before the start date")
+	v.Check(validator.PermittedValue(f.Weekday, "", "sun", "mon", "tue", "wed", "thu", "fri", "sat"), "weekday", "must be a short day name such as mon")
+
+	// Intensity bounds are stored as text like the other filters; blank means the end of the scale.
+	minIntensity, maxIntensity := MoodIntensityMin, MoodIntensityMax
+	var minErr, maxErr error
+	if f.MinIntensity != "" {
+		minIntensity, minErr = strconv.Atoi(f.MinIntensity)
+	}
+	if f.MaxIntensity != "" {
+		maxIntensity, maxErr = strconv.Atoi(f.MaxIntensity)
+	}
+	v.Check(minErr == nil, "min_intensity", "must be a whole number")
+	v.Check(maxErr == nil, "max_intensity", "must be a whole number")
+	ValidateIntensityRange(v, minIntensity, maxIntensity)
+}
+
+// SavedViewModel wraps the database pool for saved_views queries.
+// Every method is scoped to a user ID, so one user can never see or delete another's views.
+type SavedViewModel struct {
+	DB *sql.DB
+}
+
+// Insert stores a new view for view.UserID, filling in its ID and CreatedAt.
+// It returns ErrDuplicateViewName if the user already has a view with that name,
+// and ErrTooManySavedViews once they have MaxSavedViews.
+func (m *SavedViewModel) Insert(ctx context.Context, view *SavedView) error {
+	if view.UserID < 1 {
+		return errors.New("invalid user ID provided for saved view insert")
+	}
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		return fmt.Errorf("saved view insert: %w", err)
+	}
+
+	// The count check and insert run as one statement, so two quick saves can't
+	// both slip under the limit.
+	query := `
+        INSERT INTO saved_views (user_id, name, filters)
+        SELECT $1::bigint, $2::text, $3::jsonb
+        WHERE (SELECT COUNT(*) FROM saved_views WHERE user_id = $1) < $4
+        RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, view.UserID, view.Name, string(filters), MaxSavedViews).Scan(&view.ID, &view.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrTooManySavedViews
+		case errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == savedViewsUserNameConstraint:
+			return ErrDuplicateViewName
+		default:
+			return fmt.Errorf("saved view insert: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAllForUser returns the user's saved views ordered by name.
+func (m *SavedViewModel) GetAllForUser(ctx context.Context, userID int64) ([]*SavedView, error) {
+	if userID < 1 {
+		return nil, errors.New("invalid user ID")
+	}
+	query := `
+        SELECT id, created_at, user_id, name, filters
+        FROM saved_views
+        WHERE user_id = $1
+        ORDER BY name ASC, id ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("saved views query: %w", err)
+	}
+	defer rows.Close()
+
+	views := []*SavedView{}
+	for rows.Next() {
+		var view SavedView
+		var filters []byte
+		if err := rows.Scan(&view.ID, &view.CreatedAt, &view.UserID, &view.Name, &filters); err != nil {
+			return nil, fmt.Errorf("saved views scan: %w", err)
+		}
+		if err := json.Unmarshal(filters, &view.Filters); err != nil {
+			return nil, fmt.Errorf("saved view %d filters: %w", view.ID, err)
+		}
+		views = append(views, &view)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("saved views rows: %w", err)
+	}
+	return views, nil
+}
+
+// Delete removes one of the user's saved views. It returns ErrRecordNotFound if the
+// view doesn't exist or belongs to someone else.
+func (m *SavedViewModel) Delete(ctx context.Context, id, userID int64) error {
+	if id < 1 || userID < 1 {
+		return ErrRecordNotFound
+	}
+	query := `DELETE FROM saved_views WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("saved view delete: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("saved view delete rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// internal/data/saved_views_test.go
+package data
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mickali02/mood/internal/validator"
+)
+
+func TestValidateSavedView(t *testing.T) {
+	tests := []struct {
+		name      string
+		view      SavedView
+		wantField string // "" means valid
+	}{
+		{"Valid", SavedView{Name: "Anxious spring", Filters: SavedViewFilters{Emotion: "Anxious::%F0%9F%98%9F", StartDate: "2024-03-01", EndDate: "2024-05-31"}}, ""},
+		{"MissingName", SavedView{Name: " ", Filters: SavedViewFilters{Query: "work"}}, "name"},
+		{"NoFilters", SavedView{Name: "Everything"}, "filters"},
+		{"BadDate", SavedView{Name: "x", Filters: SavedViewFilters{StartDate: "01/03/2024"}}, "start_date"},
+		{"EndBeforeStart", SavedView{Name: "x", Filters: SavedViewFilters{StartDate: "2024-05-02", EndDate: "2024-05-01"}}, "end_date"},
+		{"BadWeekday", SavedView{Name: "x", Filters: SavedViewFilters{Weekday: "funday"}}, "weekday"},
+		{"InvertedIntensity", SavedView{Name: "x", Filters: SavedViewFilters{MinIntensity: "4", MaxIntensity: "2"}}, "max_intensity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.NewValidator()
+			ValidateSavedView(v, &tt.view)
+			if tt.wantField == "" {
+				if !v.ValidData() {
+					t.Errorf("Expected no errors, got %v", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.wantField]; !ok {
+				t.Errorf("Expected an error for %q, got %v", tt.wantField, v.Errors)
+			}
+		})
+	}
+}
+
+func TestSavedViewFilters_Values(t *testing.T) {
+	f := SavedViewFilters{Query: "long day", Weekday: "mon"}
+	if got, want := f.Values().Encode(), "query=long+day&weekday=mon"; got != want {
+		t.Errorf("Values().Encode() = %q, want %q", got, want)
+	}
+}
+
+func TestSavedViewModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	ownerID := insertTestUser(t, db)
+	otherID := insertTestUser(t, db)
+	model := SavedViewModel{DB: db}
+	ctx := context.Background()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		filters := SavedViewFilters{Emotion: "Anxious::%F0%9F%98%9F", StartDate: "2024-03-01", Weekday: "fri"}
+		view := &SavedView{UserID: ownerID, Name: "Anxious Fridays", Filters: filters}
+		if err := model.Insert(ctx, view); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if view.ID < 1 || view.CreatedAt.IsZero() {
+			t.Fatalf("Expected Insert to set ID and CreatedAt, got %+v", view)
+		}
+
+		views, err := model.GetAllForUser(ctx, ownerID)
+		if err != nil {
+			t.Fatalf("GetAllForUser failed: %v", err)
+		}
+		if len(views) != 1 || views[0].Name != "Anxious Fridays" || !reflect.DeepEqual(views[0].Filters, filters) {
+			t.Fatalf("Expected the saved filters back unchanged, got %+v", views)
+		}
+
+		if err := model.Insert(ctx, &SavedView{UserID: ownerID, Name: "Anxious Fridays", Filters: filters}); !errors.Is(err, ErrDuplicateViewName) {
+			t.Errorf("Expected ErrDuplicateViewName, got %v", err)
+		}
+	})
+
+	t.Run("CrossUserIsolation", func(t *testing.T) {
+		views, err := model.GetAllForUser(ctx, otherID)
+		if err != nil {
+			t.Fatalf("GetAllForUser failed: %v", err)
+		}
+		if len(views) != 0 {
+			t.Errorf("Expected no views for another user, got %d", len(views))
+		}
+
+		// The same name is fine for a different user.
+		if err := model.Insert(ctx, &SavedView{UserID: otherID, Name: "Anxious Fridays", Filters: SavedViewFilters{Query: "x"}}); err != nil {
+			t.Errorf("Expected another user to reuse the name, got %v", err)
+		}
+
+		ownerViews, _ := model.GetAllForUser(ctx, ownerID)
+		if err := model.Delete(ctx, ownerViews[0].ID, otherID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound deleting another user's view, got %v", err)
+		}
+		if err := model.Delete(ctx, ownerViews[0].ID, ownerID); err != nil {
+			t.Errorf("Expected the owner to delete their view, got %v", err)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		limitedID := insertTestUser(t, db)
+		for i := 0; i < MaxSavedViews; i++ {
+			view := &SavedView{UserID: limitedID, Name: string(rune('A' + i)), Filters: SavedViewFilters{Query: "q"}}
+			if err := model.Insert(ctx, view); err != nil {
+				t.Fatalf("Insert %d failed: %v", i, err)
+			}
+		}
+		err := model.Insert(ctx, &SavedView{UserID: limitedID, Name: "One Too Many", Filters: SavedViewFilters{Query: "q"}})
+		if !errors.Is(err, ErrTooManySavedViews) {
+			t.Errorf("Expected ErrTooManySavedViews, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+// mood/internal/data/tokens.go
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// tokenBytes is the amount of randomness in each emailed token (128 bits).
+const tokenBytes = 16
+
+// hashToken returns the SHA-256 of a plaintext token, which is all the database ever sees.
+func hashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+// tokenStore holds single-use, expiring tokens in one table shaped
+// (hash BYTEA PRIMARY KEY, user_id BIGINT, expiry TIMESTAMPTZ). The password reset,
+// account activation and email change models are thin wrappers around it, each with its own table.
+type tokenStore struct {
+	db    *sql.DB
+	table string // A constant table name, never user input.
+}
+
+// new creates a token for userID that expires after ttl and returns its plaintext,
+// which should only ever be emailed to the user. Only the token's hash is stored.
+func (s tokenStore) new(ctx context.Context, userID int64, ttl time.Duration) (string, error) {
+	if userID < 1 {
+		return "", fmt.Errorf("invalid user ID provided for %s insert", s.table)
+	}
+	randomBytes := make([]byte, tokenBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("%s token: %w", s.table, err)
+	}
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	query := fmt.Sprintf(`
+        INSERT INTO %s (hash, user_id, expiry)
+        VALUES ($1, $2, $3)`, s.table)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, hashToken(plaintext), userID, time.Now().Add(ttl)); err != nil {
+		return "", fmt.Errorf("%s insert: %w", s.table, err)
+	}
+	return plaintext, nil
+}
+
+// getUserID returns the ID of the user a token was issued to. It returns ErrRecordNotFound
+// if the token is unknown, already used or expired.
+func (s tokenStore) getUserID(ctx context.Context, plaintext string) (int64, error) {
+	if plaintext == "" {
+		return 0, ErrRecordNotFound
+	}
+	query := fmt.Sprintf(`
+        SELECT user_id
+        FROM %s
+        WHERE hash = $1 AND expiry > NOW()`, s.table)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var userID int64
+	err := s.db.QueryRowContext(ctx, query, hashToken(plaintext)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, fmt.Errorf("%s lookup: %w", s.table, err)
+	}
+	return userID, nil
+}
+
+// deleteAllForUser removes every token issued to userID, so neither the one just used
+// nor any older one can be replayed. Expired tokens are swept at the same time.
+func (s tokenStore) deleteAllForUser(ctx context.Context, userID int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1 OR expiry <= NOW()`, s.table)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("%s delete: %w", s.table, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// internal/data/tokens_test.go
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHashToken(t *testing.T) {
+	a, b := hashToken("TOKENA"), hashToken("TOKENB")
+	if len(a) != 32 {
+		t.Fatalf("Expected a 32-byte SHA-256 hash, got %d bytes", len(a))
+	}
+	if bytes.Equal(a, b) || !bytes.Equal(a, hashToken("TOKENA")) {
+		t.Error("hashToken must be deterministic and differ between tokens")
+	}
+}
+
+func TestActivationModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	userID := insertTestUser(t, db)
+	activations := ActivationModel{DB: db}
+	resets := PasswordResetModel{DB: db}
+	ctx := context.Background()
+
+	token, err := activations.New(ctx, userID, ActivationTTL)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got, err := activations.GetUserID(ctx, token); err != nil || got != userID {
+		t.Fatalf("GetUserID = %d, %v; want %d, nil", got, err, userID)
+	}
+	// Each kind of token lives in its own table, so one can't stand in for the other.
+	if _, err := resets.GetUserID(ctx, token); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected an activation token to be rejected as a reset token, got %v", err)
+	}
+	if err := activations.DeleteAllForUser(ctx, userID); err != nil {
+		t.Fatalf("DeleteAllForUser failed: %v", err)
+	}
+	if _, err := activations.GetUserID(ctx, token); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected the token to be gone, got %v", err)
+	}
+}
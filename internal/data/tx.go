@@ -0,0 +1,54 @@
+// mood/internal/data/tx.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// runInTx begins a transaction on db, runs fn inside it, and commits if fn returns
+// nil. If fn returns an error or panics, the transaction is rolled back instead.
+// sql.ErrNoRows from fn is translated to ErrRecordNotFound, matching the models'
+// single-statement methods; any other error from fn is returned as-is.
+func runInTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	// Roll back on a panic, then let it continue up the stack.
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			err = errors.Join(err, fmt.Errorf("rollback transaction: %w", rbErr))
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withTx runs fn in a transaction on the moods database; see runInTx.
+// Use it for operations that must change several rows together or not at all.
+func (m *MoodModel) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	return runInTx(ctx, m.DB, fn)
+}
+
+// withTx runs fn in a transaction on the users database; see runInTx.
+func (m *UserModel) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	return runInTx(ctx, m.DB, fn)
+}
@@ -0,0 +1,117 @@
+// internal/data/tx_test.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestMoodModel_WithTx(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	insert := func(tx *sql.Tx, title string) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO moods (title, content, emotion, emoji, color, user_id) VALUES ($1, '<p>c</p>', 'Calm', '😌', '#ADD8E6', $2)`,
+			title, testUserID)
+		return err
+	}
+
+	t.Run("RollsBackOnError", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := model.withTx(ctx, func(tx *sql.Tx) error {
+			if err := insert(tx, "Rolled back"); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Expected the callback's error back, got %v", err)
+		}
+		if count, _ := model.GetTotalMoodCount(ctx, testUserID); count != 0 {
+			t.Errorf("Expected the insert to be rolled back, found %d moods", count)
+		}
+	})
+
+	t.Run("CommitsOnSuccess", func(t *testing.T) {
+		err := model.withTx(ctx, func(tx *sql.Tx) error {
+			if err := insert(tx, "First"); err != nil {
+				return err
+			}
+			return insert(tx, "Second")
+		})
+		if err != nil {
+			t.Fatalf("withTx failed: %v", err)
+		}
+		if count, _ := model.GetTotalMoodCount(ctx, testUserID); count != 2 {
+			t.Errorf("Expected both inserts to be committed, found %d moods", count)
+		}
+	})
+
+	t.Run("TranslatesNoRows", func(t *testing.T) {
+		userModel := UserModel{DB: db}
+		err := userModel.withTx(ctx, func(tx *sql.Tx) error {
+			var id int64
+			return tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = -1`).Scan(&id)
+		})
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound, got %v", err)
+		}
+	})
+}
+
+func TestMoodModel_WritesAreAtomic(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := MoodModel{DB: db}
+	ctx := context.Background()
+
+	t.Run("FailedSecondInsertRollsBackFirst", func(t *testing.T) {
+		first := &Mood{Title: "First", Content: "<p>c</p>", Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: testUserID}
+		second := &Mood{Title: "Second", Content: "<p>c</p>", Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: testUserID + 1000} // No such user.
+		err := model.withTx(ctx, func(tx *sql.Tx) error {
+			if err := insertMood(ctx, tx, first); err != nil {
+				return err
+			}
+			return insertMood(ctx, tx, second)
+		})
+		if err == nil {
+			t.Fatal("Expected the second insert to fail")
+		}
+		if count, _ := model.GetTotalMoodCount(ctx, testUserID); count != 0 {
+			t.Errorf("Expected the first insert to be rolled back, found %d moods", count)
+		}
+	})
+
+	t.Run("FailedUpdateRollsBackSnapshot", func(t *testing.T) {
+		mood := &Mood{Title: "Kept", Content: "<p>c</p>", Emotion: "Calm", Emoji: "😌", Color: "#ADD8E6", UserID: testUserID}
+		if err := model.Insert(ctx, mood); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		edit := *mood
+		edit.Title, edit.Intensity = "Never saved", 9 // Violates moods_intensity_check after the snapshot is written.
+		if err := model.Update(ctx, &edit); err == nil {
+			t.Fatal("Expected the update to fail")
+		}
+		if revisions, err := model.GetRevisions(ctx, mood.ID, testUserID); err != nil || len(revisions) != 0 {
+			t.Errorf("Expected the revision snapshot to be rolled back, found %d (err %v)", len(revisions), err)
+		}
+		if current, _ := model.Get(ctx, mood.ID, testUserID); current == nil || current.Title != "Kept" {
+			t.Errorf("Expected the entry to be unchanged, got %+v", current)
+		}
+	})
+}
@@ -5,9 +5,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"strings"
+	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mickali02/mood/internal/validator"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -15,22 +16,120 @@ import (
 // Define user-specific errors for consistent error handling across the application.
 // Standardized errors for common user-related issues like duplicate email or invalid login.
 var (
-	ErrDuplicateEmail     = errors.New("duplicate email")     // Error when trying to register an email already in use.
-	ErrRecordNotFound     = errors.New("record not found")    // Error when a user record cannot be found.
-	ErrInvalidCredentials = errors.New("invalid credentials") // Error for failed login attempts.
-	ErrEditConflict       = errors.New("edit conflict")       // Placeholder for optimistic locking
+	ErrDuplicateEmail     = errors.New("duplicate email")       // Error when trying to register an email already in use.
+	ErrRecordNotFound     = errors.New("record not found")      // Error when a user record cannot be found.
+	ErrInvalidCredentials = errors.New("invalid credentials")   // Error for failed login attempts.
+	ErrEditConflict       = errors.New("edit conflict")         // Error when a mood changed after it was read (see MoodModel.Update).
+	ErrNotActivated       = errors.New("account not activated") // Correct password, but the email hasn't been confirmed yet.
 )
 
 // User struct defines the structure of a user, mapping to the 'users' database table.
 // JSON tags control serialization; `json:"-"` hides a field (like Password) from JSON output.
 // This is our User model, representing a user in the system. Note the custom 'password' type for security.
 type User struct {
-	ID        int64     `json:"id"`         // Unique identifier (Primary Key).
-	CreatedAt time.Time `json:"created_at"` // Timestamp of user creation.
-	Name      string    `json:"name"`       // User's display name.
-	Email     string    `json:"email"`      // User's email address (used for login, must be unique).
-	Password  password  `json:"-"`          // Custom type to handle password hashing and comparison.
-	Activated bool      `json:"activated"`  // Flag indicating if the user account is active.
+	ID         int64     `json:"id"`          // Unique identifier (Primary Key).
+	CreatedAt  time.Time `json:"created_at"`  // Timestamp of user creation.
+	Name       string    `json:"name"`        // User's display name.
+	Email      string    `json:"email"`       // User's email address (used for login, must be unique).
+	Password   password  `json:"-"`           // Custom type to handle password hashing and comparison.
+	Activated  bool      `json:"activated"`   // Flag indicating if the user account is active.
+	Theme      string    `json:"theme"`       // UI theme preference ("light", "dark" or "system").
+	TimeFormat string    `json:"time_format"` // Clock format preference ("12h" or "24h").
+	TimeZone   string    `json:"timezone"`    // IANA time zone name; "UTC" unless the user picked one.
+
+	// PreviewLength is how many characters of an entry's text dashboard cards show.
+	PreviewLength int `json:"preview_length"`
+
+	// Check-in reminder preference. Clients read it to schedule a local notification, and
+	// the server emails the reminder too if EmailReminders is on (see GetDueReminders).
+	ReminderTime      string `json:"reminder_time"`      // Local time of day as "HH:MM" (24-hour), or "" if not set.
+	ReminderEnabled   bool   `json:"reminder_enabled"`   // Whether the client should remind the user.
+	ReminderFrequency string `json:"reminder_frequency"` // "daily" or "weekly"; see ValidReminderFrequencies.
+	EmailReminders    bool   `json:"email_reminders"`    // Whether the server should email the reminder; off by default.
+
+	// OneEntryPerDay turns on journaling mode: a second entry on the same day opens
+	// the day's existing entry for editing instead of creating another.
+	OneEntryPerDay bool `json:"one_entry_per_day"`
+
+	// PendingEmail is a new address the user asked to switch to, or "". It only replaces
+	// Email, and so only becomes the login, once confirmed through the link sent to it.
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// usersEmailUniqueConstraint is the name Postgres gave the UNIQUE constraint on users.email.
+const usersEmailUniqueConstraint = "users_email_key"
+
+// isDuplicateEmailError reports whether err is a unique_violation (SQLSTATE 23505) on the
+// users.email constraint. It inspects the *pq.Error fields rather than the message text,
+// which varies between Postgres versions and server locales.
+func isDuplicateEmailError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == usersEmailUniqueConstraint
+}
+
+// ValidThemes lists the accepted values for a user's theme preference.
+// "system" follows the browser/OS preference and is the default for new accounts.
+var ValidThemes = []string{"light", "dark", "system"}
+
+// ValidateTheme checks that a theme preference is one of ValidThemes.
+func ValidateTheme(v *validator.Validator, theme string) {
+	v.Check(validator.PermittedValue(theme, ValidThemes...), "theme", "Theme must be light, dark or system")
+}
+
+// ValidTimeFormats lists the accepted values for a user's clock format preference.
+var ValidTimeFormats = []string{"12h", "24h"}
+
+// ValidateTimeFormat checks that a clock format preference is one of ValidTimeFormats.
+func ValidateTimeFormat(v *validator.Validator, format string) {
+	v.Check(validator.PermittedValue(format, ValidTimeFormats...), "time_format", "Time format must be 12h or 24h")
+}
+
+// DefaultTimeZone is the zone used for users who haven't chosen one.
+const DefaultTimeZone = "UTC"
+
+// ValidateTimeZone checks that a time zone preference is an IANA name the server knows.
+// time.LoadLocation treats "" and "Local" specially, so both are rejected explicitly.
+func ValidateTimeZone(v *validator.Validator, timeZone string) {
+	_, err := time.LoadLocation(timeZone)
+	v.Check(err == nil && timeZone != "" && timeZone != "Local", "timezone", "Choose a valid time zone")
+}
+
+// Bounds and default for User.PreviewLength. The default is the dashboard's original
+// fixed preview length.
+const (
+	DefaultPreviewLength = 35
+	MinPreviewLength     = 20
+	MaxPreviewLength     = 200
+)
+
+// ValidatePreviewLength checks that a preview length preference is within range.
+func ValidatePreviewLength(v *validator.Validator, length int) {
+	v.Check(length >= MinPreviewLength && length <= MaxPreviewLength, "preview_length", "Preview length must be between 20 and 200 characters")
+}
+
+// ReminderTimeLayout is the "HH:MM" format used for User.ReminderTime,
+// matching what an HTML <input type="time"> submits.
+const ReminderTimeLayout = "15:04"
+
+// ValidateReminder checks a check-in reminder preference. A time is only required
+// when the reminder is enabled, but any time that is given must be a valid "HH:MM".
+func ValidateReminder(v *validator.Validator, enabled bool, reminderTime string) {
+	if enabled {
+		v.Check(reminderTime != "", "reminder_time", "Choose a time for your reminder")
+	}
+	if reminderTime != "" {
+		_, err := time.Parse(ReminderTimeLayout, reminderTime)
+		v.Check(err == nil && len(reminderTime) == len(ReminderTimeLayout), "reminder_time", "Reminder time must be in HH:MM format")
+	}
+}
+
+// ValidReminderFrequencies lists the accepted values for User.ReminderFrequency.
+// A daily reminder goes out on days without an entry; a weekly one after a week without any.
+var ValidReminderFrequencies = []string{"daily", "weekly"}
+
+// ValidateReminderFrequency checks that a reminder frequency is one of ValidReminderFrequencies.
+func ValidateReminderFrequency(v *validator.Validator, frequency string) {
+	v.Check(validator.PermittedValue(frequency, ValidReminderFrequencies...), "reminder_frequency", "Reminder frequency must be daily or weekly")
 }
 
 // password is a custom struct to manage user passwords securely.
@@ -41,12 +140,33 @@ type password struct {
 	hash      []byte  // Bcrypt hash of the password.
 }
 
+// DefaultBcryptCost is the bcrypt cost used for new hashes unless SetBcryptCost changes it.
+const DefaultBcryptCost = 12
+
+// bcryptCost is the cost factor for new password hashes. Existing hashes keep working
+// whatever it's set to, because bcrypt records the cost inside each hash.
+var bcryptCost = DefaultBcryptCost
+
+// SetBcryptCost sets the cost factor for new password hashes. It's meant to be called
+// once at startup and rejects costs outside bcrypt's supported range.
+func SetBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	bcryptCost = cost
+	return nil
+}
+
+// HashPassword returns a bcrypt hash of plaintextPassword at the configured cost.
+func HashPassword(plaintextPassword string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(plaintextPassword), bcryptCost)
+}
+
 // Set generates a bcrypt hash for a given plaintext password and stores it.
-// The cost factor (12) determines hashing strength.
+// The cost factor (see SetBcryptCost) determines hashing strength.
 // The Set method securely hashes passwords using bcrypt before they are stored.
 func (p *password) Set(plaintextPassword string) error {
-	// Generate bcrypt hash with a cost factor of 12.
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	hash, err := HashPassword(plaintextPassword)
 	if err != nil {
 		return err
 	}
@@ -94,19 +214,42 @@ func ValidateUser(v *validator.Validator, user *User) {
 		v.Check(validator.NotBlank(*user.Password.plaintext), "password", "Password must be provided")
 		v.Check(validator.MinLength(*user.Password.plaintext, 8), "password", "Must be at least 8 characters long")
 		v.Check(validator.MaxLength(*user.Password.plaintext, 72), "password", "Must not be more than 72 characters")
+		ValidateNewPassword(v, "password", *user.Password.plaintext)
 	} else if user.ID == 0 && len(user.Password.hash) == 0 {
 		// Special case: For a new user (ID=0) where password was never set (hash is empty).
 		v.AddError("password", "Password must be provided")
 	}
 }
 
+// RejectCommonPasswords turns the common-password check on or off for new passwords.
+// It is set once at startup from the -reject-common-passwords flag.
+var RejectCommonPasswords = true
+
+// commonPasswordMessage is shown when a new password is on the common-passwords list.
+const commonPasswordMessage = "This password is too common and easy to guess. Please choose a different one"
+
+// ValidateNewPassword checks that a new password isn't one of the bundled common
+// passwords, recording any error under key. It does nothing if RejectCommonPasswords is off.
+func ValidateNewPassword(v *validator.Validator, key, password string) {
+	if RejectCommonPasswords {
+		v.Check(!validator.IsCommonPassword(password), key, commonPasswordMessage)
+	}
+}
+
 // ValidatePasswordUpdate checks fields specific to a password change operation.
 // Specific validation rules for the password change form.
 func ValidatePasswordUpdate(v *validator.Validator, currentPassword, newPassword, confirmPassword string) {
 	v.Check(validator.NotBlank(currentPassword), "current_password", "Current password must be provided")
+	ValidatePasswordReset(v, newPassword, confirmPassword)
+}
+
+// ValidatePasswordReset checks the new and confirmation fields shared by the password
+// change form and the emailed reset form, which has no current password.
+func ValidatePasswordReset(v *validator.Validator, newPassword, confirmPassword string) {
 	v.Check(validator.NotBlank(newPassword), "new_password", "New password must be provided")
 	v.Check(validator.MinLength(newPassword, 8), "new_password", "New password must be at least 8 characters long")
 	v.Check(validator.MaxLength(newPassword, 72), "new_password", "New password must not be more than 72 characters long")
+	ValidateNewPassword(v, "new_password", newPassword)
 	v.Check(validator.NotBlank(confirmPassword), "confirm_password", "Confirm new password must be provided")
 	v.Check(newPassword == confirmPassword, "confirm_password", "New passwords do not match")
 }
@@ -114,6 +257,7 @@ func ValidatePasswordUpdate(v *validator.Validator, currentPassword, newPassword
 // UserModel provides methods for database operations related to users.
 // It embeds a `*sql.DB` connection pool.
 // The UserModel encapsulates all database interaction logic for users (CRUD, authentication).
+// Like MoodModel, every method takes the caller's context for cancellation.
 type UserModel struct {
 	DB *sql.DB
 }
@@ -123,12 +267,12 @@ type UserModel struct {
 
 // Insert adds a new user record to the 'users' table.
 // Creates a new user in the database after signup.
-func (m *UserModel) Insert(user *User) error {
+func (m *UserModel) Insert(ctx context.Context, user *User) error {
 	// SQL query to insert user data and return DB-generated ID and CreatedAt.
 	query := `
         INSERT INTO users (name, email, password_hash, activated)
         VALUES ($1, $2, $3, $4)
-        RETURNING id, created_at`
+        RETURNING id, created_at, theme, time_format`
 
 	args := []any{
 		user.Name,
@@ -138,14 +282,14 @@ func (m *UserModel) Insert(user *User) error {
 	}
 
 	// Execute query with a timeout context.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	// Scan the returned ID and CreatedAt back into the user struct.
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Theme, &user.TimeFormat)
 	if err != nil {
 		// Handle PostgreSQL unique constraint violation for email.
-		if strings.Contains(err.Error(), `duplicate key value violates unique constraint "users_email_key"`) {
+		if isDuplicateEmailError(err) {
 			return ErrDuplicateEmail
 		}
 		return err
@@ -155,18 +299,20 @@ func (m *UserModel) Insert(user *User) error {
 
 // Get retrieves a user by their unique ID.
 // Fetches a user's details from the database by their ID.
-func (m *UserModel) Get(id int64) (*User, error) {
+func (m *UserModel) Get(ctx context.Context, id int64) (*User, error) {
 	if id < 1 { // Basic validation for ID.
 		return nil, ErrRecordNotFound
 	}
 	// SQL query to select user data by ID.
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated
+        SELECT id, created_at, name, email, password_hash, activated, theme, time_format, timezone, preview_length,
+               COALESCE(TO_CHAR(reminder_time, 'HH24:MI'), ''), reminder_enabled, reminder_frequency, email_reminders, one_entry_per_day,
+               COALESCE(pending_email, '')
         FROM users
         WHERE id = $1`
 
 	var user User // Struct to hold the fetched data.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	// Execute query and scan results into the user struct.
@@ -177,6 +323,16 @@ func (m *UserModel) Get(id int64) (*User, error) {
 		&user.Email,
 		&user.Password.hash,
 		&user.Activated,
+		&user.Theme,
+		&user.TimeFormat,
+		&user.TimeZone,
+		&user.PreviewLength,
+		&user.ReminderTime,
+		&user.ReminderEnabled,
+		&user.ReminderFrequency,
+		&user.EmailReminders,
+		&user.OneEntryPerDay,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) { //User not found
@@ -190,14 +346,16 @@ func (m *UserModel) Get(id int64) (*User, error) {
 // GetByEmail retrieves a user by their email address.
 // Useful for checking if an email already exists or for login.
 // Fetches user details by email, often used during login or signup checks.
-func (m *UserModel) GetByEmail(email string) (*User, error) {
+func (m *UserModel) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated
+        SELECT id, created_at, name, email, password_hash, activated, theme, time_format, timezone, preview_length,
+               COALESCE(TO_CHAR(reminder_time, 'HH24:MI'), ''), reminder_enabled, reminder_frequency, email_reminders, one_entry_per_day,
+               COALESCE(pending_email, '')
         FROM users
         WHERE email = $1` // Query by email.
 
 	var user User
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, query, email).Scan(
@@ -207,6 +365,16 @@ func (m *UserModel) GetByEmail(email string) (*User, error) {
 		&user.Email,
 		&user.Password.hash,
 		&user.Activated,
+		&user.Theme,
+		&user.TimeFormat,
+		&user.TimeZone,
+		&user.PreviewLength,
+		&user.ReminderTime,
+		&user.ReminderEnabled,
+		&user.ReminderFrequency,
+		&user.EmailReminders,
+		&user.OneEntryPerDay,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -219,7 +387,7 @@ func (m *UserModel) GetByEmail(email string) (*User, error) {
 
 // Update modifies a user's profile information (name, email).
 // Updates user's name and email in the database.
-func (m *UserModel) Update(user *User) error {
+func (m *UserModel) Update(ctx context.Context, user *User) error {
 	// SQL query to update name and email for a given user ID.
 	query := `
         UPDATE users
@@ -233,13 +401,13 @@ func (m *UserModel) Update(user *User) error {
 		user.ID,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID) // Scan is used with RETURNING.
 	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), `duplicate key value violates unique constraint "users_email_key"`):
+		case isDuplicateEmailError(err):
 			return ErrDuplicateEmail // Email conflict.
 		case errors.Is(err, sql.ErrNoRows): // Should not happen if ID exists, but defensive.
 			return ErrRecordNotFound
@@ -250,15 +418,73 @@ func (m *UserModel) Update(user *User) error {
 	return nil // Success.
 }
 
+// SetPendingEmail records email as the address the user wants to switch to, replacing
+// any earlier pending one; the login email is unchanged until ConfirmEmailChange. It
+// returns ErrDuplicateEmail if another account already uses the address.
+func (m *UserModel) SetPendingEmail(ctx context.Context, userID int64, email string) error {
+	// Checked up front too, so the user hears about a taken address now rather than
+	// only when the confirmation link fails.
+	query := `
+        UPDATE users
+        SET pending_email = $1
+        WHERE id = $2 AND NOT EXISTS (SELECT 1 FROM users WHERE email = $1 AND id <> $2)
+        RETURNING id`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var id int64
+	err := m.DB.QueryRowContext(ctx, query, email, userID).Scan(&id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("set pending email: %w", err)
+		}
+		// No row means either an unknown user or a taken address; tell them apart.
+		if _, err := m.Get(ctx, userID); err != nil {
+			return err
+		}
+		return ErrDuplicateEmail
+	}
+	return nil
+}
+
+// ConfirmEmailChange moves the user's pending email into email, making it their login,
+// and returns the new address. It returns ErrRecordNotFound if the user has no pending
+// email, and ErrDuplicateEmail if another account took the address in the meantime.
+func (m *UserModel) ConfirmEmailChange(ctx context.Context, userID int64) (string, error) {
+	query := `
+        UPDATE users
+        SET email = pending_email, pending_email = NULL
+        WHERE id = $1 AND pending_email IS NOT NULL
+        RETURNING email`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var email string
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&email)
+	if err != nil {
+		switch {
+		case isDuplicateEmailError(err):
+			return "", ErrDuplicateEmail
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrRecordNotFound
+		default:
+			return "", fmt.Errorf("confirm email change: %w", err)
+		}
+	}
+	return email, nil
+}
+
 // UpdatePassword changes a user's password_hash in the database.
 // Specifically updates the user's hashed password.
-func (m *UserModel) UpdatePassword(userID int64, newPasswordHash []byte) error {
+func (m *UserModel) UpdatePassword(ctx context.Context, userID int64, newPasswordHash []byte) error {
 	query := `
 		UPDATE users
 		SET password_hash = $1
 		WHERE id = $2`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	// ExecContext is used for UPDATEs that don't return rows (unless RETURNING is used differently).
@@ -278,11 +504,219 @@ func (m *UserModel) UpdatePassword(userID int64, newPasswordHash []byte) error {
 	return nil // Success.
 }
 
+// Activate marks a user's email as confirmed, which allows them to log in.
+// Activating an already active account is not an error.
+func (m *UserModel) Activate(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE users
+		SET activated = TRUE
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateTheme persists a user's UI theme preference.
+// The value should already have been checked with ValidateTheme.
+func (m *UserModel) UpdateTheme(ctx context.Context, userID int64, theme string) error {
+	query := `
+		UPDATE users
+		SET theme = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, theme, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateTimeFormat persists a user's clock format preference.
+// The value should already have been checked with ValidateTimeFormat.
+func (m *UserModel) UpdateTimeFormat(ctx context.Context, userID int64, format string) error {
+	query := `
+		UPDATE users
+		SET time_format = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, format, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateReminder persists a user's check-in reminder preference.
+// The values should already have been checked with ValidateReminder and
+// ValidateReminderFrequency; an empty reminderTime clears the stored time.
+func (m *UserModel) UpdateReminder(ctx context.Context, userID int64, enabled bool, reminderTime, frequency string) error {
+	query := `
+		UPDATE users
+		SET reminder_enabled = $1, reminder_time = NULLIF($2, '')::time, reminder_frequency = $3
+		WHERE id = $4`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, enabled, reminderTime, frequency, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateTimeZone stores a user's time zone. It should already have been checked with
+// ValidateTimeZone.
+func (m *UserModel) UpdateTimeZone(ctx context.Context, userID int64, timeZone string) error {
+	query := `
+		UPDATE users
+		SET timezone = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, timeZone, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateEmailReminders turns check-in reminder emails on or off. The time and frequency
+// come from the reminder preference saved by UpdateReminder.
+func (m *UserModel) UpdateEmailReminders(ctx context.Context, userID int64, enabled bool) error {
+	query := `
+		UPDATE users
+		SET email_reminders = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, enabled, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdateOneEntryPerDay turns the one-entry-per-day journaling mode on or off.
+func (m *UserModel) UpdateOneEntryPerDay(ctx context.Context, userID int64, enabled bool) error {
+	query := `
+		UPDATE users
+		SET one_entry_per_day = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, enabled, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
+// UpdatePreviewLength stores how many characters dashboard cards preview. It should
+// already have been checked with ValidatePreviewLength.
+func (m *UserModel) UpdatePreviewLength(ctx context.Context, userID int64, length int) error {
+	query := `
+		UPDATE users
+		SET preview_length = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, length, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 { // No user found with that ID.
+		return ErrRecordNotFound
+	}
+	return nil // Success.
+}
+
 // Authenticate verifies a user's email and password against the database.
 // It also checks if the user account is activated.
 // Returns the user's ID on success, or an error.
 // Core login logic: verifies email, compares password hash, and checks if account is active.
-func (m *UserModel) Authenticate(email, plaintextPassword string) (int64, error) {
+func (m *UserModel) Authenticate(ctx context.Context, email, plaintextPassword string) (int64, error) {
 	var id int64
 	var hashedPassword []byte
 	// SQL query to get ID and hashed password for an active user with the given email.
@@ -290,7 +724,7 @@ func (m *UserModel) Authenticate(email, plaintextPassword string) (int64, error)
         SELECT id, password_hash FROM users
         WHERE email = $1 AND activated = TRUE`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	// Fetch user's ID and stored hash.
@@ -313,15 +747,90 @@ func (m *UserModel) Authenticate(email, plaintextPassword string) (int64, error)
 	return id, nil // Authentication successful, return user ID.
 }
 
+// AuthenticateUser works like Authenticate but returns the whole user record, so callers
+// that need the name or preferences after login don't have to query again with Get.
+// The password hash is cleared from the returned user. A correct password for an account
+// that hasn't been activated returns ErrNotActivated, so the login page can say why;
+// the activation state is only revealed to someone who already knows the password.
+func (m *UserModel) AuthenticateUser(ctx context.Context, email, plaintextPassword string) (*User, error) {
+	query := `
+        SELECT id, created_at, name, email, password_hash, activated, theme, time_format, timezone, preview_length,
+               COALESCE(TO_CHAR(reminder_time, 'HH24:MI'), ''), reminder_enabled, reminder_frequency, email_reminders, one_entry_per_day,
+               COALESCE(pending_email, '')
+        FROM users
+        WHERE email = $1`
+
+	var user User
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// Fetch the user with the given email.
+	err := m.DB.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Theme,
+		&user.TimeFormat,
+		&user.TimeZone,
+		&user.PreviewLength,
+		&user.ReminderTime,
+		&user.ReminderEnabled,
+		&user.ReminderFrequency,
+		&user.EmailReminders,
+		&user.OneEntryPerDay,
+		&user.PendingEmail,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) { // User not found.
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Compare submitted password with the stored hash.
+	match, err := user.Password.Matches(plaintextPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.Activated {
+		return nil, ErrNotActivated
+	}
+
+	user.Password = password{} // Never hand the hash back to callers.
+	return &user, nil
+}
+
+// Count returns the total number of registered users.
+// Used for the aggregate numbers on the public About page.
+func (m *UserModel) Count(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM users`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var total int
+	err := m.DB.QueryRowContext(ctx, query).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // Delete removes a user and their associated data (via database cascades) by ID.
 // Permanently deletes a user account from the database.
-func (m *UserModel) Delete(id int64) error {
+func (m *UserModel) Delete(ctx context.Context, id int64) error {
 	if id < 1 { // Basic ID validation.
 		return ErrRecordNotFound
 	}
 	query := `DELETE FROM users WHERE id = $1` // SQL to delete user by ID.
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, id)
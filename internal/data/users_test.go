@@ -0,0 +1,433 @@
+// internal/data/users_test.go
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/mickali02/mood/internal/validator"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserModel_AuthenticateUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db) // Password is "password".
+	model := UserModel{DB: db}
+
+	stored, err := model.Get(context.Background(), testUserID)
+	if err != nil {
+		t.Fatalf("Setup Get failed: %v", err)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		user, err := model.AuthenticateUser(context.Background(), stored.Email, "password")
+		if err != nil {
+			t.Fatalf("AuthenticateUser failed: %v", err)
+		}
+		if user.ID != testUserID || user.Name != stored.Name || user.Email != stored.Email || user.Theme != stored.Theme {
+			t.Errorf("Expected populated user %+v, got %+v", stored, user)
+		}
+		if len(user.Password.hash) != 0 {
+			t.Error("Expected the password hash to be cleared")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		_, err := model.AuthenticateUser(context.Background(), stored.Email, "not-the-password")
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		_, err := model.AuthenticateUser(context.Background(), "nobody@example.com", "password")
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	t.Run("NotActivated", func(t *testing.T) {
+		inactive := &User{Name: "Inactive", Email: "inactive@example.com"}
+		if err := inactive.Password.Set("password"); err != nil {
+			t.Fatal(err)
+		}
+		if err := model.Insert(context.Background(), inactive); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+
+		// A wrong password must not reveal that the account exists but is inactive.
+		if _, err := model.AuthenticateUser(context.Background(), inactive.Email, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+		}
+		if _, err := model.AuthenticateUser(context.Background(), inactive.Email, "password"); !errors.Is(err, ErrNotActivated) {
+			t.Errorf("Expected ErrNotActivated, got %v", err)
+		}
+
+		if err := model.Activate(context.Background(), inactive.ID); err != nil {
+			t.Fatalf("Activate failed: %v", err)
+		}
+		if _, err := model.AuthenticateUser(context.Background(), inactive.Email, "password"); err != nil {
+			t.Errorf("Expected login to succeed after activation, got %v", err)
+		}
+	})
+
+	t.Run("ActivateUnknownUser", func(t *testing.T) {
+		if err := model.Activate(context.Background(), 999999); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound, got %v", err)
+		}
+	})
+}
+
+func TestUserModel_Count(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	model := UserModel{DB: db}
+
+	before, err := model.Count(context.Background())
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	insertTestUser(t, db)
+	insertTestUser(t, db)
+
+	after, err := model.Count(context.Background())
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if after != before+2 {
+		t.Errorf("Expected count %d, got %d", before+2, after)
+	}
+}
+
+func TestValidateReminder(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		time      string
+		wantValid bool
+	}{
+		{name: "DisabledNoTime", enabled: false, time: "", wantValid: true},
+		{name: "EnabledWithTime", enabled: true, time: "20:30", wantValid: true},
+		{name: "DisabledKeepsTime", enabled: false, time: "07:00", wantValid: true},
+		{name: "Midnight", enabled: true, time: "00:00", wantValid: true},
+		{name: "EnabledNoTime", enabled: true, time: "", wantValid: false},
+		{name: "HourOutOfRange", enabled: true, time: "24:00", wantValid: false},
+		{name: "MinuteOutOfRange", enabled: true, time: "12:60", wantValid: false},
+		{name: "SingleDigitHour", enabled: true, time: "9:05", wantValid: false},
+		{name: "WithSeconds", enabled: true, time: "09:05:00", wantValid: false},
+		{name: "TwelveHour", enabled: true, time: "9:05 PM", wantValid: false},
+		{name: "InvalidWhenDisabled", enabled: false, time: "soon", wantValid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.NewValidator()
+			ValidateReminder(v, tt.enabled, tt.time)
+			if v.ValidData() != tt.wantValid {
+				t.Errorf("ValidateReminder(%v, %q): valid = %v, want %v (errors: %v)", tt.enabled, tt.time, v.ValidData(), tt.wantValid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateReminderFrequency(t *testing.T) {
+	for _, frequency := range []string{"daily", "weekly"} {
+		v := validator.NewValidator()
+		ValidateReminderFrequency(v, frequency)
+		if !v.ValidData() {
+			t.Errorf("Expected %q to be valid, got %v", frequency, v.Errors)
+		}
+	}
+	for _, frequency := range []string{"", "hourly", "Daily"} {
+		v := validator.NewValidator()
+		ValidateReminderFrequency(v, frequency)
+		if v.ValidData() {
+			t.Errorf("Expected %q to be rejected", frequency)
+		}
+	}
+}
+
+func TestUserModel_UpdateReminder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	testUserID := insertTestUser(t, db)
+	model := UserModel{DB: db}
+
+	user, err := model.Get(context.Background(), testUserID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if user.ReminderEnabled || user.ReminderTime != "" || user.ReminderFrequency != "daily" {
+		t.Errorf("Expected no daily reminder by default, got enabled=%v time=%q frequency=%q", user.ReminderEnabled, user.ReminderTime, user.ReminderFrequency)
+	}
+
+	if err := model.UpdateReminder(context.Background(), testUserID, true, "20:30", "weekly"); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+	user, err = model.Get(context.Background(), testUserID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !user.ReminderEnabled || user.ReminderTime != "20:30" || user.ReminderFrequency != "weekly" {
+		t.Errorf("Expected enabled weekly reminder at 20:30, got enabled=%v time=%q frequency=%q", user.ReminderEnabled, user.ReminderTime, user.ReminderFrequency)
+	}
+
+	if err := model.UpdateReminder(context.Background(), testUserID, false, "", "daily"); err != nil {
+		t.Fatalf("UpdateReminder (clear) failed: %v", err)
+	}
+	user, _ = model.Get(context.Background(), testUserID)
+	if user.ReminderEnabled || user.ReminderTime != "" {
+		t.Errorf("Expected cleared reminder, got enabled=%v time=%q", user.ReminderEnabled, user.ReminderTime)
+	}
+
+	if err := model.UpdateReminder(context.Background(), 999999, true, "08:00", "daily"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for unknown user, got %v", err)
+	}
+}
+
+func TestValidateTimeZone(t *testing.T) {
+	for _, tz := range []string{"UTC", "America/Belize", "Asia/Singapore"} {
+		v := validator.NewValidator()
+		ValidateTimeZone(v, tz)
+		if !v.ValidData() {
+			t.Errorf("Expected %q to be valid, got errors %v", tz, v.Errors)
+		}
+	}
+	for _, tz := range []string{"", "Local", "Mars/Olympus_Mons", "../etc/passwd"} {
+		v := validator.NewValidator()
+		ValidateTimeZone(v, tz)
+		if _, ok := v.Errors["timezone"]; !ok {
+			t.Errorf("Expected %q to be rejected", tz)
+		}
+	}
+}
+
+func TestIsDuplicateEmailError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "UniqueViolation", err: &pq.Error{Code: "23505", Constraint: "users_email_key", Message: "doppelter Schlüsselwert verletzt Unique-Constraint"}, want: true},
+		{name: "Wrapped", err: fmt.Errorf("insert: %w", &pq.Error{Code: "23505", Constraint: "users_email_key"}), want: true},
+		{name: "OtherConstraint", err: &pq.Error{Code: "23505", Constraint: "users_pkey"}, want: false},
+		{name: "OtherCode", err: &pq.Error{Code: "23503", Constraint: "users_email_key"}, want: false},
+		{name: "MessageOnly", err: errors.New(`duplicate key value violates unique constraint "users_email_key"`), want: false},
+		{name: "Nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateEmailError(tt.err); got != tt.want {
+				t.Errorf("isDuplicateEmailError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserModel_Insert_DuplicateEmail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	model := UserModel{DB: db}
+
+	first := &User{Name: "First", Email: "dup@example.com", Activated: true}
+	if err := first.Password.Set("password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.Insert(context.Background(), first); err != nil {
+		t.Fatalf("First insert failed: %v", err)
+	}
+
+	// email is CITEXT, so a case variant is still a duplicate.
+	second := &User{Name: "Second", Email: "DUP@example.com", Activated: true}
+	if err := second.Password.Set("password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.Insert(context.Background(), second); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Expected ErrDuplicateEmail on insert, got %v", err)
+	}
+
+	// Update onto another user's email is detected the same way.
+	other := &User{Name: "Other", Email: "other@example.com", Activated: true}
+	if err := other.Password.Set("password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.Insert(context.Background(), other); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	other.Email = first.Email
+	if err := model.Update(context.Background(), other); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Expected ErrDuplicateEmail on update, got %v", err)
+	}
+}
+
+func TestValidateNewPassword(t *testing.T) {
+	defer func(prev bool) { RejectCommonPasswords = prev }(RejectCommonPasswords)
+	RejectCommonPasswords = true
+
+	// Signup: ValidateUser rejects a common password with a helpful message.
+	user := &User{Name: "Test", Email: "test@example.com"}
+	user.Password.Set("password123")
+	v := validator.NewValidator()
+	ValidateUser(v, user)
+	if got := v.Errors["password"]; got != commonPasswordMessage {
+		t.Errorf("Expected the common-password message at signup, got %q", got)
+	}
+
+	// Password change: the same check applies to new_password.
+	v = validator.NewValidator()
+	ValidatePasswordUpdate(v, "old-secret", "welcome123", "welcome123")
+	if got := v.Errors["new_password"]; got != commonPasswordMessage {
+		t.Errorf("Expected the common-password message on change, got %q", got)
+	}
+
+	// A strong password passes.
+	v = validator.NewValidator()
+	ValidatePasswordUpdate(v, "old-secret", "Tq8#vLw2!mZr", "Tq8#vLw2!mZr")
+	if !v.ValidData() {
+		t.Errorf("Expected a strong password to pass, got %v", v.Errors)
+	}
+
+	// With the check disabled, common passwords are allowed again.
+	RejectCommonPasswords = false
+	v = validator.NewValidator()
+	ValidateNewPassword(v, "password", "password123")
+	if !v.ValidData() {
+		t.Errorf("Expected no error with the check disabled, got %v", v.Errors)
+	}
+}
+
+func TestSetBcryptCost(t *testing.T) {
+	defer func(prev int) { bcryptCost = prev }(bcryptCost)
+
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if err := SetBcryptCost(cost); err == nil {
+			t.Errorf("SetBcryptCost(%d) = nil, want an error", cost)
+		}
+	}
+	if bcryptCost != DefaultBcryptCost {
+		t.Fatalf("bcryptCost = %d after rejected costs, want %d", bcryptCost, DefaultBcryptCost)
+	}
+
+	// A hash made at one cost must still match after the cost changes.
+	if err := SetBcryptCost(bcrypt.MinCost); err != nil {
+		t.Fatal(err)
+	}
+	var p password
+	if err := p.Set("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	if cost, _ := bcrypt.Cost(p.Hash()); cost != bcrypt.MinCost {
+		t.Errorf("hash cost = %d, want %d", cost, bcrypt.MinCost)
+	}
+	if err := SetBcryptCost(bcrypt.MinCost + 1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := p.Matches("correct horse battery"); err != nil || !ok {
+		t.Errorf("Matches after cost change = %v, %v; want true, nil", ok, err)
+	}
+	if ok, _ := p.Matches("wrong"); ok {
+		t.Error("Matches(wrong password) = true, want false")
+	}
+}
+
+// BenchmarkPasswordSet times hashing at a range of costs, to help pick -bcrypt-cost.
+// Aim for roughly 250ms per op on the production machine:
+//
+//	go test -run=^$ -bench=PasswordSet ./internal/data
+func BenchmarkPasswordSet(b *testing.B) {
+	defer func(prev int) { bcryptCost = prev }(bcryptCost)
+
+	for cost := 10; cost <= 14; cost++ {
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			if err := SetBcryptCost(cost); err != nil {
+				b.Fatal(err)
+			}
+			var p password
+			for i := 0; i < b.N; i++ {
+				if err := p.Set("correct horse battery"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestUserModel_EmailChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test in short mode")
+	}
+	db := newTestDB(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+	model := UserModel{DB: db}
+	ctx := context.Background()
+	userID := insertTestUser(t, db)
+	otherID := insertTestUser(t, db)
+	user, _ := model.Get(ctx, userID)
+	other, _ := model.Get(ctx, otherID)
+
+	// Nothing to confirm yet.
+	if _, err := model.ConfirmEmailChange(ctx, userID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound with no pending email, got %v", err)
+	}
+	// Another account's address (in any case) can't be requested.
+	if err := model.SetPendingEmail(ctx, userID, strings.ToUpper(other.Email)); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Expected ErrDuplicateEmail, got %v", err)
+	}
+	if err := model.SetPendingEmail(ctx, 999999, "nobody@example.com"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for unknown user, got %v", err)
+	}
+
+	// A pending email doesn't change the login until it's confirmed.
+	if err := model.SetPendingEmail(ctx, userID, "changed@example.com"); err != nil {
+		t.Fatalf("SetPendingEmail failed: %v", err)
+	}
+	pending, _ := model.Get(ctx, userID)
+	if pending.Email != user.Email || pending.PendingEmail != "changed@example.com" {
+		t.Errorf("Expected email %q pending %q, got %q pending %q", user.Email, "changed@example.com", pending.Email, pending.PendingEmail)
+	}
+	if _, err := model.AuthenticateUser(ctx, "changed@example.com", "password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected the pending email not to log in, got %v", err)
+	}
+
+	newEmail, err := model.ConfirmEmailChange(ctx, userID)
+	if err != nil || newEmail != "changed@example.com" {
+		t.Fatalf("ConfirmEmailChange = %q, %v", newEmail, err)
+	}
+	confirmed, _ := model.Get(ctx, userID)
+	if confirmed.Email != "changed@example.com" || confirmed.PendingEmail != "" {
+		t.Errorf("Expected confirmed email with nothing pending, got %q pending %q", confirmed.Email, confirmed.PendingEmail)
+	}
+
+	// An address taken between the request and the confirmation isn't applied.
+	if err := model.SetPendingEmail(ctx, userID, "later@example.com"); err != nil {
+		t.Fatalf("SetPendingEmail failed: %v", err)
+	}
+	other.Email = "later@example.com"
+	if err := model.Update(ctx, other); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := model.ConfirmEmailChange(ctx, userID); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Expected ErrDuplicateEmail on confirm, got %v", err)
+	}
+}
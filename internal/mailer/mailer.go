@@ -0,0 +1,35 @@
+// mood/internal/mailer/mailer.go
+package mailer
+
+import (
+	"log/slog"
+)
+
+// Mailer sends a named email template to a single recipient. templateName is a file in
+// ui/html/mail without its .tmpl extension. The application depends on this interface
+// rather than a concrete transport: NewSMTPMailer delivers real email, and development
+// and tests can swap in NewLogMailer or a stub.
+type Mailer interface {
+	Send(recipient, templateName string, data any) error
+}
+
+// logMailer is a Mailer that writes each message to the logger instead of sending it.
+// Useful in development where no mail server is configured.
+type logMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer returns a Mailer that only logs the messages it is asked to send.
+func NewLogMailer(logger *slog.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+// Send logs the recipient, template and data rather than delivering an email.
+func (m *logMailer) Send(recipient, templateName string, data any) error {
+	m.logger.Info("email (not sent, log mailer)",
+		slog.String("recipient", recipient),
+		slog.String("template", templateName),
+		slog.Any("data", data),
+	)
+	return nil
+}
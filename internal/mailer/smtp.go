@@ -0,0 +1,129 @@
+// mood/internal/mailer/smtp.go
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig holds the -smtp-* settings for NewSMTPMailer.
+type SMTPConfig struct {
+	Host        string
+	Port        int
+	Username    string // Leave Username and Password blank for a relay that needs no login.
+	Password    string
+	Sender      string // From header, e.g. "Feel Flow <no-reply@feelflow.example>".
+	TemplateDir string // Directory of *.tmpl email templates, e.g. "./ui/html/mail".
+}
+
+// smtpMailer is a Mailer that renders a template from TemplateDir and delivers it
+// over SMTP as a multipart plain-text/HTML message.
+type smtpMailer struct {
+	addr       string
+	auth       smtp.Auth
+	sender     string // Full From header.
+	from       string // Bare envelope address taken from sender.
+	templates  *templateSet
+	retryDelay time.Duration // Pause between delivery attempts.
+
+	// send is smtp.SendMail, swappable for tests.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer returns a Mailer that sends through the given SMTP server. The templates
+// are parsed up front, so a broken one stops the app at startup rather than at first send.
+func NewSMTPMailer(cfg SMTPConfig) (Mailer, error) {
+	sender, err := mail.ParseAddress(cfg.Sender)
+	if err != nil {
+		return nil, fmt.Errorf("smtp sender %q: %w", cfg.Sender, err)
+	}
+	templates, err := loadTemplates(cfg.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &smtpMailer{
+		addr:       net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		sender:     sender.String(),
+		from:       sender.Address,
+		templates:  templates,
+		retryDelay: 500 * time.Millisecond,
+		send:       smtp.SendMail,
+	}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+// Send renders templateName with data and delivers it to recipient, trying up to
+// three times in case the server is briefly unavailable.
+func (m *smtpMailer) Send(recipient, templateName string, data any) error {
+	msg, err := m.templates.render(templateName, data)
+	if err != nil {
+		return err
+	}
+	raw, err := m.build(recipient, msg)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		err = m.send(m.addr, m.auth, m.from, []string{recipient}, raw)
+		if err == nil {
+			return nil
+		}
+		if attempt < 3 {
+			time.Sleep(m.retryDelay)
+		}
+	}
+	return fmt.Errorf("smtp send %s: %w", templateName, err)
+}
+
+// build assembles the headers and a multipart/alternative body with the plain-text
+// part first, so clients that can show HTML prefer it.
+func (m *smtpMailer) build(recipient string, msg message) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.PlainBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var raw bytes.Buffer
+	fmt.Fprintf(&raw, "From: %s\r\n", m.sender)
+	fmt.Fprintf(&raw, "To: %s\r\n", recipient)
+	fmt.Fprintf(&raw, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&raw, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	raw.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&raw, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	raw.Write(body.Bytes())
+	return raw.Bytes(), nil
+}
@@ -0,0 +1,152 @@
+// mood/internal/mailer/smtp_test.go
+package mailer
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleData has every field any email template uses.
+var sampleData = map[string]any{
+	"Name":          "Alice <3",
+	"ActivationURL": "https://feelflow.example/user/activate?token=abc",
+	"ResetURL":      "https://feelflow.example/user/reset-password?token=abc",
+	"ConfirmURL":    "https://feelflow.example/user/confirm-email?token=abc",
+	"NewEntryURL":   "https://feelflow.example/mood/new",
+	"SettingsURL":   "https://feelflow.example/user/profile",
+	"NewEmail":      "alice@new.example",
+	"ExpiresIn":     "1 hour",
+	"ChangedAt":     "Fri, 10 May 2024 12:00:00 UTC",
+	"Frequency":     "daily",
+}
+
+const templateDir = "../../ui/html/mail"
+
+func TestTemplatesRender(t *testing.T) {
+	set, err := loadTemplates(templateDir)
+	if err != nil {
+		t.Fatalf("loadTemplates failed: %v", err)
+	}
+	for _, name := range []string{"user_activation", "password_reset", "password_changed",
+		"email_change_confirmation", "email_changed", "check_in_reminder"} {
+		t.Run(name, func(t *testing.T) {
+			msg, err := set.render(name, sampleData)
+			if err != nil {
+				t.Fatalf("render failed: %v", err)
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("Expected a one-line subject, got %q", msg.Subject)
+			}
+			for _, body := range []string{msg.PlainBody, msg.HTMLBody} {
+				if strings.Contains(body, "<no value>") {
+					t.Errorf("Body uses a field the senders don't provide:\n%s", body)
+				}
+			}
+			if !strings.Contains(msg.PlainBody, "Alice <3") || !strings.Contains(msg.HTMLBody, "Alice &lt;3") {
+				t.Error("Expected the name raw in the text body and escaped in the HTML body")
+			}
+		})
+	}
+
+	if _, err := set.render("no_such_template", nil); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+}
+
+func TestLoadTemplates_MissingBlock(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{define "subject"}}Hi{{end}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTemplates(dir); err == nil {
+		t.Error("Expected an error for a template without plainBody and htmlBody")
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	m, err := NewSMTPMailer(SMTPConfig{
+		Host:        "smtp.example.com",
+		Port:        587,
+		Username:    "user",
+		Password:    "pass",
+		Sender:      "Feel Flow <no-reply@feelflow.example>",
+		TemplateDir: templateDir,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer failed: %v", err)
+	}
+	sm := m.(*smtpMailer)
+	sm.retryDelay = 0
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	sm.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	if err := m.Send("alice@example.com", "password_reset", sampleData); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotFrom != "no-reply@feelflow.example" || len(gotTo) != 1 || gotTo[0] != "alice@example.com" {
+		t.Errorf("Unexpected envelope: addr %q, from %q, to %v", gotAddr, gotFrom, gotTo)
+	}
+
+	// The message parses as multipart/alternative with a text and an HTML part.
+	parsed, err := mail.ReadMessage(strings.NewReader(string(gotMsg)))
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != "Reset your Feel Flow password" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Unexpected Content-Type %q (%v)", parsed.Header.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+		body, _ := io.ReadAll(part) // multipart decodes quoted-printable itself.
+		if !strings.Contains(string(body), sampleData["ResetURL"].(string)) {
+			t.Errorf("Expected the reset link in the %s part", part.Header.Get("Content-Type"))
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("Expected text then HTML parts, got %v", types)
+	}
+
+	// Delivery failures are retried, then returned.
+	calls := 0
+	sm.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		calls++
+		return errors.New("connection refused")
+	}
+	if err := m.Send("alice@example.com", "password_reset", sampleData); err == nil || calls != 3 {
+		t.Errorf("Expected an error after 3 attempts, got %v after %d", err, calls)
+	}
+}
+
+func TestNewSMTPMailer_InvalidSender(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: 587, Sender: "not an address", TemplateDir: templateDir}); err == nil {
+		t.Error("Expected an error for an invalid sender")
+	}
+}
@@ -0,0 +1,88 @@
+// mood/internal/mailer/templates.go
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// message is one rendered email, ready to be wrapped in MIME.
+type message struct {
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+}
+
+// templateSet holds the parsed email templates, keyed by name ("password_reset" for
+// password_reset.tmpl). Each file defines three blocks: "subject", "plainBody" and
+// "htmlBody". The first two are parsed as text, the last as HTML so data is escaped.
+type templateSet struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// loadTemplates parses every *.tmpl file in dir.
+func loadTemplates(dir string) (*templateSet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no email templates found in %s", dir)
+	}
+
+	set := &templateSet{
+		text: make(map[string]*texttemplate.Template, len(files)),
+		html: make(map[string]*htmltemplate.Template, len(files)),
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		text, err := texttemplate.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("email template %s: %w", name, err)
+		}
+		html, err := htmltemplate.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("email template %s: %w", name, err)
+		}
+		for _, block := range []string{"subject", "plainBody"} {
+			if text.Lookup(block) == nil {
+				return nil, fmt.Errorf("email template %s: missing %q block", name, block)
+			}
+		}
+		if html.Lookup("htmlBody") == nil {
+			return nil, fmt.Errorf("email template %s: missing \"htmlBody\" block", name)
+		}
+		set.text[name] = text
+		set.html[name] = html
+	}
+	return set, nil
+}
+
+// render executes the named template's three blocks with data.
+func (s *templateSet) render(name string, data any) (message, error) {
+	text, ok := s.text[name]
+	if !ok {
+		return message{}, fmt.Errorf("email template %q not found", name)
+	}
+
+	var subject, plain, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return message{}, fmt.Errorf("email template %s subject: %w", name, err)
+	}
+	if err := text.ExecuteTemplate(&plain, "plainBody", data); err != nil {
+		return message{}, fmt.Errorf("email template %s plain body: %w", name, err)
+	}
+	if err := s.html[name].ExecuteTemplate(&html, "htmlBody", data); err != nil {
+		return message{}, fmt.Errorf("email template %s HTML body: %w", name, err)
+	}
+	return message{
+		Subject:   strings.TrimSpace(subject.String()),
+		PlainBody: strings.TrimSpace(plain.String()) + "\r\n",
+		HTMLBody:  html.String(),
+	}, nil
+}
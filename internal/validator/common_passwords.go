@@ -0,0 +1,41 @@
+// internal/validator/common_passwords.go
+package validator
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// commonPasswordsFile is the bundled list of passwords too common to allow; its header
+// names the source list, whose license is in common_passwords.LICENSE.
+//
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var (
+	commonPasswordsOnce sync.Once
+	commonPasswords     map[string]struct{}
+)
+
+// loadCommonPasswords parses the embedded list into a lowercase set. Blank lines
+// and # comments are skipped.
+func loadCommonPasswords() {
+	commonPasswords = make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordsFile, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commonPasswords[strings.ToLower(line)] = struct{}{}
+	}
+}
+
+// IsCommonPassword returns true if pw appears in the bundled common-passwords list.
+// The comparison ignores case, so "Password1" matches "password1". The list is
+// parsed once, on first use.
+func IsCommonPassword(pw string) bool {
+	commonPasswordsOnce.Do(loadCommonPasswords)
+	_, found := commonPasswords[strings.ToLower(strings.TrimSpace(pw))]
+	return found
+}
@@ -0,0 +1,29 @@
+// internal/validator/common_passwords_test.go
+package validator
+
+import "testing"
+
+func TestIsCommonPassword(t *testing.T) {
+	for _, pw := range []string{"password", "Password1", "qwerty123", "iloveyou", " letmein "} {
+		if !IsCommonPassword(pw) {
+			t.Errorf("Expected %q to be reported as common", pw)
+		}
+	}
+	for _, pw := range []string{"n3wPa55word!", "correct horse battery staple", "Tq8#vLw2!mZr", ""} {
+		if IsCommonPassword(pw) {
+			t.Errorf("Expected %q not to be reported as common", pw)
+		}
+	}
+}
+
+func TestCommonPasswordsList(t *testing.T) {
+	commonPasswordsOnce.Do(loadCommonPasswords)
+	if len(commonPasswords) < 5000 {
+		t.Errorf("Expected the embedded list to load at least 5000 passwords, got %d", len(commonPasswords))
+	}
+	for pw := range commonPasswords {
+		if pw == "" || pw[0] == '#' {
+			t.Errorf("Expected blank lines and comments to be skipped, got %q", pw)
+		}
+	}
+}
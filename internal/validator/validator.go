@@ -19,7 +19,14 @@ var HexColorRX = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F
 // --- Validator Type ---
 
 type Validator struct {
-	Errors map[string]string
+	Errors     map[string]string
+	ErrorOrder []string // Field names in the order their errors were added.
+}
+
+// FieldError is a single field/message pair, as returned by OrderedErrors.
+type FieldError struct {
+	Field   string
+	Message string
 }
 
 // New creates a new Validator instance.
@@ -39,9 +46,20 @@ func (v *Validator) ValidData() bool {
 func (v *Validator) AddError(field string, message string) {
 	if _, exists := v.Errors[field]; !exists {
 		v.Errors[field] = message
+		v.ErrorOrder = append(v.ErrorOrder, field)
 	}
 }
 
+// OrderedErrors returns the errors in the order they were added, so templates
+// can list them consistently (ranging over the Errors map is randomly ordered).
+func (v *Validator) OrderedErrors() []FieldError {
+	ordered := make([]FieldError, 0, len(v.ErrorOrder))
+	for _, field := range v.ErrorOrder {
+		ordered = append(ordered, FieldError{Field: field, Message: v.Errors[field]})
+	}
+	return ordered
+}
+
 // Check adds an error message to the map only if a validation check is not 'ok'.
 func (v *Validator) Check(ok bool, field string, message string) {
 	if !ok {
@@ -86,4 +104,40 @@ func Matches(value string, rx *regexp.Regexp) bool {
 }
 
 // --- Specific Helper Functions ---
+
+// emojiRanges are the Unicode blocks that hold emoji and other pictographs. The list
+// is deliberately broad (whole blocks rather than the exact emoji set) so new emoji
+// and odd-but-valid sequences are still accepted.
+var emojiRanges = []struct{ lo, hi rune }{
+	{0x00A9, 0x00A9},   // © copyright
+	{0x00AE, 0x00AE},   // ® registered
+	{0x203C, 0x2049},   // ‼ ⁉
+	{0x20E3, 0x20E3},   // Combining enclosing keycap, as in 1️⃣
+	{0x2100, 0x214F},   // Letterlike symbols: ™ ℹ
+	{0x2190, 0x21FF},   // Arrows: ↔ ↩
+	{0x2300, 0x23FF},   // Miscellaneous technical: ⌚ ⏰
+	{0x24C2, 0x24C2},   // Ⓜ
+	{0x25A0, 0x25FF},   // Geometric shapes: ▶ ◀
+	{0x2600, 0x27BF},   // Miscellaneous symbols and dingbats: ☀ ❤ ✨
+	{0x2900, 0x297F},   // Supplemental arrows: ⤴ ⤵
+	{0x2B00, 0x2BFF},   // Miscellaneous symbols and arrows: ⭐ ⬆
+	{0x3030, 0x3030},   // 〰
+	{0x303D, 0x303D},   // 〽
+	{0x3297, 0x3299},   // ㊗ ㊙
+	{0x1F000, 0x1FAFF}, // Pictographs, emoticons, transport, flags and extensions
+}
+
+// ContainsEmoji returns true if value has at least one emoji or pictographic rune.
+// Text presentation emoji (e.g. "☺" without U+FE0F) count as well.
+func ContainsEmoji(value string) bool {
+	for _, r := range value {
+		for _, rng := range emojiRanges {
+			if r >= rng.lo && r <= rng.hi {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // (You might add IsValidEmail here later if desired, but using Matches directly is fine)
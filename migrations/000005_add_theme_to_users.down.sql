@@ -0,0 +1,6 @@
+-- File: migrations/000005_add_theme_to_users.down.sql
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_theme_check;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS theme;
@@ -0,0 +1,6 @@
+-- File: migrations/000005_add_theme_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN theme TEXT NOT NULL DEFAULT 'system'; -- UI theme preference: 'light', 'dark' or 'system'
+
+ALTER TABLE users
+ADD CONSTRAINT users_theme_check CHECK (theme IN ('light', 'dark', 'system'));
@@ -0,0 +1,3 @@
+-- File: migrations/000006_add_private_note_to_moods.down.sql
+ALTER TABLE moods
+DROP COLUMN IF EXISTS private_note;
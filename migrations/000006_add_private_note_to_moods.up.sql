@@ -0,0 +1,3 @@
+-- File: migrations/000006_add_private_note_to_moods.up.sql
+ALTER TABLE moods
+ADD COLUMN private_note TEXT NOT NULL DEFAULT ''; -- Owner-only note, never included in exports or shares
@@ -0,0 +1,6 @@
+-- File: migrations/000007_add_time_format_to_users.down.sql
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_time_format_check;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS time_format;
@@ -0,0 +1,6 @@
+-- File: migrations/000007_add_time_format_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN time_format TEXT NOT NULL DEFAULT '24h'; -- Clock format preference: '12h' or '24h'
+
+ALTER TABLE users
+ADD CONSTRAINT users_time_format_check CHECK (time_format IN ('12h', '24h'));
@@ -0,0 +1,6 @@
+-- File: migrations/000008_add_reminder_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS reminder_enabled;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS reminder_time;
@@ -0,0 +1,6 @@
+-- File: migrations/000008_add_reminder_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN reminder_time TIME; -- Time of day a client should remind the user to check in (NULL = not set)
+
+ALTER TABLE users
+ADD COLUMN reminder_enabled BOOLEAN NOT NULL DEFAULT FALSE;
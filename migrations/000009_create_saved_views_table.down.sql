@@ -0,0 +1,2 @@
+-- File: migrations/000009_create_saved_views_table.down.sql
+DROP TABLE IF EXISTS saved_views;
@@ -0,0 +1,9 @@
+-- File: migrations/000009_create_saved_views_table.up.sql
+CREATE TABLE IF NOT EXISTS saved_views (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}', -- Dashboard filter parameters only; never the user ID
+    CONSTRAINT saved_views_user_id_name_key UNIQUE (user_id, name)
+);
@@ -0,0 +1,3 @@
+-- File: migrations/000010_add_intensity_to_moods.down.sql
+ALTER TABLE moods
+DROP COLUMN IF EXISTS intensity;
@@ -0,0 +1,4 @@
+-- File: migrations/000010_add_intensity_to_moods.up.sql
+ALTER TABLE moods
+ADD COLUMN intensity SMALLINT NOT NULL DEFAULT 3 -- How strongly the emotion was felt, 1 (mild) to 5 (intense)
+    CONSTRAINT moods_intensity_check CHECK (intensity BETWEEN 1 AND 5);
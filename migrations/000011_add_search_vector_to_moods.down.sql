@@ -0,0 +1,5 @@
+-- File: migrations/000011_add_search_vector_to_moods.down.sql
+DROP INDEX IF EXISTS moods_search_vector_idx;
+
+ALTER TABLE moods
+DROP COLUMN IF EXISTS search_vector;
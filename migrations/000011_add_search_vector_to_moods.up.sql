@@ -0,0 +1,10 @@
+-- File: migrations/000011_add_search_vector_to_moods.up.sql
+-- Full-text search over title and content. The column is generated, so Postgres keeps
+-- it current on every INSERT and UPDATE. Title matches are weighted above content.
+ALTER TABLE moods
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(content, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS moods_search_vector_idx ON moods USING GIN (search_vector);
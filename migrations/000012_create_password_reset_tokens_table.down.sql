@@ -0,0 +1,2 @@
+-- File: migrations/000012_create_password_reset_tokens_table.down.sql
+DROP TABLE IF EXISTS password_reset_tokens;
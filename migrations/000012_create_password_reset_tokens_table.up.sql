@@ -0,0 +1,6 @@
+-- File: migrations/000012_create_password_reset_tokens_table.up.sql
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    hash BYTEA PRIMARY KEY, -- SHA-256 of the emailed token; the plaintext is never stored
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expiry TIMESTAMP(0) WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,2 @@
+-- File: migrations/000013_create_activation_tokens_table.down.sql
+DROP TABLE IF EXISTS activation_tokens;
@@ -0,0 +1,5 @@
+-- File: migrations/000014_add_deleted_at_to_moods.down.sql
+DROP INDEX IF EXISTS moods_deleted_at_idx;
+
+ALTER TABLE moods
+DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,8 @@
+-- File: migrations/000014_add_deleted_at_to_moods.up.sql
+-- Soft delete: a deleted entry keeps its row with deleted_at set, so it can be restored
+-- from the trash until it is purged. NULL means the entry is live.
+ALTER TABLE moods
+ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- The purge job and the trash page only ever look at deleted rows.
+CREATE INDEX IF NOT EXISTS moods_deleted_at_idx ON moods (deleted_at) WHERE deleted_at IS NOT NULL;
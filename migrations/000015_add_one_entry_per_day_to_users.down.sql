@@ -0,0 +1,3 @@
+-- File: migrations/000015_add_one_entry_per_day_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS one_entry_per_day;
@@ -0,0 +1,3 @@
+-- File: migrations/000015_add_one_entry_per_day_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN one_entry_per_day BOOLEAN NOT NULL DEFAULT FALSE; -- Journaling mode: at most one mood entry per calendar day
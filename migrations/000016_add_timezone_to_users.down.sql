@@ -0,0 +1,3 @@
+-- File: migrations/000016_add_timezone_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,3 @@
+-- File: migrations/000016_add_timezone_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC'; -- IANA zone name (e.g. "Asia/Singapore") stats and filters use for calendar days
@@ -0,0 +1,3 @@
+-- File: migrations/000017_add_preview_length_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS preview_length;
@@ -0,0 +1,3 @@
+-- File: migrations/000017_add_preview_length_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN preview_length INTEGER NOT NULL DEFAULT 35 CHECK (preview_length BETWEEN 20 AND 200); -- Characters of entry text shown on dashboard cards
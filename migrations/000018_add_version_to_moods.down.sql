@@ -0,0 +1,3 @@
+-- File: migrations/000018_add_version_to_moods.down.sql
+ALTER TABLE moods
+DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+-- File: migrations/000018_add_version_to_moods.up.sql
+ALTER TABLE moods
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1; -- Bumped on every update; edits carry the version they started from
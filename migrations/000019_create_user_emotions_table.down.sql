@@ -0,0 +1,2 @@
+-- File: migrations/000019_create_user_emotions_table.down.sql
+DROP TABLE IF EXISTS user_emotions;
@@ -0,0 +1,10 @@
+-- File: migrations/000019_create_user_emotions_table.up.sql
+CREATE TABLE IF NOT EXISTS user_emotions (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    emoji TEXT NOT NULL,
+    color TEXT NOT NULL,
+    CONSTRAINT user_emotions_user_id_name_key UNIQUE (user_id, name)
+);
@@ -0,0 +1,3 @@
+-- File: migrations/000020_add_pending_email_to_users.down.sql
+DROP TABLE IF EXISTS email_change_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
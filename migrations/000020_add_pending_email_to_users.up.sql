@@ -0,0 +1,8 @@
+-- File: migrations/000020_add_pending_email_to_users.up.sql
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email CITEXT; -- New address awaiting confirmation, or NULL
+
+CREATE TABLE IF NOT EXISTS email_change_tokens (
+    hash BYTEA PRIMARY KEY, -- SHA-256 of the emailed token; the plaintext is never stored
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expiry TIMESTAMP(0) WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,3 @@
+-- File: migrations/000021_add_pinned_to_moods.down.sql
+ALTER TABLE moods
+DROP COLUMN IF EXISTS pinned;
@@ -0,0 +1,3 @@
+-- File: migrations/000021_add_pinned_to_moods.up.sql
+ALTER TABLE moods
+ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE; -- Pinned entries sort to the top of the dashboard
@@ -0,0 +1,2 @@
+-- File: migrations/000022_create_mood_revisions_table.down.sql
+DROP TABLE IF EXISTS mood_revisions;
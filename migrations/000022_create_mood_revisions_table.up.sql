@@ -0,0 +1,17 @@
+-- File: migrations/000022_create_mood_revisions_table.up.sql
+CREATE TABLE IF NOT EXISTS mood_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    mood_id BIGINT NOT NULL REFERENCES moods(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,                        -- The mood's version this snapshot was of
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    emotion TEXT NOT NULL,
+    emoji TEXT NOT NULL,
+    color TEXT NOT NULL,
+    intensity SMALLINT NOT NULL,
+    saved_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,   -- When this version was written
+    replaced_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW() -- When an edit replaced it
+);
+
+CREATE INDEX IF NOT EXISTS mood_revisions_mood_id_idx ON mood_revisions (mood_id, version DESC);
@@ -0,0 +1,2 @@
+-- File: migrations/000023_add_user_emotion_index_to_moods.down.sql
+DROP INDEX IF EXISTS moods_user_emotion_idx;
@@ -0,0 +1,5 @@
+-- File: migrations/000023_add_user_emotion_index_to_moods.up.sql
+-- Covers the emotion dropdown (SELECT DISTINCT) and emotion counts (GROUP BY) queries, which
+-- only look at a user's live entries, so they can be answered from the index alone.
+CREATE INDEX IF NOT EXISTS moods_user_emotion_idx ON moods (user_id, emotion, emoji, color)
+WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- File: migrations/000024_add_reminder_frequency_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS reminder_sent_at;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS reminder_frequency;
@@ -0,0 +1,7 @@
+-- File: migrations/000024_add_reminder_frequency_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN reminder_frequency TEXT NOT NULL DEFAULT 'daily'
+    CHECK (reminder_frequency IN ('daily', 'weekly')); -- How often the check-in reminder email may go out
+
+ALTER TABLE users
+ADD COLUMN reminder_sent_at TIMESTAMPTZ; -- When the last reminder email was sent (NULL = never)
@@ -0,0 +1,3 @@
+-- File: migrations/000025_add_email_reminders_to_users.down.sql
+ALTER TABLE users
+DROP COLUMN IF EXISTS email_reminders;
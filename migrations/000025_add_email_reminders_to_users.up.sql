@@ -0,0 +1,3 @@
+-- File: migrations/000025_add_email_reminders_to_users.up.sql
+ALTER TABLE users
+ADD COLUMN email_reminders BOOLEAN NOT NULL DEFAULT FALSE; -- Opt-in to check-in reminder emails, separate from the client reminder
@@ -1,3 +1,15 @@
+// --- Time Zone Cookie ---
+// Share the browser's IANA time zone (e.g. "Europe/London") with the server, so the
+// dashboard date filters start and end at the user's local midnight instead of UTC's.
+try {
+    const timeZone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    if (timeZone) {
+        document.cookie = "tz=" + encodeURIComponent(timeZone) + "; path=/; max-age=31536000; SameSite=Lax";
+    }
+} catch (e) {
+    console.warn("Could not detect time zone; date filters will use UTC.", e);
+}
+
 // Wait for the entire HTML document to be fully loaded and parsed.
 document.addEventListener('DOMContentLoaded', function () {
     // Log to confirm the dashboard JavaScript file has been loaded and executed.
@@ -133,4 +145,21 @@ document.addEventListener('DOMContentLoaded', function () {
         }
     });
 
+    // --- CSRF Token Refresh ---
+    // A dashboard left open for a long time can hold a stale CSRF token, so HTMX posts
+    // start failing with 403. Fetch the current token and update every form's hidden
+    // field so the user's next attempt goes through.
+    document.body.addEventListener('htmx:responseError', function (event) {
+        if (!event.detail.xhr || event.detail.xhr.status !== 403) return;
+        fetch('/csrf-token', { credentials: 'same-origin' })
+            .then(response => response.ok ? response.json() : null)
+            .then(data => {
+                if (!data || !data.csrf_token) return;
+                document.querySelectorAll('input[name="csrf_token"]').forEach(input => {
+                    input.value = data.csrf_token;
+                });
+            })
+            .catch(err => console.error("Could not refresh CSRF token:", err));
+    });
+
 }); // End DOMContentLoaded
\ No newline at end of file
@@ -32,6 +32,8 @@ document.addEventListener('DOMContentLoaded', function () {
     }
 
 
+    initializeEmotionDrilldown();
+
     const hasData = statsContainer.dataset.hasData === 'true';
     console.log("[Global] statsContainer.dataset.hasData:", statsContainer.dataset.hasData, "(parsed as boolean:", hasData + ")");
 
@@ -182,6 +184,9 @@ function initializeCharts(emotionCountsData) {
                 },
                 options: {
                     ...baseChartOptions,
+                    onClick: (event, elements) => {
+                        if (elements.length > 0) showEmotionDrilldown(labels[elements[0].index]);
+                    },
                     scales: {
                         y: {
                             beginAtZero: true,
@@ -242,6 +247,9 @@ function initializeCharts(emotionCountsData) {
                     },
                     options: {
                         ...baseChartOptions, // Inherits animation: false
+                        onClick: (event, elements) => {
+                            if (elements.length > 0) showEmotionDrilldown(labels[elements[0].index]);
+                        },
                         plugins: {
                             ...baseChartOptions.plugins, // Inherit base plugins
                             legend: { ...baseChartOptions.plugins.legend, display: true }, // Ensure legend is ON for pie
@@ -280,6 +288,41 @@ function initializeCharts(emotionCountsData) {
     }
 }
 
+// Loads the entries for the emotion picked in the drill-down form into the results area
+// instead of navigating away. The selected option carries the emoji the endpoint expects.
+function initializeEmotionDrilldown() {
+    const form = document.getElementById('emotion-drilldown-form');
+    const results = document.getElementById('emotion-drilldown-results');
+    if (!form || !results) return;
+
+    form.addEventListener('submit', async (event) => {
+        event.preventDefault();
+        const selected = form.elements.namedItem('name').selectedOptions[0];
+        form.elements.emoji.value = selected ? selected.dataset.emoji : '';
+        const query = new URLSearchParams(new FormData(form));
+        try {
+            const response = await fetch(`/stats/emotion?${query}`, { headers: { 'Accept': 'text/html' } });
+            if (!response.ok) throw new Error(`HTTP ${response.status}`);
+            results.innerHTML = await response.text(); // Server-rendered and escaped fragment.
+        } catch (error) {
+            console.error('[Drilldown] Failed to load emotion entries:', error);
+            results.textContent = 'Could not load those entries. Check the dates and try again.';
+        }
+    });
+    form.addEventListener('change', (event) => {
+        if (event.target.type === 'date' && results.childElementCount > 0) form.requestSubmit();
+    });
+}
+
+// Selects the clicked chart emotion in the drill-down form and loads its entries.
+function showEmotionDrilldown(name) {
+    const form = document.getElementById('emotion-drilldown-form');
+    if (!form) return;
+    form.elements.namedItem('name').value = name;
+    form.requestSubmit();
+    document.getElementById('emotion-drilldown')?.scrollIntoView({ behavior: 'smooth' });
+}
+
 // This function is called if hasData is false OR if emotionCountsData is empty OR if JSON parsing fails
 function showNoDataMessage(customMessage = "") {
     const mainContent = document.querySelector('.stats-main-content');